@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+// baselineTolerance define quanto cada métrica pode piorar em relação à
+// baseline antes de ser considerada uma regressão
+type baselineTolerance struct {
+	// latency é a piora aceita nos percentis e em RPS, em fração (0.1 = 10%)
+	latency float64
+	// errorRate é o aumento aceito na taxa de erros, em pontos percentuais
+	errorRate float64
+}
+
+// baselineMetric é uma linha da comparação
+type baselineMetric struct {
+	name     string
+	baseline string
+	current  string
+	delta    string
+	percent  string
+	// regressed indica que a métrica piorou além da tolerância
+	regressed bool
+}
+
+// baselineComparison é o resultado de --baseline
+type baselineComparison struct {
+	path    string
+	savedAt time.Time
+	metrics []baselineMetric
+}
+
+// Regressions retorna os nomes das métricas que pioraram além da tolerância
+func (c *baselineComparison) Regressions() []string {
+	var names []string
+	for _, metric := range c.metrics {
+		if metric.regressed {
+			names = append(names, metric.name)
+		}
+	}
+	return names
+}
+
+// compareBaseline compara os percentis, a taxa de erros e o RPS do relatório
+// com os da baseline. Os percentis e a taxa de erros pioram quando sobem; o
+// RPS, quando desce.
+func compareBaseline(path string, baseline *savedReport, report *stress.Report, tolerance baselineTolerance) *baselineComparison {
+	c := &baselineComparison{path: path, savedAt: baseline.SavedAt}
+	for _, q := range []struct {
+		name     string
+		baseline time.Duration
+		current  time.Duration
+	}{
+		{"P50", baseline.P50.duration(), report.P50},
+		{"P95", baseline.P95.duration(), report.P95},
+		{"P99", baseline.P99.duration(), report.P99},
+	} {
+		change := relativeChange(float64(q.baseline), float64(q.current))
+		c.metrics = append(c.metrics, baselineMetric{
+			name:      q.name,
+			baseline:  compactDuration(q.baseline),
+			current:   compactDuration(q.current),
+			delta:     signedDuration(q.current - q.baseline),
+			percent:   formatChange(change),
+			regressed: change > tolerance.latency,
+		})
+	}
+
+	baseRate := float64(baseline.FailedRequests) / float64(baseline.TotalRequests) * 100
+	var rate float64
+	if report.TotalRequests > 0 {
+		rate = float64(report.FailedRequests) / float64(report.TotalRequests) * 100
+	}
+	c.metrics = append(c.metrics, baselineMetric{
+		name:      "Taxa de erros",
+		baseline:  fmt.Sprintf("%.2f%%", baseRate),
+		current:   fmt.Sprintf("%.2f%%", rate),
+		delta:     fmt.Sprintf("%+.2f p.p.", rate-baseRate),
+		percent:   formatChange(relativeChange(baseRate, rate)),
+		regressed: rate-baseRate > tolerance.errorRate,
+	})
+
+	change := relativeChange(baseline.RequestsPerSecond, report.RequestsPerSecond)
+	c.metrics = append(c.metrics, baselineMetric{
+		name:      "RPS",
+		baseline:  fmt.Sprintf("%.1f", baseline.RequestsPerSecond),
+		current:   fmt.Sprintf("%.1f", report.RequestsPerSecond),
+		delta:     fmt.Sprintf("%+.1f", report.RequestsPerSecond-baseline.RequestsPerSecond),
+		percent:   formatChange(change),
+		regressed: -change > tolerance.latency,
+	})
+	return c
+}
+
+// relativeChange retorna a variação de base para current em fração; sem
+// base, qualquer valor positivo conta como aumento infinito
+func relativeChange(base, current float64) float64 {
+	switch {
+	case base != 0:
+		return (current - base) / base
+	case current == 0:
+		return 0
+	}
+	return math.Inf(1)
+}
+
+func formatChange(change float64) string {
+	if math.IsInf(change, 1) {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", change*100)
+}
+
+// signedDuration formata a diferença com o sinal, como +12ms ou -3.5ms
+func signedDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + compactDuration(-d)
+	}
+	return "+" + compactDuration(d)
+}
+
+// printBaselineComparison imprime a tabela de --baseline e, por último, as
+// métricas que regrediram
+func printBaselineComparison(w io.Writer, style reportStyle, c *baselineComparison) {
+	p := &reportPrinter{out: w, style: style}
+	defer p.flush()
+	title := "Comparação com a Baseline (" + c.path
+	if !c.savedAt.IsZero() {
+		title += ", " + c.savedAt.Local().Format("2006-01-02 15:04")
+	}
+	p.section(title + ")")
+	rows := make([][]string, 0, len(c.metrics))
+	for _, metric := range c.metrics {
+		status := "OK"
+		if metric.regressed {
+			status = "REGRESSÃO"
+		}
+		rows = append(rows, []string{metric.name, metric.baseline, metric.current, metric.delta, metric.percent, status})
+	}
+	p.table([]string{"Métrica", "Baseline", "Atual", "Delta", "%", "Status"}, rows)
+	if regressions := c.Regressions(); len(regressions) > 0 {
+		p.line(ansiRed, "FALHA: %d de %d métricas pioraram além da tolerância: %s", len(regressions), len(c.metrics), strings.Join(regressions, ", "))
+	}
+}
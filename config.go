@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadConfig lê o arquivo de --config, em YAML ou JSON, e atribui cada chave
+// ao flag de mesmo nome. Os flags informados na linha de comando prevalecem
+// e não são alterados; nos que podem ser repetidos, a lista da linha de
+// comando substitui a do arquivo. Chaves desconhecidas são erros, com a
+// sugestão do flag de nome mais parecido. Os valores passam por expand antes
+// de serem atribuídos. Quando scenario traz os passos em vez de um caminho, o
+// cenário é retornado em JSON e o flag recebe o caminho do arquivo de
+// configuração.
+func loadConfig(flags *flag.FlagSet, path string, expand func(string) string) (scenario []byte, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, errors.New("arquivo vazio")
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("linha %d: a configuração deve ser um objeto com os nomes dos flags como chaves", root.Line)
+	}
+
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	seen := make(map[string]bool)
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		name := key.Value
+		if seen[name] {
+			return nil, fmt.Errorf("linha %d: chave %q repetida", key.Line, name)
+		}
+		seen[name] = true
+		f := flags.Lookup(name)
+		if f == nil || !configurable(name) {
+			if suggestion := closestFlag(flags, name); suggestion != "" {
+				return nil, fmt.Errorf("linha %d: chave desconhecida %q (seria %q?)", key.Line, name, suggestion)
+			}
+			return nil, fmt.Errorf("linha %d: chave desconhecida %q", key.Line, name)
+		}
+		if explicit[name] {
+			continue
+		}
+		if name == "scenario" && value.Kind == yaml.MappingNode {
+			var steps any
+			if err := value.Decode(&steps); err != nil {
+				return nil, fmt.Errorf("linha %d: scenario: %v", value.Line, err)
+			}
+			if scenario, err = json.Marshal(steps); err != nil {
+				return nil, fmt.Errorf("linha %d: scenario: %v", value.Line, err)
+			}
+			value = &yaml.Node{Kind: yaml.ScalarNode, Value: path, Line: value.Line}
+		}
+		values, err := configValues(name, value, repeatable(f))
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: %s: %v", value.Line, name, err)
+		}
+		for _, v := range values {
+			if err := flags.Set(name, expand(v)); err != nil {
+				return nil, fmt.Errorf("linha %d: %s: %v", value.Line, name, err)
+			}
+		}
+	}
+	return scenario, nil
+}
+
+// configurable indica se o flag pode vir do arquivo de configuração: o
+// próprio --config e os de --serve valem apenas na linha de comando
+func configurable(name string) bool {
+	return name != "config" && name != "serve" && name != "serve-token" && name != "serve-max-tests"
+}
+
+// repeatable indica se o flag aceita uma lista de valores
+func repeatable(f *flag.Flag) bool {
+	switch f.Value.(type) {
+	case *stringListFlag, *headerFlag:
+		return true
+	}
+	return false
+}
+
+// configValues converte o valor de uma chave nos argumentos do flag. Os
+// escalares são usados como escritos (ex.: 30s, 100, true); listas só são
+// aceitas nos flags repetíveis. header aceita também um objeto
+// {Nome: valor}, e cada alvo de target, um objeto {url, method, weight}.
+func configValues(name string, value *yaml.Node, list bool) ([]string, error) {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		if value.Tag == "!!null" {
+			return nil, errors.New("valor ausente")
+		}
+		return []string{value.Value}, nil
+	case yaml.SequenceNode:
+		if !list {
+			return nil, errors.New("o flag não aceita uma lista de valores")
+		}
+		var values []string
+		for _, item := range value.Content {
+			switch {
+			case item.Kind == yaml.MappingNode && name == "target":
+				spec, err := mappingSpec(item)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, spec)
+			case item.Kind == yaml.ScalarNode && item.Tag != "!!null":
+				values = append(values, item.Value)
+			default:
+				return nil, fmt.Errorf("linha %d: os itens da lista devem ser valores simples", item.Line)
+			}
+		}
+		return values, nil
+	case yaml.MappingNode:
+		if name != "header" {
+			return nil, errors.New("o flag não aceita um objeto")
+		}
+		var values []string
+		for i := 0; i < len(value.Content); i += 2 {
+			header, item := value.Content[i], value.Content[i+1]
+			items := []*yaml.Node{item}
+			if item.Kind == yaml.SequenceNode {
+				items = item.Content
+			}
+			for _, v := range items {
+				if v.Kind != yaml.ScalarNode {
+					return nil, fmt.Errorf("linha %d: o valor do header %s deve ser um texto", v.Line, header.Value)
+				}
+				values = append(values, header.Value+": "+v.Value)
+			}
+		}
+		return values, nil
+	}
+	return nil, errors.New("valor inválido")
+}
+
+// mappingSpec converte um objeto simples no formato "chave=valor,..."
+func mappingSpec(node *yaml.Node) (string, error) {
+	parts := make([]string, 0, len(node.Content)/2)
+	for i := 0; i < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			return "", fmt.Errorf("linha %d: %s deve ser um valor simples", value.Line, key.Value)
+		}
+		parts = append(parts, key.Value+"="+value.Value)
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// closestFlag retorna o flag de nome mais próximo de name, quando a
+// diferença é pequena o bastante para ser um erro de digitação
+func closestFlag(flags *flag.FlagSet, name string) string {
+	best, bestDistance := "", 3
+	flags.VisitAll(func(f *flag.Flag) {
+		if !configurable(f.Name) {
+			return
+		}
+		if d := editDistance(name, f.Name); d < bestDistance {
+			best, bestDistance = f.Name, d
+		}
+	})
+	return best
+}
+
+// editDistance é a distância de Levenshtein entre a e b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
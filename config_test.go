@@ -0,0 +1,163 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// configFlags reúne os flags usados em testdata/config.yaml, com os mesmos
+// tipos de main
+type configFlags struct {
+	set         *flag.FlagSet
+	baseURL     *string
+	targets     stringListFlag
+	headers     headerFlag
+	body        *string
+	contentType *string
+	concurrency *int
+	duration    *time.Duration
+	rampUp      *time.Duration
+	rps         *float64
+	burst       *int
+	timeout     *time.Duration
+	failIf      stringListFlag
+	apdexT      *time.Duration
+	output      *string
+	saveReport  *string
+}
+
+func newConfigFlags() *configFlags {
+	c := &configFlags{set: flag.NewFlagSet("stress-test", flag.ContinueOnError)}
+	c.set.String("config", "", "")
+	c.baseURL = c.set.String("base-url", "", "")
+	c.set.Var(&c.targets, "target", "")
+	c.set.Var(&c.headers, "header", "")
+	c.body = c.set.String("body", "", "")
+	c.contentType = c.set.String("content-type", "", "")
+	c.concurrency = c.set.Int("concurrency", 0, "")
+	c.duration = c.set.Duration("duration", 0, "")
+	c.rampUp = c.set.Duration("ramp-up", 0, "")
+	c.rps = c.set.Float64("rps", 0, "")
+	c.burst = c.set.Int("burst", 1, "")
+	c.timeout = c.set.Duration("timeout", 10*time.Second, "")
+	c.set.Var(&c.failIf, "fail-if", "")
+	c.apdexT = c.set.Duration("apdex-t", 0, "")
+	c.output = c.set.String("output", "text", "")
+	c.saveReport = c.set.String("save-report", "", "")
+	return c
+}
+
+func noExpand(value string) string { return value }
+
+func TestLoadConfig(t *testing.T) {
+	c := newConfigFlags()
+	scenario, err := loadConfig(c.set, filepath.Join("testdata", "config.yaml"), noExpand)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if scenario != nil {
+		t.Errorf("scenario = %s, esperava nil", scenario)
+	}
+	if *c.baseURL != "https://api.exemplo.com" {
+		t.Errorf("base-url = %q", *c.baseURL)
+	}
+	if want := []string{"url=/produtos,weight=8", "url=/carrinho,method=POST,weight=2"}; !slices.Equal(c.targets, want) {
+		t.Errorf("target = %q, esperava %q", c.targets, want)
+	}
+	if want := []string{"Accept: application/json", "X-Tenant: loja-1"}; !slices.Equal(c.headers, want) {
+		t.Errorf("header = %q, esperava %q", c.headers, want)
+	}
+	if *c.body != `{"sku": 42, "quantidade": 1}` {
+		t.Errorf("body = %q", *c.body)
+	}
+	if *c.contentType != "application/json" {
+		t.Errorf("content-type = %q", *c.contentType)
+	}
+	if *c.concurrency != 20 || *c.rps != 500 || *c.burst != 50 {
+		t.Errorf("concurrency, rps, burst = %d, %v, %d, esperava 20, 500, 50", *c.concurrency, *c.rps, *c.burst)
+	}
+	if *c.duration != 2*time.Minute || *c.rampUp != 15*time.Second || *c.timeout != 5*time.Second || *c.apdexT != 250*time.Millisecond {
+		t.Errorf("duration, ramp-up, timeout, apdex-t = %v, %v, %v, %v", *c.duration, *c.rampUp, *c.timeout, *c.apdexT)
+	}
+	if want := []string{"p95>300ms", "error_rate>1%", "rps<400"}; !slices.Equal(c.failIf, want) {
+		t.Errorf("fail-if = %q, esperava %q", c.failIf, want)
+	}
+	if *c.output != "markdown" || *c.saveReport != "/tmp/stress-report.json" {
+		t.Errorf("output, save-report = %q, %q", *c.output, *c.saveReport)
+	}
+}
+
+func TestLoadConfigCommandLine(t *testing.T) {
+	c := newConfigFlags()
+	args := []string{"--concurrency=5", "--header=X-Tenant: loja-2", "--output=json"}
+	if err := c.set.Parse(args); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, err := loadConfig(c.set, filepath.Join("testdata", "config.yaml"), noExpand); err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if *c.concurrency != 5 {
+		t.Errorf("concurrency = %d, esperava 5 da linha de comando", *c.concurrency)
+	}
+	if *c.output != "json" {
+		t.Errorf("output = %q, esperava json da linha de comando", *c.output)
+	}
+	// A lista da linha de comando substitui a do arquivo
+	if want := []string{"X-Tenant: loja-2"}; !slices.Equal(c.headers, want) {
+		t.Errorf("header = %q, esperava %q", c.headers, want)
+	}
+	if *c.duration != 2*time.Minute {
+		t.Errorf("duration = %v, esperava 2m do arquivo", *c.duration)
+	}
+}
+
+func TestLoadConfigExpand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("header:\n  Authorization: Bearer ${TOKEN}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := newConfigFlags()
+	expand := strings.NewReplacer("${TOKEN}", "segredo").Replace
+	if _, err := loadConfig(c.set, path, expand); err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if want := []string{"Authorization: Bearer segredo"}; !slices.Equal(c.headers, want) {
+		t.Errorf("header = %q, esperava %q", c.headers, want)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{name: "chave desconhecida com sugestão", config: "concurrency: 10\nconcurency: 20\n", err: `linha 2: chave desconhecida "concurency" (seria "concurrency"?)`},
+		{name: "chave desconhecida", config: "requisicoes: 10\n", err: `linha 1: chave desconhecida "requisicoes"`},
+		{name: "flag apenas da linha de comando", config: "config: outro.yaml\n", err: `linha 1: chave desconhecida "config"`},
+		{name: "chave repetida", config: "output: json\noutput: text\n", err: `linha 2: chave "output" repetida`},
+		{name: "lista em flag simples", config: "output:\n  - json\n", err: "linha 2: output: o flag não aceita uma lista de valores"},
+		{name: "objeto fora de header", config: "body:\n  a: 1\n", err: "linha 2: body: o flag não aceita um objeto"},
+		{name: "valor ausente", config: "duration:\n", err: "linha 1: duration: valor ausente"},
+		{name: "valor inválido", config: "concurrency: muitos\n", err: "linha 1: concurrency: "},
+		{name: "raiz não é objeto", config: "- concurrency\n", err: "linha 1: a configuração deve ser um objeto"},
+		{name: "arquivo vazio", config: "", err: "arquivo vazio"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := loadConfig(newConfigFlags().set, path, noExpand)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Fatalf("loadConfig = %v, esperava %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+// workerStartDelay é a espera enviada a todos os workers. Os testes são
+// enviados em paralelo, então todos começam com a mesma espera contada a
+// partir do recebimento, sem depender dos relógios das máquinas.
+const workerStartDelay = time.Second
+
+// defaultWorkerInterval é o intervalo das linhas de progresso dos workers
+// quando --report-interval não é informado
+const defaultWorkerInterval = 5 * time.Second
+
+// coordinatorFlags são os flags tratados apenas pelo coordenador, que não
+// são repassados aos workers: a divisão da carga e as saídas geradas a
+// partir do relatório combinado
+var coordinatorFlags = map[string]bool{
+	"workers": true, "config": true, "requests": true, "rps": true, "arrival-rate": true, "max-in-flight": true, "stages": true, "spike": true,
+	"report-interval": true, "no-progress": true, "quiet": true, "version": true,
+	"output": true, "output-html": true, "no-color": true, "no-histogram": true, "histogram-buckets": true,
+	"timeline-csv": true, "save-report": true, "baseline": true, "baseline-tolerance": true, "baseline-error-tolerance": true,
+	"pushgateway-url": true, "push-job": true, "label": true, "worker-token": true, "curl": true,
+}
+
+// workerLoad é a carga total repartida entre os workers
+type workerLoad struct {
+	requests    int
+	rps         float64
+	arrivalRate float64
+	maxInFlight int
+	stages      []stress.Stage
+	spike       *stress.Spike
+}
+
+// share retorna os valores dos flags da parte do worker i de n. As sobras da divisão
+// das requests e das vagas de --max-in-flight ficam com os primeiros
+// workers. As taxas das etapas de --stages são repartidas como
+// --arrival-rate, e as concorrências repetidas, como --concurrency; as de
+// --spike também são repartidas.
+func (l workerLoad) share(i, n int) map[string][]string {
+	split := func(total int) int {
+		part := total / n
+		if i < total%n {
+			part++
+		}
+		return part
+	}
+	values := make(map[string][]string)
+	set := func(name, value string) {
+		values[name] = []string{value}
+	}
+	if l.requests > 0 {
+		set("requests", strconv.Itoa(split(l.requests)))
+	}
+	if l.rps > 0 {
+		set("rps", strconv.FormatFloat(l.rps/float64(n), 'f', -1, 64))
+	}
+	if l.arrivalRate > 0 {
+		set("arrival-rate", strconv.FormatFloat(l.arrivalRate/float64(n), 'f', -1, 64))
+		set("max-in-flight", strconv.Itoa(max(split(l.maxInFlight), 1)))
+	}
+	if len(l.stages) > 0 {
+		stages := make([]string, len(l.stages))
+		for j, stage := range l.stages {
+			stage.Rate /= float64(n)
+			stages[j] = stage.String()
+		}
+		set("stages", strings.Join(stages, ","))
+		if l.stages[0].Rate > 0 {
+			set("max-in-flight", strconv.Itoa(max(split(l.maxInFlight), 1)))
+		}
+	}
+	if l.spike != nil {
+		spike := *l.spike
+		spike.Base /= float64(n)
+		spike.Peak /= float64(n)
+		set("spike", spike.String())
+		set("max-in-flight", strconv.Itoa(max(split(l.maxInFlight), 1)))
+	}
+	return values
+}
+
+// workerFailure descreve um worker que não entregou o relatório
+type workerFailure struct {
+	addr string
+	err  error
+}
+
+// parseWorkerAddrs interpreta a lista host:porta de --workers
+func parseWorkerAddrs(value string) ([]string, error) {
+	var addrs []string
+	for _, addr := range strings.Split(value, ",") {
+		addr = strings.TrimSpace(addr)
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("worker inválido %q: use o formato host:porta", addr)
+		}
+		if slices.Contains(addrs, addr) {
+			return nil, fmt.Errorf("worker %s repetido", addr)
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// workerValues monta os valores dos flags do teste enviados aos workers a
+// partir dos definidos na linha de comando ou no --config, já com as
+// referências substituídas. resolved substitui os valores de alguns flags
+// (ex.: os lidos de --curl). Os flags fora de workerFlags, que leem arquivos
+// ou abrem portas na máquina do worker, são recusados.
+func workerValues(flags *flag.FlagSet, resolved map[string][]string) (map[string][]string, error) {
+	values := maps.Clone(resolved)
+	if values == nil {
+		values = make(map[string][]string)
+	}
+	var rejected []string
+	flags.Visit(func(f *flag.Flag) {
+		if coordinatorFlags[f.Name] || resolved[f.Name] != nil {
+			return
+		}
+		if !workerFlags[f.Name] {
+			rejected = append(rejected, "--"+f.Name)
+			return
+		}
+		switch value := f.Value.(type) {
+		case *stringListFlag:
+			values[f.Name] = slices.Clone(*value)
+		case *headerFlag:
+			values[f.Name] = slices.Clone(*value)
+		default:
+			values[f.Name] = []string{f.Value.String()}
+		}
+	})
+	if len(rejected) > 0 {
+		return nil, fmt.Errorf("os workers não aceitam flags que leem ou gravam arquivos ou abrem portas: %s", strings.Join(rejected, ", "))
+	}
+	return values, nil
+}
+
+// runDistributed divide o teste entre os workers, com as requests, o RPS e
+// a taxa de chegadas repartidos igualmente e a mesma duração e concorrência
+// em cada um, e
+// combina os relatórios com Report.Merge. As linhas de progresso dos
+// workers vão para progress, quando não é nil. Um worker que falha ou deixa
+// de responder é informado em failures e o relatório combina os demais; o
+// erro só é retornado quando nenhum worker conclui o teste. Cancelar ctx
+// interrompe todos os workers, que ainda entregam os relatórios parciais.
+// token, quando definido, é enviado no header Authorization.
+func runDistributed(ctx context.Context, addrs []string, token string, values map[string][]string, load workerLoad, interval time.Duration, progress io.Writer) (*stress.Report, []workerFailure, error) {
+	if interval == 0 {
+		interval = defaultWorkerInterval
+	}
+	var progressMu sync.Mutex
+	reports := make([]*stress.Report, len(addrs))
+	errs := make([]error, len(addrs))
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		job := workerJob{Flags: maps.Clone(values), DelayMs: workerStartDelay.Milliseconds()}
+		job.Flags["report-interval"] = []string{interval.String()}
+		maps.Copy(job.Flags, load.share(i, len(addrs)))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lines := func(line string) {
+				if progress != nil {
+					progressMu.Lock()
+					fmt.Fprintf(progress, "[%s] %s\n", addr, line)
+					progressMu.Unlock()
+				}
+			}
+			reports[i], errs[i] = runOnWorker(addr, token, job, 3*interval+30*time.Second, lines)
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		stopWorkers(addrs, token)
+		<-done
+	}
+
+	var merged *stress.Report
+	var failures []workerFailure
+	for i, addr := range addrs {
+		if errs[i] != nil {
+			failures = append(failures, workerFailure{addr: addr, err: errs[i]})
+			continue
+		}
+		if merged == nil {
+			merged = reports[i]
+			continue
+		}
+		merged.Merge(reports[i])
+	}
+	if merged == nil {
+		return nil, failures, errors.New("nenhum worker concluiu o teste")
+	}
+	return merged, failures, nil
+}
+
+// runOnWorker envia o teste a um worker e acompanha o stream até o
+// relatório. Sem nenhuma linha por idle, o worker é considerado perdido.
+func runOnWorker(addr, token string, job workerJob, idle time.Duration, progress func(string)) (*stress.Report, error) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+	req, err := workerRequest(addr, "/run", token, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	// Fechar o corpo desbloqueia a leitura quando o worker para de responder
+	var stalled atomic.Bool
+	timer := time.AfterFunc(idle, func() {
+		stalled.Store(true)
+		resp.Body.Close()
+	})
+	defer timer.Stop()
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event workerEvent
+		if err := decoder.Decode(&event); err != nil {
+			if stalled.Load() {
+				return nil, fmt.Errorf("sem resposta há %v", idle)
+			}
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("conexão encerrada antes do relatório")
+			}
+			return nil, fmt.Errorf("conexão interrompida antes do relatório: %w", err)
+		}
+		timer.Reset(idle)
+		switch event.Type {
+		case workerEventProgress:
+			progress(event.Line)
+		case workerEventError:
+			return nil, errors.New(event.Error)
+		case workerEventReport:
+			var saved savedReport
+			if err := json.Unmarshal(event.Report, &saved); err != nil {
+				return nil, fmt.Errorf("relatório inválido: %w", err)
+			}
+			return saved.report()
+		}
+	}
+}
+
+// workerRequest monta uma request ao worker, com o token no header
+// Authorization quando definido
+func workerRequest(addr, path, token string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, "http://"+addr+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+// stopWorkers pede a todos os workers que interrompam o teste
+func stopWorkers(addrs []string, token string) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	var wg sync.WaitGroup
+	for _, addr := range addrs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, err := workerRequest(addr, "/stop", token, nil)
+			if err != nil {
+				return
+			}
+			if resp, err := client.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+}
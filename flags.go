@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+// headerFlag implementa flag.Value permitindo repetir -header várias vezes
+type headerFlag []string
+
+func (h *headerFlag) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlag) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
+
+// Header converte os valores informados em um http.Header, preservando
+// múltiplos valores para o mesmo nome
+func (h headerFlag) Header() (http.Header, error) {
+	header := make(http.Header)
+	for _, raw := range h {
+		name, value, ok := strings.Cut(raw, ":")
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if !ok || !stress.ValidHeaderName(name) {
+			return nil, fmt.Errorf("header inválido %q: use o formato \"Nome: Valor\"", raw)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("header inválido %q: o valor não pode conter quebras de linha", raw)
+		}
+		header.Add(name, value)
+	}
+	return header, nil
+}
+
+// parseUserFlag interpreta credenciais no formato "nome:senha" (como o -u do
+// curl). Apenas o primeiro ":" separa os campos, então a senha pode conter ":".
+func parseUserFlag(value string) (*stress.BasicAuth, error) {
+	username, password, ok := strings.Cut(value, ":")
+	if !ok || username == "" {
+		return nil, fmt.Errorf("credencial inválida: use o formato \"nome:senha\"")
+	}
+	return &stress.BasicAuth{Username: username, Password: password}, nil
+}
+
+// curlWarning retorna o aviso das opções de --curl sem equivalente no teste,
+// vazio quando todas foram convertidas
+func curlWarning(curl *stress.CurlRequest) string {
+	if len(curl.Ignored) == 0 {
+		return ""
+	}
+	return "AVISO: opções do curl ignoradas: " + strings.Join(curl.Ignored, ", ")
+}
+
+// stringListFlag implementa flag.Value para flags que podem ser repetidas
+type stringListFlag []string
+
+func (s *stringListFlag) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringListFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// warmupFlag implementa flag.Value para -warmup, que aceita tanto uma
+// quantidade de requests ("500") quanto uma duração ("30s")
+type warmupFlag struct {
+	requests int
+	duration time.Duration
+}
+
+func (w *warmupFlag) String() string {
+	if w.duration > 0 {
+		return w.duration.String()
+	}
+	if w.requests > 0 {
+		return strconv.Itoa(w.requests)
+	}
+	return ""
+}
+
+func (w *warmupFlag) Set(value string) error {
+	if n, err := strconv.Atoi(value); err == nil {
+		if n < 0 {
+			return fmt.Errorf("a quantidade de requests não pode ser negativa")
+		}
+		*w = warmupFlag{requests: n}
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return fmt.Errorf("use uma quantidade de requests (ex.: 500) ou uma duração (ex.: 30s)")
+	}
+	*w = warmupFlag{duration: d}
+	return nil
+}
+
+// defaultHistogramBuckets é a quantidade de faixas do histograma do
+// relatório em texto sem -histogram-buckets
+const defaultHistogramBuckets = 10
+
+// maxHistogramBuckets limita as faixas do histograma, uma por linha
+const maxHistogramBuckets = 100
+
+// histogramFlag implementa flag.Value para -histogram-buckets, que aceita
+// uma quantidade de faixas logarítmicas ("20") ou os limites das faixas
+// ("10ms,50ms,100ms")
+type histogramFlag struct {
+	buckets int
+	bounds  []time.Duration
+}
+
+func (h *histogramFlag) String() string {
+	if len(h.bounds) > 0 {
+		bounds := make([]string, len(h.bounds))
+		for i, bound := range h.bounds {
+			bounds[i] = bound.String()
+		}
+		return strings.Join(bounds, ",")
+	}
+	return strconv.Itoa(h.buckets)
+}
+
+func (h *histogramFlag) Set(value string) error {
+	if n, err := strconv.Atoi(value); err == nil {
+		if n < 1 || n > maxHistogramBuckets {
+			return fmt.Errorf("a quantidade de faixas deve estar entre 1 e %d", maxHistogramBuckets)
+		}
+		*h = histogramFlag{buckets: n}
+		return nil
+	}
+	var bounds []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		bound, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || bound <= 0 {
+			return fmt.Errorf("use uma quantidade de faixas (ex.: 20) ou limites separados por vírgula (ex.: 10ms,50ms,100ms)")
+		}
+		if len(bounds) > 0 && bound <= bounds[len(bounds)-1] {
+			return fmt.Errorf("os limites devem estar em ordem crescente")
+		}
+		bounds = append(bounds, bound)
+	}
+	if len(bounds) >= maxHistogramBuckets {
+		return fmt.Errorf("use no máximo %d limites", maxHistogramBuckets-1)
+	}
+	*h = histogramFlag{bounds: bounds}
+	return nil
+}
+
+// distribution retorna as faixas do relatório conforme a flag
+func (h *histogramFlag) distribution(report *stress.Report) []stress.LatencyBucket {
+	if len(h.bounds) > 0 {
+		return report.LatencyBuckets(h.bounds)
+	}
+	return report.LatencyDistribution(h.buckets)
+}
+
+// inlineOrFile retorna o valor da flag ou, quando ele começa com @, o
+// conteúdo do arquivo indicado, como no -d do curl
+func inlineOrFile(value string) (string, error) {
+	path, ok := strings.CutPrefix(value, "@")
+	if !ok {
+		return value, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
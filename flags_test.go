@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+func TestHeaderFlag(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   http.Header
+		err    bool
+	}{
+		{name: "simples", values: []string{"X-Custom: valor"}, want: http.Header{"X-Custom": {"valor"}}},
+		{name: "espaços", values: []string{"  x-custom  :   valor  "}, want: http.Header{"X-Custom": {"valor"}}},
+		{name: "vários valores", values: []string{"X-Multi: 1", "X-Multi: 2"}, want: http.Header{"X-Multi": {"1", "2"}}},
+		{name: "dois-pontos no valor", values: []string{"Referer: http://exemplo.com:8080/"}, want: http.Header{"Referer": {"http://exemplo.com:8080/"}}},
+		{name: "valor vazio", values: []string{"X-Vazio:"}, want: http.Header{"X-Vazio": {""}}},
+		{name: "sem dois-pontos", values: []string{"X-Custom valor"}, err: true},
+		{name: "nome vazio", values: []string{": valor"}, err: true},
+		{name: "nome com espaço", values: []string{"X Custom: valor"}, err: true},
+		{name: "nome com acento", values: []string{"Ação: valor"}, err: true},
+		{name: "quebra de linha", values: []string{"X-Custom: a\r\nX-Injetado: b"}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var flag headerFlag
+			for _, value := range tt.values {
+				if err := flag.Set(value); err != nil {
+					t.Fatalf("Set(%q): %v", value, err)
+				}
+			}
+			got, err := flag.Header()
+			if tt.err {
+				if err == nil {
+					t.Fatalf("Header() = %v, esperava erro", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Header(): %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Header() = %v, esperava %v", got, tt.want)
+			}
+			for name, values := range tt.want {
+				if !slices.Equal(got[name], values) {
+					t.Errorf("Header()[%q] = %q, esperava %q", name, got[name], values)
+				}
+			}
+		})
+	}
+}
+
+func TestHeaderFlagString(t *testing.T) {
+	var flag headerFlag
+	flag.Set("X-A: 1")
+	flag.Set("X-B: 2")
+	if got, want := flag.String(), "X-A: 1, X-B: 2"; got != want {
+		t.Errorf("String() = %q, esperava %q", got, want)
+	}
+}
+
+func TestCurlWarning(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{command: "curl -sS -L https://api.exemplo.com --compressed -k", want: ""},
+		{command: "curl -o saida.json https://api.exemplo.com", want: "AVISO: opções do curl ignoradas: -o"},
+		{
+			command: "curl --retry 3 -b cookies.txt --http2 -d a=1 https://api.exemplo.com",
+			want:    "AVISO: opções do curl ignoradas: --retry, -b cookies.txt, --http2",
+		},
+	}
+	for _, tt := range tests {
+		curl, err := stress.ParseCurl(tt.command)
+		if err != nil {
+			t.Fatalf("ParseCurl(%q): %v", tt.command, err)
+		}
+		if got := curlWarning(curl); got != tt.want {
+			t.Errorf("curlWarning(%q) = %q, esperava %q", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+// Dimensões dos gráficos SVG do relatório HTML
+const (
+	chartWidth  = 900.0
+	chartHeight = 260.0
+	chartLeft   = 70.0
+	chartRight  = 20.0
+	chartTop    = 20.0
+	chartBottom = 40.0
+	// histogramBuckets é a quantidade de faixas do histograma de latências
+	histogramBuckets = 30
+	// maxChartPoints limita os pontos dos gráficos no tempo; séries maiores
+	// são agrupadas (ver downsample)
+	maxChartPoints = 1500
+	// maxHeaderTargets limita os alvos listados no cabeçalho da página
+	maxHeaderTargets = 5
+)
+
+// htmlStyleSheet é o CSS embutido na página, que não depende de nenhum
+// arquivo externo
+const htmlStyleSheet = `
+body { font-family: system-ui, -apple-system, "Segoe UI", sans-serif; color: #222; background: #fafafa; margin: 0; }
+main { max-width: 960px; margin: 0 auto; padding: 24px; }
+h1 { margin-bottom: 4px; }
+h2 { margin-top: 32px; border-bottom: 1px solid #ddd; padding-bottom: 4px; }
+.meta { color: #666; margin-top: 0; }
+table { border-collapse: collapse; margin: 8px 0; font-size: 14px; }
+th, td { border: 1px solid #ddd; padding: 4px 10px; text-align: left; vertical-align: top; }
+th { background: #f0f0f0; }
+tr.num td:last-child { text-align: right; font-variant-numeric: tabular-nums; }
+.good { color: #1a7f37; }
+.bad { color: #cf222e; }
+.warn { color: #9a6700; }
+svg { background: #fff; border: 1px solid #ddd; max-width: 100%; height: auto; }
+svg text { font-size: 11px; fill: #555; }
+.grid { stroke: #eee; }
+.axis { stroke: #999; }
+.legend span { display: inline-block; margin-right: 16px; font-size: 13px; }
+.legend i { display: inline-block; width: 12px; height: 12px; margin-right: 4px; vertical-align: middle; }
+.empty { color: #888; font-style: italic; }
+`
+
+// writeHTMLReport grava o relatório como uma página HTML única, com o CSS e
+// os gráficos SVG embutidos, que abre sem acesso à rede. As tabelas vêm das
+// mesmas linhas do relatório em texto (ver printReport).
+func writeHTMLReport(out io.Writer, report *stress.Report) error {
+	w := bufio.NewWriter(out)
+	fmt.Fprintln(w, "<!DOCTYPE html>")
+	fmt.Fprintln(w, `<html lang="pt-BR">`)
+	fmt.Fprintln(w, `<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">`)
+	fmt.Fprintln(w, "<title>Relatório do Teste de Carga</title>")
+	fmt.Fprintf(w, "<style>%s</style>\n</head>\n<body>\n<main>\n", htmlStyleSheet)
+	fmt.Fprintln(w, "<h1>Relatório do Teste de Carga</h1>")
+	fmt.Fprintf(w, "<p class=\"meta\">%s</p>\n", html.EscapeString(htmlSubtitle(report)))
+
+	fmt.Fprintln(w, "<h2>Distribuição das Latências</h2>")
+	writeHistogramChart(w, report.LatencyDistribution(histogramBuckets))
+	points, interval := downsample(report.Timeline, report.TimelineInterval)
+	fmt.Fprintln(w, "<h2>Latência ao Longo do Teste</h2>")
+	writeLatencyChart(w, points, interval)
+	fmt.Fprintln(w, "<h2>Requests por Segundo</h2>")
+	writeThroughputChart(w, points, interval, report.TotalTime)
+	fmt.Fprintln(w, "<h2>Status das Respostas</h2>")
+	writeStatusChart(w, report)
+
+	// O histograma já aparece como gráfico
+	printReport(w, report, htmlStyle, nil)
+	fmt.Fprintln(w, "</main>\n</body>\n</html>")
+	return w.Flush()
+}
+
+// htmlSubtitle resume o teste no cabeçalho: alvos, duração, requests e o
+// horário em que o relatório foi gerado
+func htmlSubtitle(report *stress.Report) string {
+	labels := slices.Sorted(maps.Keys(report.Targets))
+	if len(labels) > maxHeaderTargets {
+		labels = append(labels[:maxHeaderTargets], fmt.Sprintf("e mais %d", len(labels)-maxHeaderTargets))
+	}
+	parts := []string{}
+	if len(labels) > 0 {
+		parts = append(parts, strings.Join(labels, ", "))
+	}
+	parts = append(parts,
+		fmt.Sprintf("%d requests em %s", report.TotalRequests, fixedDuration(report.TotalTime)),
+		"gerado em "+time.Now().Format("2006-01-02 15:04:05"))
+	return strings.Join(parts, " · ")
+}
+
+// downsample agrupa os pontos da timeline quando eles passam de
+// maxChartPoints, somando as requests e mantendo o pior percentil de cada
+// grupo, para que testes longos não gerem gráficos pesados. Retorna os
+// pontos e o intervalo de cada um.
+func downsample(points []stress.TimelinePoint, interval time.Duration) ([]stress.TimelinePoint, time.Duration) {
+	group := (len(points) + maxChartPoints - 1) / maxChartPoints
+	if group <= 1 {
+		return points, interval
+	}
+	merged := make([]stress.TimelinePoint, 0, maxChartPoints)
+	for start := 0; start < len(points); start += group {
+		point := stress.TimelinePoint{Start: time.Duration(start) * interval, Stage: points[start].Stage}
+		var sum time.Duration
+		for _, p := range points[start:min(start+group, len(points))] {
+			point.Requests += p.Requests
+			point.Errors += p.Errors
+			sum += p.Avg * time.Duration(p.Requests)
+			point.P50 = max(point.P50, p.P50)
+			point.P95 = max(point.P95, p.P95)
+			point.P99 = max(point.P99, p.P99)
+			point.Max = max(point.Max, p.Max)
+		}
+		if point.Requests > 0 {
+			point.Avg = sum / time.Duration(point.Requests)
+		}
+		merged = append(merged, point)
+	}
+	return merged, interval * time.Duration(group)
+}
+
+// niceCeil arredonda o valor para cima em 1, 2 ou 5 vezes uma potência de
+// 10, para que o eixo tenha marcas legíveis
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, step := range []float64{1, 2, 5, 10} {
+		if v <= step*magnitude {
+			return step * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// chartFrame abre o SVG e desenha as linhas de grade horizontais, com os
+// rótulos do eixo vertical formatados por label
+func chartFrame(w io.Writer, top float64, label func(float64) string) {
+	fmt.Fprintf(w, `<svg viewBox="0 0 %.0f %.0f" width="%.0f" height="%.0f" role="img">`+"\n", chartWidth, chartHeight, chartWidth, chartHeight)
+	plotHeight := chartHeight - chartTop - chartBottom
+	for i := 0; i <= 4; i++ {
+		y := chartTop + plotHeight*float64(4-i)/4
+		fmt.Fprintf(w, `<line class="grid" x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/>`+"\n", chartLeft, y, chartWidth-chartRight, y)
+		fmt.Fprintf(w, `<text x="%.1f" y="%.1f" text-anchor="end">%s</text>`+"\n", chartLeft-6, y+4, html.EscapeString(label(top*float64(i)/4)))
+	}
+	bottom := chartHeight - chartBottom
+	fmt.Fprintf(w, `<line class="axis" x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/>`+"\n", chartLeft, bottom, chartWidth-chartRight, bottom)
+}
+
+// xLabel escreve um rótulo do eixo horizontal
+func xLabel(w io.Writer, x float64, text string) {
+	fmt.Fprintf(w, `<text x="%.1f" y="%.1f" text-anchor="middle">%s</text>`+"\n", x, chartHeight-chartBottom+16, html.EscapeString(text))
+}
+
+// emptyChart indica um gráfico sem dados
+func emptyChart(w io.Writer) {
+	fmt.Fprintln(w, `<p class="empty">Sem dados para o gráfico.</p>`)
+}
+
+// writeHistogramChart desenha as faixas de LatencyDistribution como barras
+func writeHistogramChart(w io.Writer, buckets []stress.LatencyBucket) {
+	if len(buckets) == 0 {
+		emptyChart(w)
+		return
+	}
+	var highest int64
+	for _, bucket := range buckets {
+		highest = max(highest, bucket.Count)
+	}
+	top := niceCeil(float64(highest))
+	chartFrame(w, top, func(v float64) string { return fmt.Sprintf("%.0f", v) })
+	plotWidth := chartWidth - chartLeft - chartRight
+	plotHeight := chartHeight - chartTop - chartBottom
+	barWidth := plotWidth / float64(len(buckets))
+	for i, bucket := range buckets {
+		x := chartLeft + float64(i)*barWidth
+		height := float64(bucket.Count) / top * plotHeight
+		fmt.Fprintf(w, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#4c78a8"><title>%s – %s: %d requests</title></rect>`+"\n",
+			x+1, chartTop+plotHeight-height, max(barWidth-2, 1), height,
+			fixedDuration(bucket.From), fixedDuration(bucket.To), bucket.Count)
+		if i%5 == 0 {
+			xLabel(w, x, compactDuration(bucket.From))
+		}
+	}
+	xLabel(w, chartWidth-chartRight, compactDuration(buckets[len(buckets)-1].To))
+	fmt.Fprintln(w, "</svg>")
+}
+
+// timeSeries associa uma série da timeline à cor usada no gráfico
+type timeSeries struct {
+	name  string
+	color string
+	value func(stress.TimelinePoint) time.Duration
+}
+
+var latencySeries = []timeSeries{
+	{"P50", "#4c78a8", func(p stress.TimelinePoint) time.Duration { return p.P50 }},
+	{"P95", "#f58518", func(p stress.TimelinePoint) time.Duration { return p.P95 }},
+	{"P99", "#e45756", func(p stress.TimelinePoint) time.Duration { return p.P99 }},
+}
+
+// timeLabels escreve até seis rótulos de tempo decorrido no eixo horizontal
+func timeLabels(w io.Writer, span time.Duration) {
+	plotWidth := chartWidth - chartLeft - chartRight
+	for i := 0; i <= 5; i++ {
+		xLabel(w, chartLeft+plotWidth*float64(i)/5, compactDuration(span*time.Duration(i)/5))
+	}
+}
+
+// stageMarkers desenha uma linha tracejada no início de cada etapa de
+// --stages, depois da primeira, marcando os degraus da carga
+func stageMarkers(w io.Writer, points []stress.TimelinePoint) {
+	plotWidth := chartWidth - chartLeft - chartRight
+	step := plotWidth / float64(len(points))
+	for i := 1; i < len(points); i++ {
+		if points[i].Stage == points[i-1].Stage {
+			continue
+		}
+		x := chartLeft + float64(i)*step
+		fmt.Fprintf(w, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#888" stroke-dasharray="4 3"><title>etapa %d</title></line>`+"\n",
+			x, chartTop, x, chartHeight-chartBottom, points[i].Stage)
+	}
+}
+
+// writeLatencyChart desenha os percentis de cada intervalo como linhas,
+// interrompidas nos intervalos sem respostas
+func writeLatencyChart(w io.Writer, points []stress.TimelinePoint, interval time.Duration) {
+	var highest time.Duration
+	for _, point := range points {
+		highest = max(highest, point.P99)
+	}
+	if highest == 0 {
+		emptyChart(w)
+		return
+	}
+	top := niceCeil(float64(highest))
+	chartFrame(w, top, func(v float64) string { return compactDuration(time.Duration(v)) })
+	plotWidth := chartWidth - chartLeft - chartRight
+	plotHeight := chartHeight - chartTop - chartBottom
+	step := plotWidth / float64(len(points))
+	for _, series := range latencySeries {
+		var line []string
+		flush := func() {
+			if len(line) > 0 {
+				fmt.Fprintf(w, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"><title>%s</title></polyline>`+"\n",
+					series.color, strings.Join(line, " "), series.name)
+			}
+			line = line[:0]
+		}
+		for i, point := range points {
+			if point.Requests == 0 || point.P99 == 0 {
+				flush()
+				continue
+			}
+			x := chartLeft + (float64(i)+0.5)*step
+			y := chartTop + plotHeight - float64(series.value(point))/top*plotHeight
+			line = append(line, fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+		flush()
+	}
+	stageMarkers(w, points)
+	timeLabels(w, interval*time.Duration(len(points)))
+	fmt.Fprintln(w, "</svg>")
+	fmt.Fprint(w, `<div class="legend">`)
+	for _, series := range latencySeries {
+		fmt.Fprintf(w, `<span><i style="background:%s"></i>%s</span>`, series.color, series.name)
+	}
+	fmt.Fprintln(w, "</div>")
+}
+
+// writeThroughputChart desenha as requests por segundo de cada intervalo,
+// com as falhas empilhadas sobre os sucessos. O último intervalo costuma
+// terminar antes do fim, e sua taxa considera apenas o trecho até total.
+func writeThroughputChart(w io.Writer, points []stress.TimelinePoint, interval, total time.Duration) {
+	rates := make([]float64, len(points))
+	var highest float64
+	for i, point := range points {
+		seconds := min(interval, total-point.Start).Seconds()
+		if seconds <= 0 {
+			seconds = interval.Seconds()
+		}
+		rates[i] = float64(point.Requests) / seconds
+		highest = max(highest, rates[i])
+	}
+	if highest == 0 {
+		emptyChart(w)
+		return
+	}
+	top := niceCeil(highest)
+	chartFrame(w, top, func(v float64) string { return fmt.Sprintf("%g", v) })
+	plotWidth := chartWidth - chartLeft - chartRight
+	plotHeight := chartHeight - chartTop - chartBottom
+	barWidth := plotWidth / float64(len(points))
+	for i, point := range points {
+		x := chartLeft + float64(i)*barWidth
+		failed := rates[i] * float64(point.Errors) / float64(max(point.Requests, 1)) / top * plotHeight
+		success := rates[i]/top*plotHeight - failed
+		title := fmt.Sprintf("%s: %.1f req/s, %d falhas", compactDuration(point.Start), rates[i], point.Errors)
+		fmt.Fprintf(w, `<g><title>%s</title><rect x="%.2f" y="%.1f" width="%.2f" height="%.1f" fill="#54a24b"/><rect x="%.2f" y="%.1f" width="%.2f" height="%.1f" fill="#e45756"/></g>`+"\n",
+			html.EscapeString(title),
+			x, chartTop+plotHeight-success, barWidth, success,
+			x, chartTop+plotHeight-success-failed, barWidth, failed)
+	}
+	stageMarkers(w, points)
+	timeLabels(w, interval*time.Duration(len(points)))
+	fmt.Fprintln(w, "</svg>")
+	fmt.Fprintln(w, `<div class="legend"><span><i style="background:#54a24b"></i>sucesso</span><span><i style="background:#e45756"></i>falha</span></div>`)
+}
+
+// statusBar é uma barra do gráfico de status
+type statusBar struct {
+	label string
+	count int
+	color string
+}
+
+// statusBars lista os status HTTP (ou os códigos gRPC) em ordem, com as
+// requests sem resposta ao final
+func statusBars(report *stress.Report) []statusBar {
+	var bars []statusBar
+	if report.GRPCMethod != "" {
+		for _, code := range sortedByCount(report.GRPCCodes) {
+			color := "#e45756"
+			if code == "OK" {
+				color = "#54a24b"
+			}
+			bars = append(bars, statusBar{code, report.GRPCCodes[code], color})
+		}
+	} else {
+		for _, status := range slices.Sorted(maps.Keys(report.StatusCodes)) {
+			color := "#54a24b"
+			switch {
+			case status >= 500:
+				color = "#e45756"
+			case status >= 400:
+				color = "#f58518"
+			case status >= 300:
+				color = "#4c78a8"
+			}
+			bars = append(bars, statusBar{fmt.Sprint(status), report.StatusCodes[status], color})
+		}
+	}
+	if report.TransportErrors > 0 {
+		bars = append(bars, statusBar{"sem resposta", report.TransportErrors, "#888888"})
+	}
+	return bars
+}
+
+// writeStatusChart desenha a distribuição de status como barras
+// horizontais com a proporção de cada uma
+func writeStatusChart(w io.Writer, report *stress.Report) {
+	bars := statusBars(report)
+	if len(bars) == 0 || report.TotalRequests == 0 {
+		emptyChart(w)
+		return
+	}
+	const rowHeight, labelWidth = 26.0, 110.0
+	height := rowHeight*float64(len(bars)) + 10
+	fmt.Fprintf(w, `<svg viewBox="0 0 %.0f %.0f" width="%.0f" height="%.0f" role="img">`+"\n", chartWidth, height, chartWidth, height)
+	plotWidth := chartWidth - labelWidth - 140
+	for i, bar := range bars {
+		y := 5 + float64(i)*rowHeight
+		share := float64(bar.count) / float64(report.TotalRequests)
+		fmt.Fprintf(w, `<text x="%.1f" y="%.1f" text-anchor="end">%s</text>`+"\n", labelWidth-8, y+rowHeight/2+4, html.EscapeString(bar.label))
+		fmt.Fprintf(w, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`+"\n", labelWidth, y+4, max(share*plotWidth, 1), rowHeight-8, bar.color)
+		fmt.Fprintf(w, `<text x="%.1f" y="%.1f">%d (%.2f%%)</text>`+"\n", labelWidth+max(share*plotWidth, 1)+6, y+rowHeight/2+4, bar.count, share*100)
+	}
+	fmt.Fprintln(w, "</svg>")
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+const (
+	// influxBatchLines e influxBatchInterval definem quando as linhas
+	// acumuladas são enviadas: ao atingir a quantidade ou o intervalo,
+	// verificados no fechamento de cada intervalo
+	influxBatchLines    = 5000
+	influxBatchInterval = 10 * time.Second
+	// influxQueueSize limita os lotes aguardando envio; com a fila cheia,
+	// o lote é descartado em vez de atrasar o teste
+	influxQueueSize = 16
+	// influxWriteTimeout limita cada escrita na API do InfluxDB
+	influxWriteTimeout = 10 * time.Second
+)
+
+// influxKey identifica uma série de um intervalo
+type influxKey struct {
+	method string
+	url    string
+	class  string
+}
+
+// influxSeries acumula as requests de uma série no intervalo corrente
+type influxSeries struct {
+	count     int
+	errors    int
+	total     time.Duration
+	durations *stress.Histogram
+}
+
+// influxWriter converte os resultados em line protocol do InfluxDB: uma
+// linha por intervalo e série (método, URL e classe de status) e, ao fim,
+// o resumo do relatório. As linhas são gravadas em --influx-file e enviadas
+// em lotes à API de escrita por uma goroutine própria, para que a rede não
+// atrase o teste; os erros são informados ao fim.
+type influxWriter struct {
+	interval time.Duration
+	// current é o início do intervalo corrente, em nanossegundos Unix
+	current int64
+	series  map[influxKey]*influxSeries
+
+	pending  bytes.Buffer
+	lines    int
+	lastSend time.Time
+
+	file *os.File
+	out  *bufio.Writer
+
+	client   *http.Client
+	writeURL string
+	token    string
+	batches  chan []byte
+	done     chan struct{}
+	errs     []error
+	sent     int
+	dropped  int
+	// failed classifica os resultados como o relatório
+	failed func(stress.Result) bool
+}
+
+// influxOptions reúne as flags de --influx-url e --influx-file
+type influxOptions struct {
+	url    string
+	token  string
+	org    string
+	bucket string
+	file   string
+}
+
+func newInfluxWriter(opts influxOptions, interval time.Duration, failed func(stress.Result) bool) (*influxWriter, error) {
+	w := &influxWriter{interval: interval, series: make(map[influxKey]*influxSeries), lastSend: time.Now(), failed: failed}
+	if opts.file != "" {
+		file, err := os.Create(opts.file)
+		if err != nil {
+			return nil, err
+		}
+		w.file, w.out = file, bufio.NewWriter(file)
+	}
+	if opts.url != "" {
+		query := url.Values{"bucket": {opts.bucket}, "precision": {"ns"}}
+		if opts.org != "" {
+			query.Set("org", opts.org)
+		}
+		w.client = http.DefaultClient
+		w.writeURL = strings.TrimSuffix(opts.url, "/") + "/api/v2/write?" + query.Encode()
+		w.token = opts.token
+		w.batches = make(chan []byte, influxQueueSize)
+		w.done = make(chan struct{})
+		go w.send()
+	}
+	return w, nil
+}
+
+// add registra um resultado no intervalo em que ele terminou, com os limites
+// dos intervalos alinhados ao relógio (múltiplos de interval desde a época
+// Unix). Resultados que chegam depois do fechamento do seu intervalo entram
+// no corrente.
+func (w *influxWriter) add(result stress.Result) {
+	if result.Canceled {
+		return
+	}
+	end := result.Timestamp.Add(result.Duration).UnixNano()
+	if start := end - end%int64(w.interval); start > w.current {
+		w.closeInterval()
+		w.current = start
+	}
+	method, target := splitTargetLabel(result.Target)
+	key := influxKey{method: method, url: target, class: statusClass(result)}
+	series := w.series[key]
+	if series == nil {
+		series = &influxSeries{durations: stress.NewHistogram(int64(time.Microsecond), int64(time.Hour), 2)}
+		w.series[key] = series
+	}
+	series.count++
+	if w.failed(result) {
+		series.errors++
+	}
+	// Erros de transporte não têm resposta cuja duração medir
+	if result.Error != nil && result.StatusCode == 0 && result.GRPCCode == "" {
+		return
+	}
+	series.total += result.Duration
+	series.durations.Record(result.Duration)
+}
+
+// closeInterval escreve as linhas do intervalo corrente e envia o lote
+// quando ele atinge influxBatchLines ou influxBatchInterval
+func (w *influxWriter) closeInterval() {
+	if len(w.series) == 0 {
+		return
+	}
+	keys := slices.SortedFunc(maps.Keys(w.series), func(a, b influxKey) int {
+		return strings.Compare(a.method+" "+a.url+" "+a.class, b.method+" "+b.url+" "+b.class)
+	})
+	for _, key := range keys {
+		series := w.series[key]
+		fields := []string{"count=" + strconv.Itoa(series.count) + "i", "errors=" + strconv.Itoa(series.errors) + "i"}
+		if measured := series.durations.TotalCount(); measured > 0 {
+			fields = append(fields,
+				"avg="+influxMilliseconds(series.total/time.Duration(measured)),
+				"p95="+influxMilliseconds(series.durations.ValueAtQuantile(95)))
+		}
+		w.line("stress_interval", []metricLabel{{"method", key.method}, {"status_class", key.class}, {"url", key.url}}, fields, w.current)
+	}
+	clear(w.series)
+	if w.lines >= influxBatchLines || time.Since(w.lastSend) >= influxBatchInterval {
+		w.flush()
+	}
+}
+
+// line acrescenta uma linha ao lote pendente; tags vazias são omitidas,
+// já que o line protocol não as aceita
+func (w *influxWriter) line(measurement string, tags []metricLabel, fields []string, timestamp int64) {
+	w.pending.WriteString(measurement)
+	for _, tag := range tags {
+		if tag.value != "" {
+			fmt.Fprintf(&w.pending, ",%s=%s", tag.name, influxTagEscaper.Replace(tag.value))
+		}
+	}
+	fmt.Fprintf(&w.pending, " %s %d\n", strings.Join(fields, ","), timestamp)
+	w.lines++
+}
+
+// flush grava o lote pendente no arquivo e o coloca na fila de envio
+func (w *influxWriter) flush() {
+	if w.pending.Len() == 0 {
+		return
+	}
+	if w.out != nil {
+		w.out.Write(w.pending.Bytes())
+	}
+	if w.batches != nil {
+		select {
+		case w.batches <- bytes.Clone(w.pending.Bytes()):
+		default:
+			w.dropped++
+		}
+	}
+	w.pending.Reset()
+	w.lines = 0
+	w.lastSend = time.Now()
+}
+
+// send envia os lotes da fila até que ela seja fechada
+func (w *influxWriter) send() {
+	defer close(w.done)
+	for batch := range w.batches {
+		w.sent++
+		if err := w.write(batch); err != nil {
+			w.errs = append(w.errs, err)
+		}
+	}
+}
+
+func (w *influxWriter) write(batch []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), influxWriteTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.writeURL, bytes.NewReader(batch))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Token "+w.token)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// Close fecha o último intervalo, acrescenta o resumo do relatório e aguarda
+// o envio dos lotes. Retorna os erros da gravação do arquivo e da API.
+func (w *influxWriter) Close(report *stress.Report) error {
+	w.closeInterval()
+	w.summary(report, time.Now().UnixNano())
+	w.flush()
+
+	var errs []error
+	if w.out != nil {
+		if err := w.out.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("--influx-file: %w", err))
+		}
+		w.file.Close()
+	}
+	if w.batches != nil {
+		close(w.batches)
+		<-w.done
+		if w.dropped > 0 {
+			errs = append(errs, fmt.Errorf("%d lotes descartados com a fila de envio cheia", w.dropped))
+		}
+		if len(w.errs) > 0 {
+			errs = append(errs, fmt.Errorf("%d de %d lotes rejeitados pela API, o primeiro com %w", len(w.errs), w.sent, w.errs[0]))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// summary acrescenta o resumo do relatório: uma linha por alvo, com as tags
+// method e url, e uma linha para o teste inteiro, sem tags
+func (w *influxWriter) summary(report *stress.Report, timestamp int64) {
+	for _, label := range slices.Sorted(maps.Keys(report.Targets)) {
+		target := report.Targets[label]
+		method, targetURL := targetLabels(report, label)
+		w.line("stress_summary", []metricLabel{{"method", method}, {"url", targetURL}},
+			append([]string{"count=" + strconv.Itoa(target.Requests) + "i", "errors=" + strconv.Itoa(target.FailedRequests) + "i"},
+				influxDurationFields(target.Durations)...), timestamp)
+	}
+	fields := []string{
+		"count=" + strconv.Itoa(report.TotalRequests) + "i",
+		"errors=" + strconv.Itoa(report.FailedRequests) + "i",
+		"rps=" + strconv.FormatFloat(report.RequestsPerSecond, 'f', -1, 64),
+		"duration=" + strconv.FormatFloat(report.TotalTime.Seconds(), 'f', -1, 64),
+	}
+	fields = append(fields, influxDurationFields(stress.DurationStats{Avg: report.AvgDuration, P50: report.P50, P95: report.P95, P99: report.P99})...)
+	w.line("stress_summary", nil, fields, timestamp)
+}
+
+func influxDurationFields(stats stress.DurationStats) []string {
+	return []string{
+		"avg=" + influxMilliseconds(stats.Avg),
+		"p50=" + influxMilliseconds(stats.P50),
+		"p95=" + influxMilliseconds(stats.P95),
+		"p99=" + influxMilliseconds(stats.P99),
+	}
+}
+
+// influxMilliseconds formata a duração em ms, como campo float
+func influxMilliseconds(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+}
+
+// influxTagEscaper escapa os separadores do line protocol nos valores das
+// tags
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
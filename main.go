@@ -1,170 +1,2111 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/csv"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net"
 	"net/http"
-	"sync"
+	neturl "net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
 )
 
-// Result representa o resultado de uma requisição individual
-type Result struct {
-	StatusCode int
-	Duration   time.Duration
-	Error      error
-}
+// Códigos de saída do processo
+const (
+	exitOK = 0
+	// exitUsage indica parâmetros inválidos ou falha ao executar o teste
+	exitUsage = 1
+	// exitThresholds indica que algum limite de --fail-if foi violado
+	exitThresholds = 2
+	// exitBaseline indica que alguma métrica piorou em relação a --baseline
+	// além da tolerância
+	exitBaseline = 3
+)
 
-// Report contém todas as métricas do teste
-type Report struct {
-	TotalRequests      int
-	SuccessfulRequests int
-	FailedRequests     int
-	TotalTime          time.Duration
-	StatusCodes        map[int]int
-	MinDuration        time.Duration
-	MaxDuration        time.Duration
-	AvgDuration        time.Duration
-}
+// Padrões de --soak para a duração e os intervalos não informados
+const (
+	soakDuration         = time.Hour
+	soakReportInterval   = time.Minute
+	soakTimelineInterval = 10 * time.Second
+)
 
-// StressTest representa a configuração do teste de carga
-type StressTest struct {
-	URL         string
-	Requests    int
-	Concurrency int
-	Client      *http.Client
-}
+// errInterrupted é a causa registrada no relatório quando o teste é
+// interrompido por SIGINT ou SIGTERM
+var errInterrupted = errors.New("sinal de interrupção recebido")
 
-// NewStressTest cria uma nova instância de StressTest
-func NewStressTest(url string, requests, concurrency int) *StressTest {
-	return &StressTest{
-		URL:         url,
-		Requests:    requests,
-		Concurrency: concurrency,
-		Client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "merge":
+			os.Exit(runMerge(os.Args[2:]))
+		case "validate":
+			os.Exit(run(os.Args[2:], true))
+		case "worker":
+			os.Exit(runWorker(os.Args[2:]))
+		}
 	}
+	os.Exit(run(os.Args[1:], false))
 }
 
-// Run executa o teste de carga
-func (st *StressTest) Run() *Report {
-	results := make(chan Result, st.Requests)
-	var wg sync.WaitGroup
-	report := &Report{
-		StatusCodes: make(map[int]int),
-		MinDuration: time.Duration(1<<63 - 1), // Inicializa com o maior valor possível
+// run executa a CLI e retorna o código de saída do processo. Com
+// validateOnly, os parâmetros e arquivos são verificados e o teste não é
+// executado.
+func run(args []string, validateOnly bool) int {
+	// Configuração dos flags
+	url := flag.String("url", "", "URL do serviço a ser testado")
+	curlCommand := flag.String("curl", "", "Comando curl (ex.: copiado do navegador) com a request testada, no lugar de -url; \"-\" lê de stdin")
+	urlFile := flag.String("url-file", "", "Arquivo com um alvo por linha, no formato \"URL\" ou \"MÉTODO URL\"")
+	var targetSpecs stringListFlag
+	flag.Var(&targetSpecs, "target", "Alvo no formato \"url=...,weight=N,method=...\" (pode ser repetido)")
+	seed := flag.Uint64("seed", 0, "Semente do sorteio dos alvos e dos valores aleatórios (0 = aleatória)")
+	cacheBust := flag.Bool("cache-bust", false, "Acrescenta a cada request um parâmetro de query com valor aleatório")
+	cacheBustParam := flag.String("cache-bust-param", "_cb", "Nome do parâmetro usado por -cache-bust")
+	dataFile := flag.String("data", "", "CSV cujas linhas preenchem os templates {{.coluna}} da URL, dos headers e do corpo")
+	dataStop := flag.Bool("data-stop", false, "Encerra o teste quando as linhas de -data acabarem, em vez de recomeçar")
+	var queryParams stringListFlag
+	flag.Var(&queryParams, "query", "Parâmetro de query \"nome=valor\"; {{rand}} recebe um valor aleatório (pode ser repetido)")
+	scenarioFile := flag.String("scenario", "", "Arquivo JSON com os passos de um cenário executado em sequência por cada worker")
+	harFile := flag.String("har", "", "Arquivo HAR cujas requests gravadas são usadas como alvos")
+	var harHosts stringListFlag
+	flag.Var(&harHosts, "har-host", "Usa apenas as entradas do -har deste host (pode ser repetido)")
+	harCookies := flag.Bool("har-cookies", false, "Mantém o header Cookie das entradas do -har")
+	harAuth := flag.Bool("har-auth", false, "Mantém os headers Authorization e Proxy-Authorization das entradas do -har")
+	baseURL := flag.String("base-url", "", "URL base para resolver os caminhos relativos de -url-file, -target e -scenario")
+	requests := flag.Int("requests", 0, "Número total de requests")
+	concurrency := flag.Int("concurrency", 0, "Número de chamadas simultâneas")
+	duration := flag.Duration("duration", 0, "Duração do teste (alternativa a -requests)")
+	rps := flag.Float64("rps", 0, "Limite global de requests por segundo (0 = sem limite)")
+	burst := flag.Int("burst", 1, "Quantidade de requests permitidas em rajada com -rps")
+	rampUp := flag.Duration("ramp-up", 0, "Período para iniciar os workers gradualmente")
+	arrivalRate := flag.Float64("arrival-rate", 0, "Requests iniciadas por segundo em horários fixos, mesmo com o alvo lento (modelo aberto, no lugar de -concurrency)")
+	arrivalDistribution := flag.String("arrival-distribution", "constant", "Intervalos entre as chegadas de -arrival-rate: constant (iguais) ou poisson (sorteados, com rajadas, reproduzíveis com -seed)")
+	maxInFlight := flag.Int("max-in-flight", 0, "Limite de requests em andamento com -arrival-rate; as chegadas acima dele são descartadas e contadas (0 = a taxa de -arrival-rate)")
+	stagesFlag := flag.String("stages", "", "Perfil de carga em etapas duração:valor percorridas em ordem, como 1m:100,1m:200,1m:400 (no lugar de -requests e -duration)")
+	spikeFlag := flag.String("spike", "", "Perfil de pico no modelo aberto, como base=100,peak=2000,peak-duration=20s,total=5m (start= opcional), comparando o pico com a base e medindo a recuperação")
+	findMaxFlag := flag.String("find-max", "", "Busca a maior carga que respeita os limites de -fail-if com sondas curtas, dobrando e depois bissectando, como target=rate,start=50,max=5000,probe=30s,warmup=5s,precision=5% (todas as chaves opcionais)")
+	soak := flag.Bool("soak", false, "Teste longo de estabilidade: dura 1h sem -duration, ativa -report-interval=1m, amostra a memória, as goroutines e o GC do próprio gerador na série no tempo e compara os primeiros com os últimos 10% do teste")
+	stageTarget := flag.String("stage-target", "rate", "O valor das etapas de -stages: rate (chegadas por segundo, modelo aberto) ou concurrency (workers ativos)")
+	excludeRampUp := flag.Bool("exclude-ramp-up", false, "Exclui das métricas de duração as requests do período de ramp-up")
+	thinkTime := flag.Duration("think-time", 0, "Pausa de cada worker entre uma request e a seguinte")
+	thinkTimeJitter := flag.Duration("think-time-jitter", 0, "Variação aleatória, para mais ou para menos, de -think-time")
+	var warmup warmupFlag
+	flag.Var(&warmup, "warmup", "Aquecimento excluído das métricas: quantidade de requests (ex.: 500) ou duração (ex.: 30s)")
+	histogramMax := flag.Duration("histogram-max", time.Hour, "Maior duração registrada com precisão nos percentis")
+	histogramSigFigs := flag.Int("histogram-sigfigs", 3, "Dígitos significativos preservados nos percentis (1 a 5)")
+	gracePeriod := flag.Duration("grace-period", 5*time.Second, "Tempo para requests em andamento terminarem após -duration")
+	method := flag.String("method", http.MethodGet, "Método HTTP utilizado nas requests")
+	body := flag.String("body", "", "Corpo da request")
+	bodyFile := flag.String("body-file", "", "Arquivo com o corpo da request")
+	contentType := flag.String("content-type", "", "Valor do header Content-Type")
+	requestLogPath := flag.String("request-log", "", "Arquivo CSV que recebe uma linha por request")
+	resultsJSONL := flag.String("results-jsonl", "", "Arquivo JSON Lines que recebe, durante o teste, um objeto por request concluída")
+	resultsRotateMB := flag.Int("results-jsonl-rotate-mb", 0, "Tamanho, em MB, a partir do qual -results-jsonl passa a gravar em um novo arquivo (0 = sem rotação)")
+	saveFailures := flag.String("save-failures", "", "Diretório que recebe as primeiras requests com falha e suas respostas, para depuração")
+	saveFailuresMax := flag.Int("save-failures-max", 20, "Quantidade máxima de falhas gravadas com -save-failures")
+	saveFailuresFormat := flag.String("save-failures-format", "files", "Formato de -save-failures: files (um JSON por falha) ou jsonl (um único failures.jsonl)")
+	saveFailuresUnredacted := flag.Bool("save-failures-unredacted", false, "Grava os headers Authorization, Proxy-Authorization, Cookie e Set-Cookie das falhas sem omitir os valores")
+	defaults := stress.DefaultTransportConfig()
+	timeout := flag.Duration("timeout", 10*time.Second, "Timeout total de cada request (0 = sem limite)")
+	dialTimeout := flag.Duration("dial-timeout", defaults.DialTimeout, "Timeout para estabelecer a conexão TCP (0 = sem limite)")
+	tlsTimeout := flag.Duration("tls-timeout", defaults.TLSHandshakeTimeout, "Timeout do handshake TLS (0 = sem limite)")
+	responseHeaderTimeout := flag.Duration("response-header-timeout", defaults.ResponseHeaderTimeout, "Timeout aguardando os headers da resposta (0 = sem limite)")
+	followRedirects := flag.Bool("follow-redirects", true, "Segue redirecionamentos HTTP")
+	maxRedirects := flag.Int("max-redirects", 10, "Quantidade máxima de redirecionamentos seguidos por request")
+	var assertContains, assertNotContains, assertRegex, assertNotRegex stringListFlag
+	flag.Var(&assertContains, "assert-body-contains", "Texto que o corpo de toda resposta deve conter (pode ser repetido)")
+	flag.Var(&assertNotContains, "assert-body-not-contains", "Texto que o corpo das respostas não pode conter (pode ser repetido)")
+	flag.Var(&assertRegex, "assert-body-regex", "Regex que o corpo de toda resposta deve casar (pode ser repetido)")
+	flag.Var(&assertNotRegex, "assert-body-not-regex", "Regex que o corpo das respostas não pode casar (pode ser repetido)")
+	var assertJSON stringListFlag
+	flag.Var(&assertJSON, "assert-json", "Asserção \"caminho=valor\" sobre o corpo JSON, ex.: \"data.status=ok\" (pode ser repetido)")
+	assertMaxBody := flag.Int64("assert-max-body", 1<<20, "Bytes do início do corpo verificados pelas asserções")
+	abortOnErrorRate := flag.Float64("abort-on-error-rate", 0, "Interrompe o teste quando a taxa de falhas da janela ultrapassa o limite, ex.: 0.5 (0 = desativado)")
+	abortWindow := flag.Int("abort-window", 200, "Quantidade de requests da janela de --abort-on-error-rate")
+	abortOnConsecutiveErrors := flag.Int("abort-on-consecutive-errors", 0, "Interrompe o teste após N falhas seguidas (0 = desativado)")
+	apdexT := flag.Duration("apdex-t", 0, "Tempo de resposta satisfatório do índice Apdex, ex.: 500ms (0 = não calcula)")
+	var failIf stringListFlag
+	flag.Var(&failIf, "fail-if", "Limite que, se violado, encerra com código 2, ex.: \"p95>300ms\", \"error_rate>1%\" ou \"rps<500\" (pode ser repetido)")
+	expectStatus := flag.String("expect-status", "", "Status HTTP considerados sucesso, ex.: \"200-204,404\" (padrão: 2xx e 3xx)")
+	retries := flag.Int("retries", 0, "Repete até N vezes as requests que falham por erro de transporte ou com os status de --retry-status (0 = sem retentativas)")
+	retryBackoff := flag.String("retry-backoff", "", "Backoff exponencial com jitter entre as retentativas, no formato \"base,max\" (padrão: 100ms,5s)")
+	respectRetryAfter := flag.Bool("respect-retry-after", false, "Pausa o worker que recebe 429 ou 503 com Retry-After pelo tempo indicado antes da retentativa ou da próxima request")
+	retryAfterMax := flag.Duration("retry-after-max", 0, "Limite de cada pausa de --respect-retry-after (0 = 1m)")
+	retryStatus := flag.String("retry-status", "", "Status HTTP também repetidos por --retries, ex.: \"502-504\" (padrão: apenas erros de transporte)")
+	insecure := flag.Bool("insecure", false, "Não verifica o certificado TLS do servidor")
+	caCert := flag.String("cacert", "", "Arquivo PEM com as autoridades certificadoras usadas para verificar o servidor")
+	certFile := flag.String("cert", "", "Certificado PEM de client para mTLS")
+	keyFile := flag.String("key", "", "Chave privada PEM do certificado de client")
+	http2 := flag.Bool("http2", false, "Negocia HTTP/2 e destaca no relatório respostas em outro protocolo")
+	h2c := flag.Bool("h2c", false, "Usa HTTP/2 sem TLS (h2c) para URLs http://")
+	http1 := flag.Bool("http1", false, "Força HTTP/1.1 em todas as requests")
+	http3 := flag.Bool("http3", false, "Usa HTTP/3 sobre QUIC em vez de TCP")
+	quicHandshakeTimeout := flag.Duration("quic-handshake-timeout", 0, "Timeout do handshake QUIC com -http3 (0 = padrão do quic-go)")
+	quicIdleTimeout := flag.Duration("quic-idle-timeout", 0, "Timeout de inatividade da conexão QUIC com -http3 (0 = padrão do quic-go)")
+	disableKeepAlive := flag.Bool("disable-keepalive", false, "Abre uma nova conexão para cada request")
+	maxIdleConns := flag.Int("max-idle-conns", 0, "Máximo de conexões ociosas no pool (0 = automático, baseado em -concurrency)")
+	maxIdleConnsPerHost := flag.Int("max-idle-conns-per-host", 0, "Máximo de conexões ociosas por host (0 = automático, igual a -concurrency)")
+	maxConnsPerHost := flag.Int("max-conns-per-host", 0, "Máximo de conexões por host (0 = sem limite)")
+	proxy := flag.String("proxy", "", "URL do proxy (http://, https:// ou socks5://, com credenciais opcionais)")
+	noProxyEnv := flag.Bool("no-proxy-env", false, "Ignora as variáveis HTTP_PROXY, HTTPS_PROXY e NO_PROXY")
+	unixSocket := flag.String("unix-socket", "", "Conecta pelo socket Unix informado em vez de TCP (a URL define path e Host)")
+	host := flag.String("host", "", "Substitui o header Host (e o SNI) das requests")
+	connectTo := flag.String("connect-to", "", "Conecta sempre em ip:porta, mantendo a URL, o SNI e o Host originais")
+	var resolveEntries stringListFlag
+	flag.Var(&resolveEntries, "resolve", "Endereço fixo no formato host:porta:endereço (pode ser repetido)")
+	dnsServer := flag.String("dns-server", "", "Servidor DNS (ip:porta) usado para resolver os nomes")
+	noBodyRead := flag.Bool("no-body-read", false, "Não lê o corpo das respostas, medindo apenas até os headers")
+	skipBody := flag.Bool("skip-body", false, "Fecha as respostas sem ler o corpo, como -no-body-read; os tamanhos ficam indisponíveis e, no HTTP/1.1, as conexões não são reaproveitadas")
+	maxBodyBytes := flag.Int64("max-body-bytes", 10<<20, "Máximo de bytes lidos de cada corpo; respostas maiores são abandonadas no limite e contam como falha (0 = sem limite)")
+	discardBody := flag.Bool("discard-body", false, "Lê o corpo das respostas até o fim e o descarta sem guardá-lo, mantendo o reuso das conexões")
+	traceFlag := flag.Bool("trace", false, "Detalha o tempo de DNS, conexão, TLS e servidor de cada request")
+	verbose := flag.Bool("v", false, "Registra em stderr cada request concluída: horário, worker, método, URL, status, duração e erro")
+	veryVerbose := flag.Bool("vv", false, "Como -v, incluindo os headers da request e da resposta")
+	noProgress := flag.Bool("no-progress", false, "Desativa a linha de progresso em stderr")
+	webAddr := flag.String("web", "", "Endereço (ex.: :8089) de uma página com gráficos do teste em tempo real e o relatório JSON ao fim; o processo aguarda Ctrl+C após o teste")
+	tui := flag.Bool("tui", false, "Exibe durante o teste um painel em tela cheia com RPS, latências, erros e status, atualizado a cada 500ms; fora do terminal, usa a linha de progresso")
+	pushgatewayURL := flag.String("pushgateway-url", "", "URL do Prometheus Pushgateway que recebe as métricas do relatório ao fim do teste")
+	pushJob := flag.String("push-job", "stress_test", "Job da chave de agrupamento com -pushgateway-url")
+	var pushLabels stringListFlag
+	flag.Var(&pushLabels, "label", "Label \"nome=valor\" da chave de agrupamento de -pushgateway-url (pode ser repetido)")
+	statsdAddr := flag.String("statsd-addr", "", "Servidor StatsD (host:porta, UDP) que recebe a duração de cada request e os erros")
+	statsdFormat := flag.String("statsd-format", "statsd", "Formato das métricas de -statsd-addr: statsd (sem tags) ou dogstatsd (com tags)")
+	statsdPrefix := flag.String("statsd-prefix", "stress.", "Prefixo dos nomes das métricas de -statsd-addr")
+	statsdSampleRate := flag.Float64("statsd-sample-rate", 1, "Fração das durações enviadas a -statsd-addr, de 0 a 1; os erros são sempre enviados")
+	influxURL := flag.String("influx-url", "", "URL do InfluxDB que recebe, em line protocol, os agregados por intervalo e o resumo do teste")
+	influxToken := flag.String("influx-token", "", "Token da API do InfluxDB (padrão: a variável INFLUX_TOKEN)")
+	influxOrg := flag.String("influx-org", "", "Organização do InfluxDB 2")
+	influxBucket := flag.String("influx-bucket", "", "Bucket do InfluxDB que recebe as métricas (no InfluxDB 1.8, \"banco/retention-policy\")")
+	influxFile := flag.String("influx-file", "", "Grava as linhas de line protocol em um arquivo, para importação posterior")
+	metricsAddr := flag.String("metrics-addr", "", "Endereço (ex.: :9090) em que as métricas do teste são servidas em /metrics no formato do Prometheus")
+	reportInterval := flag.Duration("report-interval", 0, "Imprime em stderr um resumo a cada intervalo: tempo, requests, RPS, P95 e taxa de erros do intervalo (0 = desativado)")
+	intervalJSONL := flag.String("interval-jsonl", "", "Arquivo JSON Lines que recebe um objeto por resumo de -report-interval")
+	quiet := flag.Bool("quiet", false, "Suprime o relatório, o progresso e os avisos, imprimindo apenas uma linha de resumo chave=valor (em stderr com -output=json)")
+	cookies := flag.Bool("cookies", false, "Dá a cada worker um cookie jar próprio, mantendo os cookies recebidos entre as requests")
+	var cookieValues stringListFlag
+	flag.Var(&cookieValues, "cookie", "Cookie \"nome=valor\" registrado no jar de todos os workers (pode ser repetido)")
+	perWorkerClient := flag.Bool("per-worker-client", false, "Dá a cada worker um client e um transporte próprios, com conexões exclusivas")
+	clientIDHeader := flag.String("client-id-header", "", "Header enviado com a identidade estável de cada worker (ex.: X-Client-Id: worker-17)")
+	otel := flag.Bool("otel", false, "Envia o header W3C traceparent em cada request HTTP, com o trace ID no log de -v e de -request-log")
+	otelExport := flag.Bool("otel-export", false, "Exporta um span de client por request amostrada via OTLP/HTTP (configurado pelas variáveis OTEL_EXPORTER_OTLP_*); implica -otel")
+	otelSampleRate := flag.Float64("otel-sample-rate", 1, "Fração das requests marcadas como amostradas no traceparent e exportadas por -otel-export, de 0 a 1")
+	slowest := flag.Int("slowest", 10, "Quantidade de requests mais lentas listadas no relatório com -otel, com o trace ID de cada uma (0 = nenhuma)")
+	sourcePorts := flag.String("source-ports", "", "Intervalo \"min-max\" das portas de origem; com -per-worker-client, dividido entre os workers")
+	user := flag.String("user", "", "Credenciais de autenticação básica no formato \"nome:senha\"")
+	userEnv := flag.String("user-env", "", "Variável de ambiente com as credenciais no formato \"nome:senha\"")
+	bearerToken := flag.String("bearer-token", "", "Token enviado no header \"Authorization: Bearer\"")
+	bearerTokenFile := flag.String("bearer-token-file", "", "Arquivo com o token enviado no header \"Authorization: Bearer\"")
+	bearerTokenRefresh := flag.Duration("bearer-token-refresh", 0, "Intervalo para reler o arquivo de -bearer-token-file (0 = ler apenas uma vez)")
+	output := flag.String("output", "text", "Formato do relatório (text|json|markdown)")
+	outputHTML := flag.String("output-html", "", "Grava também um relatório HTML autocontido, com gráficos, no arquivo informado")
+	saveReport := flag.String("save-report", "", "Grava o relatório completo em JSON, com a versão do formato, para uso posterior com -baseline")
+	baselinePath := flag.String("baseline", "", "Relatório de -save-report comparado ao teste atual; regressões além da tolerância encerram com código 3")
+	baselineTolerancePct := flag.Float64("baseline-tolerance", 10, "Piora aceita, em %, nos percentis P50/P95/P99 e no RPS em relação a -baseline")
+	baselineErrorTolerance := flag.Float64("baseline-error-tolerance", 1, "Aumento aceito na taxa de erros em relação a -baseline, em pontos percentuais")
+	timelineInterval := flag.Duration("timeline-interval", time.Second, "Duração dos intervalos da série no tempo do JSON, de -timeline-csv e dos gráficos HTML (mínimo de 10ms)")
+	timelineCSV := flag.String("timeline-csv", "", "Grava a série no tempo (requests, erros e durações por intervalo) em um arquivo CSV")
+	histogramBuckets := histogramFlag{buckets: defaultHistogramBuckets}
+	flag.Var(&histogramBuckets, "histogram-buckets", "Faixas do histograma de latências do relatório: uma quantidade de faixas logarítmicas (ex.: 20) ou os limites (ex.: 10ms,50ms,100ms)")
+	noHistogram := flag.Bool("no-histogram", false, "Omite o histograma de latências do relatório em texto e Markdown")
+	noColor := flag.Bool("no-color", false, "Imprime o relatório sem cores mesmo no terminal (também desativadas com a variável NO_COLOR)")
+	userAgent := flag.String("user-agent", "", "User-Agent enviado em todas as requests (padrão: o do Go)")
+	userAgentFile := flag.String("user-agent-file", "", "Arquivo com um User-Agent por linha, alternados entre as requests")
+	userAgentMode := flag.String("user-agent-mode", "round-robin", "Escolha dos User-Agents de -user-agent-file (round-robin|random)")
+	compressionMode := flag.String("compression", "", "Accept-Encoding das requests: gzip (descomprime e mede o tamanho no fio), none ou identity (padrão: gzip automático do Go)")
+	var formFields, formFiles stringListFlag
+	flag.Var(&formFields, "form", "Campo \"nome=valor\" de um corpo url-encoded ou, com -form-file, multipart (pode ser repetido)")
+	flag.Var(&formFiles, "form-file", "Arquivo \"campo=/caminho\" enviado em um corpo multipart/form-data (pode ser repetido)")
+	graphqlQuery := flag.String("graphql-query", "", "Query GraphQL enviada via POST, inline ou @arquivo")
+	graphqlVariables := flag.String("graphql-variables", "", "Objeto JSON com as variáveis da query GraphQL, inline ou @arquivo; aceita os templates de -data")
+	graphqlOperation := flag.String("graphql-operation", "", "Nome da operação GraphQL executada quando a query define várias")
+	grpcTarget := flag.String("grpc", "", "Servidor gRPC (host:porta) testado com chamadas unárias no lugar de -url")
+	grpcMethod := flag.String("grpc-method", "", "Método chamado com -grpc, no formato \"pacote.Servico/Metodo\"; o corpo JSON vem de -body ou -body-file")
+	grpcProtoset := flag.String("grpc-protoset", "", "Descritores gerados com \"protoc --include_imports --descriptor_set_out\", usados no lugar da reflection do servidor")
+	grpcPlaintext := flag.Bool("grpc-plaintext", false, "Conecta ao servidor gRPC sem TLS")
+	grpcTimeout := flag.Duration("grpc-timeout", 0, "Deadline de cada chamada gRPC (0 = o valor de -timeout)")
+	grpcConnections := flag.Int("grpc-connections", 1, "Quantidade de canais gRPC divididos entre os workers")
+	wsMode := flag.Bool("ws", false, "Mantém uma conexão WebSocket por worker com -url (ws:// ou wss://), enviando -body como mensagem")
+	wsInterval := flag.Duration("ws-interval", 0, "Intervalo entre as mensagens de cada conexão com -ws")
+	wsMaxMessageSize := flag.Int64("ws-max-message-size", 1<<20, "Tamanho máximo das mensagens recebidas com -ws; uma maior encerra a conexão")
+	wsBinary := flag.Bool("ws-binary", false, "Envia as mensagens de -ws como binárias em vez de texto")
+	sseMode := flag.Bool("sse", false, "Mantém um stream Server-Sent Events por worker até o fim de -duration, contando os eventos recebidos")
+	sseMaxLineSize := flag.Int("sse-max-line-size", 1<<20, "Tamanho máximo de cada linha do stream com -sse; uma maior encerra o stream como malformado")
+	var headers headerFlag
+	flag.Var(&headers, "header", "Header no formato \"Nome: Valor\" (pode ser repetido)")
+	version := flag.Bool("version", false, "Exibe a versão e encerra")
+	configFile := flag.String("config", "", "Arquivo YAML ou JSON com os valores dos flags; os flags da linha de comando prevalecem")
+	serveAddr := flag.String("serve", "", "Endereço (ex.: :8080) em que a CLI atende uma API HTTP para iniciar e acompanhar testes, em vez de executar um")
+	serveToken := flag.String("serve-token", "", "Token exigido pela API de -serve no header \"Authorization: Bearer <token>\"; aceita ${VAR} e ${file:/caminho}")
+	serveMaxTests := flag.Int("serve-max-tests", 1, "Quantidade de testes executados ao mesmo tempo pela API de -serve")
+	workers := flag.String("workers", "", "Workers (host:porta, separados por vírgula) iniciados com \"stress-test worker\" que dividem as requests e o RPS do teste")
+	workerTokenFlag := flag.String("worker-token", "", "Token enviado aos workers de -workers no header \"Authorization: Bearer <token>\" (o --token do worker); aceita ${VAR} e ${file:/caminho}")
+	// Erros nos flags encerram com exitUsage, e não com o código 2 padrão
+	// do pacote flag, reservado a --fail-if
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	if err := flag.CommandLine.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
 	}
 
-	// Inicia o timer
-	startTime := time.Now()
-
-	// Cria um canal para controlar o número de requests
-	requestChan := make(chan struct{}, st.Requests)
-	for i := 0; i < st.Requests; i++ {
-		requestChan <- struct{}{}
+	if *version {
+		fmt.Printf("stress-test %s\n", stress.Version)
+		return exitOK
 	}
-	close(requestChan)
-
-	// Inicia as goroutines de teste
-	for i := 0; i < st.Concurrency; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for range requestChan {
-				start := time.Now()
-				resp, err := st.Client.Get(st.URL)
-				duration := time.Since(start)
+	// As referências ${VAR} e ${file:/caminho} são substituídas nos valores
+	// do --config e, na linha de comando, em --url, --header e --worker-token
+	secrets := &secrets{}
+	commandLine := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { commandLine[f.Name] = true })
+	// Na API de --serve, cada teste traz a própria configuração
+	if *serveAddr != "" {
+		var others []string
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name != "serve" && f.Name != "serve-token" && f.Name != "serve-max-tests" {
+				others = append(others, "--"+f.Name)
+			}
+		})
+		if len(others) > 0 || validateOnly {
+			fmt.Println("Erro: --serve aceita apenas --serve-token e --serve-max-tests; os testes são configurados em POST /tests")
+			return exitUsage
+		}
+		if *serveMaxTests < 1 {
+			fmt.Println("Erro: --serve-max-tests deve ser ao menos 1")
+			return exitUsage
+		}
+		token := secrets.expand(*serveToken)
+		if err := secrets.check(); err != nil {
+			fmt.Printf("Erro: %v\n", err)
+			return exitUsage
+		}
+		return runServe(*serveAddr, token, *serveMaxTests)
+	}
+	var inlineScenario []byte
+	if *configFile != "" {
+		var err error
+		if inlineScenario, err = loadConfig(flag.CommandLine, *configFile, secrets.expand); err != nil {
+			fmt.Printf("Erro: --config %s: %v\n", *configFile, err)
+			return exitUsage
+		}
+	} else if validateOnly {
+		fmt.Println("Erro: validate requer --config")
+		return exitUsage
+	}
+	if commandLine["url"] {
+		*url = secrets.expand(*url)
+	}
+	if commandLine["header"] {
+		for i, raw := range headers {
+			headers[i] = secrets.expand(raw)
+		}
+	}
+	if commandLine["worker-token"] {
+		*workerTokenFlag = secrets.expand(*workerTokenFlag)
+	}
+	if err := secrets.check(); err != nil {
+		fmt.Printf("Erro: %v\n", err)
+		return exitUsage
+	}
+	// --skip-body é o nome novo de --no-body-read
+	*noBodyRead = *noBodyRead || *skipBody
 
-				if err != nil {
-					results <- Result{Error: err}
-					continue
-				}
+	// --curl preenche os flags da request a partir do comando; os flags
+	// informados junto prevalecem sobre as opções equivalentes do curl
+	var curlValues map[string][]string
+	// methodGiven indica um método escolhido com --method ou com o -X do
+	// --curl, e não o padrão
+	methodGiven := false
+	flag.Visit(func(f *flag.Flag) { methodGiven = methodGiven || f.Name == "method" })
+	if *curlCommand != "" {
+		if *url != "" || *urlFile != "" || len(targetSpecs) > 0 || *harFile != "" || *scenarioFile != "" || *grpcTarget != "" {
+			fmt.Println("Erro: --curl não pode ser usado junto com --url, --url-file, --target, --har, --scenario ou --grpc")
+			return exitUsage
+		}
+		command := *curlCommand
+		if command == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Printf("Erro: não foi possível ler o comando curl: %v\n", err)
+				return exitUsage
+			}
+			command = string(data)
+		}
+		curl, err := stress.ParseCurl(command)
+		if err != nil {
+			fmt.Printf("Erro: --curl inválido: %v\n", err)
+			return exitUsage
+		}
+		if warning := curlWarning(curl); warning != "" {
+			fmt.Fprintln(os.Stderr, warning)
+		}
+		explicit := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
 
-				resp.Body.Close()
-				results <- Result{
-					StatusCode: resp.StatusCode,
-					Duration:   duration,
-				}
+		*url = curl.URL
+		if !explicit["method"] {
+			*method = curl.Method
+			methodGiven = curl.ExplicitMethod
+		}
+		if curl.Body != nil && *body == "" && *bodyFile == "" && len(formFields) == 0 && len(formFiles) == 0 && *graphqlQuery == "" {
+			*body = string(curl.Body)
+		}
+		given := make(map[string]bool)
+		for _, raw := range headers {
+			name, _, _ := strings.Cut(raw, ":")
+			given[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+		}
+		if *contentType != "" {
+			given["Content-Type"] = true
+		}
+		var curlHeaders headerFlag
+		for _, name := range slices.Sorted(maps.Keys(curl.Header)) {
+			if given[name] {
+				continue
 			}
-		}()
+			for _, value := range curl.Header[name] {
+				curlHeaders = append(curlHeaders, name+": "+value)
+			}
+		}
+		headers = append(curlHeaders, headers...)
+		if curl.BasicAuth != nil && *user == "" && *userEnv == "" {
+			*user = curl.BasicAuth.Username + ":" + curl.BasicAuth.Password
+		}
+		if curl.Insecure && !explicit["insecure"] {
+			*insecure = true
+		}
+		if curl.Compressed && *compressionMode == "" {
+			*compressionMode = "gzip"
+		}
+		// Os workers de --workers recebem os valores lidos, e não o comando,
+		// que poderia ler arquivos com -d @arquivo
+		curlValues = map[string][]string{"url": {*url}, "method": {*method}, "header": headers}
+		if *body != "" {
+			curlValues["body"] = []string{*body}
+		}
+		if *user != "" && *userEnv == "" {
+			curlValues["user"] = []string{*user}
+		}
+		if *insecure {
+			curlValues["insecure"] = []string{"true"}
+		}
+		if *compressionMode != "" {
+			curlValues["compression"] = []string{*compressionMode}
+		}
 	}
 
-	// Coleta os resultados
-	var totalDuration time.Duration
-	for i := 0; i < st.Requests; i++ {
-		result := <-results
-		report.TotalRequests++
+	// Validação dos parâmetros
+	if *stageTarget != "rate" && *stageTarget != "concurrency" {
+		fmt.Printf("Erro: --stage-target inválido %q: use rate ou concurrency\n", *stageTarget)
+		return exitUsage
+	}
+	var stages []stress.Stage
+	if *stagesFlag != "" {
+		var err error
+		if stages, err = stress.ParseStages(*stagesFlag, *stageTarget == "concurrency"); err != nil {
+			fmt.Printf("Erro: --stages: %v\n", err)
+			return exitUsage
+		}
+	} else if *stageTarget != "rate" {
+		fmt.Println("Erro: --stage-target requer --stages")
+		return exitUsage
+	}
+	// --spike é executado como as etapas de --stages, com as mesmas restrições
+	profile := "--stages"
+	var spike *stress.Spike
+	if *spikeFlag != "" {
+		if *stagesFlag != "" {
+			fmt.Println("Erro: use apenas um entre --stages e --spike")
+			return exitUsage
+		}
+		parsed, err := stress.ParseSpike(*spikeFlag)
+		if err != nil {
+			fmt.Printf("Erro: --spike: %v\n", err)
+			return exitUsage
+		}
+		spike, stages, profile = &parsed, parsed.Stages(), "--spike"
+	}
+	// --find-max executa sondas com a própria duração, aquecimento e carga
+	var findMax *stress.FindMax
+	if *findMaxFlag != "" {
+		parsed, err := stress.ParseFindMax(*findMaxFlag)
+		if err != nil {
+			fmt.Printf("Erro: --find-max: %v\n", err)
+			return exitUsage
+		}
+		switch {
+		case len(failIf) == 0:
+			fmt.Println("Erro: --find-max requer ao menos um --fail-if, os limites que cada sonda deve respeitar")
+			return exitUsage
+		case *requests > 0 || *duration > 0 || len(stages) > 0 || *soak:
+			fmt.Println("Erro: --find-max define a duração das sondas e não pode ser usado com --requests, --duration, --stages, --spike ou --soak")
+			return exitUsage
+		case *concurrency > 0 || *arrivalRate > 0:
+			fmt.Println("Erro: --find-max define a carga de cada sonda e não pode ser usado com --concurrency ou --arrival-rate")
+			return exitUsage
+		case warmup.requests > 0 || warmup.duration > 0:
+			fmt.Println("Erro: use a chave warmup de --find-max no lugar de --warmup")
+			return exitUsage
+		case *workers != "" || *tui || *webAddr != "" || *sourcePorts != "":
+			fmt.Println("Erro: --find-max não pode ser usado com --workers, --tui, --web ou --source-ports")
+			return exitUsage
+		case parsed.Rate && (*rps > 0 || *rampUp > 0 || *thinkTime > 0 || *thinkTimeJitter > 0 || *wsMode || *sseMode):
+			fmt.Println("Erro: --rps, --ramp-up, --think-time, --ws e --sse não podem ser usados com target=rate de --find-max")
+			return exitUsage
+		case !parsed.Rate && (*maxInFlight > 0 || *arrivalDistribution != "constant"):
+			fmt.Println("Erro: --max-in-flight e --arrival-distribution requerem target=rate em --find-max")
+			return exitUsage
+		}
+		findMax = &parsed
+		// Os pools de conexões comportam a maior carga prevista
+		*concurrency = max(parsed.Max, parsed.Start)
+	}
+	// --soak completa a duração e os intervalos não informados, inclusive no
+	// --config
+	if *soak {
+		if *requests > 0 {
+			fmt.Println("Erro: --soak usa --duration, --stages ou --spike, não --requests")
+			return exitUsage
+		}
+		explicit := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+		if *duration == 0 && len(stages) == 0 {
+			*duration = soakDuration
+		}
+		if *reportInterval == 0 && !*quiet && !*tui {
+			*reportInterval = soakReportInterval
+		}
+		if !explicit["timeline-interval"] {
+			*timelineInterval = soakTimelineInterval
+		}
+	}
+	plannedDuration := *duration
+	for _, stage := range stages {
+		plannedDuration += stage.Duration
+	}
+	if (*url == "" && *urlFile == "" && len(targetSpecs) == 0 && *scenarioFile == "" && *harFile == "" && *grpcTarget == "") || (*concurrency <= 0 && *arrivalRate <= 0 && len(stages) == 0) || (*requests <= 0 && *duration <= 0 && len(stages) == 0 && findMax == nil) {
+		fmt.Println("Erro: Todos os parâmetros são obrigatórios e devem ser válidos")
+		fmt.Println("Uso: ./stress-test --url=<URL> --requests=<N> --concurrency=<N>")
+		fmt.Println("     ./stress-test --url=<URL> --duration=<D> --concurrency=<N>")
+		fmt.Println("     ./stress-test --url=<URL> --duration=<D> --arrival-rate=<N>")
+		fmt.Println("     ./stress-test --url=<URL> --stages=<D:N,D:N...> [--stage-target=concurrency]")
+		fmt.Println("     ./stress-test --url=<URL> --spike=base=<N>,peak=<N>,peak-duration=<D>,total=<D>")
+		fmt.Println("     ./stress-test --curl=\"curl ...\" --requests=<N> --concurrency=<N>")
+		fmt.Println("     ./stress-test --url-file=<arquivo> --requests=<N> --concurrency=<N>")
+		fmt.Println("     ./stress-test --scenario=<arquivo> --requests=<N> --concurrency=<N>")
+		fmt.Println("     ./stress-test --har=<arquivo> --requests=<N> --concurrency=<N>")
+		fmt.Println("     ./stress-test --grpc=<host:porta> --grpc-method=<pacote.Servico/Metodo> --requests=<N> --concurrency=<N>")
+		fmt.Println("     ./stress-test --config=<arquivo.yaml>")
+		fmt.Println("     ./stress-test validate --config=<arquivo.yaml>")
+		fmt.Println("     ./stress-test merge <relatório.json> <relatório.json>...")
+		fmt.Println("     ./stress-test worker [--listen=<endereço>] [--token=<token>]")
+		fmt.Println("     ./stress-test --serve=<endereço> [--serve-token=<token>]")
+		return exitUsage
+	}
+	if *grpcTarget != "" {
+		if *url != "" || *urlFile != "" || len(targetSpecs) > 0 || *scenarioFile != "" || len(formFields) > 0 || len(formFiles) > 0 || *graphqlQuery != "" {
+			fmt.Println("Erro: --grpc não pode ser usado junto com --url, --url-file, --target, --scenario, --form, --form-file ou --graphql-query")
+			return exitUsage
+		}
+		if *http1 || *http2 || *h2c || *http3 || *proxy != "" || *unixSocket != "" || *connectTo != "" || len(resolveEntries) > 0 || *dnsServer != "" || *sourcePorts != "" || *perWorkerClient || *host != "" {
+			fmt.Println("Erro: as opções de transporte HTTP não se aplicam a --grpc")
+			return exitUsage
+		}
+		if len(assertContains) > 0 || len(assertNotContains) > 0 || len(assertRegex) > 0 || len(assertNotRegex) > 0 || len(assertJSON) > 0 ||
+			*traceFlag || *noBodyRead || *cookies || len(cookieValues) > 0 || *userAgent != "" || *userAgentFile != "" || *compressionMode != "" {
+			fmt.Println("Erro: asserções, --trace, --skip-body, cookies, User-Agent e --compression não se aplicam a --grpc")
+			return exitUsage
+		}
+		if *grpcMethod == "" {
+			fmt.Println("Erro: --grpc requer --grpc-method")
+			return exitUsage
+		}
+		if *grpcTimeout < 0 || *grpcConnections < 1 {
+			fmt.Println("Erro: --grpc-timeout não pode ser negativo e --grpc-connections deve ser ao menos 1")
+			return exitUsage
+		}
+		if *grpcPlaintext && (*insecure || *caCert != "" || *certFile != "") {
+			fmt.Println("Erro: --grpc-plaintext não pode ser usado junto com --insecure, --cacert ou --cert")
+			return exitUsage
+		}
+	} else if *grpcMethod != "" || *grpcProtoset != "" || *grpcPlaintext || *grpcTimeout != 0 {
+		fmt.Println("Erro: --grpc-method, --grpc-protoset, --grpc-plaintext e --grpc-timeout requerem --grpc")
+		return exitUsage
+	}
+	if *wsMode {
+		if *url == "" || *grpcTarget != "" || *urlFile != "" || len(targetSpecs) > 0 || *scenarioFile != "" || len(formFields) > 0 || len(formFiles) > 0 || *graphqlQuery != "" {
+			fmt.Println("Erro: --ws requer --url e não pode ser usado junto com --grpc, --url-file, --target, --scenario, --form, --form-file ou --graphql-query")
+			return exitUsage
+		}
+		if *http1 || *http2 || *h2c || *http3 {
+			fmt.Println("Erro: --ws sempre usa HTTP/1.1 no handshake e não pode ser usado junto com --http1, --http2, --h2c ou --http3")
+			return exitUsage
+		}
+		if len(assertContains) > 0 || len(assertNotContains) > 0 || len(assertRegex) > 0 || len(assertNotRegex) > 0 || len(assertJSON) > 0 ||
+			*traceFlag || *noBodyRead || *compressionMode != "" {
+			fmt.Println("Erro: asserções, --trace, --skip-body e --compression não se aplicam a --ws")
+			return exitUsage
+		}
+		if *body == "" && *bodyFile == "" && *duration == 0 {
+			fmt.Println("Erro: sem --body ou --body-file, --ws apenas mantém as conexões abertas e requer --duration")
+			return exitUsage
+		}
+		if *wsInterval < 0 || *wsMaxMessageSize <= 0 {
+			fmt.Println("Erro: --ws-interval não pode ser negativo e --ws-max-message-size deve ser maior que zero")
+			return exitUsage
+		}
+		if *wsInterval > 0 && *thinkTime > 0 {
+			fmt.Println("Erro: use apenas um entre --ws-interval e --think-time")
+			return exitUsage
+		}
+	} else if *wsInterval != 0 || *wsBinary {
+		fmt.Println("Erro: --ws-interval e --ws-binary requerem --ws")
+		return exitUsage
+	}
+	if *sseMode {
+		if *grpcTarget != "" || *wsMode || *scenarioFile != "" {
+			fmt.Println("Erro: --sse não pode ser usado junto com --grpc, --ws ou --scenario")
+			return exitUsage
+		}
+		if len(assertContains) > 0 || len(assertNotContains) > 0 || len(assertRegex) > 0 || len(assertNotRegex) > 0 || len(assertJSON) > 0 ||
+			*traceFlag || *noBodyRead || *compressionMode != "" {
+			fmt.Println("Erro: asserções, --trace, --skip-body e --compression não se aplicam a --sse")
+			return exitUsage
+		}
+		if *duration == 0 {
+			fmt.Println("Erro: --sse mantém os streams abertos até o fim do teste e requer --duration")
+			return exitUsage
+		}
+		if *sseMaxLineSize <= 0 {
+			fmt.Println("Erro: --sse-max-line-size deve ser maior que zero")
+			return exitUsage
+		}
+	}
+	if *scenarioFile != "" && (*url != "" || *urlFile != "" || len(targetSpecs) > 0) {
+		fmt.Println("Erro: --scenario não pode ser usado junto com --url, --url-file ou --target")
+		return exitUsage
+	}
+	if *scenarioFile != "" && (*body != "" || *bodyFile != "") {
+		fmt.Println("Erro: em --scenario o corpo é definido em cada passo, não com --body ou --body-file")
+		return exitUsage
+	}
+	if *harFile != "" {
+		if *url != "" || *urlFile != "" || len(targetSpecs) > 0 || *scenarioFile != "" || *grpcTarget != "" || *wsMode || *sseMode {
+			fmt.Println("Erro: --har não pode ser usado junto com --url, --url-file, --target, --scenario, --grpc, --ws ou --sse")
+			return exitUsage
+		}
+		if *body != "" || *bodyFile != "" || len(formFields) > 0 || len(formFiles) > 0 || *graphqlQuery != "" {
+			fmt.Println("Erro: com --har o corpo de cada request vem do arquivo, não de --body, --body-file, --form, --form-file ou --graphql-query")
+			return exitUsage
+		}
+	} else if len(harHosts) > 0 || *harCookies || *harAuth {
+		fmt.Println("Erro: --har-host, --har-cookies e --har-auth requerem --har")
+		return exitUsage
+	}
+	if *url != "" && (*urlFile != "" || len(targetSpecs) > 0) {
+		fmt.Println("Erro: --url não pode ser usado junto com --url-file ou --target")
+		return exitUsage
+	}
+	if *baseURL != "" && *urlFile == "" && len(targetSpecs) == 0 && *scenarioFile == "" {
+		fmt.Println("Erro: --base-url só pode ser usado junto com --url-file, --target ou --scenario")
+		return exitUsage
+	}
 
-		if result.Error == nil {
-			report.StatusCodes[result.StatusCode]++
-			if result.StatusCode == http.StatusOK {
-				report.SuccessfulRequests++
-			} else {
-				report.FailedRequests++
+	if *requests > 0 && *duration > 0 {
+		fmt.Println("Erro: use apenas um entre --requests e --duration")
+		return exitUsage
+	}
+	if *rps < 0 || *burst < 1 {
+		fmt.Println("Erro: --rps não pode ser negativo e --burst deve ser ao menos 1")
+		return exitUsage
+	}
+	if *rampUp < 0 {
+		fmt.Println("Erro: --ramp-up não pode ser negativo")
+		return exitUsage
+	}
+	if *duration > 0 && *rampUp > *duration {
+		fmt.Println("Erro: --ramp-up não pode ser maior que --duration")
+		return exitUsage
+	}
+	if *arrivalRate < 0 || *maxInFlight < 0 {
+		fmt.Println("Erro: --arrival-rate e --max-in-flight não podem ser negativos")
+		return exitUsage
+	}
+	rateStages := len(stages) > 0 && *stageTarget == "rate"
+	if (*maxInFlight > 0 || *arrivalDistribution != "constant") && *arrivalRate == 0 && !rateStages && findMax == nil {
+		fmt.Println("Erro: --max-in-flight e --arrival-distribution requerem --arrival-rate, --stages com taxas ou --spike")
+		return exitUsage
+	}
+	if len(stages) > 0 {
+		switch {
+		case *requests > 0 || *duration > 0:
+			fmt.Printf("Erro: %s já define a duração do teste e não pode ser usado com --requests ou --duration\n", profile)
+			return exitUsage
+		case *concurrency > 0 || *arrivalRate > 0:
+			fmt.Printf("Erro: %s define a carga de cada etapa e não pode ser usado com --concurrency ou --arrival-rate\n", profile)
+			return exitUsage
+		case *rampUp > 0 || warmup.requests > 0 || warmup.duration > 0:
+			fmt.Printf("Erro: --ramp-up e --warmup não podem ser usados com %s; use uma primeira etapa com menos carga\n", profile)
+			return exitUsage
+		case *wsMode || *sseMode:
+			fmt.Printf("Erro: --ws e --sse mantêm uma conexão por worker e não podem ser usados com %s\n", profile)
+			return exitUsage
+		case rateStages && (*rps > 0 || *thinkTime > 0 || *thinkTimeJitter > 0):
+			fmt.Printf("Erro: --rps e --think-time não podem ser usados com as taxas de %s, que já definem o ritmo das requests\n", profile)
+			return exitUsage
+		}
+		var highest float64
+		for _, stage := range stages {
+			highest = max(highest, stage.Rate, float64(stage.Concurrency))
+		}
+		// Como em --arrival-rate, cada worker é uma vaga de --max-in-flight;
+		// com concorrências, os workers são os da maior etapa
+		*concurrency = int(highest)
+		if rateStages {
+			if *maxInFlight == 0 {
+				*maxInFlight = max(int(math.Ceil(highest)), 1)
 			}
-
-			// Atualiza métricas de duração
-			totalDuration += result.Duration
-			if result.Duration < report.MinDuration {
-				report.MinDuration = result.Duration
+			*concurrency = *maxInFlight
+		}
+	}
+	distribution, err := stress.ParseArrivalDistribution(*arrivalDistribution)
+	if err != nil {
+		fmt.Printf("Erro: --arrival-distribution: %v\n", err)
+		return exitUsage
+	}
+	if *arrivalRate > 0 {
+		switch {
+		case *concurrency > 0:
+			fmt.Println("Erro: use apenas um entre --concurrency e --arrival-rate; o limite de requests em andamento do modelo aberto é --max-in-flight")
+			return exitUsage
+		case *rps > 0 || *rampUp > 0 || *thinkTime > 0 || *thinkTimeJitter > 0:
+			fmt.Println("Erro: --rps, --ramp-up e --think-time não podem ser usados com --arrival-rate, que já define o ritmo das requests")
+			return exitUsage
+		case *wsMode || *sseMode:
+			fmt.Println("Erro: --ws e --sse mantêm uma conexão por worker e não podem ser usados com --arrival-rate")
+			return exitUsage
+		}
+		// Pela lei de Little, a taxa comporta respostas de até 1s em média
+		if *maxInFlight == 0 {
+			*maxInFlight = max(int(math.Ceil(*arrivalRate)), 1)
+		}
+		// No modelo aberto cada worker é uma vaga de --max-in-flight, e
+		// --concurrency dimensiona os pools e as portas de origem por worker
+		*concurrency = *maxInFlight
+	}
+	if *timeout < 0 || *dialTimeout < 0 || *tlsTimeout < 0 || *responseHeaderTimeout < 0 {
+		fmt.Println("Erro: os timeouts não podem ser negativos")
+		return exitUsage
+	}
+	if *http1 && (*http2 || *h2c) {
+		fmt.Println("Erro: --http1 não pode ser usado junto com --http2 ou --h2c")
+		return exitUsage
+	}
+	if *http3 && (*http1 || *http2 || *h2c) {
+		fmt.Println("Erro: --http3 não pode ser usado junto com --http1, --http2 ou --h2c")
+		return exitUsage
+	}
+	if *quicHandshakeTimeout < 0 || *quicIdleTimeout < 0 {
+		fmt.Println("Erro: os timeouts QUIC não podem ser negativos")
+		return exitUsage
+	}
+	if *disableKeepAlive && *http3 {
+		fmt.Println("Erro: --disable-keepalive não se aplica a --http3")
+		return exitUsage
+	}
+	if *maxIdleConns < 0 || *maxIdleConnsPerHost < 0 || *maxConnsPerHost < 0 {
+		fmt.Println("Erro: os limites do pool de conexões não podem ser negativos")
+		return exitUsage
+	}
+	if *maxRedirects < 0 {
+		fmt.Println("Erro: --max-redirects não pode ser negativo")
+		return exitUsage
+	}
+	if *abortOnErrorRate < 0 || *abortOnErrorRate >= 1 {
+		fmt.Println("Erro: --abort-on-error-rate deve estar entre 0 e 1 (ex.: 0.5 para 50%)")
+		return exitUsage
+	}
+	if *abortWindow <= 0 || *abortOnConsecutiveErrors < 0 {
+		fmt.Println("Erro: --abort-window deve ser maior que zero e --abort-on-consecutive-errors não pode ser negativo")
+		return exitUsage
+	}
+	var initialCookies []*http.Cookie
+	for _, text := range cookieValues {
+		parsed, err := http.ParseCookie(text)
+		if err != nil {
+			fmt.Printf("Erro: --cookie inválido %q: use o formato \"nome=valor\"\n", text)
+			return exitUsage
+		}
+		initialCookies = append(initialCookies, parsed...)
+	}
+	var thresholds []stress.Threshold
+	for _, rule := range failIf {
+		threshold, err := stress.ParseThreshold(rule)
+		if err != nil {
+			fmt.Printf("Erro: --fail-if: %v\n", err)
+			return exitUsage
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	if *apdexT < 0 {
+		fmt.Println("Erro: --apdex-t não pode ser negativo")
+		return exitUsage
+	}
+	if *apdexT == 0 && slices.ContainsFunc(thresholds, func(t stress.Threshold) bool { return t.Metric == "apdex" }) {
+		fmt.Println("Erro: --fail-if com apdex requer --apdex-t")
+		return exitUsage
+	}
+	var expectedStatus stress.StatusRanges
+	if *expectStatus != "" {
+		var err error
+		if expectedStatus, err = stress.ParseStatusRanges(*expectStatus); err != nil {
+			fmt.Printf("Erro: --expect-status: %v\n", err)
+			return exitUsage
+		}
+	}
+	var retry *stress.Retry
+	switch {
+	case *retries < 0:
+		fmt.Println("Erro: --retries não pode ser negativo")
+		return exitUsage
+	case *retries > 0:
+		if *grpcTarget != "" || *wsMode || *sseMode {
+			fmt.Println("Erro: --retries se aplica apenas às requests HTTP, não a --grpc, --ws ou --sse")
+			return exitUsage
+		}
+		retry = &stress.Retry{Max: *retries}
+		var err error
+		if *retryBackoff != "" {
+			if retry.BackoffBase, retry.BackoffMax, err = stress.ParseRetryBackoff(*retryBackoff); err != nil {
+				fmt.Printf("Erro: --retry-backoff: %v\n", err)
+				return exitUsage
 			}
-			if result.Duration > report.MaxDuration {
-				report.MaxDuration = result.Duration
+		}
+		if *retryStatus != "" {
+			if retry.Status, err = stress.ParseStatusRanges(*retryStatus); err != nil {
+				fmt.Printf("Erro: --retry-status: %v\n", err)
+				return exitUsage
 			}
-		} else {
-			report.FailedRequests++
 		}
+	case *retryStatus != "" || *retryBackoff != "":
+		fmt.Println("Erro: --retry-backoff e --retry-status requerem --retries")
+		return exitUsage
+	}
+	switch {
+	case *retryAfterMax < 0 || (*retryAfterMax > 0 && !*respectRetryAfter):
+		fmt.Println("Erro: --retry-after-max não pode ser negativo e requer --respect-retry-after")
+		return exitUsage
+	case *respectRetryAfter && (*grpcTarget != "" || *wsMode || *sseMode):
+		fmt.Println("Erro: --respect-retry-after se aplica apenas às requests HTTP, não a --grpc, --ws ou --sse")
+		return exitUsage
+	}
+	var assertions []stress.BodyAssertion
+	for _, text := range assertContains {
+		assertions = append(assertions, stress.BodyAssertion{Contains: text})
+	}
+	for _, text := range assertNotContains {
+		assertions = append(assertions, stress.BodyAssertion{Contains: text, Not: true})
+	}
+	for i, patterns := range []stringListFlag{assertRegex, assertNotRegex} {
+		for _, pattern := range patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				fmt.Printf("Erro: regex inválida %q: %v\n", pattern, err)
+				return exitUsage
+			}
+			assertions = append(assertions, stress.BodyAssertion{Regex: re, Not: i == 1})
+		}
+	}
+	var jsonAssertions []stress.JSONAssertion
+	for _, text := range assertJSON {
+		assertion, err := stress.ParseJSONAssertion(text)
+		if err != nil {
+			fmt.Printf("Erro: --assert-json: %v\n", err)
+			return exitUsage
+		}
+		jsonAssertions = append(jsonAssertions, assertion)
+	}
+	if (len(assertions) > 0 || len(jsonAssertions) > 0) && *noBodyRead {
+		fmt.Println("Erro: --skip-body não pode ser usado junto com as asserções de corpo")
+		return exitUsage
+	}
+	// O corpo só é guardado ou descomprimido quando algo o inspeciona, então
+	// --discard-body apenas recusa as opções que fariam isso
+	if *discardBody && (*noBodyRead || len(assertions) > 0 || len(jsonAssertions) > 0 || *graphqlQuery != "" || *compressionMode == "gzip") {
+		fmt.Println("Erro: --discard-body não pode ser usado junto com --skip-body, as asserções de corpo, --graphql-query ou --compression=gzip")
+		return exitUsage
+	}
+	if *saveFailures != "" {
+		if *grpcTarget != "" || *wsMode || *sseMode {
+			fmt.Println("Erro: --save-failures captura apenas requests HTTP e não se aplica a --grpc, --ws ou --sse")
+			return exitUsage
+		}
+		if *saveFailuresMax < 1 {
+			fmt.Println("Erro: --save-failures-max deve ser ao menos 1")
+			return exitUsage
+		}
+		if *saveFailuresFormat != "files" && *saveFailuresFormat != "jsonl" {
+			fmt.Printf("Erro: formato de --save-failures inválido: %s\n", *saveFailuresFormat)
+			return exitUsage
+		}
+	}
+	if *maxBodyBytes < 0 {
+		fmt.Println("Erro: --max-body-bytes não pode ser negativo")
+		return exitUsage
+	}
+	if *assertMaxBody <= 0 {
+		fmt.Println("Erro: --assert-max-body deve ser maior que zero")
+		return exitUsage
+	}
+	if *thinkTime < 0 || *thinkTimeJitter < 0 {
+		fmt.Println("Erro: --think-time e --think-time-jitter não podem ser negativos")
+		return exitUsage
+	}
+	if *histogramSigFigs < 1 || *histogramSigFigs > 5 {
+		fmt.Println("Erro: --histogram-sigfigs deve estar entre 1 e 5")
+		return exitUsage
+	}
+	if *histogramMax < 2*time.Microsecond {
+		fmt.Println("Erro: --histogram-max deve ser ao menos 2µs")
+		return exitUsage
+	}
+	if *timelineInterval < 10*time.Millisecond {
+		fmt.Println("Erro: --timeline-interval deve ser ao menos 10ms")
+		return exitUsage
+	}
+	if *gracePeriod < 0 {
+		fmt.Println("Erro: --grace-period não pode ser negativo")
+		return exitUsage
 	}
 
-	// Calcula o tempo total e a duração média
-	report.TotalTime = time.Since(startTime)
-	if report.SuccessfulRequests > 0 {
-		report.AvgDuration = totalDuration / time.Duration(report.SuccessfulRequests)
+	if *output != "text" && *output != "json" && *output != "markdown" {
+		fmt.Printf("Erro: formato de saída inválido: %s\n", *output)
+		return exitUsage
+	}
+	if *quiet && (*verbose || *veryVerbose) {
+		fmt.Println("Erro: --quiet não pode ser usado com -v ou -vv")
+		return exitUsage
+	}
+	if *reportInterval < 0 {
+		fmt.Println("Erro: --report-interval não pode ser negativo")
+		return exitUsage
+	}
+	if *quiet && *reportInterval > 0 {
+		fmt.Println("Erro: --quiet não pode ser usado com --report-interval")
+		return exitUsage
+	}
+	if *intervalJSONL != "" && *reportInterval == 0 {
+		fmt.Println("Erro: --interval-jsonl requer --report-interval")
+		return exitUsage
+	}
+	if *tui && (*quiet || *verbose || *veryVerbose || *reportInterval > 0 || *workers != "") {
+		fmt.Println("Erro: --tui não pode ser usado com --quiet, -v, -vv, --report-interval ou --workers")
+		return exitUsage
+	}
+	if *webAddr != "" && *workers != "" {
+		fmt.Println("Erro: --web não pode ser usado com --workers")
+		return exitUsage
+	}
+	var workerAddrs []string
+	var workerFlagValues map[string][]string
+	if *workers != "" {
+		var err error
+		if workerAddrs, err = parseWorkerAddrs(*workers); err != nil {
+			fmt.Printf("Erro: --workers: %v\n", err)
+			return exitUsage
+		}
+		// Os resultados de cada request ficam nos workers; o coordenador
+		// recebe apenas os relatórios
+		if *requestLogPath != "" || *resultsJSONL != "" || *saveFailures != "" || *metricsAddr != "" || *statsdAddr != "" || *influxURL != "" || *influxFile != "" || *otelExport || *verbose || *veryVerbose {
+			fmt.Println("Erro: --request-log, --results-jsonl, --save-failures, --metrics-addr, --statsd-addr, --influx-url, --influx-file, --otel-export, -v e -vv não se aplicam a --workers")
+			return exitUsage
+		}
+		if inlineScenario != nil && *scenarioFile == *configFile {
+			fmt.Println("Erro: os cenários não se aplicam a --workers")
+			return exitUsage
+		}
+		if workerFlagValues, err = workerValues(flag.CommandLine, curlValues); err != nil {
+			fmt.Printf("Erro: --workers: %v\n", err)
+			return exitUsage
+		}
+		if *requests > 0 && *requests < len(workerAddrs) {
+			fmt.Printf("Erro: --requests deve ser ao menos o número de workers (%d)\n", len(workerAddrs))
+			return exitUsage
+		}
 	}
 
-	return report
-}
+	*method = strings.ToUpper(*method)
+	if !stress.ValidMethod(*method) {
+		fmt.Printf("Erro: método HTTP inválido: %s\n", *method)
+		return exitUsage
+	}
 
-func main() {
-	// Configuração dos flags
-	url := flag.String("url", "", "URL do serviço a ser testado")
-	requests := flag.Int("requests", 0, "Número total de requests")
-	concurrency := flag.Int("concurrency", 0, "Número de chamadas simultâneas")
-	flag.Parse()
+	var compression stress.Compression
+	if *compressionMode != "" {
+		var err error
+		if compression, err = stress.ParseCompression(*compressionMode); err != nil {
+			fmt.Printf("Erro: --compression inválido: %v\n", err)
+			return exitUsage
+		}
+	}
 
-	// Validação dos parâmetros
-	if *url == "" || *requests <= 0 || *concurrency <= 0 {
-		fmt.Println("Erro: Todos os parâmetros são obrigatórios e devem ser válidos")
-		fmt.Println("Uso: ./stress-test --url=<URL> --requests=<N> --concurrency=<N>")
-		return
+	var userAgents []string
+	switch {
+	case *userAgent != "" && *userAgentFile != "":
+		fmt.Println("Erro: use apenas um entre --user-agent e --user-agent-file")
+		return exitUsage
+	case *userAgentMode != "round-robin" && *userAgentMode != "random":
+		fmt.Printf("Erro: --user-agent-mode inválido: %s (use round-robin ou random)\n", *userAgentMode)
+		return exitUsage
+	case *userAgent != "":
+		userAgents = []string{*userAgent}
+	case *userAgentFile != "":
+		file, err := os.Open(*userAgentFile)
+		if err != nil {
+			fmt.Printf("Erro: não foi possível abrir o arquivo de User-Agents: %v\n", err)
+			return exitUsage
+		}
+		userAgents, err = stress.ParseUserAgents(file)
+		file.Close()
+		if err != nil {
+			fmt.Printf("Erro: %s: %v\n", *userAgentFile, err)
+			return exitUsage
+		}
 	}
 
+	var targets []stress.Target
+	if *urlFile != "" {
+		file, err := os.Open(*urlFile)
+		if err != nil {
+			fmt.Printf("Erro: não foi possível abrir o arquivo de alvos: %v\n", err)
+			return exitUsage
+		}
+		targets, err = stress.ParseTargets(file, *method, *baseURL)
+		file.Close()
+		if err != nil {
+			fmt.Printf("Erro: %s: %v\n", *urlFile, err)
+			return exitUsage
+		}
+	}
+	for _, spec := range targetSpecs {
+		target, err := stress.ParseTargetSpec(spec, *method, *baseURL)
+		if err != nil {
+			fmt.Printf("Erro: %v\n", err)
+			return exitUsage
+		}
+		targets = append(targets, target)
+	}
+	if *harFile != "" {
+		file, err := os.Open(*harFile)
+		if err != nil {
+			fmt.Printf("Erro: não foi possível abrir o HAR: %v\n", err)
+			return exitUsage
+		}
+		targets, err = stress.ParseHAR(file, stress.HAROptions{Hosts: harHosts, Cookies: *harCookies, Auth: *harAuth})
+		file.Close()
+		if err != nil {
+			fmt.Printf("Erro: %s: %v\n", *harFile, err)
+			return exitUsage
+		}
+	}
+
+	var scenario *stress.Scenario
+	switch {
+	case inlineScenario != nil && *scenarioFile == *configFile:
+		var err error
+		if scenario, err = stress.ParseScenario(bytes.NewReader(inlineScenario), *baseURL); err != nil {
+			fmt.Printf("Erro: %s: %v\n", *scenarioFile, err)
+			return exitUsage
+		}
+	case *scenarioFile != "":
+		file, err := os.Open(*scenarioFile)
+		if err != nil {
+			fmt.Printf("Erro: não foi possível abrir o arquivo de cenário: %v\n", err)
+			return exitUsage
+		}
+		scenario, err = stress.ParseScenario(file, *baseURL)
+		file.Close()
+		if err != nil {
+			fmt.Printf("Erro: %s: %v\n", *scenarioFile, err)
+			return exitUsage
+		}
+	}
+
+	var data *stress.DataSet
+	if *dataFile != "" {
+		file, err := os.Open(*dataFile)
+		if err != nil {
+			fmt.Printf("Erro: não foi possível abrir o arquivo de dados: %v\n", err)
+			return exitUsage
+		}
+		data, err = stress.LoadCSVData(file)
+		file.Close()
+		if err != nil {
+			fmt.Printf("Erro: %s: %v\n", *dataFile, err)
+			return exitUsage
+		}
+	} else if *dataStop {
+		fmt.Println("Erro: --data-stop só pode ser usado junto com --data")
+		return exitUsage
+	}
+
+	var groupingURL string
+	if *pushgatewayURL != "" {
+		if parsed, err := neturl.Parse(*pushgatewayURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			fmt.Println("Erro: --pushgateway-url deve ser uma URL http:// ou https://")
+			return exitUsage
+		}
+		if *pushJob == "" {
+			fmt.Println("Erro: --push-job não pode ser vazio")
+			return exitUsage
+		}
+		var labels []metricLabel
+		for _, raw := range pushLabels {
+			label, err := parseMetricLabel(raw)
+			if err != nil {
+				fmt.Printf("Erro: --label: %v\n", err)
+				return exitUsage
+			}
+			labels = append(labels, label)
+		}
+		groupingURL = pushGroupingURL(*pushgatewayURL, *pushJob, labels)
+	} else if len(pushLabels) > 0 {
+		fmt.Println("Erro: --label requer --pushgateway-url")
+		return exitUsage
+	}
+
+	if *influxURL != "" {
+		if parsed, err := neturl.Parse(*influxURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			fmt.Println("Erro: --influx-url deve ser uma URL http:// ou https://")
+			return exitUsage
+		}
+		if *influxBucket == "" {
+			fmt.Println("Erro: --influx-url requer --influx-bucket")
+			return exitUsage
+		}
+		if *influxToken == "" {
+			*influxToken = os.Getenv("INFLUX_TOKEN")
+		}
+	}
+	var otlp otlpConfig
+	if *otelExport {
+		*otel = true
+		var err error
+		if otlp, err = otlpConfigFromEnv(); err != nil {
+			fmt.Printf("Erro: --otel-export: %v\n", err)
+			return exitUsage
+		}
+	}
+	if *otelSampleRate < 0 || *otelSampleRate > 1 {
+		fmt.Println("Erro: --otel-sample-rate deve estar entre 0 e 1")
+		return exitUsage
+	}
+	slowestSet := false
+	flag.Visit(func(f *flag.Flag) { slowestSet = slowestSet || f.Name == "slowest" })
+	if *slowest < 0 || (slowestSet && !*otel) {
+		fmt.Println("Erro: --slowest não pode ser negativo e requer --otel")
+		return exitUsage
+	}
+	var baseline *savedReport
+	if *baselinePath != "" {
+		var err error
+		if baseline, err = readSavedReport(*baselinePath); err != nil {
+			fmt.Printf("Erro: --baseline: %v\n", err)
+			return exitUsage
+		}
+	}
+	if *baselineTolerancePct < 0 || *baselineErrorTolerance < 0 {
+		fmt.Println("Erro: --baseline-tolerance e --baseline-error-tolerance não podem ser negativos")
+		return exitUsage
+	}
+	if *resultsRotateMB < 0 {
+		fmt.Println("Erro: --results-jsonl-rotate-mb não pode ser negativo")
+		return exitUsage
+	}
+	if *resultsRotateMB > 0 && *resultsJSONL == "" {
+		fmt.Println("Erro: --results-jsonl-rotate-mb requer --results-jsonl")
+		return exitUsage
+	}
+	if *statsdFormat != "statsd" && *statsdFormat != "dogstatsd" {
+		fmt.Println("Erro: --statsd-format deve ser statsd ou dogstatsd")
+		return exitUsage
+	}
+	if *statsdSampleRate <= 0 || *statsdSampleRate > 1 {
+		fmt.Println("Erro: --statsd-sample-rate deve ser maior que 0 e no máximo 1")
+		return exitUsage
+	}
+
+	var query []stress.QueryParam
+	for _, raw := range queryParams {
+		param, err := stress.ParseQueryParam(raw)
+		if err != nil {
+			fmt.Printf("Erro: %v\n", err)
+			return exitUsage
+		}
+		query = append(query, param)
+	}
+	if *cacheBust && *cacheBustParam == "" {
+		fmt.Println("Erro: --cache-bust-param não pode ser vazio")
+		return exitUsage
+	}
+
+	header, err := headers.Header()
+	if err != nil {
+		fmt.Printf("Erro: %v\n", err)
+		return exitUsage
+	}
+
+	if *user != "" && *userEnv != "" {
+		fmt.Println("Erro: use apenas um entre --user e --user-env")
+		return exitUsage
+	}
+	credentials := *user
+	if *userEnv != "" {
+		value, ok := os.LookupEnv(*userEnv)
+		if !ok {
+			fmt.Printf("Erro: a variável de ambiente %s não está definida\n", *userEnv)
+			return exitUsage
+		}
+		credentials = value
+	}
+	var basicAuth *stress.BasicAuth
+	if credentials != "" {
+		basicAuth, err = parseUserFlag(credentials)
+		if err != nil {
+			fmt.Printf("Erro: %v\n", err)
+			return exitUsage
+		}
+	}
+
+	if *bearerToken != "" && *bearerTokenFile != "" {
+		fmt.Println("Erro: use apenas um entre --bearer-token e --bearer-token-file")
+		return exitUsage
+	}
+	if basicAuth != nil && (*bearerToken != "" || *bearerTokenFile != "") {
+		fmt.Println("Erro: autenticação básica e bearer token não podem ser usados juntos")
+		return exitUsage
+	}
+	if *bearerTokenRefresh < 0 {
+		fmt.Println("Erro: --bearer-token-refresh não pode ser negativo")
+		return exitUsage
+	}
+	var token *stress.BearerToken
+	if *bearerToken != "" {
+		token = stress.NewStaticBearerToken(*bearerToken)
+	}
+	if *bearerTokenFile != "" {
+		token, err = stress.NewFileBearerToken(*bearerTokenFile, *bearerTokenRefresh)
+		if err != nil {
+			fmt.Printf("Erro: %v\n", err)
+			return exitUsage
+		}
+	}
+
+	if *body != "" && *bodyFile != "" {
+		fmt.Println("Erro: use apenas um entre --body e --body-file")
+		return exitUsage
+	}
+
+	// O arquivo é lido uma única vez e reaproveitado em todas as requests
+	var payload []byte
+	if *body != "" {
+		payload = []byte(*body)
+	}
+	if *bodyFile != "" {
+		data, err := os.ReadFile(*bodyFile)
+		if err != nil {
+			fmt.Printf("Erro: não foi possível ler o arquivo do corpo: %v\n", err)
+			return exitUsage
+		}
+		payload = data
+	}
+
+	// Como em um formulário HTML, os campos viram um corpo url-encoded e
+	// passam a multipart quando há arquivos
+	var fields []stress.FormField
+	var form *stress.Multipart
+	if len(formFields) > 0 || len(formFiles) > 0 {
+		if payload != nil || *scenarioFile != "" {
+			fmt.Println("Erro: --form e --form-file não podem ser usados junto com --body, --body-file ou --scenario")
+			return exitUsage
+		}
+		if len(formFiles) > 0 && *contentType != "" {
+			fmt.Println("Erro: --content-type não pode ser usado junto com --form-file, que define o boundary do multipart")
+			return exitUsage
+		}
+		// Sem --method nem o -X do --curl, o formulário é enviado via POST,
+		// como no navegador
+		switch {
+		case !methodGiven:
+			*method = http.MethodPost
+		case *method == http.MethodGet || *method == http.MethodHead:
+			fmt.Printf("Erro: --form e --form-file enviam um corpo e não podem ser usados com --method=%s\n", *method)
+			return exitUsage
+		}
+		for _, text := range formFields {
+			field, err := stress.ParseFormField(text)
+			if err != nil {
+				fmt.Printf("Erro: --form inválido: %v\n", err)
+				return exitUsage
+			}
+			fields = append(fields, field)
+		}
+	}
+	var graphql *stress.GraphQL
+	if *graphqlQuery != "" {
+		if payload != nil || len(formFields) > 0 || len(formFiles) > 0 || *scenarioFile != "" || len(targets) > 0 {
+			fmt.Println("Erro: --graphql-query não pode ser usado junto com --body, --body-file, --form, --form-file, --scenario, --url-file ou --target")
+			return exitUsage
+		}
+		if *method != http.MethodGet && *method != http.MethodPost {
+			fmt.Println("Erro: --graphql-query envia as requests via POST")
+			return exitUsage
+		}
+		graphql = &stress.GraphQL{OperationName: *graphqlOperation}
+		var err error
+		if graphql.Query, err = inlineOrFile(*graphqlQuery); err != nil {
+			fmt.Printf("Erro: --graphql-query: %v\n", err)
+			return exitUsage
+		}
+		if graphql.Variables, err = inlineOrFile(*graphqlVariables); err != nil {
+			fmt.Printf("Erro: --graphql-variables: %v\n", err)
+			return exitUsage
+		}
+	} else if *graphqlVariables != "" || *graphqlOperation != "" {
+		fmt.Println("Erro: --graphql-variables e --graphql-operation requerem --graphql-query")
+		return exitUsage
+	}
+
+	// Os arquivos são abertos antes do teste para validar os caminhos, mas
+	// só são lidos durante o envio de cada request
+	if len(formFiles) > 0 {
+		form = &stress.Multipart{Fields: fields}
+		fields = nil
+		for _, text := range formFiles {
+			file, err := stress.ParseFormFile(text)
+			if err != nil {
+				fmt.Printf("Erro: --form-file inválido: %v\n", err)
+				return exitUsage
+			}
+			form.Files = append(form.Files, file)
+		}
+	}
+
+	// O primeiro Ctrl+C interrompe o teste e imprime o relatório parcial;
+	// o segundo encerra o processo imediatamente
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	// O painel de --tui ocupa o terminal apenas quando stdout é um terminal
+	// title descreve o teste no painel de --tui e na página de --web
+	title := *method + " " + *url
+	switch {
+	case *grpcTarget != "":
+		title = "gRPC " + *grpcTarget + " " + *grpcMethod
+	case scenario != nil:
+		title = "cenário " + *scenarioFile
+	case len(targets) > 0:
+		title = fmt.Sprintf("%d alvos", len(targets))
+	}
+	title = secrets.redact(title)
+	var dash *dashboard
+	if *tui && isTerminal(os.Stdout) {
+		dash = newDashboard(os.Stdout, *noColor, title, *requests, plannedDuration, func() { cancel(errDashboardQuit) })
+	}
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		if !*quiet {
+			fmt.Fprintln(os.Stderr, "\nInterrompendo o teste... pressione Ctrl+C novamente para sair imediatamente")
+		}
+		cancel(errInterrupted)
+		<-signals
+		if dash != nil {
+			dash.restoreTerminal()
+		}
+		os.Exit(130)
+	}()
+
 	// Cria e executa o teste
-	test := NewStressTest(*url, *requests, *concurrency)
-	report := test.Run()
+	test := stress.NewStressTest(*url, *requests, *concurrency)
+	test.Method = *method
+	test.Targets = targets
+	test.Scenario = scenario
+	test.Seed = *seed
+	test.Query = query
+	test.Data = data
+	test.StopWhenDataExhausted = *dataStop
+	if *cacheBust {
+		test.CacheBust = *cacheBustParam
+	}
+	test.Body = payload
+	test.ContentType = *contentType
+	test.Header = header
+	test.UserAgents = userAgents
+	test.Compression = compression
+	test.Multipart = form
+	test.Form = fields
+	if *wsMode {
+		test.WebSocket = &stress.WebSocket{MaxMessageSize: *wsMaxMessageSize, Binary: *wsBinary}
+	}
+	if *sseMode {
+		test.SSE = &stress.SSE{MaxLineSize: *sseMaxLineSize}
+	}
+	if graphql != nil {
+		test.GraphQL = graphql
+		test.Method = http.MethodPost
+	}
+	test.RandomUserAgent = *userAgentMode == "random"
+	test.BasicAuth = basicAuth
+	test.BearerToken = token
+	test.Duration = *duration
+	test.GracePeriod = *gracePeriod
+	test.RPS = *rps
+	test.Burst = *burst
+	test.ArrivalRate = *arrivalRate
+	test.MaxInFlight = *maxInFlight
+	if spike != nil {
+		test.Spike = spike
+	} else {
+		test.Stages = stages
+	}
+	test.Soak = *soak
+	if *arrivalRate > 0 || rateStages || (findMax != nil && findMax.Rate) {
+		test.ArrivalDistribution = distribution
+	}
+	test.RampUp = *rampUp
+	test.ExcludeRampUp = *excludeRampUp
+	// O intervalo entre as mensagens WebSocket é o think time de cada worker,
+	// e as flags são exclusivas
+	test.ThinkTime = max(*thinkTime, *wsInterval)
+	test.ThinkTimeJitter = *thinkTimeJitter
+	test.WarmupRequests = warmup.requests
+	test.WarmupDuration = warmup.duration
+	test.HistogramMax = *histogramMax
+	test.TimelineInterval = *timelineInterval
+	test.Trace = *traceFlag
+	test.NoBodyRead = *noBodyRead
+	test.MaxBodyBytes = *maxBodyBytes
+	test.HistogramSigFigs = *histogramSigFigs
+	test.Client.Timeout = *timeout
+	test.ExpectStatus = expectedStatus
+	test.Retry = retry
+	test.RespectRetryAfter = *respectRetryAfter
+	test.RetryAfterMax = *retryAfterMax
+	test.Thresholds = thresholds
+	test.Cookies = *cookies
+	test.InitialCookies = initialCookies
+	test.ApdexT = *apdexT
+	test.AbortOnErrorRate = *abortOnErrorRate
+	test.AbortWindow = *abortWindow
+	test.AbortOnConsecutiveErrors = *abortOnConsecutiveErrors
+	test.Assertions = assertions
+	test.JSONAssertions = jsonAssertions
+	test.MaxCapturedBody = *assertMaxBody
+	test.Client.CheckRedirect = stress.RedirectPolicy(*followRedirects, *maxRedirects)
+	var rootCAs *x509.CertPool
+	if *caCert != "" {
+		rootCAs, err = stress.LoadCertPool(*caCert)
+		if err != nil {
+			fmt.Printf("Erro: %v\n", err)
+			return exitUsage
+		}
+	}
+	if (*certFile == "") != (*keyFile == "") {
+		fmt.Println("Erro: --cert e --key devem ser informados juntos")
+		return exitUsage
+	}
+	var certificates []tls.Certificate
+	if *certFile != "" {
+		cert, err := stress.LoadClientCertificate(*certFile, *keyFile)
+		if err != nil {
+			fmt.Printf("Erro: %v\n", err)
+			return exitUsage
+		}
+		certificates = append(certificates, cert)
+	}
+	var proxyURL *neturl.URL
+	if *proxy != "" {
+		proxyURL, err = stress.ParseProxyURL(*proxy)
+		if err != nil {
+			fmt.Printf("Erro: %v\n", err)
+			return exitUsage
+		}
+		if *http3 {
+			fmt.Println("Erro: --proxy não pode ser usado junto com --http3")
+			return exitUsage
+		}
+	}
+	if *unixSocket != "" {
+		if *http3 || proxyURL != nil {
+			fmt.Println("Erro: --unix-socket não pode ser usado junto com --http3 ou --proxy")
+			return exitUsage
+		}
+		if err := stress.CheckUnixSocket(*unixSocket); err != nil {
+			fmt.Printf("Erro: %v\n", err)
+			return exitUsage
+		}
+	}
+	if *connectTo != "" {
+		if _, _, err := net.SplitHostPort(*connectTo); err != nil {
+			fmt.Printf("Erro: --connect-to inválido, use o formato ip:porta: %v\n", err)
+			return exitUsage
+		}
+		if *http3 || proxyURL != nil || *unixSocket != "" {
+			fmt.Println("Erro: --connect-to não pode ser usado junto com --http3, --proxy ou --unix-socket")
+			return exitUsage
+		}
+	}
+	resolve, err := stress.ParseResolve(resolveEntries)
+	if err != nil {
+		fmt.Printf("Erro: %v\n", err)
+		return exitUsage
+	}
+	if *dnsServer != "" {
+		if _, _, err := net.SplitHostPort(*dnsServer); err != nil {
+			fmt.Printf("Erro: --dns-server inválido, use o formato ip:porta: %v\n", err)
+			return exitUsage
+		}
+	}
+	if (len(resolve) > 0 || *dnsServer != "") && (*http3 || proxyURL != nil || *unixSocket != "" || *connectTo != "") {
+		fmt.Println("Erro: --resolve e --dns-server não podem ser usados junto com --http3, --proxy, --unix-socket ou --connect-to")
+		return exitUsage
+	}
+	var localPorts stress.PortRange
+	if *sourcePorts != "" {
+		if *http3 || *unixSocket != "" {
+			fmt.Println("Erro: --source-ports não pode ser usado junto com --http3 ou --unix-socket")
+			return exitUsage
+		}
+		if localPorts, err = stress.ParsePortRange(*sourcePorts); err != nil {
+			fmt.Printf("Erro: --source-ports inválido: %v\n", err)
+			return exitUsage
+		}
+		if *perWorkerClient && localPorts.Size() < *concurrency {
+			fmt.Printf("Erro: --source-ports tem %d portas, menos que uma por worker (%d)\n", localPorts.Size(), *concurrency)
+			return exitUsage
+		}
+	}
+	// Com -host, o SNI e a verificação do certificado usam o novo nome
+	var serverName string
+	if *host != "" {
+		serverName = *host
+		if name, _, err := net.SplitHostPort(*host); err == nil {
+			serverName = name
+		}
+	}
+	transportConfig := stress.TransportConfig{
+		MaxIdleConns:          defaults.MaxIdleConns,
+		DialTimeout:           *dialTimeout,
+		TLSHandshakeTimeout:   *tlsTimeout,
+		ResponseHeaderTimeout: *responseHeaderTimeout,
+		InsecureSkipVerify:    *insecure,
+		RootCAs:               rootCAs,
+		Certificates:          certificates,
+		HTTP2:                 *http2,
+		H2C:                   *h2c,
+		HTTP1Only:             *http1,
+		Proxy:                 proxyURL,
+		NoProxyEnv:            *noProxyEnv,
+		UnixSocket:            *unixSocket,
+		ConnectTo:             *connectTo,
+		ServerName:            serverName,
+		Resolve:               resolve,
+		DNSServer:             *dnsServer,
+		LocalPorts:            localPorts,
+		DisableCompression:    compression != stress.CompressionAuto,
+		DisableKeepAlives:     *disableKeepAlive,
+		HTTP3:                 *http3,
+		QUICHandshakeTimeout:  *quicHandshakeTimeout,
+		QUICIdleTimeout:       *quicIdleTimeout,
+	}
+	transportConfig = transportConfig.WithPoolForConcurrency(*concurrency)
+	if *maxIdleConns > 0 {
+		transportConfig.MaxIdleConns = *maxIdleConns
+	}
+	if *maxIdleConnsPerHost > 0 {
+		transportConfig.MaxIdleConnsPerHost = *maxIdleConnsPerHost
+	}
+	transportConfig.MaxConnsPerHost = *maxConnsPerHost
+	test.Settings = make(map[string]string)
+	if *perWorkerClient {
+		// Cada worker envia uma request por vez, então o pool de cada
+		// transporte não precisa acompanhar a concorrência
+		workerConfig := transportConfig
+		if *maxIdleConns == 0 {
+			workerConfig.MaxIdleConns = defaults.MaxIdleConns
+		}
+		if *maxIdleConnsPerHost == 0 {
+			workerConfig.MaxIdleConnsPerHost = defaults.MaxIdleConnsPerHost
+		}
+		test.WorkerTransport = func(workerID int) http.RoundTripper {
+			cfg := workerConfig
+			if !localPorts.IsZero() {
+				cfg.LocalPorts = localPorts.Split(workerID, *concurrency)
+			}
+			return stress.NewRoundTripper(cfg)
+		}
+		transportConfig = workerConfig
+		test.Settings["client"] = "por worker"
+	} else {
+		test.Client.Transport = stress.NewRoundTripper(transportConfig)
+	}
+	if *sourcePorts != "" {
+		test.Settings["source-ports"] = localPorts.String()
+		if *perWorkerClient {
+			test.Settings["source-ports"] += fmt.Sprintf(" (%d por worker)", localPorts.Split(0, *concurrency).Size())
+		}
+	}
+	if *clientIDHeader != "" {
+		if !stress.ValidHeaderName(*clientIDHeader) {
+			fmt.Printf("Erro: --client-id-header inválido: %q\n", *clientIDHeader)
+			return exitUsage
+		}
+		test.ClientIDHeader = *clientIDHeader
+		test.Settings["client-id-header"] = *clientIDHeader
+	}
+	if *otel {
+		test.TraceContext = &stress.TraceContext{SampleRate: *otelSampleRate}
+		test.SlowestRequests = *slowest
+		test.Settings["otel"] = fmt.Sprintf("traceparent (amostragem %g)", *otelSampleRate)
+		if *otelExport {
+			test.Settings["otel"] += ", spans em " + otlp.endpoint
+		}
+	}
+	// O pool de conexões não se aplica ao HTTP/3, às chamadas gRPC nem às
+	// conexões WebSocket
+	if !*http3 && *grpcTarget == "" && !*wsMode {
+		test.Settings["max-idle-conns"] = strconv.Itoa(transportConfig.MaxIdleConns)
+		test.Settings["max-idle-conns-per-host"] = strconv.Itoa(transportConfig.MaxIdleConnsPerHost)
+		test.Settings["max-conns-per-host"] = strconv.Itoa(transportConfig.MaxConnsPerHost)
+	}
+	if *disableKeepAlive {
+		test.Settings["keep-alive"] = "desativado"
+	}
+	switch {
+	case *noBodyRead:
+		test.Settings["leitura-do-corpo"] = "desativada"
+	case *discardBody:
+		test.Settings["leitura-do-corpo"] = "descartada"
+	}
+	if !*noBodyRead {
+		test.Settings["max-body-bytes"] = "sem limite"
+		if *maxBodyBytes > 0 {
+			test.Settings["max-body-bytes"] = strconv.FormatInt(*maxBodyBytes, 10)
+		}
+	}
+	if *unixSocket != "" {
+		test.Settings["unix-socket"] = *unixSocket
+	}
+	if data != nil {
+		test.Settings["data"] = fmt.Sprintf("%s (%d linhas)", *dataFile, len(data.Rows))
+	}
+	if *cacheBust {
+		test.Settings["cache-bust"] = *cacheBustParam
+	}
+	if len(queryParams) > 0 {
+		test.Settings["query"] = queryParams.String()
+	}
+	// Apenas os nomes dos cookies entram no relatório, já que os valores
+	// costumam ser credenciais de sessão
+	if *cookies || len(initialCookies) > 0 {
+		names := make([]string, len(initialCookies))
+		for i, cookie := range initialCookies {
+			names[i] = cookie.Name
+		}
+		test.Settings["cookies"] = "jar por worker"
+		if len(names) > 0 {
+			test.Settings["cookies"] += " com " + strings.Join(names, ", ")
+		}
+	}
+	if graphql != nil {
+		test.Settings["graphql"] = "query inline"
+		if path, ok := strings.CutPrefix(*graphqlQuery, "@"); ok {
+			test.Settings["graphql"] = "query de " + path
+		}
+		if graphql.OperationName != "" {
+			test.Settings["graphql"] += ", operação " + graphql.OperationName
+		}
+	}
+	switch {
+	case form != nil:
+		test.Settings["form"] = fmt.Sprintf("multipart com %d campos e %d arquivos", len(form.Fields), len(form.Files))
+	case len(fields) > 0:
+		test.Settings["form"] = fmt.Sprintf("url-encoded com %d campos", len(fields))
+	}
+	if *wsMode {
+		test.Settings["websocket"] = "conexões ociosas"
+		if payload != nil {
+			test.Settings["websocket"] = "mensagens de texto"
+			if *wsBinary {
+				test.Settings["websocket"] = "mensagens binárias"
+			}
+			if *wsInterval > 0 {
+				test.Settings["websocket"] += " a cada " + wsInterval.String()
+			}
+		}
+		test.Settings["ws-max-message-size"] = strconv.FormatInt(*wsMaxMessageSize, 10)
+	}
+	if *sseMode {
+		test.Settings["sse"] = "um stream por worker"
+		test.Settings["sse-max-line-size"] = strconv.Itoa(*sseMaxLineSize)
+	}
+	if compression != stress.CompressionAuto {
+		test.Settings["compression"] = string(compression)
+	}
+	switch {
+	case *userAgent != "":
+		test.Settings["user-agent"] = *userAgent
+	case *userAgentFile != "":
+		mode := "rodízio"
+		if test.RandomUserAgent {
+			mode = "sorteio"
+		}
+		test.Settings["user-agent"] = fmt.Sprintf("%s entre %d de %s", mode, len(userAgents), *userAgentFile)
+	}
+	if scenario != nil {
+		test.Settings["scenario"] = fmt.Sprintf("%s (%d passos)", *scenarioFile, len(scenario.Steps))
+	}
+	if *urlFile != "" {
+		test.Settings["url-file"] = fmt.Sprintf("%s (%d alvos)", *urlFile, len(targets))
+	}
+	if *harFile != "" {
+		test.Settings["har"] = fmt.Sprintf("%s (%d entradas)", *harFile, len(targets))
+	}
+	// Os overrides ficam registrados para que o resultado não seja confundido
+	// com números de todo o balanceador
+	if *host != "" {
+		test.Host = *host
+		test.Settings["host"] = *host
+	}
+	if *connectTo != "" {
+		test.Settings["connect-to"] = *connectTo
+	}
+	if len(resolveEntries) > 0 {
+		test.Settings["resolve"] = resolveEntries.String()
+	}
+	if *dnsServer != "" {
+		test.Settings["dns-server"] = *dnsServer
+	}
+	if proxyURL != nil {
+		// As credenciais do proxy não são exibidas no relatório
+		test.Settings["proxy"] = proxyURL.Redacted()
+	}
+	if *http2 || *h2c {
+		test.ExpectedProtocol = stress.ProtocolName(2, 0)
+	}
+	if *http1 {
+		test.ExpectedProtocol = stress.ProtocolName(1, 1)
+	}
+	// validate para antes de qualquer conexão ou arquivo criado; a conexão
+	// com o servidor gRPC não é verificada. Com --workers, as conexões ficam
+	// a cargo dos workers e o teste é verificado aqui, antes de ser enviado.
+	if validateOnly || len(workerAddrs) > 0 {
+		if *grpcTarget == "" {
+			if err := test.Validate(); err != nil {
+				fmt.Printf("Erro: %v\n", err)
+				return exitUsage
+			}
+		}
+	}
+	if validateOnly {
+		fmt.Printf("%s: configuração válida\n", *configFile)
+		return exitOK
+	}
+	if *http3 && len(workerAddrs) == 0 {
+		test.ExpectedProtocol = stress.ProtocolName(3, 0)
+		probeURL := *url
+		if len(targets) > 0 {
+			probeURL = targets[0].URL
+		}
+		if err := stress.ProbeQUIC(ctx, probeURL, transportConfig); err != nil {
+			fmt.Printf("Erro: %v\n", err)
+			return exitUsage
+		}
+	}
+	if *grpcTarget != "" && len(workerAddrs) == 0 {
+		callTimeout := *grpcTimeout
+		if callTimeout == 0 {
+			callTimeout = *timeout
+		}
+		dialCtx, cancelDial := context.WithTimeout(ctx, *dialTimeout)
+		call, err := stress.DialGRPC(dialCtx, stress.GRPCDialConfig{
+			Target:    *grpcTarget,
+			Method:    *grpcMethod,
+			Protoset:  *grpcProtoset,
+			Plaintext: *grpcPlaintext,
+			TLS: &tls.Config{
+				InsecureSkipVerify: *insecure,
+				RootCAs:            rootCAs,
+				Certificates:       certificates,
+			},
+			Connections: *grpcConnections,
+			Timeout:     callTimeout,
+		})
+		cancelDial()
+		if err != nil {
+			fmt.Printf("Erro: --grpc: %v\n", err)
+			return exitUsage
+		}
+		defer call.Close()
+		test.GRPC = call
+		descriptors := "reflection"
+		if *grpcProtoset != "" {
+			descriptors = *grpcProtoset
+		}
+		security := "TLS"
+		if *grpcPlaintext {
+			security = "plaintext"
+		}
+		if *grpcConnections > 1 {
+			security += fmt.Sprintf(", %d canais", *grpcConnections)
+		}
+		test.Settings["grpc"] = fmt.Sprintf("%s (%s, descritores de %s)", *grpcTarget, security, descriptors)
+		test.Settings["grpc-timeout"] = callTimeout.String()
+	}
+	if *insecure && !*quiet {
+		fmt.Fprintln(os.Stderr, "AVISO: --insecure ativo, os certificados TLS do servidor NÃO serão verificados")
+	}
+	if *noBodyRead && !*disableKeepAlive && !*quiet {
+		fmt.Fprintln(os.Stderr, "AVISO: --skip-body fecha as respostas sem ler o corpo; no HTTP/1.1 as conexões não voltam ao pool e cada request abre uma nova (ver \"Conexões\" no relatório)")
+	}
 
-	// Imprime o relatório
-	printReport(report)
-}
+	// A linha de progresso se misturaria às linhas do log detalhado e aos
+	// resumos periódicos
+	if !*noProgress && !*quiet && !*verbose && !*veryVerbose && *reportInterval == 0 && dash == nil {
+		test.Progress = os.Stderr
+	}
+	// --report-interval, --tui e --web recebem os mesmos resumos periódicos
+	var onInterval []func(stress.IntervalStats)
+	if dash != nil {
+		test.ReportInterval = dashboardInterval
+		test.IntervalLatencyBuckets = dashboardBuckets
+		onInterval = append(onInterval, dash.update)
+	}
+	if *reportInterval > 0 {
+		test.ReportInterval = *reportInterval
+		onInterval = append(onInterval, func(stats stress.IntervalStats) {
+			printIntervalLine(os.Stderr, stats)
+		})
+	}
+	if *intervalJSONL != "" {
+		file, err := os.Create(*intervalJSONL)
+		if err != nil {
+			fmt.Printf("Erro: não foi possível criar o arquivo de --interval-jsonl: %v\n", err)
+			return exitUsage
+		}
+		defer file.Close()
+		onInterval = append(onInterval, intervalWriter(file))
+	}
+	var web *webServer
+	if *webAddr != "" {
+		var err error
+		if web, err = startWebServer(*webAddr, title); err != nil {
+			fmt.Printf("Erro: não foi possível servir a página de --web em %s: %v\n", *webAddr, err)
+			return exitUsage
+		}
+		defer web.Close()
+		if test.ReportInterval == 0 {
+			test.ReportInterval = webInterval
+		}
+		onInterval = append(onInterval, web.add)
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "Acompanhe o teste em %s\n", web.URL())
+		}
+	}
+	if len(onInterval) > 0 {
+		test.OnInterval = func(stats stress.IntervalStats) {
+			for _, f := range onInterval {
+				f(stats)
+			}
+		}
+	}
 
-func printReport(report *Report) {
-	fmt.Println("\n=== Relatório do Teste de Carga ===")
-	fmt.Printf("Tempo Total: %v\n", report.TotalTime)
-	fmt.Printf("Total de Requests: %d\n", report.TotalRequests)
-	fmt.Printf("Requests com Sucesso (200): %d\n", report.SuccessfulRequests)
-	fmt.Printf("Requests com Falha: %d\n", report.FailedRequests)
-
-	fmt.Println("\nMétricas de Duração:")
-	fmt.Printf("Duração Mínima: %v\n", report.MinDuration)
-	fmt.Printf("Duração Máxima: %v\n", report.MaxDuration)
-	fmt.Printf("Duração Média: %v\n", report.AvgDuration)
-
-	fmt.Println("\nDistribuição de Status HTTP:")
-	for status, count := range report.StatusCodes {
-		fmt.Printf("Status %d: %d requests (%.2f%%)\n",
-			status,
-			count,
-			float64(count)/float64(report.TotalRequests)*100)
+	var onResult []func(stress.Result)
+	if *requestLogPath != "" {
+		file, err := os.Create(*requestLogPath)
+		if err != nil {
+			fmt.Printf("Erro: não foi possível criar o log de requests: %v\n", err)
+			return exitUsage
+		}
+		defer file.Close()
+		requestLog := csv.NewWriter(file)
+		requestLog.Write(requestLogHeader)
+		defer requestLog.Flush()
+		onResult = append(onResult, func(result stress.Result) {
+			requestLog.Write(requestLogRecord(result))
+		})
+	}
+	var results *resultsWriter
+	if *resultsJSONL != "" {
+		var err error
+		if results, err = newResultsWriter(*resultsJSONL, int64(*resultsRotateMB)<<20); err != nil {
+			fmt.Printf("Erro: não foi possível criar o arquivo de --results-jsonl: %v\n", err)
+			return exitUsage
+		}
+		test.RecordRequests = true
+		onResult = append(onResult, results.add)
+	}
+	// O arquivo HTML é criado antes do teste para que um caminho inválido
+	// não seja descoberto só ao final
+	var htmlFile *os.File
+	if *outputHTML != "" {
+		var err error
+		if htmlFile, err = os.Create(*outputHTML); err != nil {
+			fmt.Printf("Erro: não foi possível criar o relatório HTML: %v\n", err)
+			return exitUsage
+		}
+		defer htmlFile.Close()
+	}
+	var timelineFile *os.File
+	if *timelineCSV != "" {
+		var err error
+		if timelineFile, err = os.Create(*timelineCSV); err != nil {
+			fmt.Printf("Erro: não foi possível criar o CSV da série no tempo: %v\n", err)
+			return exitUsage
+		}
+		defer timelineFile.Close()
+	}
+	if *metricsAddr != "" {
+		test.Gauges = &stress.Gauges{}
+		metrics := newLiveMetrics(test.Gauges)
+		server, err := startMetricsServer(*metricsAddr, metrics)
+		if err != nil {
+			fmt.Printf("Erro: não foi possível servir as métricas em %s: %v\n", *metricsAddr, err)
+			return exitUsage
+		}
+		defer server.Close()
+		onResult = append(onResult, metrics.add)
+	}
+	var statsd *statsdEmitter
+	if *statsdAddr != "" {
+		var err error
+		if statsd, err = newStatsdEmitter(*statsdAddr, *statsdPrefix, *statsdFormat, *statsdSampleRate); err != nil {
+			fmt.Printf("Erro: não foi possível abrir o socket de --statsd-addr: %v\n", err)
+			return exitUsage
+		}
+		onResult = append(onResult, statsd.add)
+	}
+	var influx *influxWriter
+	if *influxURL != "" || *influxFile != "" {
+		opts := influxOptions{url: *influxURL, token: *influxToken, org: *influxOrg, bucket: *influxBucket, file: *influxFile}
+		var err error
+		if influx, err = newInfluxWriter(opts, test.TimelineInterval, test.Failed); err != nil {
+			fmt.Printf("Erro: não foi possível criar o arquivo de --influx-file: %v\n", err)
+			return exitUsage
+		}
+		onResult = append(onResult, influx.add)
+	}
+	var spans *spanExporter
+	if *otelExport {
+		spans = newSpanExporter(otlp, test.Failed)
+		onResult = append(onResult, spans.add)
+	}
+	var logger *verboseLogger
+	if *verbose || *veryVerbose {
+		logger = newVerboseLogger(os.Stderr, *veryVerbose)
+		test.RecordRequests = true
+		onResult = append(onResult, logger.log)
+	}
+	var failures *failureWriter
+	if *saveFailures != "" {
+		var err error
+		if failures, err = newFailureWriter(*saveFailures, *saveFailuresFormat, !*saveFailuresUnredacted); err != nil {
+			fmt.Printf("Erro: não foi possível preparar --save-failures: %v\n", err)
+			return exitUsage
+		}
+		test.CaptureFailures = *saveFailuresMax
+		test.Settings["save-failures"] = fmt.Sprintf("%s (até %d, %s)", *saveFailures, *saveFailuresMax, *saveFailuresFormat)
+		onResult = append(onResult, failures.write)
+	}
+	if len(onResult) > 0 {
+		test.OnResult = func(result stress.Result) {
+			if secrets.active() {
+				result = secrets.redactResult(result)
+			}
+			for _, f := range onResult {
+				f(result)
+			}
+		}
+	}
+	var report *stress.Report
+	if len(workerAddrs) > 0 {
+		var progress io.Writer
+		if !*quiet {
+			progress = os.Stderr
+		}
+		var lost []workerFailure
+		report, lost, err = runDistributed(ctx, workerAddrs, *workerTokenFlag, workerFlagValues, workerLoad{requests: *requests, rps: *rps, arrivalRate: *arrivalRate, maxInFlight: *maxInFlight, stages: test.Stages, spike: spike}, *reportInterval, progress)
+		// Um worker perdido não invalida o teste, mas é sempre informado
+		description := strings.Join(workerAddrs, ", ")
+		if len(lost) > 0 {
+			failed := make([]string, len(lost))
+			for i, failure := range lost {
+				fmt.Fprintf(os.Stderr, "AVISO: worker %s falhou: %v\n", failure.addr, failure.err)
+				failed[i] = failure.addr
+			}
+			description += fmt.Sprintf(" (%d de %d falharam: %s)", len(lost), len(workerAddrs), strings.Join(failed, ", "))
+		}
+		if report != nil {
+			if report.Settings == nil {
+				report.Settings = make(map[string]string)
+			}
+			report.Settings["workers"] = description
+		}
+	} else {
+		if dash != nil {
+			dash.start()
+		}
+		if findMax != nil {
+			if !*quiet {
+				findMax.OnProbe = func(probe stress.FindMaxProbe) { printFindMaxProbe(os.Stderr, findMax.Rate, probe) }
+			}
+			report, err = test.FindMax(ctx, *findMax)
+		} else {
+			report, err = test.Run(ctx)
+		}
+		if dash != nil {
+			dash.stop()
+		}
+	}
+	// O log detalhado termina antes do relatório
+	if logger != nil {
+		logger.Flush()
+	}
+	// Os resultados são gravados também quando o teste é interrompido
+	if results != nil {
+		if err := results.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "AVISO: falha ao gravar os resultados em %s: %v\n", *resultsJSONL, err)
+		}
+	}
+	if statsd != nil {
+		if dropped := statsd.Close(); dropped > 0 && !*quiet {
+			fmt.Fprintf(os.Stderr, "AVISO: %d métricas StatsD descartadas (fila cheia ou servidor indisponível)\n", dropped)
+		}
+	}
+	if failures != nil {
+		if err := failures.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "AVISO: falha ao gravar as falhas em %s: %v\n", *saveFailures, err)
+		} else if failures.count > 0 && !*quiet {
+			fmt.Fprintf(os.Stderr, "%d falhas gravadas em %s\n", failures.count, *saveFailures)
+		}
+	}
+	if err != nil {
+		fmt.Printf("Erro: %s\n", secrets.redact(err.Error()))
+		return exitUsage
+	}
+	// Todas as saídas a seguir, incluindo --save-report e os exportadores,
+	// recebem o relatório sem os valores substituídos
+	secrets.redactReport(report)
+	if web != nil {
+		if err := web.finish(report); err != nil {
+			fmt.Fprintf(os.Stderr, "AVISO: não foi possível gerar o relatório da página de --web: %v\n", err)
+		}
+	}
+
+	var histogram []stress.LatencyBucket
+	if !*noHistogram {
+		histogram = histogramBuckets.distribution(report)
+	}
+
+	// Imprime o relatório; com --quiet, a linha de resumo vai para stderr
+	// quando o JSON ou o Markdown ocupam stdout
+	switch {
+	case *output == "json":
+		if err := printJSONReport(os.Stdout, report); err != nil {
+			fmt.Printf("Erro: não foi possível gerar o JSON: %v\n", err)
+			return exitUsage
+		}
+	case *output == "markdown":
+		printReport(os.Stdout, report, markdownStyle, histogram)
+	case *quiet:
+		printSummaryLine(os.Stdout, report)
+	default:
+		printReport(os.Stdout, report, newReportStyle(os.Stdout, *noColor), histogram)
+	}
+	if *quiet && *output != "text" {
+		printSummaryLine(os.Stderr, report)
+	}
+	// A comparação acompanha o relatório em texto e em Markdown; com o JSON
+	// ou --quiet ocupando stdout, vai para stderr
+	var comparison *baselineComparison
+	if baseline != nil {
+		comparison = compareBaseline(*baselinePath, baseline, report, baselineTolerance{latency: *baselineTolerancePct / 100, errorRate: *baselineErrorTolerance})
+		switch {
+		case *output == "json" || *quiet:
+			printBaselineComparison(os.Stderr, reportStyle{}, comparison)
+		case *output == "markdown":
+			printBaselineComparison(os.Stdout, markdownStyle, comparison)
+		default:
+			printBaselineComparison(os.Stdout, newReportStyle(os.Stdout, *noColor), comparison)
+		}
+	}
+	if *saveReport != "" {
+		if err := writeSavedReport(*saveReport, report); err != nil {
+			fmt.Printf("Erro: não foi possível gravar --save-report: %v\n", err)
+			return exitUsage
+		}
+	}
+	if htmlFile != nil {
+		if err := writeHTMLReport(htmlFile, report); err != nil {
+			fmt.Printf("Erro: não foi possível gravar o relatório HTML: %v\n", err)
+			return exitUsage
+		}
+	}
+	if timelineFile != nil {
+		if err := writeTimelineCSV(timelineFile, report.Timeline); err != nil {
+			fmt.Printf("Erro: não foi possível gravar o CSV da série no tempo: %v\n", err)
+			return exitUsage
+		}
+	}
+	// Uma falha no envio é informada sem alterar o código de saída, que
+	// continua refletindo os limites de --fail-if
+	if influx != nil {
+		if err := influx.Close(report); err != nil {
+			fmt.Fprintf(os.Stderr, "AVISO: falha ao gravar as métricas do InfluxDB: %v\n", err)
+		}
+	}
+	if spans != nil {
+		if err := spans.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "AVISO: falha ao exportar os spans OpenTelemetry: %v\n", err)
+		}
+	}
+	if groupingURL != "" {
+		if err := pushReport(context.Background(), http.DefaultClient, groupingURL, report); err != nil {
+			fmt.Fprintf(os.Stderr, "AVISO: não foi possível enviar as métricas ao Pushgateway: %v\n", err)
+		}
+	}
+	// A página de --web continua com o resultado até o próximo Ctrl+C. Sem
+	// terminal (em CI ou nos testes de --serve) ninguém pressionaria a tecla,
+	// então o processo termina em seguida.
+	if web != nil && isTerminal(os.Stdin) {
+		signal.Stop(signals)
+		fmt.Fprintf(os.Stderr, "Resultado disponível em %s; pressione Ctrl+C para encerrar\n", web.URL())
+		wait := make(chan os.Signal, 1)
+		signal.Notify(wait, os.Interrupt, syscall.SIGTERM)
+		<-wait
+	}
+	if !report.ThresholdsPassed() {
+		return exitThresholds
+	}
+	if comparison != nil && len(comparison.Regressions()) > 0 {
+		return exitBaseline
 	}
+	return exitOK
 }
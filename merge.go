@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+// runMerge executa o subcomando merge, que combina os relatórios de
+// --save-report de execuções simultâneas em um único relatório
+func runMerge(args []string) int {
+	flags := flag.NewFlagSet("merge", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: ./stress-test merge [opções] <relatório.json> <relatório.json>...")
+		flags.PrintDefaults()
+	}
+	output := flags.String("output", "text", "Formato do relatório combinado (text|json|markdown)")
+	saveReport := flags.String("save-report", "", "Grava o relatório combinado, com os histogramas, para uso com -baseline ou em outro merge")
+	noColor := flags.Bool("no-color", false, "Imprime o relatório sem cores mesmo no terminal")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() < 2 {
+		fmt.Println("Erro: merge requer ao menos dois relatórios")
+		flags.Usage()
+		return exitUsage
+	}
+	if *output != "text" && *output != "json" && *output != "markdown" {
+		fmt.Println("Erro: --output deve ser text, json ou markdown")
+		return exitUsage
+	}
+
+	var merged *stress.Report
+	for _, path := range flags.Args() {
+		saved, err := readSavedReport(path)
+		if err != nil {
+			fmt.Printf("Erro: %v\n", err)
+			return exitUsage
+		}
+		// Sem o histograma, os percentis combinados seriam apenas
+		// aproximações
+		if saved.Histogram == nil {
+			fmt.Printf("Erro: %s não contém o histograma de durações: grave o relatório com --save-report\n", path)
+			return exitUsage
+		}
+		report, err := saved.report()
+		if err != nil {
+			fmt.Printf("Erro: %s: %v\n", path, err)
+			return exitUsage
+		}
+		if merged == nil {
+			merged = report
+			continue
+		}
+		merged.Merge(report)
+	}
+
+	histogram := histogramFlag{buckets: defaultHistogramBuckets}
+	switch *output {
+	case "json":
+		if err := printJSONReport(os.Stdout, merged); err != nil {
+			fmt.Printf("Erro: não foi possível gerar o JSON: %v\n", err)
+			return exitUsage
+		}
+	case "markdown":
+		printReport(os.Stdout, merged, markdownStyle, histogram.distribution(merged))
+	default:
+		printReport(os.Stdout, merged, newReportStyle(os.Stdout, *noColor), histogram.distribution(merged))
+	}
+	if *saveReport != "" {
+		if err := writeSavedReport(*saveReport, merged); err != nil {
+			fmt.Printf("Erro: não foi possível gravar --save-report: %v\n", err)
+			return exitUsage
+		}
+	}
+	if !merged.ThresholdsPassed() {
+		return exitThresholds
+	}
+	return exitOK
+}
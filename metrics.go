@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+// metricsBuckets são os limites, em segundos, do histograma de latências de
+// --metrics-addr (os padrões dos clients do Prometheus)
+var metricsBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metricsShutdownTimeout limita a espera pelas coletas em andamento ao
+// encerrar o servidor de --metrics-addr
+const metricsShutdownTimeout = 5 * time.Second
+
+// liveMetrics acumula as métricas de --metrics-addr. É atualizado a partir
+// de OnResult e lido pelo handler HTTP, por isso usa um mutex.
+type liveMetrics struct {
+	gauges *stress.Gauges
+
+	mu sync.Mutex
+	// requests conta as requests por classe de status ("2xx", "none"...)
+	requests map[string]int64
+	// errors conta as falhas por categoria de erro
+	errors map[string]int64
+	// buckets acumula as durações em cada limite de metricsBuckets, sem o
+	// +Inf, que é o total de durações
+	buckets       []int64
+	durationCount int64
+	durationSum   float64
+}
+
+func newLiveMetrics(gauges *stress.Gauges) *liveMetrics {
+	return &liveMetrics{
+		gauges:   gauges,
+		requests: make(map[string]int64),
+		errors:   make(map[string]int64),
+		buckets:  make([]int64, len(metricsBuckets)),
+	}
+}
+
+// add incorpora um resultado; as requests canceladas no fim do teste não
+// entram nas métricas, como no relatório
+func (m *liveMetrics) add(result stress.Result) {
+	if result.Canceled {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[statusClass(result)]++
+	if result.Error != nil {
+		m.errors[result.ErrorCategory]++
+	}
+	// Erros de transporte não têm resposta cuja duração medir
+	if result.Error != nil && result.StatusCode == 0 && result.GRPCCode == "" {
+		return
+	}
+	seconds := result.Duration.Seconds()
+	for i, bound := range metricsBuckets {
+		if seconds <= bound {
+			m.buckets[i]++
+		}
+	}
+	m.durationCount++
+	m.durationSum += seconds
+}
+
+// statusClass agrupa o status HTTP em classes como "2xx"; resultados sem
+// status HTTP (erros de transporte, chamadas gRPC e mensagens WebSocket)
+// ficam em "none"
+func statusClass(result stress.Result) string {
+	if result.GRPCCode != "" || result.StatusCode < 100 || result.StatusCode > 599 {
+		return "none"
+	}
+	return strconv.Itoa(result.StatusCode/100) + "xx"
+}
+
+// ServeHTTP responde no formato de texto do Prometheus. Os nomes das métricas
+// e dos labels são estáveis, documentados no README.
+func (m *liveMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.write(w)
+}
+
+func (m *liveMetrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP stress_requests_total Requests concluídas, por classe de status HTTP.")
+	fmt.Fprintln(w, "# TYPE stress_requests_total counter")
+	for _, class := range slices.Sorted(maps.Keys(m.requests)) {
+		fmt.Fprintf(w, "stress_requests_total{status_class=\"%s\"} %d\n", class, m.requests[class])
+	}
+	fmt.Fprintln(w, "# HELP stress_errors_total Requests com erro, por categoria.")
+	fmt.Fprintln(w, "# TYPE stress_errors_total counter")
+	for _, category := range slices.Sorted(maps.Keys(m.errors)) {
+		fmt.Fprintf(w, "stress_errors_total{category=\"%s\"} %d\n", promLabel(category), m.errors[category])
+	}
+	fmt.Fprintln(w, "# HELP stress_request_duration_seconds Duração das requests que receberam resposta.")
+	fmt.Fprintln(w, "# TYPE stress_request_duration_seconds histogram")
+	for i, bound := range metricsBuckets {
+		fmt.Fprintf(w, "stress_request_duration_seconds_bucket{le=\"%s\"} %d\n", promValue(bound), m.buckets[i])
+	}
+	fmt.Fprintf(w, "stress_request_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.durationCount)
+	fmt.Fprintf(w, "stress_request_duration_seconds_sum %s\n", promValue(m.durationSum))
+	fmt.Fprintf(w, "stress_request_duration_seconds_count %d\n", m.durationCount)
+	fmt.Fprintln(w, "# HELP stress_in_flight_requests Requests em andamento.")
+	fmt.Fprintln(w, "# TYPE stress_in_flight_requests gauge")
+	fmt.Fprintf(w, "stress_in_flight_requests %d\n", m.gauges.InFlight())
+	fmt.Fprintln(w, "# HELP stress_active_workers Workers em execução.")
+	fmt.Fprintln(w, "# TYPE stress_active_workers gauge")
+	fmt.Fprintf(w, "stress_active_workers %d\n", m.gauges.ActiveWorkers())
+}
+
+// metricsServer serve /metrics durante o teste
+type metricsServer struct {
+	server *http.Server
+	done   chan struct{}
+}
+
+// startMetricsServer abre o endereço antes do teste, para que uma porta em
+// uso seja informada logo, e serve as métricas em /metrics
+func startMetricsServer(addr string, metrics *liveMetrics) (*metricsServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	s := &metricsServer{server: &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		s.server.Serve(listener)
+	}()
+	return s, nil
+}
+
+// Close encerra o servidor, aguardando as coletas em andamento
+func (s *metricsServer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+	defer cancel()
+	err := s.server.Shutdown(ctx)
+	<-s.done
+	return err
+}
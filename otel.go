@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+const (
+	// otlpDefaultEndpoint é o endpoint OTLP/HTTP padrão do coletor local
+	otlpDefaultEndpoint = "http://localhost:4318"
+	// otlpDefaultTimeout é o timeout de cada envio sem OTEL_EXPORTER_OTLP_TIMEOUT
+	otlpDefaultTimeout = 10 * time.Second
+	// otlpBatchSize e otlpFlushInterval definem quando os spans acumulados
+	// são enviados
+	otlpBatchSize     = 512
+	otlpFlushInterval = time.Second
+	// otlpQueueSize limita os spans aguardando envio; com a fila cheia, os
+	// novos são descartados em vez de atrasar o teste
+	otlpQueueSize = 8192
+)
+
+// otlpConfig é a configuração do exportador, lida das variáveis
+// OTEL_EXPORTER_OTLP_* padrão do OpenTelemetry
+type otlpConfig struct {
+	endpoint    string
+	headers     http.Header
+	timeout     time.Duration
+	serviceName string
+}
+
+// otlpConfigFromEnv lê o endpoint, os headers, o timeout e o protocolo,
+// com as variáveis específicas de traces (OTEL_EXPORTER_OTLP_TRACES_*)
+// prevalecendo sobre as gerais. Apenas o protocolo http/json é suportado.
+func otlpConfigFromEnv() (otlpConfig, error) {
+	env := func(name string) string {
+		if value := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_" + name); value != "" {
+			return value
+		}
+		return os.Getenv("OTEL_EXPORTER_OTLP_" + name)
+	}
+	config := otlpConfig{headers: make(http.Header), timeout: otlpDefaultTimeout, serviceName: "stress-test"}
+	if protocol := env("PROTOCOL"); protocol != "" && protocol != "http/json" {
+		return config, fmt.Errorf("protocolo OTLP %q não suportado: use OTEL_EXPORTER_OTLP_PROTOCOL=http/json", protocol)
+	}
+	// O endpoint específico de traces é usado como está; o geral recebe o
+	// caminho /v1/traces, como definido na especificação
+	switch endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); {
+	case endpoint != "":
+		config.endpoint = endpoint
+	case os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "":
+		config.endpoint = strings.TrimSuffix(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "/") + "/v1/traces"
+	default:
+		config.endpoint = otlpDefaultEndpoint + "/v1/traces"
+	}
+	if parsed, err := url.Parse(config.endpoint); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return config, fmt.Errorf("endpoint OTLP inválido %q: use uma URL http:// ou https://", config.endpoint)
+	}
+	if headers := env("HEADERS"); headers != "" {
+		for _, pair := range strings.Split(headers, ",") {
+			name, value, ok := strings.Cut(pair, "=")
+			name = strings.TrimSpace(name)
+			if !ok || name == "" {
+				return config, fmt.Errorf("header OTLP inválido %q: use o formato \"nome=valor\"", pair)
+			}
+			// Os valores são codificados como em uma URL
+			if decoded, err := url.QueryUnescape(strings.TrimSpace(value)); err == nil {
+				value = decoded
+			}
+			config.headers.Add(name, value)
+		}
+	}
+	if timeout := env("TIMEOUT"); timeout != "" {
+		ms, err := strconv.Atoi(timeout)
+		if err != nil || ms <= 0 {
+			return config, fmt.Errorf("timeout OTLP inválido %q: use milissegundos", timeout)
+		}
+		config.timeout = time.Duration(ms) * time.Millisecond
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		config.serviceName = name
+	}
+	return config, nil
+}
+
+// spanExporter envia um span de client por request amostrada ao endpoint
+// OTLP/HTTP, com o trace ID e o span ID do traceparent enviado. add é chamado
+// a partir de OnResult e nunca bloqueia: os spans vão para uma fila
+// consumida por uma goroutine que os envia em lotes.
+type spanExporter struct {
+	config  otlpConfig
+	client  *http.Client
+	failed  func(stress.Result) bool
+	queue   chan otlpSpan
+	done    chan struct{}
+	dropped int64
+	sent    int
+	errs    []error
+}
+
+func newSpanExporter(config otlpConfig, failed func(stress.Result) bool) *spanExporter {
+	e := &spanExporter{
+		config: config,
+		client: &http.Client{Timeout: config.timeout},
+		failed: failed,
+		queue:  make(chan otlpSpan, otlpQueueSize),
+		done:   make(chan struct{}),
+	}
+	go e.send()
+	return e
+}
+
+// add converte o resultado em span quando a request foi amostrada
+func (e *spanExporter) add(result stress.Result) {
+	if !result.TraceSampled || result.Canceled {
+		return
+	}
+	method, target := resultTarget(result)
+	span := otlpSpan{
+		TraceID: result.TraceID,
+		SpanID:  result.SpanID,
+		Name:    method,
+		Kind:    otlpSpanKindClient,
+		Start:   strconv.FormatInt(result.Timestamp.UnixNano(), 10),
+		End:     strconv.FormatInt(result.Timestamp.Add(result.Duration).UnixNano(), 10),
+		Attributes: []otlpAttribute{
+			otlpString("http.request.method", method),
+			otlpString("url.full", target),
+			otlpString("stress.worker", stress.WorkerName(result.WorkerID)),
+		},
+	}
+	if result.StatusCode != 0 {
+		span.Attributes = append(span.Attributes, otlpInt("http.response.status_code", result.StatusCode))
+	}
+	if e.failed(result) {
+		span.Status = &otlpStatus{Code: otlpStatusError}
+		if result.Error != nil {
+			span.Status.Message = result.Error.Error()
+			span.Attributes = append(span.Attributes, otlpString("error.type", result.ErrorCategory))
+		} else {
+			span.Attributes = append(span.Attributes, otlpString("error.type", strconv.Itoa(result.StatusCode)))
+		}
+	}
+	select {
+	case e.queue <- span:
+	default:
+		e.dropped++
+	}
+}
+
+// send envia os spans da fila em lotes de até otlpBatchSize, ou a cada
+// otlpFlushInterval, até que a fila seja fechada
+func (e *spanExporter) send() {
+	defer close(e.done)
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]otlpSpan, 0, otlpBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		e.sent++
+		if err := e.export(batch); err != nil {
+			e.errs = append(e.errs, err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case span, ok := <-e.queue:
+			if !ok {
+				flush()
+				return
+			}
+			if batch = append(batch, span); len(batch) == otlpBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (e *spanExporter) export(spans []otlpSpan) error {
+	body, err := json.Marshal(otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{otlpString("service.name", e.config.serviceName)}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/Playerleleo/Stress-Test", Version: stress.Version},
+			Spans: spans,
+		}},
+	}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, e.config.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = e.config.headers.Clone()
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// Close envia os spans pendentes e retorna os descartes e os erros do envio
+func (e *spanExporter) Close() error {
+	close(e.queue)
+	<-e.done
+	var errs []error
+	if e.dropped > 0 {
+		errs = append(errs, fmt.Errorf("%d spans descartados com a fila de envio cheia", e.dropped))
+	}
+	if len(e.errs) > 0 {
+		errs = append(errs, fmt.Errorf("%d de %d lotes rejeitados, o primeiro com %w", len(e.errs), e.sent, e.errs[0]))
+	}
+	return errors.Join(errs...)
+}
+
+// Tipos do OTLP/JSON (ExportTraceServiceRequest). Os IDs vão em
+// hexadecimal e os horários em nanossegundos como texto, como a
+// especificação define para a codificação JSON.
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type otlpSpan struct {
+	TraceID    string          `json:"traceId"`
+	SpanID     string          `json:"spanId"`
+	Name       string          `json:"name"`
+	Kind       int             `json:"kind"`
+	Start      string          `json:"startTimeUnixNano"`
+	End        string          `json:"endTimeUnixNano"`
+	Attributes []otlpAttribute `json:"attributes"`
+	Status     *otlpStatus     `json:"status,omitempty"`
+}
+
+// otlpSpanKindClient e otlpStatusError são os valores de SpanKind e
+// StatusCode do OTLP
+const (
+	otlpSpanKindClient = 3
+	otlpStatusError    = 2
+)
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+func otlpString(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func otlpInt(key string, value int) otlpAttribute {
+	text := strconv.Itoa(value)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &text}}
+}
@@ -0,0 +1,1948 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+// printReport imprime o relatório em texto ou Markdown, com o estilo de
+// style. O histograma de latências usa as faixas de histogram e é omitido
+// quando elas são nil.
+func printReport(w io.Writer, report *stress.Report, style reportStyle, histogram []stress.LatencyBucket) {
+	p := &reportPrinter{out: w, style: style}
+	defer p.flush()
+	p.title("Relatório do Teste de Carga")
+	if report.Aborted {
+		p.line(ansiYellow, "Teste abortado após %d requests: %s", report.TotalRequests, report.AbortReason)
+	}
+	if report.Interrupted {
+		p.line(ansiYellow, "Teste interrompido após %d requests: %s", report.TotalRequests, interruptReason(report.InterruptCause))
+	}
+	if report.Merged > 0 {
+		p.line("", "Relatório combinado de %d execuções simultâneas", report.Merged)
+	}
+	switch {
+	case report.GRPCMethod != "":
+		p.field("", "Método gRPC", "%s", report.GRPCMethod)
+	case report.WebSocket != nil:
+		p.field("", "Modo", "WebSocket (uma request por mensagem enviada)")
+	case report.SSE != nil:
+		p.field("", "Método HTTP", "%s (SSE, uma request por stream)", report.Method)
+	default:
+		p.field("", "Método HTTP", "%s", report.Method)
+	}
+	if report.ExpectedProtocol != "" {
+		p.field("", "Protocolo Solicitado", "%s", report.ExpectedProtocol)
+	}
+	if report.PlannedDuration > 0 {
+		p.field("", "Duração Planejada", "%v", report.PlannedDuration)
+	}
+	p.field("", "Tempo Total", "%v", report.TotalTime)
+	p.field("", "Total de Requests", "%d", report.TotalRequests)
+	if report.TargetRPS > 0 {
+		p.field("", "RPS Alvo", "%.2f", report.TargetRPS)
+	}
+	if report.ArrivalRate > 0 {
+		planned := "configurada"
+		if len(report.Stages) > 0 {
+			planned = "média planejada"
+		}
+		p.field("", "Taxa de Chegadas", "%.2f/s %s | %.2f/s gerada (modelo aberto, %s)", report.ArrivalRate, planned, report.AchievedArrivalRate, report.ArrivalDistribution)
+		p.field("", "Requests em Andamento", "pico de %d | limite de %d", report.PeakInFlight, report.MaxInFlight)
+		droppedColor := ansiGreen
+		if report.DroppedArrivals > 0 {
+			droppedColor = ansiRed
+		}
+		p.field(droppedColor, "Chegadas Descartadas", "%d (%.2f%%)", report.DroppedArrivals, report.DroppedArrivalRate()*100)
+	}
+	p.field("", "RPS Atingido", "%.2f", report.RequestsPerSecond)
+	p.field("", "RPS com Sucesso", "%.2f", report.SuccessfulRequestsPerSecond)
+	expected := "2xx/3xx"
+	switch {
+	case report.GRPCMethod != "":
+		expected = "gRPC OK"
+	case report.WebSocket != nil:
+		expected = "mensagens respondidas"
+	case report.SSE != nil:
+		expected = "streams sem falhas"
+	case len(report.ExpectStatus) > 0:
+		expected = "status " + report.ExpectStatus.String()
+	}
+	p.field(ansiGreen, fmt.Sprintf("Requests com Sucesso (%s)", expected), "%d", report.SuccessfulRequests)
+	failureColor := ""
+	if report.FailedRequests > 0 {
+		failureColor = ansiRed
+	}
+	p.field(failureColor, "Requests com Falha", "%d", report.FailedRequests)
+	if report.FailedRequests > 0 {
+		p.detail("Status Inesperado: %d | Erros de Transporte: %d | Erros de Aplicação: %d",
+			report.UnexpectedStatus, report.TransportErrors, report.ApplicationErrors)
+	}
+	if report.RedirectedRequests > 0 {
+		p.field("", "Requests Redirecionadas", "%d", report.RedirectedRequests)
+	}
+	if report.CanceledRequests > 0 {
+		p.field("", "Requests Canceladas", "%d", report.CanceledRequests)
+	}
+	if report.Seed != 0 {
+		p.field("", "Semente", "%d", report.Seed)
+	}
+	if report.ThinkTime > 0 || report.ThinkTimeJitter > 0 {
+		p.field("", "Think Time", "%v (± %v) entre as requests de cada worker", report.ThinkTime, report.ThinkTimeJitter)
+	}
+	if report.DataExhausted {
+		p.line("", "Teste encerrado ao fim das linhas de dados (--data-stop)")
+	}
+	if report.WarmupRequests > 0 {
+		p.field("", "Requests de Aquecimento (excluídas das métricas)", "%d", report.WarmupRequests)
+	}
+	if report.RampUp > 0 {
+		p.field("", "Ramp-up", "%v (concorrência total atingida em %v)", report.RampUp, report.FullConcurrencyAt)
+	}
+
+	p.section("Dados Transferidos")
+	p.field("", "Enviados", "%s (%s por request)", formatBytes(float64(report.BytesSent)), formatBytes(perRequest(report.BytesSent, report.TotalRequests)))
+	if report.BodySkipped {
+		// Sem ler os corpos não há bytes recebidos a medir
+		p.field("", "Recebidos", "indisponível (corpos não lidos com --skip-body)")
+		p.field("", "Vazão", "%s/s enviados", formatBytes(report.SentBytesPerSecond()))
+	} else {
+		p.field("", "Recebidos", "%s (%s por request)", formatBytes(float64(report.BytesReceived)), formatBytes(perRequest(report.BytesReceived, report.TotalRequests)))
+		p.field("", "Vazão", "%s/s recebidos | %s/s enviados", formatBytes(report.ReceivedBytesPerSecond()), formatBytes(report.SentBytesPerSecond()))
+		p.field("", "Tamanho das Respostas", "mín %s | máx %s | média %s",
+			formatBytes(float64(report.MinResponseSize)), formatBytes(float64(report.MaxResponseSize)), formatBytes(report.AvgResponseSize))
+	}
+	if connections := report.NewConnections + report.ReusedConnections; connections > 0 {
+		p.field("", "Conexões", "%d novas | %d reaproveitadas (%.2f%% de reuso)",
+			report.NewConnections, report.ReusedConnections, float64(report.ReusedConnections)/float64(connections)*100)
+	}
+	if report.TruncatedResponses > 0 {
+		p.line(ansiYellow, "AVISO: %d respostas truncadas (corpo menor que o Content-Length)", report.TruncatedResponses)
+	}
+	if report.BodyLimitedResponses > 0 {
+		p.line(ansiYellow, "AVISO: %d respostas passaram de --max-body-bytes e foram abandonadas; seus tamanhos contam apenas até o limite", report.BodyLimitedResponses)
+	}
+	if compression := report.Compression; compression != nil {
+		p.field("", "Respostas com gzip", "%d de %d | no fio: %s | descomprimidos: %s | razão: %.2fx",
+			compression.CompressedResponses, report.TotalRequests-report.TransportErrors,
+			formatBytes(float64(compression.WireBytes)), formatBytes(float64(compression.DecodedBytes)), compression.Ratio)
+		if compression.CompressedResponses > 0 {
+			decompression := compression.Decompression
+			p.field("", "Tempo de Descompressão", "média %v | P95 %v | máx %v", decompression.Avg, decompression.P95, decompression.Max)
+		}
+	}
+
+	if len(report.Settings) > 0 {
+		p.section("Configuração")
+		names := make([]string, 0, len(report.Settings))
+		for name := range report.Settings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p.field("", name, "%s", report.Settings[name])
+		}
+	}
+
+	p.section("Métricas de Duração")
+	p.field("", "Duração Mínima", "%v", report.MinDuration)
+	p.field("", "Duração Máxima", "%v", report.MaxDuration)
+	p.field("", "Duração Média", "%v", report.AvgDuration)
+	p.field("", "Desvio Padrão", "%v", report.StdDevDuration)
+	p.field("", "Coeficiente de Variação", "%.2f%%", report.CoefficientOfVariation*100)
+
+	p.section("Tempo até o Primeiro Byte (TTFB)")
+	p.field("", "Mínimo", "%v", report.TTFB.Min)
+	p.field("", "Médio", "%v", report.TTFB.Avg)
+	p.field("", "P95", "%v", report.TTFB.P95)
+
+	if report.Phases != nil {
+		printPhases(p, report.Phases)
+	}
+
+	p.section("Percentis de Duração")
+	for _, percentile := range reportPercentiles {
+		p.column("", percentileName(percentile), report.ValueAtQuantile(percentile))
+	}
+	if report.ClampedDurations > 0 {
+		p.line(ansiYellow, "AVISO: %d durações acima de %v foram registradas como o máximo do histograma (ajuste --histogram-max)",
+			report.ClampedDurations, report.HistogramMax)
+	}
+	if len(histogram) > 0 {
+		printHistogram(p, histogram)
+	}
+	if report.ResponseTime != nil {
+		printResponseTime(p, report)
+	}
+	if report.Retries != nil {
+		printRetries(p, report)
+	}
+	if report.RetryAfter != nil {
+		printRetryAfter(p, report.RetryAfter)
+	}
+	if report.Slowest != nil && len(report.Slowest.Requests) > 0 {
+		printSlowest(p, report)
+	}
+
+	// As chamadas gRPC e as mensagens WebSocket não registram o protocolo HTTP
+	if report.GRPCMethod == "" && report.WebSocket == nil {
+		p.section("Protocolos")
+		for protocol, count := range report.Protocols {
+			p.field("", protocol, "%d requests", count)
+		}
+	}
+	if fallback := report.ProtocolMismatches(); fallback > 0 {
+		p.line(ansiYellow, "AVISO: %s solicitado, mas %d requests usaram outro protocolo", report.ExpectedProtocol, fallback)
+	}
+
+	if report.Scenario != nil {
+		printScenario(p, report.Scenario)
+	}
+	if report.WebSocket != nil {
+		printWebSocket(p, report.WebSocket)
+	}
+	if report.SSE != nil {
+		printSSE(p, report.SSE)
+	}
+	if len(report.Targets) > 1 || report.Scenario != nil {
+		printTargets(p, report)
+	}
+	if len(report.Stages) > 0 {
+		printStages(p, report)
+	}
+	if report.Spike != nil {
+		printSpike(p, report.Spike)
+	}
+	if report.Trend != nil {
+		printTrend(p, report.Trend)
+	}
+	if report.FindMax != nil {
+		printFindMax(p, report.FindMax)
+	}
+	if len(report.Workers) > 0 {
+		printWorkers(p, report.Workers)
+	}
+
+	if report.GRPCMethod != "" {
+		p.section("Distribuição de Status gRPC")
+		for _, code := range sortedByCount(report.GRPCCodes) {
+			count := report.GRPCCodes[code]
+			p.field("", code, "%d chamadas (%.2f%%)",
+				count,
+				float64(count)/float64(report.TotalRequests)*100)
+		}
+	} else if report.WebSocket == nil || len(report.StatusCodes) > 0 {
+		// No modo WebSocket só os handshakes recusados têm status
+		p.section("Distribuição de Status HTTP")
+		statuses := slices.Sorted(maps.Keys(report.StatusCodes))
+		for _, status := range statuses {
+			count := report.StatusCodes[status]
+			p.field(statusColor(status), fmt.Sprintf("Status %d", status), "%d requests (%.2f%%)",
+				count,
+				float64(count)/float64(report.TotalRequests)*100)
+		}
+	}
+
+	if report.Apdex != nil {
+		apdex := report.Apdex
+		p.heading("Apdex (T = %v): %.2f", apdex.T, apdex.Score)
+		p.line("", "Satisfeitos (≤ %v): %d | Tolerados (≤ %v): %d | Frustrados: %d",
+			apdex.T, apdex.Satisfied, 4*apdex.T, apdex.Tolerating, apdex.Frustrated)
+	}
+
+	if len(report.Thresholds) > 0 {
+		printThresholds(p, report.Thresholds)
+	}
+
+	if len(report.MergeConflicts) > 0 {
+		p.section("Diferenças entre as Execuções Combinadas")
+		for _, conflict := range report.MergeConflicts {
+			p.line(ansiYellow, "%s", conflict)
+		}
+	}
+
+	if len(report.AssertionFailures) > 0 {
+		p.section("Falhas de Asserção")
+		for _, assertion := range sortedByCount(report.AssertionFailures) {
+			p.field("", assertion, "%d requests", report.AssertionFailures[assertion])
+		}
+	}
+
+	if len(report.GraphQLErrors) > 0 {
+		p.section("Erros GraphQL")
+		messages := sortedByCount(report.GraphQLErrors)
+		for _, message := range messages[:min(len(messages), maxListedMessages)] {
+			p.field("", message, "%d requests", report.GraphQLErrors[message])
+		}
+		if len(messages) > maxListedMessages {
+			p.line("", "... e mais %d mensagens", len(messages)-maxListedMessages)
+		}
+	}
+
+	if len(report.ErrorCategories) > 0 {
+		p.section("Erros por Categoria")
+		for _, category := range sortedByCount(report.ErrorCategories) {
+			count := report.ErrorCategories[category]
+			p.field(ansiRed, category, "%d requests (%.2f%%)",
+				count,
+				float64(count)/float64(report.TotalRequests)*100)
+		}
+	}
+}
+
+// statusColor destaca os status 5xx em vermelho e os 4xx em amarelo
+func statusColor(status int) string {
+	switch {
+	case status >= 500:
+		return ansiRed
+	case status >= 400:
+		return ansiYellow
+	}
+	return ""
+}
+
+// printThresholds imprime o resultado de cada --fail-if e, por último, os
+// limites violados
+func printThresholds(p *reportPrinter, results []stress.ThresholdResult) {
+	p.section("Limites (--fail-if)")
+	var violated []string
+	for _, result := range results {
+		status, color := "OK", ansiGreen
+		if !result.Passed {
+			status, color = "VIOLADO", ansiRed
+			violated = append(violated, result.Threshold.String())
+		}
+		p.field(color, status, "%s (atual: %s)", result.Threshold, result.Threshold.FormatValue(result.Actual))
+	}
+	if len(violated) > 0 {
+		p.line(ansiRed, "FALHA: %d de %d limites violados: %s", len(violated), len(results), strings.Join(violated, ", "))
+	}
+}
+
+// printScenario imprime o resumo das iterações de -scenario
+func printScenario(p *reportPrinter, scenario *stress.ScenarioStats) {
+	p.section("Cenário")
+	p.field("", "Iterações", "%d (concluídas: %d | abortadas: %d)",
+		scenario.Iterations, scenario.CompletedIterations, scenario.AbortedIterations)
+	for _, step := range sortedByCount(scenario.AbortedBySteps) {
+		p.field("", "Abortadas em "+step, "%d", scenario.AbortedBySteps[step])
+	}
+	durations := scenario.Durations
+	p.field("", "Duração das Iterações Concluídas", "mín %v | média %v | P50 %v | P95 %v | P99 %v | máx %v",
+		durations.Min, durations.Avg, durations.P50, durations.P95, durations.P99, durations.Max)
+}
+
+func printWebSocket(p *reportPrinter, ws *stress.WebSocketStats) {
+	p.section("WebSocket")
+	p.field("", "Conexões Estabelecidas", "%d | Falhas de Conexão: %d | Desconexões: %d",
+		ws.ConnectionsEstablished, ws.ConnectionFailures, ws.Disconnects)
+	for _, reason := range sortedByCount(ws.DisconnectReasons) {
+		p.detail("%s: %d", reason, ws.DisconnectReasons[reason])
+	}
+	p.field("", "Mensagens Enviadas", "%d | Recebidas: %d", ws.MessagesSent, ws.MessagesReceived)
+	handshake := ws.Handshake
+	p.field("", "Handshake", "mín %v | média %v | P50 %v | P95 %v | P99 %v | máx %v",
+		handshake.Min, handshake.Avg, handshake.P50, handshake.P95, handshake.P99, handshake.Max)
+	if ws.MessagesSent > 0 {
+		rtt := ws.RTT
+		p.field("", "Round-trip das Mensagens", "mín %v | média %v | P50 %v | P95 %v | P99 %v | máx %v",
+			rtt.Min, rtt.Avg, rtt.P50, rtt.P95, rtt.P99, rtt.Max)
+	}
+}
+
+func printSSE(p *reportPrinter, sse *stress.SSEStats) {
+	p.section("SSE")
+	p.field("", "Streams Abertos", "%d | Falhas de Conexão: %d | Desconexões: %d",
+		sse.Connections, sse.ConnectionFailures, sse.Disconnects)
+	for _, reason := range sortedByCount(sse.DisconnectReasons) {
+		p.detail("%s: %d", reason, sse.DisconnectReasons[reason])
+	}
+	p.field("", "Eventos Recebidos", "%d (%.2f/s) | Linhas Malformadas: %d", sse.Events, sse.EventsPerSecond, sse.MalformedLines)
+	if sse.Connections > 0 {
+		p.field("", "Eventos por Stream", "mín %d | média %.2f | máx %d",
+			sse.MinEventsPerConnection, sse.AvgEventsPerConnection, sse.MaxEventsPerConnection)
+	}
+	if sse.Events > 0 {
+		first := sse.FirstEvent
+		p.field("", "Tempo até o Primeiro Evento", "mín %v | média %v | P50 %v | P95 %v | P99 %v | máx %v",
+			first.Min, first.Avg, first.P50, first.P95, first.P99, first.Max)
+		gap := sse.InterEvent
+		p.field("", "Intervalo entre Eventos", "mín %v | média %v | P50 %v | P95 %v | P99 %v | máx %v",
+			gap.Min, gap.Avg, gap.P50, gap.P95, gap.P99, gap.Max)
+	}
+}
+
+// maxListedMessages limita quantas mensagens de erro GraphQL são listadas
+// no relatório em texto; o JSON traz todas
+const maxListedMessages = 10
+
+// maxListedWorkers limita quantos workers com erros de transporte são
+// listados no relatório em texto; o JSON traz todos
+const maxListedWorkers = 10
+
+// printWorkers resume a distribuição das requests entre os workers e lista
+// os workers com mais erros de transporte, que revelam conexões problemáticas
+// com -per-worker-client
+func printWorkers(p *reportPrinter, workers []stress.WorkerReport) {
+	p.section("Métricas por Worker")
+	minRequests, maxRequests, total := workers[0].Requests, workers[0].Requests, 0
+	var withErrors []int
+	for id, worker := range workers {
+		minRequests = min(minRequests, worker.Requests)
+		maxRequests = max(maxRequests, worker.Requests)
+		total += worker.Requests
+		if worker.TransportErrors > 0 {
+			withErrors = append(withErrors, id)
+		}
+	}
+	p.field("", "Requests por Worker", "mín %d | média %.1f | máx %d",
+		minRequests, float64(total)/float64(len(workers)), maxRequests)
+	p.field("", "Workers com Erros de Transporte", "%d de %d", len(withErrors), len(workers))
+	sort.SliceStable(withErrors, func(i, j int) bool {
+		return workers[withErrors[i]].TransportErrors > workers[withErrors[j]].TransportErrors
+	})
+	for _, id := range withErrors[:min(len(withErrors), maxListedWorkers)] {
+		worker := workers[id]
+		categories := sortedByCount(worker.ErrorCategories)
+		for i, category := range categories {
+			categories[i] = fmt.Sprintf("%s:%d", category, worker.ErrorCategories[category])
+		}
+		p.detail("%s: %d de %d requests (%s)",
+			stress.WorkerName(id), worker.TransportErrors, worker.Requests, strings.Join(categories, " "))
+	}
+	if len(withErrors) > maxListedWorkers {
+		p.detail("... e mais %d workers", len(withErrors)-maxListedWorkers)
+	}
+}
+
+// printTargets imprime uma tabela com as métricas de cada alvo, ou de cada
+// passo do cenário, ordenada pelo P95 (mais lentos primeiro). Com pesos
+// diferentes, a proporção atingida é comparada com a esperada.
+func printTargets(p *reportPrinter, report *stress.Report) {
+	title, column := "Métricas por Alvo", "Alvo"
+	if report.Scenario != nil {
+		title, column = "Métricas por Passo", "Passo"
+	}
+	p.section(title)
+	labels := make([]string, 0, len(report.Targets))
+	var totalWeight int
+	weights := make(map[int]bool)
+	for label, target := range report.Targets {
+		labels = append(labels, label)
+		totalWeight += target.Weight
+		weights[target.Weight] = true
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		a, b := report.Targets[labels[i]], report.Targets[labels[j]]
+		if a.Durations.P95 != b.Durations.P95 {
+			return a.Durations.P95 > b.Durations.P95
+		}
+		return labels[i] < labels[j]
+	})
+
+	header := []string{column, "Requests", "Proporção", "Sucesso", "Mín", "Média", "P95", "Status"}
+	rows := make([][]string, 0, len(labels))
+	for _, label := range labels {
+		target := report.Targets[label]
+		share := fmt.Sprintf("%.2f%%", float64(target.Requests)/float64(report.TotalRequests)*100)
+		if len(weights) > 1 && totalWeight > 0 {
+			share += fmt.Sprintf(" (esperado %.2f%%)", float64(target.Weight)/float64(totalWeight)*100)
+		}
+		rows = append(rows, []string{
+			label, strconv.Itoa(target.Requests), share, fmt.Sprintf("%.2f%%", target.SuccessRate()*100),
+			p.sprintf("%v", target.Durations.Min), p.sprintf("%v", target.Durations.Avg), p.sprintf("%v", target.Durations.P95),
+			formatStatusCodes(target.StatusCodes),
+		})
+	}
+	p.table(header, rows)
+}
+
+// printStages imprime uma tabela com as métricas de cada etapa de --stages,
+// na ordem em que foram executadas
+func printStages(p *reportPrinter, report *stress.Report) {
+	p.section("Métricas por Etapa")
+	header := []string{"Etapa", "Início", "Carga", "Requests", "RPS", "Erros", "P50", "P95", "P99"}
+	if report.ArrivalRate > 0 {
+		header = append(header, "Descartadas")
+	}
+	rows := make([][]string, 0, len(report.Stages))
+	for i, stage := range report.Stages {
+		load := fmt.Sprintf("%d workers", stage.Stage.Concurrency)
+		if stage.Stage.Rate > 0 {
+			load = fmt.Sprintf("%.2f/s", stage.Stage.Rate)
+		}
+		row := []string{
+			strconv.Itoa(i + 1), p.sprintf("%v", stage.Start), load, strconv.Itoa(stage.Requests),
+			fmt.Sprintf("%.2f", stage.RequestsPerSecond), fmt.Sprintf("%.2f%%", stage.ErrorRate()*100),
+			p.sprintf("%v", stage.Durations.P50), p.sprintf("%v", stage.Durations.P95), p.sprintf("%v", stage.Durations.P99),
+		}
+		if report.ArrivalRate > 0 {
+			row = append(row, strconv.Itoa(stage.DroppedArrivals))
+		}
+		rows = append(rows, row)
+	}
+	p.table(header, rows)
+}
+
+// printSpike compara o pico de --spike com a base, antes e depois dele, e
+// informa quanto tempo as métricas levaram para voltar ao normal
+func printSpike(p *reportPrinter, spike *stress.SpikeReport) {
+	p.section("Pico")
+	p.field("", "Base (antes do pico)", "erros %.2f%% | P99 %s", spike.BaselineErrorRate*100, p.sprintf("%v", spike.BaselineP99))
+	peakColor := ""
+	if spike.PeakErrorRate > spike.BaselineErrorRate {
+		peakColor = ansiRed
+	}
+	p.field(peakColor, "Pico", "erros %.2f%% | P99 %s", spike.PeakErrorRate*100, p.sprintf("%v", spike.PeakP99))
+	p.field("", "Base (depois do pico)", "erros %.2f%% | P99 %s", spike.AfterErrorRate*100, p.sprintf("%v", spike.AfterP99))
+	if spike.Recovered {
+		p.field(ansiGreen, "Recuperação", "%v após o fim do pico", spike.RecoveryTime)
+	} else {
+		p.field(ansiRed, "Recuperação", "as métricas não voltaram ao normal até o fim do teste")
+	}
+}
+
+// printResponseTime compara o tempo de serviço, medido desde o envio real,
+// com o tempo de resposta corrigido para a omissão coordenada, medido desde
+// o horário planejado pelo ritmo do teste
+func printResponseTime(p *reportPrinter, report *stress.Report) {
+	rt := report.ResponseTime
+	p.section("Tempo de Serviço x Tempo de Resposta")
+	p.line("", "Serviço: do envio real até a resposta (as métricas acima)")
+	p.line("", "Resposta: do horário planejado até a resposta, incluindo a espera das requests atrasadas pelo alvo lento (omissão coordenada)")
+	p.table([]string{"", "P50", "P90", "P95", "P99", "Máx"}, [][]string{
+		{"Serviço", p.sprintf("%v", report.P50), p.sprintf("%v", report.P90), p.sprintf("%v", report.P95), p.sprintf("%v", report.P99), p.sprintf("%v", report.MaxDuration)},
+		{"Resposta", p.sprintf("%v", rt.Durations.P50), p.sprintf("%v", rt.P90), p.sprintf("%v", rt.Durations.P95), p.sprintf("%v", rt.Durations.P99), p.sprintf("%v", rt.Durations.Max)},
+	})
+	if rt.ExpectedInterval > 0 {
+		p.field("", "Amostras Sintetizadas", "%d (intervalo esperado por worker: %v)", rt.SynthesizedSamples, rt.ExpectedInterval)
+		return
+	}
+	color := ""
+	if rt.LateRequests > 0 {
+		color = ansiYellow
+	}
+	p.field(color, "Requests Atrasadas", "%d (atraso médio %v | máx %v)", rt.LateRequests, rt.AvgDelay, rt.MaxDelay)
+}
+
+// printRetries resume as tentativas de --retries e compara a duração da
+// última tentativa, medida nas métricas acima, com o tempo total das
+// requests, incluindo as tentativas anteriores e as esperas
+func printRetries(p *reportPrinter, report *stress.Report) {
+	retries := report.Retries
+	p.section("Retentativas")
+	repeated := "apenas erros de transporte"
+	if len(retries.Status) > 0 {
+		repeated = "erros de transporte e status " + retries.Status.String()
+	}
+	p.field("", "Configuração", "até %d retentativas de %s | backoff de %v a %v", retries.Max, repeated, retries.BackoffBase, retries.BackoffMax)
+	perRequest := 0.0
+	if retries.Requests > 0 {
+		perRequest = float64(retries.Attempts) / float64(retries.Requests)
+	}
+	p.field("", "Tentativas", "%d em %d requests (%.2f por request)", retries.Attempts, retries.Requests, perRequest)
+	color := ""
+	if retries.Exhausted > 0 {
+		color = ansiYellow
+	}
+	p.field(color, "Requests Repetidas", "%d (%d recuperadas | %d esgotaram as retentativas)", retries.Retried, retries.Recovered, retries.Exhausted)
+	p.field("", "Espera Total", "%v", retries.Backoff)
+	p.table([]string{"", "P50", "P90", "P95", "P99", "Máx"}, [][]string{
+		{"Última tentativa", p.sprintf("%v", report.P50), p.sprintf("%v", report.P90), p.sprintf("%v", report.P95), p.sprintf("%v", report.P99), p.sprintf("%v", report.MaxDuration)},
+		{"Total", p.sprintf("%v", retries.Durations.P50), p.sprintf("%v", retries.P90), p.sprintf("%v", retries.Durations.P95), p.sprintf("%v", retries.Durations.P99), p.sprintf("%v", retries.Durations.Max)},
+	})
+}
+
+// printRetryAfter resume as pausas de --respect-retry-after
+func printRetryAfter(p *reportPrinter, retryAfter *stress.RetryAfterStats) {
+	p.section("Retry-After")
+	if retryAfter.Pauses == 0 {
+		p.field("", "Pausas", "nenhuma resposta 429 ou 503 pediu uma pausa (limite de %v por pausa)", retryAfter.Max)
+		return
+	}
+	p.field(ansiYellow, "Pausas", "%d (total %v | média %v | limite de %v por pausa)",
+		retryAfter.Pauses, retryAfter.Wait, retryAfter.Wait/time.Duration(retryAfter.Pauses), retryAfter.Max)
+}
+
+// printSlowest lista as requests mais lentas de --slowest, com o trace ID
+// para localizá-las no backend de traces (ex.: Jaeger)
+func printSlowest(p *reportPrinter, report *stress.Report) {
+	p.section("Requests Mais Lentas")
+	header := []string{"Duração", "Horário", "Worker", "Status", "Trace ID"}
+	targets := len(report.Targets) > 1
+	if targets {
+		header = slices.Insert(header, 3, "Alvo")
+	}
+	rows := make([][]string, 0, len(report.Slowest.Requests))
+	for _, request := range report.Slowest.Requests {
+		status := strconv.Itoa(request.StatusCode)
+		switch {
+		case request.StatusCode == 0:
+			status = request.ErrorCategory
+		case request.ErrorCategory != "":
+			status += " (" + request.ErrorCategory + ")"
+		}
+		trace := request.TraceID
+		if trace != "" && !request.TraceSampled {
+			trace += " (não amostrada)"
+		}
+		row := []string{p.sprintf("%v", request.Duration), request.Timestamp.Format("15:04:05.000"), stress.WorkerName(request.WorkerID), status, trace}
+		if targets {
+			row = slices.Insert(row, 3, request.Target)
+		}
+		rows = append(rows, row)
+	}
+	p.table(header, rows)
+}
+
+// printFindMax informa o maior nível aprovado por --find-max e o histórico
+// das sondas, na ordem em que foram executadas; o restante do relatório é o
+// da sonda aprovada
+func printFindMax(p *reportPrinter, search *stress.FindMaxReport) {
+	p.section("Busca da Carga Máxima")
+	unit := func(level int) string { return findMaxLevel(search.Rate, level) }
+	switch {
+	case !search.Found:
+		p.field(ansiRed, "Maior Carga Aprovada", "nenhuma: a menor carga testada já violou os limites de --fail-if")
+	case search.Limited:
+		p.field(ansiGreen, "Maior Carga Aprovada", "%s (o máximo da busca, sem nenhuma reprovação)", unit(search.Level))
+	default:
+		p.field(ansiGreen, "Maior Carga Aprovada", "%s", unit(search.Level))
+	}
+	rows := make([][]string, len(search.Probes))
+	for i, probe := range search.Probes {
+		result := "aprovada"
+		if !probe.Passed {
+			result = "reprovada: " + strings.Join(probe.Violated, ", ")
+		}
+		rows[i] = []string{
+			strconv.Itoa(i + 1), unit(probe.Level), strconv.Itoa(probe.Requests), fmt.Sprintf("%.2f", probe.RequestsPerSecond),
+			fmt.Sprintf("%.2f%%", probe.ErrorRate*100), p.sprintf("%v", probe.P95), p.sprintf("%v", probe.P99), result,
+		}
+	}
+	p.table([]string{"Sonda", "Carga", "Requests", "RPS", "Erros", "P95", "P99", "Resultado"}, rows)
+}
+
+// findMaxLevel descreve a carga de uma sonda de --find-max
+func findMaxLevel(rate bool, level int) string {
+	if rate {
+		return fmt.Sprintf("%d/s", level)
+	}
+	return fmt.Sprintf("%d workers", level)
+}
+
+// trendLatencyTolerance e trendErrorTolerance são as variações consideradas
+// estáveis em printTrend: 10% no P95 e 1 ponto percentual na taxa de erros
+const (
+	trendLatencyTolerance = 0.10
+	trendErrorTolerance   = 0.01
+)
+
+// printTrend compara os primeiros e os últimos 10% de um teste com --soak,
+// em vermelho quando o P95 ou a taxa de erros sobe, e o consumo do próprio
+// gerador nos mesmos trechos
+func printTrend(p *reportPrinter, trend *stress.TrendReport) {
+	p.section("Tendência (primeiros 10% x últimos 10%)")
+	p.field("", "Trecho Comparado", "%v no início e no fim (%d e %d requests)", trend.Window, trend.FirstRequests, trend.LastRequests)
+	change := trend.P95Change()
+	p.field(trendColor(change, trendLatencyTolerance), "P95", "%s → %s (%+.1f%%, %s)",
+		p.sprintf("%v", trend.FirstP95), p.sprintf("%v", trend.LastP95), change*100, trendDirection(change, trendLatencyTolerance))
+	difference := trend.LastErrorRate - trend.FirstErrorRate
+	p.field(trendColor(difference, trendErrorTolerance), "Taxa de Erros", "%.2f%% → %.2f%% (%+.2f pp, %s)",
+		trend.FirstErrorRate*100, trend.LastErrorRate*100, difference*100, trendDirection(difference, trendErrorTolerance))
+	if trend.FirstRSS > 0 || trend.LastRSS > 0 {
+		p.field("", "Memória do Gerador (RSS)", "%s → %s", formatBytes(float64(trend.FirstRSS)), formatBytes(float64(trend.LastRSS)))
+	}
+	p.field("", "Goroutines do Gerador", "%d → %d", trend.FirstGoroutines, trend.LastGoroutines)
+}
+
+// trendDirection descreve uma variação de printTrend
+func trendDirection(change, tolerance float64) string {
+	switch {
+	case change > tolerance:
+		return "subindo"
+	case change < -tolerance:
+		return "caindo"
+	}
+	return "estável"
+}
+
+// trendColor destaca em vermelho uma métrica que piorou
+func trendColor(change, tolerance float64) string {
+	if change > tolerance {
+		return ansiRed
+	}
+	return ""
+}
+
+// histogramBarWidth é a largura da maior barra do histograma de latências
+const histogramBarWidth = 40
+
+// printHistogram imprime as faixas de latência com a contagem e uma barra
+// proporcional, como no hey; faixas com requests têm ao menos um #
+func printHistogram(p *reportPrinter, buckets []stress.LatencyBucket) {
+	p.section("Histograma de Latências")
+	var highest, total int64
+	for _, bucket := range buckets {
+		highest = max(highest, bucket.Count)
+		total += bucket.Count
+	}
+	rows := make([][]string, len(buckets))
+	for i, bucket := range buckets {
+		bar := int(bucket.Count * histogramBarWidth / max(highest, 1))
+		if bucket.Count > 0 {
+			bar = max(bar, 1)
+		}
+		rows[i] = []string{
+			compactDuration(bucket.From) + " – " + compactDuration(bucket.To),
+			strconv.FormatInt(bucket.Count, 10),
+			fmt.Sprintf("%.2f%%", float64(bucket.Count)/float64(max(total, 1))*100),
+			strings.Repeat("#", bar),
+		}
+	}
+	p.table([]string{"Faixa", "Requests", "Proporção", "Distribuição"}, rows)
+}
+
+// formatStatusCodes resume a distribuição de status em ordem crescente (ex.:
+// "200:95 503:5")
+func formatStatusCodes(codes map[int]int) string {
+	statuses := make([]int, 0, len(codes))
+	for status := range codes {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	parts := make([]string, len(statuses))
+	for i, status := range statuses {
+		parts[i] = fmt.Sprintf("%d:%d", status, codes[status])
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
+
+// printPhases imprime o detalhamento das fases coletado com -trace
+func printPhases(p *reportPrinter, phases *stress.PhaseStats) {
+	p.section("Fases da Request")
+	p.field("", "Conexões novas", "%d | reaproveitadas: %d", phases.NewConnections, phases.ReusedConnections)
+	p.line("", "(DNS, conexão e TLS consideram apenas as conexões novas)")
+	for _, phase := range []struct {
+		name  string
+		stats stress.DurationStats
+	}{
+		{"DNS", phases.DNS},
+		{"Conexão TCP", phases.Connect},
+		{"Handshake TLS", phases.TLS},
+		{"Servidor", phases.Server},
+	} {
+		p.field("", phase.name, "média %v | P95 %v | máx %v", phase.stats.Avg, phase.stats.P95, phase.stats.Max)
+	}
+}
+
+// formatBytes formata uma quantidade de bytes com prefixos decimais (kB, MB, GB)
+func formatBytes(n float64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB"}
+	unit := 0
+	for n >= 1000 && unit < len(units)-1 {
+		n /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%.0f %s", n, units[unit])
+	}
+	return fmt.Sprintf("%.2f %s", n, units[unit])
+}
+
+// perRequest retorna a média de bytes por request, zero sem requests
+func perRequest(total int64, requests int) float64 {
+	if requests == 0 {
+		return 0
+	}
+	return float64(total) / float64(requests)
+}
+
+// reportPercentiles são os percentis exibidos na tabela do relatório
+var reportPercentiles = []float64{50, 75, 90, 95, 99, 99.9}
+
+// percentileName formata o percentil como no relatório (ex.: "P99.9")
+func percentileName(p float64) string {
+	return "P" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// interruptReason descreve em português a causa da interrupção do teste
+func interruptReason(cause error) string {
+	switch {
+	case errors.Is(cause, context.DeadlineExceeded):
+		return "prazo esgotado"
+	case cause == nil || errors.Is(cause, context.Canceled):
+		return "cancelado"
+	default:
+		return cause.Error()
+	}
+}
+
+// sortedByCount retorna as chaves do mapa em ordem decrescente de contagem
+func sortedByCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// jsonDuration representa uma duração tanto em nanossegundos quanto em texto
+type jsonDuration struct {
+	Nanoseconds int64  `json:"ns"`
+	Human       string `json:"human"`
+}
+
+func newJSONDuration(d time.Duration) jsonDuration {
+	return jsonDuration{Nanoseconds: int64(d), Human: d.String()}
+}
+
+// jsonDurationStats é a representação de um stress.DurationStats
+type jsonDurationStats struct {
+	Min jsonDuration `json:"min"`
+	Max jsonDuration `json:"max"`
+	Avg jsonDuration `json:"avg"`
+	P50 jsonDuration `json:"p50"`
+	P95 jsonDuration `json:"p95"`
+	P99 jsonDuration `json:"p99"`
+}
+
+func newJSONDurationStats(stats stress.DurationStats) jsonDurationStats {
+	return jsonDurationStats{
+		Min: newJSONDuration(stats.Min),
+		Max: newJSONDuration(stats.Max),
+		Avg: newJSONDuration(stats.Avg),
+		P50: newJSONDuration(stats.P50),
+		P95: newJSONDuration(stats.P95),
+		P99: newJSONDuration(stats.P99),
+	}
+}
+
+// jsonTargetReport é a representação de um stress.TargetReport
+type jsonTargetReport struct {
+	Requests           int               `json:"requests"`
+	SuccessfulRequests int               `json:"successful_requests"`
+	FailedRequests     int               `json:"failed_requests"`
+	SuccessRate        float64           `json:"success_rate"`
+	Weight             int               `json:"weight"`
+	StatusCodes        map[int]int       `json:"status_codes"`
+	Durations          jsonDurationStats `json:"durations"`
+}
+
+func newJSONTargetReport(target *stress.TargetReport) jsonTargetReport {
+	return jsonTargetReport{
+		Requests:           target.Requests,
+		SuccessfulRequests: target.SuccessfulRequests,
+		FailedRequests:     target.FailedRequests,
+		SuccessRate:        target.SuccessRate(),
+		Weight:             target.Weight,
+		StatusCodes:        target.StatusCodes,
+		Durations:          newJSONDurationStats(target.Durations),
+	}
+}
+
+// jsonStageReport é a representação de um stress.StageReport; rate ou
+// concurrency define a carga da etapa, conforme o modelo
+type jsonStageReport struct {
+	Duration           jsonDuration      `json:"duration"`
+	Rate               float64           `json:"rate,omitempty"`
+	Concurrency        int               `json:"concurrency,omitempty"`
+	Start              jsonDuration      `json:"start"`
+	Requests           int               `json:"requests"`
+	SuccessfulRequests int               `json:"successful_requests"`
+	FailedRequests     int               `json:"failed_requests"`
+	ErrorRate          float64           `json:"error_rate"`
+	Arrivals           int               `json:"arrivals"`
+	DroppedArrivals    int               `json:"dropped_arrivals"`
+	RequestsPerSecond  float64           `json:"requests_per_second"`
+	Durations          jsonDurationStats `json:"durations"`
+}
+
+func newJSONStages(stages []*stress.StageReport) []jsonStageReport {
+	if len(stages) == 0 {
+		return nil
+	}
+	reports := make([]jsonStageReport, len(stages))
+	for i, stage := range stages {
+		reports[i] = jsonStageReport{
+			Duration:           newJSONDuration(stage.Stage.Duration),
+			Rate:               stage.Stage.Rate,
+			Concurrency:        stage.Stage.Concurrency,
+			Start:              newJSONDuration(stage.Start),
+			Requests:           stage.Requests,
+			SuccessfulRequests: stage.SuccessfulRequests,
+			FailedRequests:     stage.FailedRequests,
+			ErrorRate:          stage.ErrorRate(),
+			Arrivals:           stage.Arrivals,
+			DroppedArrivals:    stage.DroppedArrivals,
+			RequestsPerSecond:  stage.RequestsPerSecond,
+			Durations:          newJSONDurationStats(stage.Durations),
+		}
+	}
+	return reports
+}
+
+// jsonSpikeReport é a representação de um stress.SpikeReport
+type jsonSpikeReport struct {
+	BaselineErrorRate float64      `json:"baseline_error_rate"`
+	BaselineP99       jsonDuration `json:"baseline_p99"`
+	PeakErrorRate     float64      `json:"peak_error_rate"`
+	PeakP99           jsonDuration `json:"peak_p99"`
+	AfterErrorRate    float64      `json:"after_error_rate"`
+	AfterP99          jsonDuration `json:"after_p99"`
+	RecoveryTime      jsonDuration `json:"recovery_time"`
+	Recovered         bool         `json:"recovered"`
+}
+
+func newJSONSpikeReport(spike *stress.SpikeReport) *jsonSpikeReport {
+	if spike == nil {
+		return nil
+	}
+	return &jsonSpikeReport{
+		BaselineErrorRate: spike.BaselineErrorRate,
+		BaselineP99:       newJSONDuration(spike.BaselineP99),
+		PeakErrorRate:     spike.PeakErrorRate,
+		PeakP99:           newJSONDuration(spike.PeakP99),
+		AfterErrorRate:    spike.AfterErrorRate,
+		AfterP99:          newJSONDuration(spike.AfterP99),
+		RecoveryTime:      newJSONDuration(spike.RecoveryTime),
+		Recovered:         spike.Recovered,
+	}
+}
+
+// jsonTrendReport é a representação de um stress.TrendReport
+type jsonTrendReport struct {
+	Window          jsonDuration `json:"window"`
+	FirstRequests   int          `json:"first_requests"`
+	LastRequests    int          `json:"last_requests"`
+	FirstP95        jsonDuration `json:"first_p95"`
+	LastP95         jsonDuration `json:"last_p95"`
+	FirstErrorRate  float64      `json:"first_error_rate"`
+	LastErrorRate   float64      `json:"last_error_rate"`
+	FirstRSS        uint64       `json:"first_rss_bytes"`
+	LastRSS         uint64       `json:"last_rss_bytes"`
+	FirstGoroutines int          `json:"first_goroutines"`
+	LastGoroutines  int          `json:"last_goroutines"`
+}
+
+func newJSONTrendReport(trend *stress.TrendReport) *jsonTrendReport {
+	if trend == nil {
+		return nil
+	}
+	return &jsonTrendReport{
+		Window:          newJSONDuration(trend.Window),
+		FirstRequests:   trend.FirstRequests,
+		LastRequests:    trend.LastRequests,
+		FirstP95:        newJSONDuration(trend.FirstP95),
+		LastP95:         newJSONDuration(trend.LastP95),
+		FirstErrorRate:  trend.FirstErrorRate,
+		LastErrorRate:   trend.LastErrorRate,
+		FirstRSS:        trend.FirstRSS,
+		LastRSS:         trend.LastRSS,
+		FirstGoroutines: trend.FirstGoroutines,
+		LastGoroutines:  trend.LastGoroutines,
+	}
+}
+
+// jsonResponseTimeStats é a representação de um stress.ResponseTimeStats
+type jsonResponseTimeStats struct {
+	Durations          jsonDurationStats `json:"durations"`
+	P90                jsonDuration      `json:"p90"`
+	Requests           int               `json:"requests"`
+	SynthesizedSamples int64             `json:"synthesized_samples"`
+	ExpectedInterval   jsonDuration      `json:"expected_interval"`
+	LateRequests       int               `json:"late_requests"`
+	AvgDelay           jsonDuration      `json:"avg_delay"`
+	MaxDelay           jsonDuration      `json:"max_delay"`
+}
+
+func newJSONResponseTimeStats(rt *stress.ResponseTimeStats) *jsonResponseTimeStats {
+	if rt == nil {
+		return nil
+	}
+	return &jsonResponseTimeStats{
+		Durations:          newJSONDurationStats(rt.Durations),
+		P90:                newJSONDuration(rt.P90),
+		Requests:           rt.Requests,
+		SynthesizedSamples: rt.SynthesizedSamples,
+		ExpectedInterval:   newJSONDuration(rt.ExpectedInterval),
+		LateRequests:       rt.LateRequests,
+		AvgDelay:           newJSONDuration(rt.AvgDelay),
+		MaxDelay:           newJSONDuration(rt.MaxDelay),
+	}
+}
+
+// jsonRetryStats é a representação de um stress.RetryStats
+type jsonRetryStats struct {
+	Max         int               `json:"max"`
+	BackoffBase jsonDuration      `json:"backoff_base"`
+	BackoffMax  jsonDuration      `json:"backoff_max"`
+	Status      string            `json:"status,omitempty"`
+	Requests    int               `json:"requests"`
+	Attempts    int               `json:"attempts"`
+	Retried     int               `json:"retried"`
+	Recovered   int               `json:"recovered"`
+	Exhausted   int               `json:"exhausted"`
+	Backoff     jsonDuration      `json:"backoff"`
+	Durations   jsonDurationStats `json:"total_durations"`
+	P90         jsonDuration      `json:"total_p90"`
+}
+
+func newJSONRetryStats(retries *stress.RetryStats) *jsonRetryStats {
+	if retries == nil {
+		return nil
+	}
+	return &jsonRetryStats{
+		Max:         retries.Max,
+		BackoffBase: newJSONDuration(retries.BackoffBase),
+		BackoffMax:  newJSONDuration(retries.BackoffMax),
+		Status:      retries.Status.String(),
+		Requests:    retries.Requests,
+		Attempts:    retries.Attempts,
+		Retried:     retries.Retried,
+		Recovered:   retries.Recovered,
+		Exhausted:   retries.Exhausted,
+		Backoff:     newJSONDuration(retries.Backoff),
+		Durations:   newJSONDurationStats(retries.Durations),
+		P90:         newJSONDuration(retries.P90),
+	}
+}
+
+// jsonRetryAfterStats é a representação de um stress.RetryAfterStats
+type jsonRetryAfterStats struct {
+	Max    jsonDuration `json:"max"`
+	Pauses int          `json:"pauses"`
+	Wait   jsonDuration `json:"wait"`
+}
+
+func newJSONRetryAfterStats(retryAfter *stress.RetryAfterStats) *jsonRetryAfterStats {
+	if retryAfter == nil {
+		return nil
+	}
+	return &jsonRetryAfterStats{
+		Max:    newJSONDuration(retryAfter.Max),
+		Pauses: retryAfter.Pauses,
+		Wait:   newJSONDuration(retryAfter.Wait),
+	}
+}
+
+// jsonSlowestStats é a representação de um stress.SlowestStats
+type jsonSlowestStats struct {
+	Max      int               `json:"max"`
+	Requests []jsonSlowRequest `json:"requests"`
+}
+
+// jsonSlowRequest é a representação de um stress.SlowRequest
+type jsonSlowRequest struct {
+	Timestamp     time.Time    `json:"timestamp"`
+	Duration      jsonDuration `json:"duration"`
+	WorkerID      int          `json:"worker_id"`
+	Target        string       `json:"target,omitempty"`
+	StatusCode    int          `json:"status_code,omitempty"`
+	ErrorCategory string       `json:"error_category,omitempty"`
+	TraceID       string       `json:"trace_id,omitempty"`
+	TraceSampled  bool         `json:"trace_sampled"`
+}
+
+func newJSONSlowestStats(slowest *stress.SlowestStats) *jsonSlowestStats {
+	if slowest == nil {
+		return nil
+	}
+	requests := make([]jsonSlowRequest, len(slowest.Requests))
+	for i, request := range slowest.Requests {
+		requests[i] = jsonSlowRequest{
+			Timestamp:     request.Timestamp,
+			Duration:      newJSONDuration(request.Duration),
+			WorkerID:      request.WorkerID,
+			Target:        request.Target,
+			StatusCode:    request.StatusCode,
+			ErrorCategory: request.ErrorCategory,
+			TraceID:       request.TraceID,
+			TraceSampled:  request.TraceSampled,
+		}
+	}
+	return &jsonSlowestStats{Max: slowest.Max, Requests: requests}
+}
+
+// jsonFindMaxReport é a representação de um stress.FindMaxReport; target é
+// concurrency ou rate
+type jsonFindMaxReport struct {
+	Target  string             `json:"target"`
+	Level   int                `json:"level"`
+	Found   bool               `json:"found"`
+	Limited bool               `json:"limited"`
+	Probes  []jsonFindMaxProbe `json:"probes"`
+}
+
+// jsonFindMaxProbe é a representação de um stress.FindMaxProbe
+type jsonFindMaxProbe struct {
+	Level             int          `json:"level"`
+	Requests          int          `json:"requests"`
+	RequestsPerSecond float64      `json:"requests_per_second"`
+	ErrorRate         float64      `json:"error_rate"`
+	P95               jsonDuration `json:"p95"`
+	P99               jsonDuration `json:"p99"`
+	Passed            bool         `json:"passed"`
+	Violated          []string     `json:"violated,omitempty"`
+}
+
+func newJSONFindMaxReport(search *stress.FindMaxReport) *jsonFindMaxReport {
+	if search == nil {
+		return nil
+	}
+	report := &jsonFindMaxReport{Target: "concurrency", Level: search.Level, Found: search.Found, Limited: search.Limited}
+	if search.Rate {
+		report.Target = "rate"
+	}
+	report.Probes = make([]jsonFindMaxProbe, len(search.Probes))
+	for i, probe := range search.Probes {
+		report.Probes[i] = jsonFindMaxProbe{
+			Level:             probe.Level,
+			Requests:          probe.Requests,
+			RequestsPerSecond: probe.RequestsPerSecond,
+			ErrorRate:         probe.ErrorRate,
+			P95:               newJSONDuration(probe.P95),
+			P99:               newJSONDuration(probe.P99),
+			Passed:            probe.Passed,
+			Violated:          probe.Violated,
+		}
+	}
+	return report
+}
+
+// jsonWorkerReport é a representação de um stress.WorkerReport
+type jsonWorkerReport struct {
+	Worker             string         `json:"worker"`
+	Requests           int            `json:"requests"`
+	SuccessfulRequests int            `json:"successful_requests"`
+	FailedRequests     int            `json:"failed_requests"`
+	TransportErrors    int            `json:"transport_errors"`
+	ErrorCategories    map[string]int `json:"error_categories"`
+}
+
+func newJSONWorkerReports(workers []stress.WorkerReport) []jsonWorkerReport {
+	if workers == nil {
+		return nil
+	}
+	reports := make([]jsonWorkerReport, len(workers))
+	for id, worker := range workers {
+		reports[id] = jsonWorkerReport{
+			Worker:             stress.WorkerName(id),
+			Requests:           worker.Requests,
+			SuccessfulRequests: worker.SuccessfulRequests,
+			FailedRequests:     worker.FailedRequests,
+			TransportErrors:    worker.TransportErrors,
+			ErrorCategories:    worker.ErrorCategories,
+		}
+	}
+	return reports
+}
+
+// jsonPhaseStats é a representação de um stress.PhaseStats
+type jsonPhaseStats struct {
+	NewConnections    int               `json:"new_connections"`
+	ReusedConnections int               `json:"reused_connections"`
+	DNS               jsonDurationStats `json:"dns"`
+	Connect           jsonDurationStats `json:"connect"`
+	TLS               jsonDurationStats `json:"tls"`
+	Server            jsonDurationStats `json:"server"`
+}
+
+// jsonApdexScore é a representação de um stress.ApdexScore
+type jsonApdexScore struct {
+	T          jsonDuration `json:"t"`
+	Satisfied  int          `json:"satisfied"`
+	Tolerating int          `json:"tolerating"`
+	Frustrated int          `json:"frustrated"`
+	Score      float64      `json:"score"`
+}
+
+func newJSONApdexScore(apdex *stress.ApdexScore) *jsonApdexScore {
+	if apdex == nil {
+		return nil
+	}
+	return &jsonApdexScore{
+		T:          newJSONDuration(apdex.T),
+		Satisfied:  apdex.Satisfied,
+		Tolerating: apdex.Tolerating,
+		Frustrated: apdex.Frustrated,
+		Score:      apdex.Score,
+	}
+}
+
+// jsonThresholdResult é a representação de um stress.ThresholdResult
+type jsonThresholdResult struct {
+	Rule   string `json:"rule"`
+	Actual string `json:"actual"`
+	Passed bool   `json:"passed"`
+}
+
+// jsonCompressionStats é a representação de um stress.CompressionStats
+type jsonCompressionStats struct {
+	CompressedResponses int               `json:"compressed_responses"`
+	WireBytes           int64             `json:"wire_bytes"`
+	DecodedBytes        int64             `json:"decoded_bytes"`
+	Ratio               float64           `json:"ratio"`
+	Decompression       jsonDurationStats `json:"decompression"`
+}
+
+func newJSONCompressionStats(compression *stress.CompressionStats) *jsonCompressionStats {
+	if compression == nil {
+		return nil
+	}
+	return &jsonCompressionStats{
+		CompressedResponses: compression.CompressedResponses,
+		WireBytes:           compression.WireBytes,
+		DecodedBytes:        compression.DecodedBytes,
+		Ratio:               compression.Ratio,
+		Decompression:       newJSONDurationStats(compression.Decompression),
+	}
+}
+
+// jsonWebSocketStats é a representação de um stress.WebSocketStats
+type jsonWebSocketStats struct {
+	ConnectionsEstablished int               `json:"connections_established"`
+	ConnectionFailures     int               `json:"connection_failures"`
+	Disconnects            int               `json:"disconnects"`
+	DisconnectReasons      map[string]int    `json:"disconnect_reasons"`
+	MessagesSent           int               `json:"messages_sent"`
+	MessagesReceived       int               `json:"messages_received"`
+	Handshake              jsonDurationStats `json:"handshake"`
+	RTT                    jsonDurationStats `json:"rtt"`
+}
+
+func newJSONWebSocketStats(ws *stress.WebSocketStats) *jsonWebSocketStats {
+	if ws == nil {
+		return nil
+	}
+	return &jsonWebSocketStats{
+		ConnectionsEstablished: ws.ConnectionsEstablished,
+		ConnectionFailures:     ws.ConnectionFailures,
+		Disconnects:            ws.Disconnects,
+		DisconnectReasons:      ws.DisconnectReasons,
+		MessagesSent:           ws.MessagesSent,
+		MessagesReceived:       ws.MessagesReceived,
+		Handshake:              newJSONDurationStats(ws.Handshake),
+		RTT:                    newJSONDurationStats(ws.RTT),
+	}
+}
+
+// jsonSSEStats é a representação de um stress.SSEStats
+type jsonSSEStats struct {
+	Connections            int               `json:"connections"`
+	ConnectionFailures     int               `json:"connection_failures"`
+	Disconnects            int               `json:"disconnects"`
+	DisconnectReasons      map[string]int    `json:"disconnect_reasons"`
+	Events                 int               `json:"events"`
+	EventsPerSecond        float64           `json:"events_per_second"`
+	MalformedLines         int               `json:"malformed_lines"`
+	MinEventsPerConnection int               `json:"min_events_per_connection"`
+	MaxEventsPerConnection int               `json:"max_events_per_connection"`
+	AvgEventsPerConnection float64           `json:"avg_events_per_connection"`
+	FirstEvent             jsonDurationStats `json:"first_event"`
+	InterEvent             jsonDurationStats `json:"inter_event"`
+}
+
+func newJSONSSEStats(sse *stress.SSEStats) *jsonSSEStats {
+	if sse == nil {
+		return nil
+	}
+	return &jsonSSEStats{
+		Connections:            sse.Connections,
+		ConnectionFailures:     sse.ConnectionFailures,
+		Disconnects:            sse.Disconnects,
+		DisconnectReasons:      sse.DisconnectReasons,
+		Events:                 sse.Events,
+		EventsPerSecond:        sse.EventsPerSecond,
+		MalformedLines:         sse.MalformedLines,
+		MinEventsPerConnection: sse.MinEventsPerConnection,
+		MaxEventsPerConnection: sse.MaxEventsPerConnection,
+		AvgEventsPerConnection: sse.AvgEventsPerConnection,
+		FirstEvent:             newJSONDurationStats(sse.FirstEvent),
+		InterEvent:             newJSONDurationStats(sse.InterEvent),
+	}
+}
+
+// jsonScenarioStats é a representação de ScenarioStats no relatório JSON
+type jsonScenarioStats struct {
+	Iterations          int               `json:"iterations"`
+	CompletedIterations int               `json:"completed_iterations"`
+	AbortedIterations   int               `json:"aborted_iterations"`
+	AbortedBySteps      map[string]int    `json:"aborted_by_steps"`
+	Durations           jsonDurationStats `json:"durations"`
+}
+
+func newJSONScenarioStats(scenario *stress.ScenarioStats) *jsonScenarioStats {
+	if scenario == nil {
+		return nil
+	}
+	return &jsonScenarioStats{
+		Iterations:          scenario.Iterations,
+		CompletedIterations: scenario.CompletedIterations,
+		AbortedIterations:   scenario.AbortedIterations,
+		AbortedBySteps:      scenario.AbortedBySteps,
+		Durations:           newJSONDurationStats(scenario.Durations),
+	}
+}
+
+// jsonTimelinePoint é um intervalo da série no tempo; start é contado a
+// partir do início do teste, stage é a etapa de --stages em vigor nele e
+// resources é o consumo do gerador amostrado com --soak
+type jsonTimelinePoint struct {
+	Start     jsonDuration        `json:"start"`
+	Stage     int                 `json:"stage,omitempty"`
+	Requests  int                 `json:"requests"`
+	Errors    int                 `json:"errors"`
+	Avg       jsonDuration        `json:"avg"`
+	P50       jsonDuration        `json:"p50"`
+	P95       jsonDuration        `json:"p95"`
+	P99       jsonDuration        `json:"p99"`
+	Max       jsonDuration        `json:"max"`
+	Resources *jsonResourceSample `json:"resources,omitempty"`
+}
+
+// jsonResourceSample é a representação de um stress.ResourceSample
+type jsonResourceSample struct {
+	RSS        uint64       `json:"rss_bytes"`
+	HeapAlloc  uint64       `json:"heap_alloc_bytes"`
+	Goroutines int          `json:"goroutines"`
+	GCCycles   uint32       `json:"gc_cycles"`
+	GCPause    jsonDuration `json:"gc_pause"`
+}
+
+func newJSONResourceSample(sample *stress.ResourceSample) *jsonResourceSample {
+	if sample == nil {
+		return nil
+	}
+	return &jsonResourceSample{
+		RSS:        sample.RSS,
+		HeapAlloc:  sample.HeapAlloc,
+		Goroutines: sample.Goroutines,
+		GCCycles:   sample.GCCycles,
+		GCPause:    newJSONDuration(sample.GCPause),
+	}
+}
+
+func newJSONTimeline(points []stress.TimelinePoint) []jsonTimelinePoint {
+	timeline := make([]jsonTimelinePoint, len(points))
+	for i, point := range points {
+		timeline[i] = jsonTimelinePoint{
+			Start:     newJSONDuration(point.Start),
+			Stage:     point.Stage,
+			Requests:  point.Requests,
+			Errors:    point.Errors,
+			Avg:       newJSONDuration(point.Avg),
+			P50:       newJSONDuration(point.P50),
+			P95:       newJSONDuration(point.P95),
+			P99:       newJSONDuration(point.P99),
+			Max:       newJSONDuration(point.Max),
+			Resources: newJSONResourceSample(point.Resources),
+		}
+	}
+	return timeline
+}
+
+func newJSONPhaseStats(phases *stress.PhaseStats) *jsonPhaseStats {
+	if phases == nil {
+		return nil
+	}
+	return &jsonPhaseStats{
+		NewConnections:    phases.NewConnections,
+		ReusedConnections: phases.ReusedConnections,
+		DNS:               newJSONDurationStats(phases.DNS),
+		Connect:           newJSONDurationStats(phases.Connect),
+		TLS:               newJSONDurationStats(phases.TLS),
+		Server:            newJSONDurationStats(phases.Server),
+	}
+}
+
+// jsonReport é a representação do Report emitida por -output=json
+type jsonReport struct {
+	Method                      string                `json:"method"`
+	TotalRequests               int                   `json:"total_requests"`
+	SuccessfulRequests          int                   `json:"successful_requests"`
+	FailedRequests              int                   `json:"failed_requests"`
+	TransportErrors             int                   `json:"transport_errors"`
+	UnexpectedStatus            int                   `json:"unexpected_status"`
+	ApplicationErrors           int                   `json:"application_errors"`
+	ExpectStatus                string                `json:"expect_status,omitempty"`
+	ErrorCategories             map[string]int        `json:"error_categories"`
+	Apdex                       *jsonApdexScore       `json:"apdex,omitempty"`
+	Thresholds                  []jsonThresholdResult `json:"thresholds,omitempty"`
+	AssertionFailures           map[string]int        `json:"assertion_failures"`
+	GraphQLErrors               map[string]int        `json:"graphql_errors"`
+	RedirectedRequests          int                   `json:"redirected_requests"`
+	CanceledRequests            int                   `json:"canceled_requests"`
+	WarmupRequests              int                   `json:"warmup_requests"`
+	DataExhausted               bool                  `json:"data_exhausted"`
+	Aborted                     bool                  `json:"aborted"`
+	AbortReason                 string                `json:"abort_reason,omitempty"`
+	Interrupted                 bool                  `json:"interrupted"`
+	InterruptCause              string                `json:"interrupt_cause,omitempty"`
+	TotalTime                   jsonDuration          `json:"total_time"`
+	TargetRPS                   float64               `json:"target_rps"`
+	ArrivalRate                 float64               `json:"arrival_rate,omitempty"`
+	MaxInFlight                 int                   `json:"max_in_flight,omitempty"`
+	ArrivalDistribution         string                `json:"arrival_distribution,omitempty"`
+	Arrivals                    int                   `json:"arrivals"`
+	DroppedArrivals             int                   `json:"dropped_arrivals"`
+	AchievedArrivalRate         float64               `json:"achieved_arrival_rate"`
+	PeakInFlight                int                   `json:"peak_in_flight"`
+	RequestsPerSecond           float64               `json:"requests_per_second"`
+	SuccessfulRequestsPerSecond float64               `json:"successful_requests_per_second"`
+	PlannedDuration             jsonDuration          `json:"planned_duration"`
+	BytesSent                   int64                 `json:"bytes_sent"`
+	BytesReceived               int64                 `json:"bytes_received"`
+	SentBytesPerSecond          float64               `json:"sent_bytes_per_second"`
+	ReceivedBytesPerSecond      float64               `json:"received_bytes_per_second"`
+	MinResponseSize             int64                 `json:"min_response_size"`
+	MaxResponseSize             int64                 `json:"max_response_size"`
+	AvgResponseSize             float64               `json:"avg_response_size"`
+	TruncatedResponses          int                   `json:"truncated_responses"`
+	BodyLimitedResponses        int                   `json:"body_limited_responses"`
+	// BodySkipped indica que bytes_received e os tamanhos das respostas
+	// não foram medidos (--skip-body)
+	BodySkipped            bool                        `json:"body_skipped"`
+	NewConnections         int                         `json:"new_connections"`
+	ReusedConnections      int                         `json:"reused_connections"`
+	Compression            *jsonCompressionStats       `json:"compression,omitempty"`
+	WebSocket              *jsonWebSocketStats         `json:"websocket,omitempty"`
+	SSE                    *jsonSSEStats               `json:"sse,omitempty"`
+	RampUp                 jsonDuration                `json:"ramp_up"`
+	FullConcurrencyAt      jsonDuration                `json:"full_concurrency_at"`
+	ThinkTime              jsonDuration                `json:"think_time"`
+	ThinkTimeJitter        jsonDuration                `json:"think_time_jitter"`
+	Settings               map[string]string           `json:"settings,omitempty"`
+	Targets                map[string]jsonTargetReport `json:"targets"`
+	Scenario               *jsonScenarioStats          `json:"scenario,omitempty"`
+	Workers                []jsonWorkerReport          `json:"workers,omitempty"`
+	Seed                   uint64                      `json:"seed"`
+	StatusCodes            map[int]int                 `json:"status_codes"`
+	GRPCMethod             string                      `json:"grpc_method,omitempty"`
+	GRPCCodes              map[string]int              `json:"grpc_codes,omitempty"`
+	Protocols              map[string]int              `json:"protocols"`
+	ExpectedProtocol       string                      `json:"expected_protocol,omitempty"`
+	ProtocolMismatches     int                         `json:"protocol_mismatches"`
+	MinDuration            jsonDuration                `json:"min_duration"`
+	MaxDuration            jsonDuration                `json:"max_duration"`
+	AvgDuration            jsonDuration                `json:"avg_duration"`
+	StdDevDuration         jsonDuration                `json:"std_dev_duration"`
+	CoefficientOfVariation float64                     `json:"coefficient_of_variation"`
+	TTFB                   jsonDurationStats           `json:"ttfb"`
+	Phases                 *jsonPhaseStats             `json:"phases,omitempty"`
+	ResponseTime           *jsonResponseTimeStats      `json:"response_time,omitempty"`
+	Retries                *jsonRetryStats             `json:"retries,omitempty"`
+	RetryAfter             *jsonRetryAfterStats        `json:"retry_after,omitempty"`
+	Slowest                *jsonSlowestStats           `json:"slowest,omitempty"`
+	P50                    jsonDuration                `json:"p50"`
+	P90                    jsonDuration                `json:"p90"`
+	P95                    jsonDuration                `json:"p95"`
+	P99                    jsonDuration                `json:"p99"`
+	Percentiles            map[string]jsonDuration     `json:"percentiles"`
+	ClampedDurations       int64                       `json:"clamped_durations"`
+	TimelineInterval       jsonDuration                `json:"timeline_interval"`
+	Timeline               []jsonTimelinePoint         `json:"timeline"`
+	Stages                 []jsonStageReport           `json:"stages,omitempty"`
+	Spike                  *jsonSpikeReport            `json:"spike,omitempty"`
+	Trend                  *jsonTrendReport            `json:"trend,omitempty"`
+	FindMax                *jsonFindMaxReport          `json:"find_max,omitempty"`
+	// Merged e MergeConflicts são preenchidos nos relatórios do subcomando
+	// merge
+	Merged         int      `json:"merged,omitempty"`
+	MergeConflicts []string `json:"merge_conflicts,omitempty"`
+}
+
+func newJSONReport(report *stress.Report) jsonReport {
+	var interruptCause string
+	if report.Interrupted {
+		interruptCause = interruptReason(report.InterruptCause)
+	}
+	targets := make(map[string]jsonTargetReport, len(report.Targets))
+	for label, target := range report.Targets {
+		targets[label] = newJSONTargetReport(target)
+	}
+	var thresholds []jsonThresholdResult
+	for _, result := range report.Thresholds {
+		thresholds = append(thresholds, jsonThresholdResult{
+			Rule:   result.Threshold.String(),
+			Actual: result.Threshold.FormatValue(result.Actual),
+			Passed: result.Passed,
+		})
+	}
+	percentiles := make(map[string]jsonDuration, len(reportPercentiles))
+	for _, p := range reportPercentiles {
+		percentiles[strings.ToLower(percentileName(p))] = newJSONDuration(report.ValueAtQuantile(p))
+	}
+	return jsonReport{
+		Method:                      report.Method,
+		TotalRequests:               report.TotalRequests,
+		SuccessfulRequests:          report.SuccessfulRequests,
+		FailedRequests:              report.FailedRequests,
+		TransportErrors:             report.TransportErrors,
+		UnexpectedStatus:            report.UnexpectedStatus,
+		ApplicationErrors:           report.ApplicationErrors,
+		ExpectStatus:                report.ExpectStatus.String(),
+		ErrorCategories:             report.ErrorCategories,
+		Apdex:                       newJSONApdexScore(report.Apdex),
+		Thresholds:                  thresholds,
+		AssertionFailures:           report.AssertionFailures,
+		GraphQLErrors:               report.GraphQLErrors,
+		RedirectedRequests:          report.RedirectedRequests,
+		CanceledRequests:            report.CanceledRequests,
+		WarmupRequests:              report.WarmupRequests,
+		DataExhausted:               report.DataExhausted,
+		Aborted:                     report.Aborted,
+		AbortReason:                 report.AbortReason,
+		Interrupted:                 report.Interrupted,
+		InterruptCause:              interruptCause,
+		TotalTime:                   newJSONDuration(report.TotalTime),
+		TargetRPS:                   report.TargetRPS,
+		ArrivalRate:                 report.ArrivalRate,
+		MaxInFlight:                 report.MaxInFlight,
+		ArrivalDistribution:         string(report.ArrivalDistribution),
+		Arrivals:                    report.Arrivals,
+		DroppedArrivals:             report.DroppedArrivals,
+		AchievedArrivalRate:         report.AchievedArrivalRate,
+		PeakInFlight:                report.PeakInFlight,
+		RequestsPerSecond:           report.RequestsPerSecond,
+		SuccessfulRequestsPerSecond: report.SuccessfulRequestsPerSecond,
+		PlannedDuration:             newJSONDuration(report.PlannedDuration),
+		BytesSent:                   report.BytesSent,
+		BytesReceived:               report.BytesReceived,
+		SentBytesPerSecond:          report.SentBytesPerSecond(),
+		ReceivedBytesPerSecond:      report.ReceivedBytesPerSecond(),
+		MinResponseSize:             report.MinResponseSize,
+		MaxResponseSize:             report.MaxResponseSize,
+		AvgResponseSize:             report.AvgResponseSize,
+		TruncatedResponses:          report.TruncatedResponses,
+		BodyLimitedResponses:        report.BodyLimitedResponses,
+		BodySkipped:                 report.BodySkipped,
+		NewConnections:              report.NewConnections,
+		ReusedConnections:           report.ReusedConnections,
+		Compression:                 newJSONCompressionStats(report.Compression),
+		WebSocket:                   newJSONWebSocketStats(report.WebSocket),
+		SSE:                         newJSONSSEStats(report.SSE),
+		RampUp:                      newJSONDuration(report.RampUp),
+		FullConcurrencyAt:           newJSONDuration(report.FullConcurrencyAt),
+		ThinkTime:                   newJSONDuration(report.ThinkTime),
+		ThinkTimeJitter:             newJSONDuration(report.ThinkTimeJitter),
+		Settings:                    report.Settings,
+		Targets:                     targets,
+		Scenario:                    newJSONScenarioStats(report.Scenario),
+		Workers:                     newJSONWorkerReports(report.Workers),
+		Seed:                        report.Seed,
+		StatusCodes:                 report.StatusCodes,
+		GRPCMethod:                  report.GRPCMethod,
+		GRPCCodes:                   report.GRPCCodes,
+		Protocols:                   report.Protocols,
+		ExpectedProtocol:            report.ExpectedProtocol,
+		ProtocolMismatches:          report.ProtocolMismatches(),
+		MinDuration:                 newJSONDuration(report.MinDuration),
+		MaxDuration:                 newJSONDuration(report.MaxDuration),
+		AvgDuration:                 newJSONDuration(report.AvgDuration),
+		StdDevDuration:              newJSONDuration(report.StdDevDuration),
+		CoefficientOfVariation:      report.CoefficientOfVariation,
+		TTFB:                        newJSONDurationStats(report.TTFB),
+		ResponseTime:                newJSONResponseTimeStats(report.ResponseTime),
+		Retries:                     newJSONRetryStats(report.Retries),
+		RetryAfter:                  newJSONRetryAfterStats(report.RetryAfter),
+		Slowest:                     newJSONSlowestStats(report.Slowest),
+		Phases:                      newJSONPhaseStats(report.Phases),
+		P50:                         newJSONDuration(report.P50),
+		P90:                         newJSONDuration(report.P90),
+		P95:                         newJSONDuration(report.P95),
+		P99:                         newJSONDuration(report.P99),
+		Percentiles:                 percentiles,
+		ClampedDurations:            report.ClampedDurations,
+		TimelineInterval:            newJSONDuration(report.TimelineInterval),
+		Timeline:                    newJSONTimeline(report.Timeline),
+		Stages:                      newJSONStages(report.Stages),
+		Spike:                       newJSONSpikeReport(report.Spike),
+		Trend:                       newJSONTrendReport(report.Trend),
+		FindMax:                     newJSONFindMaxReport(report.FindMax),
+		Merged:                      report.Merged,
+		MergeConflicts:              report.MergeConflicts,
+	}
+}
+
+// printJSONReport escreve o relatório como um único documento JSON
+func printJSONReport(w io.Writer, report *stress.Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	// Mantém legíveis regras como "p95>300ms"
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(newJSONReport(report))
+}
+
+// printSummaryLine escreve o resumo de --quiet em uma única linha de pares
+// chave=valor. As chaves e a ordem são fixas para que scripts possam
+// interpretá-la; thresholds é "none" quando não há --fail-if.
+func printSummaryLine(w io.Writer, report *stress.Report) {
+	thresholds := "none"
+	if len(report.Thresholds) > 0 {
+		thresholds = "pass"
+		if !report.ThresholdsPassed() {
+			thresholds = "fail"
+		}
+	}
+	fmt.Fprintf(w, "requests=%d success=%d errors=%d canceled=%d p50=%s p95=%s p99=%s rps=%.1f duration=%s thresholds=%s\n",
+		report.TotalRequests, report.SuccessfulRequests, report.FailedRequests, report.CanceledRequests,
+		compactDuration(report.P50), compactDuration(report.P95), compactDuration(report.P99),
+		report.RequestsPerSecond, compactDuration(report.TotalTime), thresholds)
+}
+
+// printIntervalLine escreve o resumo periódico de --report-interval. A taxa de
+// erros e o P95 consideram apenas as requests do intervalo.
+func printIntervalLine(w io.Writer, stats stress.IntervalStats) {
+	fmt.Fprintf(w, "[%s] Requests: %d | Erros: %d | RPS: %.1f | P95: %s | Taxa de erros: %.2f%%\n",
+		compactDuration(stats.Elapsed), stats.Requests, stats.Errors, stats.RPS,
+		compactDuration(stats.P95), stats.ErrorRate()*100)
+}
+
+// jsonIntervalStats é a representação de um stress.IntervalStats em
+// --interval-jsonl
+type jsonIntervalStats struct {
+	Elapsed           jsonDuration `json:"elapsed"`
+	Requests          int          `json:"requests"`
+	Errors            int          `json:"errors"`
+	IntervalRequests  int          `json:"interval_requests"`
+	IntervalErrors    int          `json:"interval_errors"`
+	RequestsPerSecond float64      `json:"rps"`
+	P50               jsonDuration `json:"p50"`
+	P95               jsonDuration `json:"p95"`
+	P99               jsonDuration `json:"p99"`
+	ErrorRate         float64      `json:"error_rate"`
+	StatusCodes       map[int]int  `json:"status_codes"`
+}
+
+func newJSONIntervalStats(stats stress.IntervalStats) jsonIntervalStats {
+	return jsonIntervalStats{
+		Elapsed:           newJSONDuration(stats.Elapsed),
+		Requests:          stats.Requests,
+		Errors:            stats.Errors,
+		IntervalRequests:  stats.IntervalRequests,
+		IntervalErrors:    stats.IntervalErrors,
+		RequestsPerSecond: stats.RPS,
+		P50:               newJSONDuration(stats.P50),
+		P95:               newJSONDuration(stats.P95),
+		P99:               newJSONDuration(stats.P99),
+		ErrorRate:         stats.ErrorRate(),
+		StatusCodes:       stats.StatusCodes,
+	}
+}
+
+// intervalWriter retorna a função que grava cada resumo periódico em w como
+// uma linha de --interval-jsonl
+func intervalWriter(w io.Writer) func(stress.IntervalStats) {
+	encoder := json.NewEncoder(w)
+	return func(stats stress.IntervalStats) {
+		encoder.Encode(newJSONIntervalStats(stats))
+	}
+}
+
+// printFindMaxProbe escreve o resultado de cada sonda de --find-max assim
+// que ela termina
+func printFindMaxProbe(w io.Writer, rate bool, probe stress.FindMaxProbe) {
+	result := "aprovada"
+	if !probe.Passed {
+		result = "reprovada (" + strings.Join(probe.Violated, ", ") + ")"
+	}
+	fmt.Fprintf(w, "[sonda %s] RPS: %.1f | P95: %s | Taxa de erros: %.2f%% | %s\n",
+		findMaxLevel(rate, probe.Level), probe.RequestsPerSecond, compactDuration(probe.P95), probe.ErrorRate*100, result)
+}
+
+// compactDuration arredonda a duração para três algarismos significativos
+// (ex.: 231ms, 11.9s)
+func compactDuration(d time.Duration) string {
+	unit := time.Duration(1)
+	for d/unit >= 1000 {
+		unit *= 10
+	}
+	return d.Round(unit).String()
+}
+
+// requestLogHeader contém as colunas do log CSV de requests
+var requestLogHeader = []string{"timestamp", "worker_id", "status_code", "duration_ms", "error", "bytes_read", "ttfb_ms", "truncated", "trace_id"}
+
+// requestLogRecord converte um Result em uma linha do log CSV. O status fica
+// vazio para erros de transporte e o erro fica vazio para respostas
+// recebidas; nas chamadas gRPC o status é sempre o código gRPC.
+func requestLogRecord(result stress.Result) []string {
+	status, errMsg := "", ""
+	if result.Error != nil {
+		errMsg = result.Error.Error()
+	} else {
+		status = strconv.Itoa(result.StatusCode)
+	}
+	if result.GRPCCode != "" {
+		status = result.GRPCCode
+	}
+
+	return []string{
+		result.Timestamp.Format(time.RFC3339Nano),
+		strconv.Itoa(result.WorkerID),
+		status,
+		csvMilliseconds(result.Duration),
+		errMsg,
+		strconv.FormatInt(result.BytesRead, 10),
+		csvMilliseconds(result.TTFB),
+		strconv.FormatBool(result.Truncated),
+		result.TraceID,
+	}
+}
+
+// timelineHeader contém as colunas do CSV de --timeline-csv
+var timelineHeader = []string{"start_s", "requests", "errors", "avg_ms", "p50_ms", "p95_ms", "p99_ms", "max_ms", "stage",
+	"rss_bytes", "heap_alloc_bytes", "goroutines", "gc_cycles", "gc_pause_ms"}
+
+// writeTimelineCSV grava uma linha por intervalo da série no tempo, com o
+// início em segundos desde o início do teste, as durações em ms, a etapa
+// de --stages (0 sem etapas) e o consumo do gerador com --soak (vazio sem
+// amostra)
+func writeTimelineCSV(w io.Writer, points []stress.TimelinePoint) error {
+	records := csv.NewWriter(w)
+	records.Write(timelineHeader)
+	for _, point := range points {
+		resources := make([]string, 5)
+		if sample := point.Resources; sample != nil {
+			resources = []string{
+				strconv.FormatUint(sample.RSS, 10),
+				strconv.FormatUint(sample.HeapAlloc, 10),
+				strconv.Itoa(sample.Goroutines),
+				strconv.FormatUint(uint64(sample.GCCycles), 10),
+				csvMilliseconds(sample.GCPause),
+			}
+		}
+		records.Write(append([]string{
+			strconv.FormatFloat(point.Start.Seconds(), 'f', 3, 64),
+			strconv.Itoa(point.Requests),
+			strconv.Itoa(point.Errors),
+			csvMilliseconds(point.Avg),
+			csvMilliseconds(point.P50),
+			csvMilliseconds(point.P95),
+			csvMilliseconds(point.P99),
+			csvMilliseconds(point.Max),
+			strconv.Itoa(point.Stage),
+		}, resources...))
+	}
+	records.Flush()
+	return records.Error()
+}
+
+// csvMilliseconds formata a duração em ms com três casas, como nos arquivos
+// CSV de --request-log e --timeline-csv
+func csvMilliseconds(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+// redactedHeaders são os headers com credenciais omitidos das falhas
+// gravadas, a menos que --save-failures-unredacted seja usado
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// jsonFailure é o formato de cada falha gravada com --save-failures. Corpos
+// que não são UTF-8 válido vão em base64 nos campos *_base64.
+type jsonFailure struct {
+	Timestamp             time.Time           `json:"timestamp"`
+	WorkerID              int                 `json:"worker_id"`
+	Target                string              `json:"target"`
+	Method                string              `json:"method"`
+	URL                   string              `json:"url"`
+	RequestHeaders        map[string][]string `json:"request_headers"`
+	RequestBody           string              `json:"request_body,omitempty"`
+	RequestBodyBase64     string              `json:"request_body_base64,omitempty"`
+	RequestBodyTruncated  bool                `json:"request_body_truncated"`
+	StatusCode            int                 `json:"status_code,omitempty"`
+	ResponseHeaders       map[string][]string `json:"response_headers,omitempty"`
+	ResponseBody          string              `json:"response_body,omitempty"`
+	ResponseBodyBase64    string              `json:"response_body_base64,omitempty"`
+	ResponseBodyTruncated bool                `json:"response_body_truncated"`
+	Error                 string              `json:"error,omitempty"`
+	ErrorCategory         string              `json:"error_category,omitempty"`
+	Duration              jsonDuration        `json:"duration"`
+}
+
+func newJSONFailure(result stress.Result, redact bool) jsonFailure {
+	capture := result.Capture
+	failure := jsonFailure{
+		Timestamp:             result.Timestamp,
+		WorkerID:              result.WorkerID,
+		Target:                result.Target,
+		Method:                capture.Method,
+		URL:                   capture.URL,
+		RequestHeaders:        failureHeaders(capture.RequestHeader, redact),
+		RequestBodyTruncated:  capture.RequestBodyTruncated,
+		StatusCode:            result.StatusCode,
+		ResponseHeaders:       failureHeaders(capture.ResponseHeader, redact),
+		ResponseBodyTruncated: capture.ResponseBodyTruncated,
+		ErrorCategory:         result.ErrorCategory,
+		Duration:              newJSONDuration(result.Duration),
+	}
+	failure.RequestBody, failure.RequestBodyBase64 = failureBody(capture.RequestBody)
+	failure.ResponseBody, failure.ResponseBodyBase64 = failureBody(capture.ResponseBody)
+	if result.Error != nil {
+		failure.Error = result.Error.Error()
+	}
+	return failure
+}
+
+func failureHeaders(header http.Header, redact bool) map[string][]string {
+	if header == nil {
+		return nil
+	}
+	if redact {
+		header = header.Clone()
+		for _, name := range redactedHeaders {
+			if values := header.Values(name); len(values) > 0 {
+				header[http.CanonicalHeaderKey(name)] = slices.Repeat([]string{"[omitido]"}, len(values))
+			}
+		}
+	}
+	return header
+}
+
+// failureBody retorna o corpo como texto ou, se não for UTF-8, em base64
+func failureBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return "", base64.StdEncoding.EncodeToString(body)
+}
+
+// failureWriter grava as falhas capturadas em um diretório, uma por arquivo
+// ou todas em failures.jsonl. É usado a partir de OnResult, que é chamado
+// em uma única goroutine. O primeiro erro de escrita interrompe a gravação.
+type failureWriter struct {
+	dir    string
+	jsonl  *os.File
+	redact bool
+	count  int
+	err    error
+}
+
+func newFailureWriter(dir, format string, redact bool) (*failureWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	w := &failureWriter{dir: dir, redact: redact}
+	if format == "jsonl" {
+		file, err := os.Create(filepath.Join(dir, "failures.jsonl"))
+		if err != nil {
+			return nil, err
+		}
+		w.jsonl = file
+	}
+	return w, nil
+}
+
+func (w *failureWriter) write(result stress.Result) {
+	if result.Capture == nil || w.err != nil {
+		return
+	}
+	w.count++
+	failure := newJSONFailure(result, w.redact)
+	if w.jsonl != nil {
+		encoder := json.NewEncoder(w.jsonl)
+		encoder.SetEscapeHTML(false)
+		w.err = encoder.Encode(failure)
+		return
+	}
+	data, err := json.MarshalIndent(failure, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(w.dir, fmt.Sprintf("failure-%04d.json", w.count)), append(data, '\n'), 0o644)
+	}
+	w.err = err
+}
+
+// Close fecha o arquivo JSONL, retornando o primeiro erro de escrita
+func (w *failureWriter) Close() error {
+	if w.jsonl != nil {
+		if err := w.jsonl.Close(); w.err == nil {
+			w.err = err
+		}
+	}
+	return w.err
+}
+
+// verboseLogger escreve uma linha por request concluída com -v e, com -vv,
+// também os headers da request e da resposta. É usado a partir de OnResult,
+// que é chamado em uma única goroutine, e escreve com buffer para não
+// limitar a vazão do teste; Flush deve ser chamado ao fim.
+type verboseLogger struct {
+	w       *bufio.Writer
+	headers bool
+}
+
+func newVerboseLogger(w io.Writer, headers bool) *verboseLogger {
+	return &verboseLogger{w: bufio.NewWriterSize(w, 64<<10), headers: headers}
+}
+
+func (l *verboseLogger) log(result stress.Result) {
+	target := result.Target
+	if result.URL != "" {
+		target = result.Method + " " + result.URL
+	}
+	status := "-"
+	switch {
+	case result.GRPCCode != "":
+		status = result.GRPCCode
+	case result.StatusCode != 0:
+		status = strconv.Itoa(result.StatusCode)
+	}
+	fmt.Fprintf(l.w, "%s %s %s %s %v", result.Timestamp.Format(time.RFC3339Nano), stress.WorkerName(result.WorkerID), target, status, result.Duration)
+	switch {
+	case result.Canceled:
+		fmt.Fprint(l.w, " cancelada")
+	case result.Error != nil:
+		fmt.Fprintf(l.w, " erro=%s: %v", result.ErrorCategory, result.Error)
+	}
+	if result.TraceID != "" {
+		fmt.Fprintf(l.w, " trace=%s", result.TraceID)
+	}
+	l.w.WriteByte('\n')
+	if l.headers {
+		l.writeHeaders("> ", result.RequestHeader)
+		l.writeHeaders("< ", result.ResponseHeader)
+	}
+}
+
+// writeHeaders escreve os headers em ordem alfabética, com os valores de
+// credenciais omitidos como em --save-failures
+func (l *verboseLogger) writeHeaders(prefix string, header http.Header) {
+	header = failureHeaders(header, true)
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		for _, value := range header[name] {
+			fmt.Fprintf(l.w, "  %s%s: %s\n", prefix, name, value)
+		}
+	}
+}
+
+func (l *verboseLogger) Flush() error {
+	return l.w.Flush()
+}
@@ -0,0 +1,211 @@
+package stress
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ArrivalDistribution define os intervalos entre as chegadas do modelo
+// aberto (ver StressTest.ArrivalRate)
+type ArrivalDistribution string
+
+const (
+	// ArrivalConstant espaça as chegadas igualmente, em 1/ArrivalRate. É o
+	// padrão, também usado com o valor vazio.
+	ArrivalConstant ArrivalDistribution = "constant"
+	// ArrivalPoisson sorteia os intervalos de uma distribuição exponencial
+	// com média 1/ArrivalRate, como as chegadas independentes de usuários
+	// reais: a taxa média é a mesma, mas com rajadas e pausas
+	ArrivalPoisson ArrivalDistribution = "poisson"
+)
+
+// ParseArrivalDistribution interpreta as distribuições "constant" e "poisson"
+func ParseArrivalDistribution(text string) (ArrivalDistribution, error) {
+	d := ArrivalDistribution(strings.ToLower(text))
+	if d == "" || !d.valid() {
+		return "", fmt.Errorf("distribuição de chegadas inválida %q: use constant ou poisson", text)
+	}
+	return d, nil
+}
+
+func (d ArrivalDistribution) valid() bool {
+	return d == "" || d == ArrivalConstant || d == ArrivalPoisson
+}
+
+// arrivalSchedule libera as requests do modelo aberto (StressTest.ArrivalRate)
+// em horários fixos, independentemente de quantas ainda estão em andamento.
+// Uma chegada que encontra maxInFlight requests em andamento é descartada e
+// contada em dropped, em vez de esperar, o que adiaria as seguintes e
+// voltaria ao modelo fechado. Com StressTest.Stages, a taxa é a da etapa em
+// vigor.
+type arrivalSchedule struct {
+	rate        float64
+	stages      *stagePlan
+	maxInFlight int64
+	// rng sorteia os intervalos com ArrivalPoisson; é nil com ArrivalConstant
+	rng *rand.Rand
+	// tickets comporta maxInFlight chegadas, com o horário planejado de
+	// cada uma, então o envio nunca bloqueia: inFlight conta as chegadas
+	// entregues e ainda não concluídas
+	tickets  chan time.Time
+	inFlight atomic.Int64
+
+	stopped  chan struct{}
+	stopOnce sync.Once
+
+	// start, stage, count e previous são o estado de next: o início do
+	// teste, a etapa em vigor, as chegadas já geradas nela e o horário da
+	// última
+	start    time.Time
+	stage    int
+	count    int64
+	previous time.Time
+
+	// Os campos seguintes são escritos apenas por run e podem ser lidos
+	// depois de finished: as chegadas geradas, inclusive as descartadas, as
+	// descartadas, as mesmas contagens por etapa, o tempo em que foram
+	// geradas e o maior número de chegadas em andamento ao mesmo tempo
+	finished      chan struct{}
+	arrivals      int
+	dropped       int
+	stageArrivals []int
+	stageDropped  []int
+	elapsed       time.Duration
+	peakInFlight  int
+}
+
+func newArrivalSchedule(st *StressTest, seed uint64) *arrivalSchedule {
+	s := &arrivalSchedule{
+		rate:        st.ArrivalRate,
+		stages:      newStagePlan(st.loadStages()),
+		maxInFlight: int64(st.MaxInFlight),
+		tickets:     make(chan time.Time, st.MaxInFlight),
+		stopped:     make(chan struct{}),
+		finished:    make(chan struct{}),
+	}
+	if st.ArrivalDistribution == ArrivalPoisson {
+		s.rng = rand.New(rand.NewPCG(seed, seed+3))
+	}
+	if s.stages != nil {
+		s.stageArrivals = make([]int, len(s.stages.stages))
+		s.stageDropped = make([]int, len(s.stages.stages))
+	}
+	return s
+}
+
+// random indica se os intervalos são sorteados, o que torna a semente
+// relevante para o Report
+func (s *arrivalSchedule) random() bool {
+	return s != nil && s.rng != nil
+}
+
+// next calcula o horário da próxima chegada. Os intervalos constantes são
+// contados a partir do início da etapa, sem acumular o arredondamento de
+// cada um, e os de ArrivalPoisson a partir da chegada anterior. Com etapas,
+// uma chegada que cairia depois do fim da etapa passa para a seguinte, com
+// a nova taxa, e next retorna false depois da última.
+func (s *arrivalSchedule) next() (time.Time, bool) {
+	for {
+		rate := s.rate
+		stageStart := s.start
+		if s.stages != nil {
+			if s.stage == len(s.stages.stages) {
+				return time.Time{}, false
+			}
+			rate = s.stages.stages[s.stage].Rate
+			stageStart = s.start.Add(s.stages.start(s.stage))
+		}
+		due := stageStart
+		switch {
+		case s.rng == nil:
+			due = stageStart.Add(time.Duration(float64(s.count) * float64(time.Second) / rate))
+		case s.count > 0:
+			due = s.previous.Add(time.Duration(s.rng.ExpFloat64() / rate * float64(time.Second)))
+		case s.stage > 0:
+			// Sem memória, o intervalo exponencial recomeça no início da etapa
+			due = stageStart.Add(time.Duration(s.rng.ExpFloat64() / rate * float64(time.Second)))
+		}
+		if s.stages != nil && !due.Before(s.start.Add(s.stages.ends[s.stage])) {
+			s.stage++
+			s.count = 0
+			continue
+		}
+		s.count++
+		s.previous = due
+		return due, true
+	}
+}
+
+// run gera as chegadas a partir de start até dispatch encerrar o teste. As
+// chegadas atrasadas (ex.: por uma pausa do agendador do Go) são liberadas
+// de uma vez, mantendo a taxa média. Ao terminar, fecha tickets e os
+// workers ociosos encerram.
+func (s *arrivalSchedule) run(ctx context.Context, dispatch *dispatcher, start time.Time) {
+	defer close(s.finished)
+	defer close(s.tickets)
+	defer func() { s.elapsed = time.Since(start) }()
+	s.start = start
+	for {
+		due, ok := s.next()
+		if !ok || !sleepContext(ctx, time.Until(due)) {
+			return
+		}
+		select {
+		case <-s.stopped:
+			return
+		default:
+		}
+		// As chegadas descartadas também contam no limite de Requests, para
+		// que o horário do teste não dependa da vazão do alvo
+		if !dispatch.next(ctx) {
+			return
+		}
+		s.arrivals++
+		if s.stages != nil {
+			s.stageArrivals[s.stage]++
+		}
+		inFlight := s.inFlight.Add(1)
+		if inFlight > s.maxInFlight {
+			s.inFlight.Add(-1)
+			s.dropped++
+			if s.stages != nil {
+				s.stageDropped[s.stage]++
+			}
+			continue
+		}
+		s.peakInFlight = max(s.peakInFlight, int(inFlight))
+		s.tickets <- due
+	}
+}
+
+// wait aguarda a próxima chegada e retorna o seu horário planejado, ou false
+// quando o teste terminou. Cada chegada recebida deve ser encerrada com done.
+func (s *arrivalSchedule) wait(ctx context.Context) (time.Time, bool) {
+	select {
+	case due, ok := <-s.tickets:
+		return due, ok
+	case <-ctx.Done():
+		return time.Time{}, false
+	}
+}
+
+// done libera a vaga de uma chegada concluída. Como stop, não faz nada no
+// modelo fechado, em que o agendamento é nil.
+func (s *arrivalSchedule) done() {
+	if s != nil {
+		s.inFlight.Add(-1)
+	}
+}
+
+// stop encerra as chegadas antes do fim do teste (ex.: ao fim das linhas de
+// dados com StopWhenDataExhausted)
+func (s *arrivalSchedule) stop() {
+	if s != nil {
+		s.stopOnce.Do(func() { close(s.stopped) })
+	}
+}
@@ -0,0 +1,163 @@
+package stress
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// BodyAssertion verifica o corpo de cada resposta com status esperado.
+// Exatamente um entre Contains e Regex deve ser definido; Not inverte a
+// verificação. Uma asserção que falha torna a request uma falha da
+// categoria ErrorAssertion.
+type BodyAssertion struct {
+	Contains string
+	Regex    *regexp.Regexp
+	Not      bool
+}
+
+// String descreve a asserção, identificando-a em Report.AssertionFailures
+func (a BodyAssertion) String() string {
+	verb, value := "contém", fmt.Sprintf("%q", a.Contains)
+	if a.Regex != nil {
+		verb, value = "casa com", "/"+a.Regex.String()+"/"
+	}
+	if a.Not {
+		verb = "não " + verb
+	}
+	return "corpo " + verb + " " + value
+}
+
+// JSONAssertion compara o valor de um caminho do corpo JSON (ex.:
+// "data.items.0.status", como em Extractor.JSONPath) com Value. Strings são
+// comparadas sem aspas; números, booleanos e null, como aparecem no JSON.
+type JSONAssertion struct {
+	Path  string
+	Value string
+}
+
+// ParseJSONAssertion interpreta "caminho=valor"
+func ParseJSONAssertion(text string) (JSONAssertion, error) {
+	path, value, ok := strings.Cut(text, "=")
+	assertion := JSONAssertion{Path: strings.TrimSpace(path), Value: value}
+	if !ok {
+		return JSONAssertion{}, fmt.Errorf("use o formato \"caminho=valor\": %q", text)
+	}
+	if _, err := splitJSONPath(assertion.Path); err != nil {
+		return JSONAssertion{}, err
+	}
+	return assertion, nil
+}
+
+// String descreve a asserção, identificando-a em Report.AssertionFailures
+func (a JSONAssertion) String() string {
+	return "json " + a.Path + "=" + a.Value
+}
+
+// check compara o valor do caminho no documento já decodificado
+func (a JSONAssertion) check(document any) bool {
+	value, err := lookupJSONPath(document, a.Path)
+	if err != nil {
+		return false
+	}
+	text, err := formatJSONValue(value)
+	return err == nil && text == a.Value
+}
+
+// validAssertions verifica se cada asserção de corpo tem exatamente um
+// critério e se os caminhos JSON são válidos
+func validAssertions(assertions []BodyAssertion, jsonAssertions []JSONAssertion) bool {
+	for _, a := range assertions {
+		if (a.Contains == "") == (a.Regex == nil) {
+			return false
+		}
+	}
+	for _, a := range jsonAssertions {
+		if _, err := splitJSONPath(a.Path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// check aplica a asserção ao corpo
+func (a BodyAssertion) check(body []byte) bool {
+	var matched bool
+	if a.Regex != nil {
+		matched = a.Regex.Match(body)
+	} else {
+		matched = bytes.Contains(body, []byte(a.Contains))
+	}
+	return matched != a.Not
+}
+
+// AssertionError é o erro das requests cujo corpo não passou em alguma
+// asserção. Failed lista a descrição de todas as asserções reprovadas (ver
+// BodyAssertion.String e JSONAssertion.String).
+type AssertionError struct {
+	Failed []string
+}
+
+func (e *AssertionError) Error() string {
+	return "asserção falhou: " + strings.Join(e.Failed, "; ")
+}
+
+// checkAssertions avalia todas as asserções no corpo, decodificando o JSON
+// uma única vez. Retorna a categoria e o erro da falha, ou um erro nil
+// quando todas passam.
+func (st *StressTest) checkAssertions(body []byte) (string, error) {
+	var failed []string
+	for _, assertion := range st.Assertions {
+		if !assertion.check(body) {
+			failed = append(failed, assertion.String())
+		}
+	}
+	if len(st.JSONAssertions) > 0 {
+		document, err := decodeJSON(body)
+		if err != nil {
+			return ErrorInvalidJSON, err
+		}
+		for _, assertion := range st.JSONAssertions {
+			if !assertion.check(document) {
+				failed = append(failed, assertion.String())
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return ErrorAssertion, &AssertionError{Failed: failed}
+	}
+	return "", nil
+}
+
+// checksBody indica se o corpo da resposta precisa ser guardado para as
+// asserções ou para as extrações do passo
+func (st *StressTest) checksBody(spec requestSpec) bool {
+	return len(st.Assertions) > 0 || len(st.JSONAssertions) > 0 || st.GraphQL != nil || needsBody(spec.extract)
+}
+
+// defaultMaxCapturedBody é o limite padrão do corpo guardado para
+// extrações e asserções
+const defaultMaxCapturedBody = 1 << 20
+
+// capturedBodyLimit retorna StressTest.MaxCapturedBody ou o padrão
+func (st *StressTest) capturedBodyLimit() int64 {
+	if st.MaxCapturedBody > 0 {
+		return st.MaxCapturedBody
+	}
+	return defaultMaxCapturedBody
+}
+
+// limitedBuffer guarda os primeiros limit bytes escritos e descarta o
+// restante, sem interromper a leitura do corpo
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int64
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - int64(b.Len()); room > 0 {
+		b.Buffer.Write(p[:min(int64(len(p)), room)])
+	}
+	return len(p), nil
+}
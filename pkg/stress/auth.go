@@ -0,0 +1,78 @@
+package stress
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// BasicAuth contém as credenciais enviadas via autenticação HTTP básica
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// BearerToken fornece o token enviado no header Authorization. Quando lido de
+// um arquivo, o token pode ser recarregado periodicamente para acompanhar a
+// rotação feita por outro processo.
+type BearerToken struct {
+	token   atomic.Pointer[string]
+	path    string
+	refresh time.Duration
+}
+
+// NewStaticBearerToken cria um BearerToken com valor fixo
+func NewStaticBearerToken(token string) *BearerToken {
+	b := &BearerToken{}
+	b.token.Store(&token)
+	return b
+}
+
+// NewFileBearerToken lê o token de path. Com refresh maior que zero o arquivo
+// é relido a cada intervalo enquanto o teste estiver em execução.
+func NewFileBearerToken(path string, refresh time.Duration) (*BearerToken, error) {
+	b := &BearerToken{path: path, refresh: refresh}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Token retorna o valor atual do token
+func (b *BearerToken) Token() string {
+	return *b.token.Load()
+}
+
+func (b *BearerToken) load() error {
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		return fmt.Errorf("não foi possível ler o arquivo do token: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return fmt.Errorf("o arquivo do token %s está vazio", b.path)
+	}
+	b.token.Store(&token)
+	return nil
+}
+
+// watch relê o arquivo a cada intervalo até o contexto ser cancelado. Se a
+// leitura falhar, o último token válido continua sendo usado.
+func (b *BearerToken) watch(ctx context.Context) {
+	if b.path == "" || b.refresh <= 0 {
+		return
+	}
+	ticker := time.NewTicker(b.refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.load()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package stress
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// FailureCapture guarda uma request HTTP que falhou e a resposta recebida,
+// para depuração (ver StressTest.CaptureFailures). O status, o erro e os
+// tempos ficam no próprio Result. Os headers são guardados como foram
+// enviados e recebidos, inclusive credenciais: quem grava a captura decide
+// o que omitir.
+type FailureCapture struct {
+	Method        string
+	URL           string
+	RequestHeader http.Header
+	// RequestBody traz até os primeiros CapturedFailureBody bytes do corpo
+	// enviado; corpos que não podem ser relidos (ex.: multipart de arquivos)
+	// ficam vazios
+	RequestBody          []byte
+	RequestBodyTruncated bool
+	// ResponseHeader e ResponseBody ficam vazios nas falhas de transporte;
+	// ResponseBody traz até os primeiros CapturedFailureBody bytes do corpo
+	// e também fica vazio quando o corpo não foi lido (NoBodyRead) ou falhou
+	// na leitura
+	ResponseHeader        http.Header
+	ResponseBody          []byte
+	ResponseBodyTruncated bool
+}
+
+// CapturedFailureBody limita os bytes de cada corpo guardados em uma
+// FailureCapture
+const CapturedFailureBody = 64 << 10
+
+// capturing indica se ainda há vagas para capturar falhas, para que o corpo
+// das respostas com status inesperado só seja guardado enquanto a captura
+// é possível
+func (st *StressTest) capturing(state *runState) bool {
+	return st.CaptureFailures > 0 && state.captured.Load() < int64(st.CaptureFailures)
+}
+
+// captureFailure reserva uma vaga e anexa ao resultado a request e a
+// resposta. resp e body são nil nas falhas de transporte.
+func (st *StressTest) captureFailure(state *runState, result *Result, req *http.Request, resp *http.Response, body []byte) {
+	if result.Canceled || !st.capturing(state) || state.captured.Add(1) > int64(st.CaptureFailures) {
+		return
+	}
+	capture := &FailureCapture{
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		RequestHeader: req.Header.Clone(),
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		capture.RequestHeader.Set("Host", req.Host)
+	}
+	if req.GetBody != nil {
+		if reader, err := req.GetBody(); err == nil {
+			capture.RequestBody, capture.RequestBodyTruncated = readCaptured(reader)
+			reader.Close()
+		}
+	}
+	if resp != nil {
+		capture.ResponseHeader = resp.Header.Clone()
+		capture.ResponseBody = bytes.Clone(body[:min(len(body), CapturedFailureBody)])
+		size := result.BytesRead
+		if result.Compressed {
+			size = result.DecodedBytes
+		}
+		capture.ResponseBodyTruncated = size > int64(len(capture.ResponseBody))
+	}
+	result.Capture = capture
+}
+
+// readCaptured lê até CapturedFailureBody bytes, indicando se havia mais
+func readCaptured(r io.Reader) ([]byte, bool) {
+	data, _ := io.ReadAll(io.LimitReader(r, CapturedFailureBody+1))
+	if len(data) > CapturedFailureBody {
+		return data[:CapturedFailureBody], true
+	}
+	return data, false
+}
@@ -0,0 +1,693 @@
+package stress
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// collector agrega os resultados no Report à medida que chegam, sem guardar
+// os Results individuais: a memória usada independe da quantidade de
+// requests do teste.
+type collector struct {
+	report   *Report
+	onResult func(Result)
+	counters *progress
+	// excludeBefore, quando definido, remove das métricas de duração as
+	// requests iniciadas antes desse instante (fim do ramp-up)
+	excludeBefore time.Time
+
+	histogram     *Histogram
+	totalDuration time.Duration
+	completed     int
+	durations     runningStats
+	responses     int64
+	responseBytes int64
+	ttfb          *durationRecorder
+	phases        *phaseRecorder
+	iterations    *durationRecorder
+	decompression *durationRecorder
+	handshakes    *durationRecorder
+	roundTrips    *durationRecorder
+	firstEvents   *durationRecorder
+	interEvents   *durationRecorder
+	// streamEvents soma os eventos dos streams SSE encerrados
+	streamEvents int
+	// weights guarda o peso configurado de cada alvo, por Target.Label
+	weights map[string]int
+	targets map[string]*targetRecorder
+	// expected são os status considerados sucesso
+	expected StatusRanges
+	// abort interrompe o teste quando um limite de erros é ultrapassado
+	abort             func()
+	errorWindow       *errorWindow
+	maxErrorRate      float64
+	maxConsecutive    int
+	consecutiveErrors int
+	// histogramMax repete StressTest.HistogramMax para os histogramas por alvo
+	histogramMax time.Duration
+	// timeline agrupa as requests por intervalo com StressTest.TimelineInterval
+	timeline *timelineRecorder
+	// stages agrupa as requests pela etapa de StressTest.Stages em que foram
+	// iniciadas, localizada em plan a partir de start
+	start  time.Time
+	plan   *stagePlan
+	stages []*stageRecorder
+	// trend compara o início e o fim do teste com StressTest.Soak
+	trend *trendRecorder
+	// responseTimes é nil sem ritmo planejado (ver ResponseTimeStats)
+	responseTimes *responseTimeRecorder
+	// retries é nil sem StressTest.Retry
+	retries *retryRecorder
+	// slowest é nil sem StressTest.SlowestRequests
+	slowest *slowestRecorder
+	// interval acumula as requests desde o último resumo de OnInterval
+	interval *intervalRecorder
+}
+
+// defaultAbortWindow é a quantidade de requests da janela de
+// StressTest.AbortOnErrorRate quando AbortWindow não é informado
+const defaultAbortWindow = 200
+
+// errorWindow acompanha as falhas das últimas requests em um buffer
+// circular de tamanho fixo
+type errorWindow struct {
+	failures []bool
+	next     int
+	filled   bool
+	count    int
+}
+
+func newErrorWindow(size int) *errorWindow {
+	return &errorWindow{failures: make([]bool, size)}
+}
+
+// add registra uma request e retorna a taxa de falhas da janela, com false
+// enquanto a janela ainda não está completa
+func (w *errorWindow) add(failed bool) (float64, bool) {
+	if w.failures[w.next] {
+		w.count--
+	}
+	w.failures[w.next] = failed
+	if failed {
+		w.count++
+	}
+	w.next++
+	if w.next == len(w.failures) {
+		w.next = 0
+		w.filled = true
+	}
+	return float64(w.count) / float64(len(w.failures)), w.filled
+}
+
+// targetHistogramSigFigs é a precisão dos histogramas por alvo, menor que a
+// do histograma geral para que listas com muitos alvos não ocupem memória
+// demais
+const targetHistogramSigFigs = 2
+
+// targetRecorder acumula as métricas de um alvo
+type targetRecorder struct {
+	report    *TargetReport
+	durations *durationRecorder
+}
+
+// phaseRecorder acumula as fases das requests com StressTest.Trace ativo
+type phaseRecorder struct {
+	newConnections    int
+	reusedConnections int
+	dns               *durationRecorder
+	connect           *durationRecorder
+	tls               *durationRecorder
+	server            *durationRecorder
+}
+
+func newPhaseRecorder(st *StressTest) *phaseRecorder {
+	return &phaseRecorder{
+		dns:     newDurationRecorder(st),
+		connect: newDurationRecorder(st),
+		tls:     newDurationRecorder(st),
+		server:  newDurationRecorder(st),
+	}
+}
+
+func (r *phaseRecorder) add(phases Phases) {
+	r.server.add(phases.Server)
+	if phases.Reused {
+		r.reusedConnections++
+		return
+	}
+	r.newConnections++
+	// Fases zeradas não aconteceram (ex.: endereço IP sem DNS, HTTP sem TLS)
+	if phases.DNS > 0 {
+		r.dns.add(phases.DNS)
+	}
+	if phases.Connect > 0 {
+		r.connect.add(phases.Connect)
+	}
+	if phases.TLS > 0 {
+		r.tls.add(phases.TLS)
+	}
+}
+
+func (r *phaseRecorder) stats() *PhaseStats {
+	return &PhaseStats{
+		NewConnections:    r.newConnections,
+		ReusedConnections: r.reusedConnections,
+		DNS:               r.dns.stats(),
+		Connect:           r.connect.stats(),
+		TLS:               r.tls.stats(),
+		Server:            r.server.stats(),
+	}
+}
+
+// durationRecorder acumula uma distribuição de durações em memória fixa,
+// resumida ao final em um DurationStats
+type durationRecorder struct {
+	histogram *Histogram
+	count     int64
+	sum       time.Duration
+	min       time.Duration
+	max       time.Duration
+}
+
+func newDurationRecorder(st *StressTest) *durationRecorder {
+	return &durationRecorder{histogram: newDurationHistogram(st.HistogramMax, st.HistogramSigFigs)}
+}
+
+func (r *durationRecorder) add(d time.Duration) {
+	if r.count == 0 || d < r.min {
+		r.min = d
+	}
+	if d > r.max {
+		r.max = d
+	}
+	r.count++
+	r.sum += d
+	r.histogram.Record(d)
+}
+
+func (r *durationRecorder) stats() DurationStats {
+	if r.count == 0 {
+		return DurationStats{}
+	}
+	return DurationStats{
+		Min: r.min,
+		Max: r.max,
+		Avg: r.sum / time.Duration(r.count),
+		P50: r.histogram.ValueAtQuantile(50),
+		P95: r.histogram.ValueAtQuantile(95),
+		P99: r.histogram.ValueAtQuantile(99),
+	}
+}
+
+// runningStats calcula média e variância em uma única passada pelo algoritmo
+// de Welford, numericamente estável mesmo com milhões de amostras
+type runningStats struct {
+	count int64
+	mean  float64
+	m2    float64
+}
+
+func (s *runningStats) add(v float64) {
+	s.count++
+	delta := v - s.mean
+	s.mean += delta / float64(s.count)
+	s.m2 += delta * (v - s.mean)
+}
+
+// stdDev retorna o desvio padrão amostral (zero com menos de duas amostras)
+func (s *runningStats) stdDev() float64 {
+	if s.count < 2 {
+		return 0
+	}
+	return math.Sqrt(s.m2 / float64(s.count-1))
+}
+
+func newCollector(st *StressTest, report *Report, counters *progress, start time.Time, abort func()) *collector {
+	c := &collector{
+		report:    report,
+		onResult:  st.OnResult,
+		counters:  counters,
+		histogram: newDurationHistogram(st.HistogramMax, st.HistogramSigFigs),
+		ttfb:      newDurationRecorder(st),
+		weights:   make(map[string]int),
+		targets:   make(map[string]*targetRecorder),
+
+		expected:     st.expectedStatus(),
+		histogramMax: st.HistogramMax,
+
+		abort:          abort,
+		maxErrorRate:   st.AbortOnErrorRate,
+		maxConsecutive: st.AbortOnConsecutiveErrors,
+	}
+	if st.AbortOnErrorRate > 0 {
+		size := st.AbortWindow
+		if size == 0 {
+			size = defaultAbortWindow
+		}
+		c.errorWindow = newErrorWindow(size)
+	}
+	for _, target := range st.Targets {
+		c.weights[target.Label()] += target.weight()
+	}
+	if st.Trace {
+		c.phases = newPhaseRecorder(st)
+	}
+	if st.RespectRetryAfter {
+		report.RetryAfter = &RetryAfterStats{Max: st.retryAfterMax()}
+	}
+	if st.ApdexT > 0 {
+		report.Apdex = &ApdexScore{T: st.ApdexT}
+	}
+	if st.Scenario != nil {
+		report.Scenario = &ScenarioStats{AbortedBySteps: make(map[string]int)}
+		c.iterations = newDurationRecorder(st)
+	}
+	if st.Compression == CompressionGzip {
+		report.Compression = &CompressionStats{}
+		c.decompression = newDurationRecorder(st)
+	}
+	if st.WebSocket != nil {
+		report.WebSocket = &WebSocketStats{DisconnectReasons: make(map[string]int)}
+		c.handshakes = newDurationRecorder(st)
+		c.roundTrips = newDurationRecorder(st)
+	}
+	if st.SSE != nil {
+		report.SSE = &SSEStats{DisconnectReasons: make(map[string]int)}
+		c.firstEvents = newDurationRecorder(st)
+		c.interEvents = newDurationRecorder(st)
+	}
+	if st.ExcludeRampUp {
+		c.excludeBefore = start.Add(st.RampUp)
+	}
+	c.start = start
+	c.plan = newStagePlan(st.loadStages())
+	c.stages = newStageRecorders(c.plan, report, st.HistogramMax)
+	c.timeline = newTimelineRecorder(st, start, c.plan)
+	report.TimelineInterval = c.timeline.interval
+	if st.Soak {
+		c.trend = newTrendRecorder(st, start)
+	}
+	c.responseTimes = newResponseTimeRecorder(st)
+	c.retries = newRetryRecorder(st)
+	c.slowest = newSlowestRecorder(st)
+	if st.ReportInterval > 0 && st.OnInterval != nil {
+		c.interval = newIntervalRecorder(st, start)
+	}
+	return c
+}
+
+// add incorpora um resultado ao relatório. As métricas de duração consideram
+// todas as requests que receberam resposta, independente do status HTTP.
+func (c *collector) add(result Result) {
+	report := c.report
+	if c.onResult != nil {
+		c.onResult(result)
+	}
+	// As pausas contam mesmo nas requests canceladas durante uma retentativa
+	if report.RetryAfter != nil {
+		report.RetryAfter.Pauses += result.RetryAfterPauses
+		report.RetryAfter.Wait += result.RetryAfterWait
+	}
+	if result.Canceled {
+		report.CanceledRequests++
+		return
+	}
+	if result.WebSocket != nil && !c.addWebSocket(result) {
+		return
+	}
+	if result.SSE != nil && !c.addSSE(result) {
+		return
+	}
+	if result.Iteration != nil {
+		c.addIteration(result.Iteration)
+	}
+	report.TotalRequests++
+	target := c.target(result.Target)
+	target.report.Requests++
+	report.BytesSent += result.BytesSent
+	report.BytesReceived += result.BytesRead
+	c.counters.completed.Add(1)
+	failed := failedResult(result, c.expected)
+	if failed {
+		c.counters.failed.Add(1)
+	}
+	c.checkAbort(failed)
+	if c.retries != nil {
+		c.retries.add(result, failed)
+	}
+	if report.Workers != nil {
+		c.addWorker(result, failed)
+	}
+	if c.report.Apdex != nil && !result.Timestamp.Before(c.excludeBefore) {
+		c.addApdex(result.Duration, failed)
+	}
+
+	// Sem status, o erro aconteceu no transporte e não há resposta a medir
+	if result.Error != nil && result.StatusCode == 0 && result.GRPCCode == "" {
+		c.timeline.add(result, true, false)
+		c.addStage(result, true, false)
+		if c.trend != nil {
+			c.trend.add(result, true, false)
+		}
+		if c.interval != nil {
+			c.interval.add(true, false, 0)
+		}
+		report.FailedRequests++
+		report.TransportErrors++
+		target.report.FailedRequests++
+		report.ErrorCategories[result.ErrorCategory]++
+		return
+	}
+
+	c.responses++
+	c.responseBytes += result.BytesRead
+	if c.responses == 1 || result.BytesRead < report.MinResponseSize {
+		report.MinResponseSize = result.BytesRead
+	}
+	if result.BytesRead > report.MaxResponseSize {
+		report.MaxResponseSize = result.BytesRead
+	}
+	if result.Truncated {
+		report.TruncatedResponses++
+	}
+	if result.BodyLimited {
+		report.BodyLimitedResponses++
+	}
+	switch result.Connection {
+	case ConnectionNew:
+		report.NewConnections++
+	case ConnectionReused:
+		report.ReusedConnections++
+	}
+	if result.Compressed && report.Compression != nil {
+		report.Compression.CompressedResponses++
+		report.Compression.WireBytes += result.BytesRead
+		report.Compression.DecodedBytes += result.DecodedBytes
+		c.decompression.add(result.Decompression)
+	}
+	switch {
+	case result.GRPCCode != "":
+		report.GRPCCodes[result.GRPCCode]++
+	case result.hasHTTPStatus():
+		report.StatusCodes[result.StatusCode]++
+		target.report.StatusCodes[result.StatusCode]++
+		report.Protocols[ProtocolName(result.ProtoMajor, result.ProtoMinor)]++
+	}
+	if result.Redirected {
+		report.RedirectedRequests++
+	}
+	// Erros com resposta (ex.: falha de extração) contam como falha mesmo
+	// com o status esperado
+	expected := c.expected.Contains(result.StatusCode)
+	if !result.hasHTTPStatus() {
+		// Sem status HTTP (gRPC e mensagens WebSocket), o sucesso depende
+		// apenas do erro
+		expected = result.Error == nil
+	}
+	if result.Error == nil && expected {
+		report.SuccessfulRequests++
+		target.report.SuccessfulRequests++
+	} else {
+		report.FailedRequests++
+		target.report.FailedRequests++
+		if expected {
+			report.ApplicationErrors++
+		} else {
+			report.UnexpectedStatus++
+		}
+		if result.Error != nil {
+			report.ErrorCategories[result.ErrorCategory]++
+		}
+		var assertionErr *AssertionError
+		if errors.As(result.Error, &assertionErr) {
+			for _, assertion := range assertionErr.Failed {
+				report.AssertionFailures[assertion]++
+			}
+		}
+		var graphqlErr *GraphQLError
+		if errors.As(result.Error, &graphqlErr) {
+			report.GraphQLErrors[graphqlErr.Messages[0]]++
+		}
+	}
+
+	// Atualiza métricas de duração; no modo WebSocket elas medem apenas
+	// o round-trip das mensagens, e no SSE o tempo até o primeiro evento
+	// dos streams que receberam algum. A timeline mede também o ramp-up.
+	measured := !(result.WebSocket != nil && result.WebSocket.Handshake) && !(result.SSE != nil && result.SSE.Events == 0)
+	c.timeline.add(result, failed, measured)
+	c.addStage(result, failed, measured)
+	if c.trend != nil {
+		c.trend.add(result, failed, measured)
+	}
+	if c.interval != nil {
+		c.interval.add(failed, measured, result.Duration)
+	}
+	if !measured || result.Timestamp.Before(c.excludeBefore) {
+		return
+	}
+	c.completed++
+	c.histogram.Record(result.Duration)
+	if c.responseTimes != nil {
+		c.responseTimes.add(result)
+	}
+	if c.retries != nil {
+		c.retries.measure(result)
+	}
+	if c.slowest != nil {
+		c.slowest.add(result)
+	}
+	c.totalDuration += result.Duration
+	c.durations.add(float64(result.Duration))
+	c.ttfb.add(result.TTFB)
+	target.durations.add(result.Duration)
+	if c.phases != nil {
+		c.phases.add(result.Phases)
+	}
+	if result.Duration < report.MinDuration {
+		report.MinDuration = result.Duration
+	}
+	if result.Duration > report.MaxDuration {
+		report.MaxDuration = result.Duration
+	}
+}
+
+// addWebSocket incorpora um evento do modo WebSocket às métricas das
+// conexões, retornando se ele também conta como request
+func (c *collector) addWebSocket(result Result) bool {
+	ws := c.report.WebSocket
+	event := result.WebSocket
+	ws.MessagesReceived += event.Received
+	if event.Disconnected {
+		ws.Disconnects++
+		ws.DisconnectReasons[result.ErrorCategory]++
+	}
+	switch {
+	case event.Handshake && result.Error == nil:
+		ws.ConnectionsEstablished++
+		c.handshakes.add(result.Duration)
+		return false
+	case event.Handshake:
+		ws.ConnectionFailures++
+		return true
+	case event.Sent:
+		ws.MessagesSent++
+		if result.Error == nil && !result.Timestamp.Before(c.excludeBefore) {
+			c.roundTrips.add(result.Duration)
+		}
+		return true
+	}
+	// Mensagens recebidas com a conexão ociosa não são requests
+	c.report.BytesReceived += result.BytesRead
+	return false
+}
+
+// addSSE incorpora um evento do modo SSE às métricas dos streams,
+// retornando se ele também conta como request
+func (c *collector) addSSE(result Result) bool {
+	sse := c.report.SSE
+	event := result.SSE
+	if !event.End {
+		sse.Events += event.Events
+		sse.MalformedLines += event.Malformed
+		if !result.Timestamp.Before(c.excludeBefore) {
+			for _, gap := range event.Gaps {
+				c.interEvents.add(gap)
+			}
+		}
+		return false
+	}
+	if !event.Connected {
+		sse.ConnectionFailures++
+		return true
+	}
+	if event.Disconnected {
+		sse.Disconnects++
+		sse.DisconnectReasons[result.ErrorCategory]++
+	}
+	sse.Connections++
+	c.streamEvents += event.Events
+	if sse.Connections == 1 || event.Events < sse.MinEventsPerConnection {
+		sse.MinEventsPerConnection = event.Events
+	}
+	sse.MaxEventsPerConnection = max(sse.MaxEventsPerConnection, event.Events)
+	if event.Events > 0 && !result.Timestamp.Before(c.excludeBefore) {
+		c.firstEvents.add(event.FirstEvent)
+	}
+	return true
+}
+
+// addApdex classifica a request na faixa do Apdex
+func (c *collector) addApdex(duration time.Duration, failed bool) {
+	apdex := c.report.Apdex
+	switch {
+	case failed || duration > 4*apdex.T:
+		apdex.Frustrated++
+	case duration > apdex.T:
+		apdex.Tolerating++
+	default:
+		apdex.Satisfied++
+	}
+}
+
+// checkAbort interrompe o teste na primeira vez em que a taxa de erros da
+// janela ou a sequência de erros consecutivos ultrapassa o limite
+func (c *collector) checkAbort(failed bool) {
+	if c.report.Aborted {
+		return
+	}
+	var reason string
+	if c.errorWindow != nil {
+		if rate, full := c.errorWindow.add(failed); full && rate > c.maxErrorRate {
+			reason = fmt.Sprintf("taxa de erros de %.1f%% nas últimas %d requests excedeu o limite de %.1f%%",
+				rate*100, len(c.errorWindow.failures), c.maxErrorRate*100)
+		}
+	}
+	if failed {
+		c.consecutiveErrors++
+	} else {
+		c.consecutiveErrors = 0
+	}
+	if c.maxConsecutive > 0 && c.consecutiveErrors >= c.maxConsecutive {
+		reason = fmt.Sprintf("%d erros consecutivos", c.consecutiveErrors)
+	}
+	if reason != "" {
+		c.report.Aborted = true
+		c.report.AbortReason = reason
+		c.abort()
+	}
+}
+
+// addIteration contabiliza uma iteração do cenário
+func (c *collector) addIteration(iteration *IterationResult) {
+	stats := c.report.Scenario
+	stats.Iterations++
+	if iteration.FailedStep != "" {
+		stats.AbortedIterations++
+		stats.AbortedBySteps[iteration.FailedStep]++
+		return
+	}
+	stats.CompletedIterations++
+	if !iteration.Start.Before(c.excludeBefore) {
+		c.iterations.add(iteration.Duration)
+	}
+}
+
+// addStage registra a request na etapa de StressTest.Stages em que foi
+// iniciada
+func (c *collector) addStage(result Result, failed, measured bool) {
+	if c.plan != nil {
+		c.stages[c.plan.at(result.Timestamp.Sub(c.start))].add(result, failed, measured)
+	}
+}
+
+// target retorna as métricas do alvo, criando-as na primeira request
+func (c *collector) target(label string) *targetRecorder {
+	target, ok := c.targets[label]
+	if !ok {
+		target = &targetRecorder{
+			report: &TargetReport{
+				Weight:      c.weights[label],
+				StatusCodes: make(map[int]int),
+			},
+			durations: &durationRecorder{histogram: newDurationHistogram(c.histogramMax, targetHistogramSigFigs)},
+		}
+		c.targets[label] = target
+		c.report.Targets[label] = target.report
+	}
+	return target
+}
+
+// finish calcula as métricas que dependem de todas as amostras
+func (c *collector) finish() {
+	report := c.report
+	if c.responses > 0 {
+		report.AvgResponseSize = float64(c.responseBytes) / float64(c.responses)
+	}
+	if c.completed > 0 {
+		report.AvgDuration = c.totalDuration / time.Duration(c.completed)
+		report.StdDevDuration = time.Duration(c.durations.stdDev())
+		if c.durations.mean > 0 {
+			report.CoefficientOfVariation = c.durations.stdDev() / c.durations.mean
+		}
+	} else {
+		report.MinDuration = 0
+	}
+	report.TTFB = c.ttfb.stats()
+	for _, target := range c.targets {
+		target.report.Durations = target.durations.stats()
+		target.report.latencies = target.durations.histogram
+	}
+	for _, stage := range c.stages {
+		stage.finish()
+	}
+	if c.phases != nil {
+		report.Phases = c.phases.stats()
+	}
+	if c.iterations != nil {
+		report.Scenario.Durations = c.iterations.stats()
+	}
+	if compression := report.Compression; compression != nil {
+		if compression.WireBytes > 0 {
+			compression.Ratio = float64(compression.DecodedBytes) / float64(compression.WireBytes)
+		}
+		compression.Decompression = c.decompression.stats()
+	}
+	if ws := report.WebSocket; ws != nil {
+		ws.Handshake = c.handshakes.stats()
+		ws.RTT = c.roundTrips.stats()
+	}
+	if sse := report.SSE; sse != nil {
+		if sse.Connections > 0 {
+			sse.AvgEventsPerConnection = float64(c.streamEvents) / float64(sse.Connections)
+		}
+		if report.TotalTime > 0 {
+			sse.EventsPerSecond = float64(sse.Events) / report.TotalTime.Seconds()
+		}
+		sse.FirstEvent = c.firstEvents.stats()
+		sse.InterEvent = c.interEvents.stats()
+	}
+	if apdex := report.Apdex; apdex != nil {
+		if total := apdex.Satisfied + apdex.Tolerating + apdex.Frustrated; total > 0 {
+			apdex.Score = (float64(apdex.Satisfied) + float64(apdex.Tolerating)/2) / float64(total)
+		}
+	}
+	report.Timeline = c.timeline.finish(report.TotalTime)
+	if c.retries != nil {
+		report.Retries = c.retries.finish()
+	}
+	if c.slowest != nil {
+		report.Slowest = c.slowest.finish()
+	}
+	if c.responseTimes != nil {
+		report.ResponseTime = c.responseTimes.finish()
+	}
+	report.latencies = c.histogram
+	report.HistogramMax = c.histogram.Highest()
+	report.ClampedDurations = c.histogram.Clamped()
+	report.P50 = c.histogram.ValueAtQuantile(50)
+	report.P90 = c.histogram.ValueAtQuantile(90)
+	report.P95 = c.histogram.ValueAtQuantile(95)
+	report.P99 = c.histogram.ValueAtQuantile(99)
+}
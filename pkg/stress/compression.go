@@ -0,0 +1,114 @@
+package stress
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Compression controla o header Accept-Encoding das requests e quem
+// descomprime as respostas
+type Compression string
+
+const (
+	// CompressionAuto mantém o comportamento do net/http: o transporte pede
+	// gzip e descomprime de forma transparente, então os bytes recebidos são
+	// os descomprimidos
+	CompressionAuto Compression = ""
+	// CompressionGzip pede gzip e descomprime as respostas no próprio teste,
+	// registrando os bytes que trafegaram, os descomprimidos e o tempo de
+	// descompressão
+	CompressionGzip Compression = "gzip"
+	// CompressionNone não envia Accept-Encoding
+	CompressionNone Compression = "none"
+	// CompressionIdentity envia "Accept-Encoding: identity", pedindo
+	// explicitamente respostas sem compressão
+	CompressionIdentity Compression = "identity"
+)
+
+// ParseCompression interpreta os modos "gzip", "none" e "identity"
+func ParseCompression(text string) (Compression, error) {
+	c := Compression(strings.ToLower(text))
+	if c == CompressionAuto || !c.valid() {
+		return "", fmt.Errorf("modo de compressão inválido %q: use gzip, none ou identity", text)
+	}
+	return c, nil
+}
+
+func (c Compression) valid() bool {
+	switch c {
+	case CompressionAuto, CompressionGzip, CompressionNone, CompressionIdentity:
+		return true
+	}
+	return false
+}
+
+// acceptEncoding retorna o valor do header Accept-Encoding do modo (vazio
+// quando o header não é enviado pelo teste)
+func (c Compression) acceptEncoding() string {
+	switch c {
+	case CompressionGzip:
+		return "gzip"
+	case CompressionIdentity:
+		return "identity"
+	}
+	return ""
+}
+
+// timedReader conta os bytes lidos de r e o tempo gasto esperando por eles
+type timedReader struct {
+	r       io.Reader
+	n       int64
+	elapsed time.Duration
+}
+
+func (t *timedReader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := t.r.Read(p)
+	t.elapsed += time.Since(start)
+	t.n += int64(n)
+	return n, err
+}
+
+// gzipDecoder descomprime um corpo gzip durante a leitura, separando o
+// tempo gasto na rede do gasto descomprimindo
+type gzipDecoder struct {
+	wire    timedReader
+	gz      *gzip.Reader
+	elapsed time.Duration
+}
+
+func newGzipDecoder(r io.Reader) *gzipDecoder {
+	return &gzipDecoder{wire: timedReader{r: r}}
+}
+
+func (d *gzipDecoder) Read(p []byte) (int, error) {
+	start := time.Now()
+	defer func() { d.elapsed += time.Since(start) }()
+	// O gzip.Reader lê o cabeçalho ao ser criado, então é criado na
+	// primeira leitura para que esse tempo também seja medido
+	if d.gz == nil {
+		gz, err := gzip.NewReader(&d.wire)
+		if err != nil {
+			return 0, err
+		}
+		d.gz = gz
+	}
+	return d.gz.Read(p)
+}
+
+// decompression é o tempo de leitura que não foi gasto esperando a rede
+func (d *gzipDecoder) decompression() time.Duration {
+	return max(d.elapsed-d.wire.elapsed, 0)
+}
+
+// isDecompressionError indica se o erro veio de um corpo gzip inválido, e
+// não da conexão
+func isDecompressionError(err error) bool {
+	var corrupt flate.CorruptInputError
+	return errors.Is(err, gzip.ErrHeader) || errors.Is(err, gzip.ErrChecksum) || errors.As(err, &corrupt)
+}
@@ -0,0 +1,50 @@
+package stress
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+)
+
+// usesCookieJar indica se cada worker precisa de um cookie jar próprio
+func (st *StressTest) usesCookieJar() bool {
+	return st.Cookies || len(st.InitialCookies) > 0 || st.Scenario != nil
+}
+
+// newCookieJar cria o cookie jar exclusivo de um worker, com InitialCookies
+func (st *StressTest) newCookieJar() http.CookieJar {
+	// cookiejar.New só falha com opções inválidas
+	jar, _ := cookiejar.New(nil)
+	if len(st.InitialCookies) > 0 {
+		for _, u := range st.cookieURLs() {
+			jar.SetCookies(u, st.InitialCookies)
+		}
+	}
+	return jar
+}
+
+// cookieURLs retorna um endereço por origem dos alvos, onde InitialCookies
+// são registrados. URLs com templates no host são ignoradas.
+func (st *StressTest) cookieURLs() []*url.URL {
+	raw := []string{st.URL}
+	for _, target := range st.Targets {
+		raw = append(raw, target.URL)
+	}
+	if st.Scenario != nil {
+		for _, step := range st.Scenario.Steps {
+			raw = append(raw, step.URL)
+		}
+	}
+
+	var urls []*url.URL
+	seen := make(map[string]bool)
+	for _, rawURL := range raw {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" || seen[u.Scheme+"://"+u.Host] {
+			continue
+		}
+		seen[u.Scheme+"://"+u.Host] = true
+		urls = append(urls, &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/"})
+	}
+	return urls
+}
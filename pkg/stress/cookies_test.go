@@ -0,0 +1,102 @@
+package stress
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// sessionServer abre uma sessão para cada request sem o cookie "session" e
+// registra, por worker (header X-Client-Id), as sessões abertas e as
+// recebidas
+type sessionServer struct {
+	mu       sync.Mutex
+	next     int
+	opened   map[string][]string
+	received map[string][]string
+}
+
+func (s *sessionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	worker := r.Header.Get("X-Client-Id")
+	if cookie, err := r.Cookie("session"); err == nil {
+		s.received[worker] = append(s.received[worker], cookie.Value)
+		return
+	}
+	s.next++
+	session := strconv.Itoa(s.next)
+	s.opened[worker] = append(s.opened[worker], session)
+	http.SetCookie(w, &http.Cookie{Name: "session", Value: session, Path: "/"})
+}
+
+func runSessions(t *testing.T, cookies bool) *sessionServer {
+	t.Helper()
+	sessions := &sessionServer{opened: make(map[string][]string), received: make(map[string][]string)}
+	server := httptest.NewServer(sessions)
+	defer server.Close()
+	st := NewStressTest(server.URL, 30, 3)
+	st.Cookies = cookies
+	st.ClientIDHeader = "X-Client-Id"
+	runTest(t, st)
+	return sessions
+}
+
+func TestRunCookiesPerWorker(t *testing.T) {
+	sessions := runSessions(t, true)
+	owner := make(map[string]string)
+	for worker, opened := range sessions.opened {
+		// Cada worker guarda o cookie da primeira resposta e o reenvia
+		if len(opened) != 1 {
+			t.Errorf("%s abriu %d sessões, esperava 1", worker, len(opened))
+			continue
+		}
+		owner[opened[0]] = worker
+	}
+	if len(owner) < 2 {
+		t.Fatalf("apenas %d workers abriram sessões, esperava vários: %v", len(owner), sessions.opened)
+	}
+	for worker, received := range sessions.received {
+		for _, session := range received {
+			if owner[session] != worker {
+				t.Errorf("%s enviou a sessão %s, aberta por %q", worker, session, owner[session])
+			}
+		}
+	}
+}
+
+func TestRunWithoutCookies(t *testing.T) {
+	sessions := runSessions(t, false)
+	if len(sessions.received) > 0 {
+		t.Errorf("sem Cookies, os workers reenviaram sessões: %v", sessions.received)
+	}
+	opened := 0
+	for _, sessions := range sessions.opened {
+		opened += len(sessions)
+	}
+	if opened != 30 {
+		t.Errorf("%d sessões abertas, esperava uma por request", opened)
+	}
+}
+
+func TestRunInitialCookies(t *testing.T) {
+	var mu sync.Mutex
+	values := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("tenant")
+		mu.Lock()
+		defer mu.Unlock()
+		if err == nil {
+			values[cookie.Value]++
+		}
+	}))
+	defer server.Close()
+	st := NewStressTest(server.URL, 6, 2)
+	st.InitialCookies = []*http.Cookie{{Name: "tenant", Value: "acme"}}
+	runTest(t, st)
+	if values["acme"] != 6 {
+		t.Errorf("cookies recebidos: %v, esperava tenant=acme em 6 requests", values)
+	}
+}
@@ -0,0 +1,372 @@
+package stress
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CurlRequest é a request descrita por uma linha de comando do curl
+type CurlRequest struct {
+	Method string
+	// ExplicitMethod indica que Method veio de -X/--request ou -I/--head, e
+	// não do padrão do curl (GET, ou POST quando há dados)
+	ExplicitMethod bool
+	URL            string
+	Header         http.Header
+	// Body é nil quando o comando não envia dados
+	Body      []byte
+	BasicAuth *BasicAuth
+	// Compressed corresponde a --compressed
+	Compressed bool
+	// Insecure corresponde a -k/--insecure
+	Insecure bool
+	// Ignored lista as opções sem equivalente no teste, na ordem do comando
+	Ignored []string
+}
+
+// curlArgOptions são as opções do curl que recebem um argumento. As que não
+// são interpretadas por ParseCurl precisam estar aqui para que o argumento
+// não seja confundido com a URL.
+var curlArgOptions = map[string]bool{
+	"-X": true, "--request": true, "-H": true, "--header": true,
+	"-d": true, "--data": true, "--data-raw": true, "--data-binary": true, "--data-ascii": true, "--data-urlencode": true,
+	"-u": true, "--user": true, "-A": true, "--user-agent": true, "-e": true, "--referer": true,
+	"-b": true, "--cookie": true, "--url": true,
+	"-o": true, "--output": true, "-c": true, "--cookie-jar": true, "-F": true, "--form": true,
+	"-m": true, "--max-time": true, "--connect-timeout": true, "-x": true, "--proxy": true, "-U": true, "--proxy-user": true,
+	"--cacert": true, "-E": true, "--cert": true, "--key": true, "--resolve": true, "--connect-to": true,
+	"-w": true, "--write-out": true, "--retry": true, "-r": true, "--range": true, "-T": true, "--upload-file": true,
+	"--limit-rate": true, "--interface": true, "--max-redirs": true, "-K": true, "--config": true,
+	"-Y": true, "--speed-limit": true, "-y": true, "--speed-time": true,
+}
+
+// curlNoops são opções que só mudam a saída do curl, sem efeito na request
+var curlNoops = map[string]bool{
+	"-s": true, "--silent": true, "-S": true, "--show-error": true, "-v": true, "--verbose": true,
+	"-i": true, "--include": true, "-L": true, "--location": true, "-#": true, "--progress-bar": true,
+}
+
+// ParseCurl interpreta uma linha de comando do curl, como a copiada das
+// ferramentas de desenvolvedor dos navegadores ("Copy as cURL"). São aceitas
+// as aspas simples e duplas, o $'...' do bash e as quebras de linha com \.
+// As opções -X, -H, -d (e variantes --data-*), -u, -A, -e, -b, -G, -I,
+// --compressed e -k são convertidas; as demais aparecem em Ignored.
+func ParseCurl(command string) (*CurlRequest, error) {
+	args, err := splitShellWords(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, errors.New(`o comando deve começar com "curl"`)
+	}
+
+	req := &CurlRequest{Header: make(http.Header)}
+	var data []string
+	var get, head bool
+	for i := 1; i < len(args); i++ {
+		name, value, hasValue := args[i], "", false
+		switch {
+		case !strings.HasPrefix(name, "-") || name == "-":
+			if req.URL != "" {
+				return nil, fmt.Errorf("mais de uma URL: %q e %q", req.URL, name)
+			}
+			req.URL = name
+			continue
+		case strings.HasPrefix(name, "--"):
+			// --opcao=valor não existe no curl, mas o argumento separado sim
+			if curlArgOptions[name] {
+				hasValue = true
+			}
+		case len(name) > 2 && curlArgOptions[name[:2]]:
+			// Opção curta com o argumento junto, como -XPOST
+			name, value = name[:2], name[2:]
+		case len(name) > 2:
+			// Várias opções curtas sem argumento juntas, como -sSk
+			for _, c := range name[1:] {
+				short := "-" + string(c)
+				if curlArgOptions[short] {
+					return nil, fmt.Errorf("a opção %s precisa de um argumento e não pode ser agrupada em %s", short, name)
+				}
+				req.flag(short, &get, &head)
+			}
+			continue
+		case curlArgOptions[name]:
+			hasValue = true
+		}
+		if hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("a opção %s precisa de um argumento", name)
+			}
+			i++
+			value = args[i]
+		}
+		if !curlArgOptions[name] {
+			req.flag(name, &get, &head)
+			continue
+		}
+
+		switch name {
+		case "-X", "--request":
+			req.Method = strings.ToUpper(value)
+			req.ExplicitMethod = true
+		case "-H", "--header":
+			header, headerValue, ok := strings.Cut(value, ":")
+			header = strings.TrimSpace(header)
+			if !ok || !ValidHeaderName(header) {
+				return nil, fmt.Errorf("header inválido %q", value)
+			}
+			// "-H 'Nome:'" remove no curl um header que ele enviaria
+			if headerValue = strings.TrimSpace(headerValue); headerValue != "" {
+				req.Header.Add(header, headerValue)
+			}
+		case "-d", "--data", "--data-ascii", "--data-binary", "--data-raw", "--data-urlencode":
+			part, err := curlData(name, value)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, part)
+		case "-u", "--user":
+			username, password, _ := strings.Cut(value, ":")
+			if username == "" {
+				return nil, fmt.Errorf("credencial inválida em %s: use o formato \"nome:senha\"", name)
+			}
+			req.BasicAuth = &BasicAuth{Username: username, Password: password}
+		case "-A", "--user-agent":
+			req.Header.Set("User-Agent", value)
+		case "-e", "--referer":
+			req.Header.Set("Referer", value)
+		case "-b", "--cookie":
+			// Sem "=", o argumento é um arquivo de cookies
+			if !strings.Contains(value, "=") {
+				req.Ignored = append(req.Ignored, name+" "+value)
+				continue
+			}
+			req.Header.Add("Cookie", value)
+		case "--url":
+			if req.URL != "" {
+				return nil, fmt.Errorf("mais de uma URL: %q e %q", req.URL, value)
+			}
+			req.URL = value
+		default:
+			req.Ignored = append(req.Ignored, name)
+		}
+	}
+
+	if req.URL == "" {
+		return nil, errors.New("o comando não tem URL")
+	}
+	// Como o curl, URLs sem esquema usam http://
+	if !strings.Contains(req.URL, "://") {
+		req.URL = "http://" + req.URL
+	}
+	u, err := parseHTTPURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case get && len(data) > 0:
+		// Com -G os dados vão na query string
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += strings.Join(data, "&")
+	case len(data) > 0:
+		req.Body = []byte(strings.Join(data, "&"))
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", formContentType)
+		}
+	}
+	u.Fragment = ""
+	req.URL = u.String()
+
+	if req.Method == "" {
+		switch {
+		case head:
+			req.Method, req.ExplicitMethod = http.MethodHead, true
+		case req.Body != nil:
+			req.Method = http.MethodPost
+		default:
+			req.Method = http.MethodGet
+		}
+	}
+	if !ValidMethod(req.Method) {
+		return nil, fmt.Errorf("método HTTP inválido: %s", req.Method)
+	}
+	return req, nil
+}
+
+// flag aplica uma opção sem argumento
+func (req *CurlRequest) flag(name string, get, head *bool) {
+	switch {
+	case name == "--compressed":
+		req.Compressed = true
+	case name == "-k" || name == "--insecure":
+		req.Insecure = true
+	case name == "-G" || name == "--get":
+		*get = true
+	case name == "-I" || name == "--head":
+		*head = true
+	case curlNoops[name]:
+	default:
+		req.Ignored = append(req.Ignored, name)
+	}
+}
+
+// curlData converte o argumento de uma opção --data-*: -d e --data-binary
+// leem @arquivo (-d removendo as quebras de linha, como o curl), e
+// --data-urlencode codifica o conteúdo
+func curlData(name, value string) (string, error) {
+	switch name {
+	case "--data-raw":
+		return value, nil
+	case "--data-urlencode":
+		// Formatos "conteúdo", "=conteúdo" e "nome=conteúdo"
+		key, content, ok := strings.Cut(value, "=")
+		if !ok {
+			return url.QueryEscape(value), nil
+		}
+		if key == "" {
+			return url.QueryEscape(content), nil
+		}
+		return key + "=" + url.QueryEscape(content), nil
+	}
+	path, ok := strings.CutPrefix(value, "@")
+	if !ok {
+		return value, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	if name == "--data-binary" {
+		return string(content), nil
+	}
+	return strings.NewReplacer("\r", "", "\n", "").Replace(string(content)), nil
+}
+
+// splitShellWords separa o comando em argumentos como um shell POSIX
+func splitShellWords(command string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '\\':
+			inWord = true
+			if i+1 < len(command) {
+				i++
+				// \ seguido de quebra de linha continua o comando
+				if command[i] == '\n' {
+					inWord = word.Len() > 0
+					continue
+				}
+				if command[i] == '\r' && i+1 < len(command) && command[i+1] == '\n' {
+					i++
+					inWord = word.Len() > 0
+					continue
+				}
+				word.WriteByte(command[i])
+			}
+		case c == '\'':
+			inWord = true
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("aspas simples sem fechamento")
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			inWord = true
+			closed := false
+			for i++; i < len(command); i++ {
+				if command[i] == '"' {
+					closed = true
+					break
+				}
+				// Entre aspas duplas, \ só escapa $, `, ", \ e a quebra de linha
+				if command[i] == '\\' && i+1 < len(command) && strings.IndexByte("$`\"\\\n", command[i+1]) >= 0 {
+					i++
+					if command[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(command[i])
+			}
+			if !closed {
+				return nil, errors.New("aspas duplas sem fechamento")
+			}
+		case c == '$' && i+1 < len(command) && command[i+1] == '\'':
+			inWord = true
+			n, err := ansiCQuoted(command[i+2:], &word)
+			if err != nil {
+				return nil, err
+			}
+			i += n + 2
+		default:
+			inWord = true
+			word.WriteByte(c)
+		}
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+// ansiCQuoted decodifica o conteúdo de $'...' até a aspa de fechamento,
+// retornando quantos bytes de s foram consumidos, incluindo a aspa
+func ansiCQuoted(s string, word *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'':
+			return i, nil
+		case '\\':
+			if i+1 >= len(s) {
+				return 0, errors.New("$'...' sem fechamento")
+			}
+			i++
+			switch c := s[i]; c {
+			case 'n':
+				word.WriteByte('\n')
+			case 't':
+				word.WriteByte('\t')
+			case 'r':
+				word.WriteByte('\r')
+			case 'x', 'u', 'U':
+				size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+				end := i + 1
+				for end < len(s) && end-i-1 < size && strings.IndexByte("0123456789abcdefABCDEF", s[end]) >= 0 {
+					end++
+				}
+				code, err := strconv.ParseUint(s[i+1:end], 16, 32)
+				if err != nil {
+					return 0, fmt.Errorf("escape \\%c inválido em $'...'", c)
+				}
+				if c == 'x' {
+					word.WriteByte(byte(code))
+				} else {
+					word.WriteString(string(rune(code)))
+				}
+				i = end - 1
+			default:
+				// \\, \', \" e os demais escapes valem o próprio caractere
+				word.WriteByte(c)
+			}
+		default:
+			word.WriteByte(s[i])
+		}
+	}
+	return 0, errors.New("$'...' sem fechamento")
+}
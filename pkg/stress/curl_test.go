@@ -0,0 +1,156 @@
+package stress
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseCurl(t *testing.T) {
+	dir := t.TempDir()
+	dataFile := filepath.Join(dir, "corpo.txt")
+	if err := os.WriteFile(dataFile, []byte("a=1\nb=2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		command string
+		want    CurlRequest
+	}{
+		{
+			name:    "apenas a URL",
+			command: "curl https://api.exemplo.com/v1",
+			want:    CurlRequest{Method: "GET", URL: "https://api.exemplo.com/v1", Header: http.Header{}},
+		},
+		{
+			name:    "sem esquema",
+			command: "curl api.exemplo.com/v1#ancora",
+			want:    CurlRequest{Method: "GET", URL: "http://api.exemplo.com/v1", Header: http.Header{}},
+		},
+		{
+			name: "Copy as cURL do navegador",
+			command: `curl 'https://api.exemplo.com/itens?q=1' \
+  -H 'accept: application/json' \
+  -H "authorization: Bearer abc" \
+  --compressed`,
+			want: CurlRequest{
+				Method: "GET", URL: "https://api.exemplo.com/itens?q=1",
+				Header:     http.Header{"Accept": {"application/json"}, "Authorization": {"Bearer abc"}},
+				Compressed: true,
+			},
+		},
+		{
+			name:    "aspas e $'...'",
+			command: `curl -X put "https://api.exemplo.com/a b" -H $'X-Texto: linha\tcom tab' --data-raw '{"nome":"O'\''Brien"}'`,
+			want: CurlRequest{
+				Method: "PUT", ExplicitMethod: true, URL: "https://api.exemplo.com/a%20b",
+				Header: http.Header{"X-Texto": {"linha\tcom tab"}, "Content-Type": {formContentType}},
+				Body:   []byte(`{"nome":"O'Brien"}`),
+			},
+		},
+		{
+			name:    "-d implica POST e junta as partes",
+			command: `curl -d a=1 --data b=2 -H 'Content-Type: text/plain' https://api.exemplo.com`,
+			want: CurlRequest{
+				Method: "POST", URL: "https://api.exemplo.com",
+				Header: http.Header{"Content-Type": {"text/plain"}},
+				Body:   []byte("a=1&b=2"),
+			},
+		},
+		{
+			name:    "-d @arquivo sem as quebras de linha",
+			command: "curl -d @" + dataFile + " https://api.exemplo.com",
+			want: CurlRequest{
+				Method: "POST", URL: "https://api.exemplo.com",
+				Header: http.Header{"Content-Type": {formContentType}},
+				Body:   []byte("a=1b=2"),
+			},
+		},
+		{
+			name:    "--data-raw não lê @",
+			command: "curl --data-raw @literal https://api.exemplo.com",
+			want: CurlRequest{
+				Method: "POST", URL: "https://api.exemplo.com",
+				Header: http.Header{"Content-Type": {formContentType}},
+				Body:   []byte("@literal"),
+			},
+		},
+		{
+			name:    "--data-urlencode",
+			command: "curl --data-urlencode 'q=a b&c' https://api.exemplo.com",
+			want: CurlRequest{
+				Method: "POST", URL: "https://api.exemplo.com",
+				Header: http.Header{"Content-Type": {formContentType}},
+				Body:   []byte("q=a+b%26c"),
+			},
+		},
+		{
+			name:    "-G leva os dados para a query",
+			command: "curl -G -d a=1 'https://api.exemplo.com/busca?x=0'",
+			want:    CurlRequest{Method: "GET", URL: "https://api.exemplo.com/busca?x=0&a=1", Header: http.Header{}},
+		},
+		{
+			name:    "-u com dois-pontos na senha",
+			command: "curl -u ana:se:nha https://api.exemplo.com",
+			want: CurlRequest{
+				Method: "GET", URL: "https://api.exemplo.com", Header: http.Header{},
+				BasicAuth: &BasicAuth{Username: "ana", Password: "se:nha"},
+			},
+		},
+		{
+			name:    "-k e opções curtas agrupadas",
+			command: "curl -sSk -XDELETE https://api.exemplo.com/1",
+			want:    CurlRequest{Method: "DELETE", ExplicitMethod: true, URL: "https://api.exemplo.com/1", Header: http.Header{}, Insecure: true},
+		},
+		{
+			name:    "-I, -A, -e e -b",
+			command: "curl -I -A meu-agente -e https://origem -b 'a=1' https://api.exemplo.com",
+			want: CurlRequest{
+				Method: "HEAD", ExplicitMethod: true, URL: "https://api.exemplo.com",
+				Header: http.Header{"User-Agent": {"meu-agente"}, "Referer": {"https://origem"}, "Cookie": {"a=1"}},
+			},
+		},
+		{
+			name:    "opções ignoradas",
+			command: "curl -L -o saida.json --retry 3 -b cookies.txt --http2 https://api.exemplo.com",
+			want: CurlRequest{
+				Method: "GET", URL: "https://api.exemplo.com", Header: http.Header{},
+				Ignored: []string{"-o", "--retry", "-b cookies.txt", "--http2"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCurl(tt.command)
+			if err != nil {
+				t.Fatalf("ParseCurl: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseCurl =\n%+v\nesperava\n%+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCurlErrors(t *testing.T) {
+	for _, command := range []string{
+		"",
+		"wget https://api.exemplo.com",
+		"curl",
+		"curl -H",
+		"curl 'https://api.exemplo.com",
+		"curl https://a https://b",
+		"curl -H 'sem dois-pontos' https://api.exemplo.com",
+		"curl -u :senha https://api.exemplo.com",
+		"curl -X FOO https://api.exemplo.com",
+		"curl -sH 'X: 1' https://api.exemplo.com",
+		"curl -d @/inexistente https://api.exemplo.com",
+		"curl ftp://api.exemplo.com",
+	} {
+		if got, err := ParseCurl(command); err == nil {
+			t.Errorf("ParseCurl(%q) = %+v, esperava erro", command, got)
+		}
+	}
+}
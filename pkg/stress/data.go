@@ -0,0 +1,196 @@
+package stress
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"text/template"
+)
+
+// DataSet contém as linhas de dados usadas nos templates das requests. Fields
+// vem da primeira linha do CSV e define os nomes usados nos placeholders
+// (ex.: {{.user_id}}).
+type DataSet struct {
+	Fields []string
+	Rows   [][]string
+}
+
+// LoadCSVData lê um CSV cuja primeira linha contém os nomes das colunas
+func LoadCSVData(r io.Reader) (*DataSet, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV inválido: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, errors.New("o CSV deve ter uma linha de cabeçalho e ao menos uma linha de dados")
+	}
+	fields := records[0]
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			return nil, errors.New("o cabeçalho do CSV contém uma coluna sem nome")
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("coluna duplicada no cabeçalho do CSV: %s", field)
+		}
+		seen[field] = true
+	}
+	return &DataSet{Fields: fields, Rows: records[1:]}, nil
+}
+
+// row retorna a linha i como um mapa coluna → valor
+func (d *DataSet) row(i int) map[string]string {
+	row := make(map[string]string, len(d.Fields))
+	for j, field := range d.Fields {
+		row[strings.TrimSpace(field)] = d.Rows[i][j]
+	}
+	return row
+}
+
+// dataFeeder entrega as linhas do DataSet às requests, na ordem do arquivo
+type dataFeeder struct {
+	data *DataSet
+	// stop encerra o teste ao fim das linhas em vez de recomeçar do início
+	stop      bool
+	counter   atomic.Int64
+	exhausted atomic.Bool
+}
+
+func newDataFeeder(st *StressTest) *dataFeeder {
+	if st.Data == nil {
+		return nil
+	}
+	return &dataFeeder{data: st.Data, stop: st.StopWhenDataExhausted}
+}
+
+// next retorna a próxima linha, ou false quando as linhas acabaram e o teste
+// deve parar
+func (f *dataFeeder) next() (map[string]string, bool) {
+	if f == nil {
+		return nil, true
+	}
+	i := int(f.counter.Add(1) - 1)
+	if i >= len(f.data.Rows) && f.stop {
+		f.exhausted.Store(true)
+		return nil, false
+	}
+	return f.data.row(i % len(f.data.Rows)), true
+}
+
+// requestTemplates guarda os templates da URL, dos headers e do corpo,
+// preenchidos a cada request com uma linha do DataSet
+type requestTemplates struct {
+	urls   map[string]*template.Template
+	header map[string][]*template.Template
+	body   *template.Template
+}
+
+// newRequestTemplates compila os templates e os executa com sample (ex.: a
+// primeira linha de dados), para que erros de sintaxe e colunas inexistentes
+// sejam detectados antes do teste começar. Retorna nil sem sample.
+func newRequestTemplates(sample map[string]string, urls []string, header http.Header, body []byte) (*requestTemplates, error) {
+	if sample == nil {
+		return nil, nil
+	}
+	parse := func(name, text string) (*template.Template, error) {
+		return parseTemplate(sample, name, text)
+	}
+
+	t := &requestTemplates{
+		urls:   make(map[string]*template.Template),
+		header: make(map[string][]*template.Template),
+	}
+	for _, rawURL := range urls {
+		tmpl, err := parse("URL "+rawURL, rawURL)
+		if err != nil {
+			return nil, err
+		}
+		t.urls[rawURL] = tmpl
+	}
+	for name, values := range header {
+		for _, value := range values {
+			tmpl, err := parse("header "+name, value)
+			if err != nil {
+				return nil, err
+			}
+			t.header[name] = append(t.header[name], tmpl)
+		}
+	}
+	if body != nil {
+		tmpl, err := parse("corpo", string(body))
+		if err != nil {
+			return nil, err
+		}
+		t.body = tmpl
+	}
+	return t, nil
+}
+
+// parseTemplate compila um template e o executa com sample, detectando
+// erros de sintaxe e colunas inexistentes
+func parseTemplate(sample map[string]string, name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template inválido em %s: %w", name, err)
+	}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return nil, fmt.Errorf("template inválido em %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+func render(tmpl *template.Template, row map[string]string) (string, error) {
+	var out strings.Builder
+	if err := tmpl.Execute(&out, row); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// url preenche a URL do alvo com a linha de dados
+func (t *requestTemplates) url(rawURL string, row map[string]string) (string, error) {
+	tmpl, ok := t.urls[rawURL]
+	if !ok {
+		return rawURL, nil
+	}
+	return render(tmpl, row)
+}
+
+// headers monta os headers preenchidos com a linha de dados
+func (t *requestTemplates) headers(row map[string]string) (http.Header, error) {
+	header := make(http.Header, len(t.header))
+	for name, templates := range t.header {
+		for _, tmpl := range templates {
+			value, err := render(tmpl, row)
+			if err != nil {
+				return nil, err
+			}
+			header[name] = append(header[name], value)
+		}
+	}
+	return header, nil
+}
+
+// renderBody retorna StressTest.Body preenchido com a linha de dados
+func (st *StressTest) renderBody(state *runState, row map[string]string) ([]byte, error) {
+	if state.templates == nil || st.Body == nil {
+		return st.Body, nil
+	}
+	text, err := render(state.templates.body, row)
+	return []byte(text), err
+}
+
+// bodyReader retorna o corpo preenchido com a linha de dados
+func (t *requestTemplates) bodyReader(row map[string]string) (*bytes.Reader, error) {
+	value, err := render(t.body, row)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader([]byte(value)), nil
+}
@@ -0,0 +1,99 @@
+package stress
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testCSV = "id,name\n1,ana\n2,bruno\n3,carla\n"
+
+// dataServer registra a URL, o header X-Name e o corpo de cada request
+func dataServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, r.URL.Path+" "+r.Header.Get("X-Name")+" "+string(body))
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(received)
+	}
+}
+
+func TestRunData(t *testing.T) {
+	server, received := dataServer(t)
+	data, err := LoadCSVData(strings.NewReader(testCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Com um worker as linhas chegam na ordem do arquivo e recomeçam do
+	// início ao acabar
+	st := NewStressTest(server.URL+"/users/{{.id}}", 4, 1)
+	st.Method = http.MethodPost
+	st.Header = http.Header{"X-Name": {"{{.name}}"}}
+	st.Body = []byte(`{"id":{{.id}}}`)
+	st.Data = data
+	runTest(t, st)
+	want := []string{
+		`/users/1 ana {"id":1}`,
+		`/users/2 bruno {"id":2}`,
+		`/users/3 carla {"id":3}`,
+		`/users/1 ana {"id":1}`,
+	}
+	if got := received(); !slices.Equal(got, want) {
+		t.Errorf("o servidor recebeu %q, esperava %q", got, want)
+	}
+}
+
+func TestRunDataStop(t *testing.T) {
+	server, received := dataServer(t)
+	data, err := LoadCSVData(strings.NewReader(testCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := NewStressTest(server.URL+"/users/{{.id}}", 10, 2)
+	st.Data = data
+	st.StopWhenDataExhausted = true
+	report := runTest(t, st)
+	got := received()
+	slices.Sort(got)
+	if want := []string{"/users/1  ", "/users/2  ", "/users/3  "}; !slices.Equal(got, want) {
+		t.Errorf("o servidor recebeu %q, esperava uma request por linha", got)
+	}
+	if report.TotalRequests != 3 {
+		t.Errorf("TotalRequests = %d, esperava 3", report.TotalRequests)
+	}
+}
+
+func TestLoadCSVData(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		err  bool
+	}{
+		{name: "válido", csv: testCSV},
+		{name: "apenas cabeçalho", csv: "id,name\n", err: true},
+		{name: "coluna sem nome", csv: "id,\n1,2\n", err: true},
+		{name: "coluna duplicada", csv: "id,id\n1,2\n", err: true},
+		{name: "colunas a mais", csv: "id\n1,2\n", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadCSVData(strings.NewReader(tt.csv))
+			if (err != nil) != tt.err {
+				t.Errorf("LoadCSVData: erro %v, esperava erro: %v", err, tt.err)
+			}
+		})
+	}
+}
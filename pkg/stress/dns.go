@@ -0,0 +1,70 @@
+package stress
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+)
+
+// ParseResolve interpreta entradas no formato do --resolve do curl
+// ("host:porta:endereço[,endereço...]"), agrupando os endereços por
+// "host:porta". Entradas repetidas para o mesmo host acumulam endereços.
+func ParseResolve(entries []string) (map[string][]string, error) {
+	resolve := make(map[string][]string)
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("--resolve inválido %q: use o formato host:porta:endereço", entry)
+		}
+		key := net.JoinHostPort(parts[0], parts[1])
+		for _, addr := range strings.Split(parts[2], ",") {
+			addr = strings.Trim(addr, "[]")
+			if net.ParseIP(addr) == nil {
+				return nil, fmt.Errorf("--resolve inválido %q: %q não é um endereço IP", entry, addr)
+			}
+			resolve[key] = append(resolve[key], addr)
+		}
+	}
+	return resolve, nil
+}
+
+// staticResolver aplica os overrides de --resolve, alternando entre os
+// endereços informados para o mesmo host a cada nova conexão
+type staticResolver struct {
+	addrs map[string][]string
+	next  map[string]*atomic.Uint64
+}
+
+func newStaticResolver(addrs map[string][]string) *staticResolver {
+	r := &staticResolver{addrs: addrs, next: make(map[string]*atomic.Uint64, len(addrs))}
+	for key := range addrs {
+		r.next[key] = new(atomic.Uint64)
+	}
+	return r
+}
+
+// lookup retorna o endereço que deve ser usado para addr ("host:porta"),
+// ou o próprio addr quando não há override
+func (r *staticResolver) lookup(addr string) string {
+	addrs, ok := r.addrs[addr]
+	if !ok {
+		return addr
+	}
+	_, port, _ := net.SplitHostPort(addr)
+	i := r.next[addr].Add(1) - 1
+	return net.JoinHostPort(addrs[i%uint64(len(addrs))], port)
+}
+
+// newDNSResolver cria um net.Resolver que envia todas as consultas ao
+// servidor DNS informado (ip:porta)
+func newDNSResolver(server string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
@@ -0,0 +1,27 @@
+// Package stress implementa o teste de carga HTTP usado pela CLI stress-test
+// e pode ser importado para executar testes a partir de outros programas Go,
+// por exemplo para subir um serviço em um teste, gerar carga e verificar o
+// Report resultante:
+//
+//	test := stress.NewStressTest("http://localhost:8080/health", 1000, 10)
+//	test.Client = myClient // opcional: client com transporte próprio
+//	test.OnResult = func(r stress.Result) {
+//		if r.Error != nil {
+//			log.Printf("worker %d: %v", r.WorkerID, r.Error)
+//		}
+//	}
+//	report, err := test.Run(ctx)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if report.FailedRequests > 0 {
+//		log.Fatalf("%d requests falharam", report.FailedRequests)
+//	}
+//
+// O transporte pode ser montado com NewRoundTripper a partir de um
+// TransportConfig, que reúne as mesmas opções das flags da CLI.
+package stress
+
+// Version é a versão da API do pacote, seguindo o versionamento semântico:
+// mudanças incompatíveis nos tipos exportados incrementam a versão major.
+const Version = "1.0.0"
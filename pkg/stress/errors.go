@@ -0,0 +1,139 @@
+package stress
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// Categorias de erros de transporte exibidas no relatório. Erros que não se
+// encaixam em nenhuma delas são agrupados pela própria mensagem, truncada.
+const (
+	ErrorTimeout           = "timeout"
+	ErrorDNS               = "dns"
+	ErrorProxy             = "proxy"
+	ErrorConnectionRefused = "connection_refused"
+	ErrorConnectionReset   = "connection_reset"
+	ErrorConnect           = "connect"
+	ErrorTLS               = "tls"
+	ErrorEOF               = "eof"
+	// ErrorLocalPorts indica que todas as portas de TransportConfig.LocalPorts
+	// estavam em uso
+	ErrorLocalPorts = "local_ports"
+	// ErrorExtraction indica que a resposta chegou, mas um Extractor do
+	// passo não encontrou o valor
+	ErrorExtraction = "extraction"
+	// ErrorAssertion indica que o corpo da resposta não passou em uma
+	// BodyAssertion
+	ErrorAssertion = "assertion"
+	// ErrorInvalidJSON indica que há JSONAssertions, mas o corpo da resposta
+	// não é um JSON válido
+	ErrorInvalidJSON = "invalid_json"
+	// ErrorDecompression indica que o corpo informado como gzip não pôde
+	// ser descomprimido (ver CompressionGzip)
+	ErrorDecompression = "decompression"
+	// ErrorGraphQL indica que a resposta GraphQL trouxe um array "errors"
+	// não vazio (ver GraphQLError)
+	ErrorGraphQL = "graphql"
+	// ErrorBodyLimit indica uma resposta com corpo maior que
+	// StressTest.MaxBodyBytes, abandonada no limite
+	ErrorBodyLimit = "body_limit"
+)
+
+// maxErrorMessageLength limita o tamanho das mensagens usadas como categoria
+// para erros desconhecidos
+const maxErrorMessageLength = 80
+
+// classifyError retorna a categoria de um erro de transporte
+func classifyError(err error) string {
+	if category := quicErrorCategory(err); category != "" {
+		return category
+	}
+
+	switch {
+	case isTimeout(err):
+		return ErrorTimeout
+	case isProxyError(err):
+		return ErrorProxy
+	case isDNSError(err):
+		return ErrorDNS
+	case isTLSError(err):
+		return ErrorTLS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return ErrorConnectionReset
+	case errors.Is(err, errPortsExhausted):
+		return ErrorLocalPorts
+	case isConnectError(err):
+		return ErrorConnect
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorEOF
+	}
+
+	// O *url.Error repete método e URL em todas as mensagens, então apenas o
+	// erro interno é usado para o agrupamento
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	return truncateMessage(err.Error())
+}
+
+// isTimeout indica se o erro foi causado por algum timeout, seja do client,
+// do transporte ou da conexão
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isConnectError indica se o erro ocorreu ao estabelecer a conexão com o
+// servidor (ex.: esgotamento de portas efêmeras)
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isProxyError indica se o erro ocorreu na conexão com o proxy e não com o
+// servidor de destino
+func isProxyError(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	return opErr.Op == "proxyconnect" || strings.HasPrefix(opErr.Op, "socks")
+}
+
+// isDNSError indica se o erro ocorreu na resolução do nome do servidor
+func isDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// isTLSError indica se o erro ocorreu no handshake TLS ou na verificação do
+// certificado do servidor
+func isTLSError(err error) bool {
+	var (
+		verifyErr   *tls.CertificateVerificationError
+		recordErr   tls.RecordHeaderError
+		alertErr    tls.AlertError
+		unknownAuth x509.UnknownAuthorityError
+		hostnameErr x509.HostnameError
+		invalidCert x509.CertificateInvalidError
+	)
+	return errors.As(err, &verifyErr) ||
+		errors.As(err, &recordErr) ||
+		errors.As(err, &alertErr) ||
+		errors.As(err, &unknownAuth) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidCert)
+}
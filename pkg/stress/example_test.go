@@ -0,0 +1,47 @@
+package stress_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+func ExampleStressTest_Run() {
+	// Serviço de teste que responde 503 a cada quinta request
+	var served atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if served.Add(1)%5 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	test := stress.NewStressTest(server.URL, 20, 4)
+	// OnResult roda em uma única goroutine, sem precisar de sincronização
+	var results, unavailable int
+	test.OnResult = func(r stress.Result) {
+		results++
+		if r.StatusCode == http.StatusServiceUnavailable {
+			unavailable++
+		}
+	}
+	report, err := test.Run(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("requests: %d, sucesso: %d, falhas: %d\n", report.TotalRequests, report.SuccessfulRequests, report.FailedRequests)
+	fmt.Printf("status 200: %d, status 503: %d\n", report.StatusCodes[http.StatusOK], report.StatusCodes[http.StatusServiceUnavailable])
+	fmt.Printf("OnResult: %d resultados, %d com 503\n", results, unavailable)
+	// Output:
+	// requests: 20, sucesso: 16, falhas: 4
+	// status 200: 16, status 503: 4
+	// OnResult: 20 resultados, 4 com 503
+}
@@ -0,0 +1,198 @@
+package stress
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Extractor extrai um valor da resposta de um passo para a variável Var,
+// disponível nos passos seguintes como {{.Var}}. Exatamente uma das fontes
+// (JSONPath, Regex ou Header) deve ser definida.
+type Extractor struct {
+	Var string
+	// JSONPath é um caminho no corpo JSON, com os campos separados por
+	// pontos e índices de array entre colchetes (ex.: "$.data.items[0].id")
+	JSONPath string
+	// Regex é aplicada ao corpo; o valor é o primeiro grupo de captura ou,
+	// sem grupos, o trecho encontrado
+	Regex *regexp.Regexp
+	// Header é o nome de um header da resposta
+	Header string
+}
+
+// readsBody indica se a extração depende do corpo da resposta
+func (e Extractor) readsBody() bool {
+	return e.JSONPath != "" || e.Regex != nil
+}
+
+// needsBody indica se alguma das extrações depende do corpo da resposta
+func needsBody(extractors []Extractor) bool {
+	for _, e := range extractors {
+		if e.readsBody() {
+			return true
+		}
+	}
+	return false
+}
+
+// validExtractor verifica se o nome da variável pode ser usado em um
+// template e se exatamente uma fonte foi definida
+func validExtractor(e Extractor) error {
+	if !validVarName(e.Var) {
+		return fmt.Errorf("nome de variável inválido %q: use letras, dígitos e _", e.Var)
+	}
+	sources := 0
+	for _, set := range []bool{e.JSONPath != "", e.Regex != nil, e.Header != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("a variável %s deve ter exatamente uma fonte: json, regex ou header", e.Var)
+	}
+	if e.JSONPath != "" {
+		if _, err := splitJSONPath(e.JSONPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validVarName aceita os identificadores usados em {{.nome}}
+func validVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// extract aplica o extrator à resposta
+func (e Extractor) extract(header http.Header, body []byte) (string, error) {
+	switch {
+	case e.Header != "":
+		values := header.Values(e.Header)
+		if len(values) == 0 {
+			return "", fmt.Errorf("extração de %s: header %s ausente na resposta", e.Var, e.Header)
+		}
+		return values[0], nil
+	case e.Regex != nil:
+		match := e.Regex.FindSubmatch(body)
+		if match == nil {
+			return "", fmt.Errorf("extração de %s: a regex %q não encontrou o padrão no corpo", e.Var, e.Regex)
+		}
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+		return string(match[0]), nil
+	default:
+		value, err := extractJSONPath(body, e.JSONPath)
+		if err != nil {
+			return "", fmt.Errorf("extração de %s: %w", e.Var, err)
+		}
+		return value, nil
+	}
+}
+
+// splitJSONPath separa "$.data.items[0].id" em ["data", "items", "0", "id"]
+func splitJSONPath(path string) ([]string, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	trimmed = strings.ReplaceAll(strings.ReplaceAll(trimmed, "[", "."), "]", "")
+	if trimmed == "" {
+		return nil, fmt.Errorf("caminho JSON vazio: %q", path)
+	}
+	parts := strings.Split(trimmed, ".")
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("caminho JSON inválido: %q", path)
+		}
+	}
+	return parts, nil
+}
+
+// extractJSONPath percorre o corpo JSON até o valor do caminho
+func extractJSONPath(body []byte, path string) (string, error) {
+	document, err := decodeJSON(body)
+	if err != nil {
+		return "", err
+	}
+	value, err := lookupJSONPath(document, path)
+	if err != nil {
+		return "", err
+	}
+	if value == nil {
+		return "", fmt.Errorf("valor nulo em %s", path)
+	}
+	return formatJSONValue(value)
+}
+
+// decodeJSON decodifica o corpo preservando os números como aparecem no JSON
+func decodeJSON(body []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("corpo não é um JSON válido: %w", err)
+	}
+	return document, nil
+}
+
+// lookupJSONPath percorre o documento até o valor do caminho
+func lookupJSONPath(value any, path string) (any, error) {
+	parts, err := splitJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, part := range parts {
+		switch node := value.(type) {
+		case map[string]any:
+			var ok bool
+			if value, ok = node[part]; !ok {
+				return nil, fmt.Errorf("campo %q ausente em %s", part, path)
+			}
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("índice %q fora do array de %d itens em %s", part, len(node), path)
+			}
+			value = node[i]
+		default:
+			return nil, fmt.Errorf("%q não é um objeto ou array em %s", part, path)
+		}
+	}
+	return value, nil
+}
+
+// formatJSONValue converte um valor decodificado em texto. Strings são
+// usadas sem aspas; números, booleanos e null, como aparecem no JSON;
+// objetos e arrays, serializados.
+func formatJSONValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+}
@@ -0,0 +1,259 @@
+package stress
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Padrões de FindMax para os campos zerados
+const (
+	defaultFindMaxProbe     = 30 * time.Second
+	defaultFindMaxWarmup    = 5 * time.Second
+	defaultFindMaxPrecision = 0.05
+)
+
+// FindMax configura a busca de StressTest.FindMax pelo maior nível de carga,
+// em workers (Concurrency) ou chegadas por segundo (ArrivalRate), que
+// mantém os Thresholds do teste aprovados. Cada sonda é um teste de
+// Duration Probe precedido de Warmup; o nível dobra a partir de Start até a
+// primeira sonda reprovada, ou até Max, e a busca continua por bisseção
+// entre o maior nível aprovado e o menor reprovado.
+type FindMax struct {
+	// Rate busca a taxa de chegadas do modelo aberto em vez da concorrência
+	Rate  bool
+	Start int
+	// Max limita os níveis testados (0 = sem limite)
+	Max    int
+	Probe  time.Duration
+	Warmup time.Duration
+	// Precision encerra a bisseção quando o intervalo entre o maior nível
+	// aprovado e o menor reprovado fica abaixo dessa fração do reprovado
+	// (ex.: 0.05 para 5%), ou de um nível (padrão: 5%)
+	Precision float64
+	// OnProbe, quando definido, é chamado ao fim de cada sonda
+	OnProbe func(FindMaxProbe)
+}
+
+// ParseFindMax interpreta uma busca no formato
+// "target=rate,start=50,max=5000,probe=30s,warmup=5s,precision=5%", com
+// todas as chaves opcionais (target=concurrency é o padrão) e "rate" ou
+// "concurrency" sozinhos como atalhos de target
+func ParseFindMax(text string) (FindMax, error) {
+	search := FindMax{Start: 1}
+	for _, item := range strings.Split(text, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok && (key == "concurrency" || key == "rate") {
+			// "rate" sozinho equivale a target=rate
+			key, value, ok = "target", key, true
+		}
+		if !ok {
+			return FindMax{}, fmt.Errorf("item inválido %q: use chave=valor", item)
+		}
+		var err error
+		switch key = strings.TrimSpace(key); key {
+		case "target":
+			switch value {
+			case "concurrency":
+				search.Rate = false
+			case "rate":
+				search.Rate = true
+			default:
+				err = errors.New("alvo inválido")
+			}
+		case "start":
+			search.Start, err = strconv.Atoi(value)
+		case "max":
+			search.Max, err = strconv.Atoi(value)
+		case "probe":
+			search.Probe, err = time.ParseDuration(value)
+		case "warmup":
+			search.Warmup, err = time.ParseDuration(value)
+		case "precision":
+			search.Precision, err = parseThresholdValue(thresholdRate, value)
+		default:
+			return FindMax{}, fmt.Errorf("chave desconhecida %q: use target, start, max, probe, warmup ou precision", key)
+		}
+		if err != nil {
+			return FindMax{}, fmt.Errorf("valor inválido para %s: %q", key, value)
+		}
+	}
+	return search, validFindMax(search)
+}
+
+func validFindMax(f FindMax) error {
+	switch {
+	case f.Start < 1:
+		return errors.New("a busca requer start de ao menos 1")
+	case f.Max != 0 && f.Max < f.Start:
+		return errors.New("max deve ser zero (sem limite) ou ao menos start")
+	case f.Probe < 0 || f.Warmup < 0:
+		return errors.New("probe e warmup não podem ser negativos")
+	case f.Precision < 0 || f.Precision >= 1:
+		return errors.New("precision deve estar entre 0 e 100%")
+	}
+	return nil
+}
+
+// FindMaxProbe resume uma sonda de StressTest.FindMax
+type FindMaxProbe struct {
+	// Level é a concorrência ou a taxa de chegadas da sonda
+	Level             int
+	Requests          int
+	RequestsPerSecond float64
+	ErrorRate         float64
+	P95               time.Duration
+	P99               time.Duration
+	Passed            bool
+	// Violated descreve os Thresholds reprovados, como em Threshold.String
+	Violated []string
+}
+
+// FindMaxReport é o resultado de StressTest.FindMax, no Report da sonda do
+// maior nível aprovado
+type FindMaxReport struct {
+	Rate bool
+	// Level é o maior nível aprovado; Found é false quando nenhuma sonda foi
+	// aprovada, e o Report é o da sonda do menor nível
+	Level int
+	Found bool
+	// Limited indica que a busca parou em FindMax.Max sem nenhuma reprovação
+	Limited bool
+	Probes  []FindMaxProbe
+}
+
+// FindMax executa as sondas da busca e retorna o Report da sonda do maior
+// nível aprovado, com a busca em Report.FindMax. Com ctx cancelado, a busca
+// termina com as sondas concluídas até ali, e o Report é marcado como
+// Interrupted; a sonda interrompida não entra no histórico.
+func (st *StressTest) FindMax(ctx context.Context, search FindMax) (*Report, error) {
+	switch {
+	case len(st.Thresholds) == 0:
+		return nil, errors.New("FindMax requer Thresholds, os limites que cada sonda deve respeitar")
+	case st.Requests > 0 || st.Duration > 0 || len(st.loadStages()) > 0 || st.Soak:
+		return nil, errors.New("FindMax define a duração de cada sonda: Requests, Duration, Stages, Spike e Soak não se aplicam")
+	case st.WarmupRequests > 0 || st.WarmupDuration > 0:
+		return nil, errors.New("FindMax define o aquecimento de cada sonda em FindMax.Warmup")
+	}
+	if err := validFindMax(search); err != nil {
+		return nil, err
+	}
+	search.Probe = cmp.Or(search.Probe, defaultFindMaxProbe)
+	search.Warmup = cmp.Or(search.Warmup, defaultFindMaxWarmup)
+	search.Precision = cmp.Or(search.Precision, defaultFindMaxPrecision)
+
+	result := &FindMaxReport{Rate: search.Rate}
+	// passed e failed são os relatórios do maior nível aprovado e do menor
+	// reprovado, e interrupted o da sonda interrompida
+	var passed, failed, interrupted *Report
+	// probe executa uma sonda e indica se ela foi aprovada; a busca termina
+	// quando interrupted é definido
+	probe := func(level int) (bool, error) {
+		report, err := st.probe(ctx, search, level)
+		if err != nil {
+			return false, err
+		}
+		if report.Interrupted {
+			interrupted = report
+			return false, nil
+		}
+		summary := newFindMaxProbe(level, report)
+		result.Probes = append(result.Probes, summary)
+		if search.OnProbe != nil {
+			search.OnProbe(summary)
+		}
+		if summary.Passed {
+			passed = report
+		} else {
+			failed = report
+		}
+		return summary.Passed, nil
+	}
+
+	// Dobra o nível até a primeira reprovação
+	level := search.Start
+	for {
+		ok, err := probe(level)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		result.Level = level
+		if search.Max > 0 && level >= search.Max {
+			result.Limited = true
+			break
+		}
+		level *= 2
+		if search.Max > 0 {
+			level = min(level, search.Max)
+		}
+	}
+	// Bisseção entre o maior nível aprovado (0 sem nenhum) e o menor
+	// reprovado
+	low, high := result.Level, level
+	for interrupted == nil && failed != nil && high-low > max(1, int(search.Precision*float64(high))) {
+		mid := (low + high) / 2
+		ok, err := probe(mid)
+		switch {
+		case err != nil:
+			return nil, err
+		case interrupted != nil:
+		case ok:
+			low = mid
+		default:
+			high = mid
+		}
+	}
+	result.Level = low
+	result.Found = passed != nil
+
+	report := cmp.Or(passed, failed, interrupted)
+	if interrupted != nil {
+		report.Interrupted, report.InterruptCause = true, interrupted.InterruptCause
+	}
+	report.FindMax = result
+	return report, nil
+}
+
+// probe executa a sonda de um nível da busca sobre uma cópia do teste
+func (st *StressTest) probe(ctx context.Context, search FindMax, level int) (*Report, error) {
+	probe := *st
+	probe.Duration = search.Probe
+	probe.WarmupDuration = search.Warmup
+	if search.Rate {
+		probe.ArrivalRate = float64(level)
+		if probe.MaxInFlight == 0 {
+			probe.MaxInFlight = level
+		}
+	} else {
+		probe.Concurrency = level
+	}
+	return probe.Run(ctx)
+}
+
+// newFindMaxProbe resume o Report de uma sonda
+func newFindMaxProbe(level int, report *Report) FindMaxProbe {
+	probe := FindMaxProbe{
+		Level:             level,
+		Requests:          report.TotalRequests,
+		RequestsPerSecond: report.RequestsPerSecond,
+		P95:               report.P95,
+		P99:               report.P99,
+		Passed:            report.ThresholdsPassed(),
+	}
+	if report.TotalRequests > 0 {
+		probe.ErrorRate = float64(report.FailedRequests) / float64(report.TotalRequests)
+	}
+	for _, threshold := range report.Thresholds {
+		if !threshold.Passed {
+			probe.Violated = append(probe.Violated, threshold.Threshold.String())
+		}
+	}
+	return probe
+}
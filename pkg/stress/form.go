@@ -0,0 +1,74 @@
+package stress
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// formContentType é o Content-Type dos corpos de StressTest.Form
+const formContentType = "application/x-www-form-urlencoded"
+
+// formBody monta o corpo url-encoded de StressTest.Form. Sem dados o corpo
+// é codificado uma única vez; com dados, cada valor é um template preenchido
+// a cada request e codificado depois, para que os valores das colunas também
+// sejam escapados.
+type formBody struct {
+	fields    []FormField
+	encoded   []byte
+	templates []*template.Template
+}
+
+// newFormBody valida os templates dos valores com sample, como
+// newRequestTemplates
+func newFormBody(sample map[string]string, fields []FormField) (*formBody, error) {
+	f := &formBody{fields: fields}
+	if sample == nil {
+		f.encoded = []byte(encodeForm(fields))
+		return f, nil
+	}
+	for _, field := range fields {
+		tmpl, err := parseTemplate(sample, "campo "+field.Name, field.Value)
+		if err != nil {
+			return nil, err
+		}
+		f.templates = append(f.templates, tmpl)
+	}
+	return f, nil
+}
+
+// reader retorna o corpo da request preenchido com a linha de dados
+func (f *formBody) reader(row map[string]string) (*bytes.Reader, error) {
+	if f.templates == nil {
+		return bytes.NewReader(f.encoded), nil
+	}
+	fields := make([]FormField, len(f.fields))
+	for i, field := range f.fields {
+		value, err := render(f.templates[i], row)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = FormField{Name: field.Name, Value: value}
+	}
+	return bytes.NewReader([]byte(encodeForm(fields))), nil
+}
+
+// encodeForm codifica os campos na ordem informada, ao contrário de
+// url.Values.Encode, que os ordena pelo nome
+func encodeForm(fields []FormField) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = url.QueryEscape(field.Name) + "=" + url.QueryEscape(field.Value)
+	}
+	return strings.Join(parts, "&")
+}
+
+func validForm(fields []FormField) bool {
+	for _, field := range fields {
+		if field.Name == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,24 @@
+package stress
+
+import "sync/atomic"
+
+// Gauges expõe o estado instantâneo dos workers durante o teste, para
+// métricas lidas de outras goroutines (ex.: um endpoint do Prometheus). Os
+// valores são atualizados por Run quando StressTest.Gauges está definido.
+type Gauges struct {
+	inFlight      atomic.Int64
+	activeWorkers atomic.Int64
+}
+
+// InFlight retorna as unidades de trabalho em execução: requests ou, com
+// Scenario, WebSocket ou SSE, iterações de cenário e conexões abertas. Os
+// workers aguardando o limite de RPS ou o ThinkTime não são contados.
+func (g *Gauges) InFlight() int64 {
+	return g.inFlight.Load()
+}
+
+// ActiveWorkers retorna os workers já iniciados (após o atraso do RampUp)
+// que ainda não terminaram
+func (g *Gauges) ActiveWorkers() int64 {
+	return g.activeWorkers.Load()
+}
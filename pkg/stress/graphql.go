@@ -0,0 +1,171 @@
+package stress
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// GraphQL descreve as requests a um endpoint GraphQL: o corpo
+// {"query": ..., "variables": ...} é montado a partir dos campos e enviado
+// via POST com Content-Type application/json. Respostas com status esperado
+// cujo corpo traz um array "errors" não vazio contam como falha, na
+// categoria ErrorGraphQL, já que servidores GraphQL costumam responder 200
+// mesmo quando a operação falha.
+type GraphQL struct {
+	Query string
+	// Variables, quando definido, é o objeto JSON das variáveis, que aceita
+	// os mesmos templates de StressTest.Body
+	Variables string
+	// OperationName escolhe a operação quando Query define várias
+	OperationName string
+}
+
+// GraphQLError reúne as mensagens do array "errors" de uma resposta GraphQL
+type GraphQLError struct {
+	Messages []string
+}
+
+func (e *GraphQLError) Error() string {
+	if len(e.Messages) == 1 {
+		return "a resposta GraphQL trouxe um erro: " + e.Messages[0]
+	}
+	return fmt.Sprintf("a resposta GraphQL trouxe %d erros: %s", len(e.Messages), strings.Join(e.Messages, "; "))
+}
+
+// graphqlContentType é o Content-Type dos corpos de StressTest.GraphQL
+const graphqlContentType = "application/json"
+
+// graphqlBody monta o corpo das requests GraphQL. A query é codificada uma
+// única vez; com dados, as variáveis são um template preenchido a cada
+// request.
+type graphqlBody struct {
+	// prefix vai até a chave "variables", inclusive, e encoded é o corpo
+	// completo quando não há templates
+	prefix    []byte
+	encoded   []byte
+	variables *template.Template
+}
+
+// newGraphQLBody valida as variáveis como JSON, preenchidas com sample
+// quando há dados
+func newGraphQLBody(sample map[string]string, g *GraphQL) (*graphqlBody, error) {
+	var head bytes.Buffer
+	head.WriteString(`{"query":`)
+	head.Write(encodeJSONString(g.Query))
+	if g.OperationName != "" {
+		head.WriteString(`,"operationName":`)
+		head.Write(encodeJSONString(g.OperationName))
+	}
+	if g.Variables == "" {
+		head.WriteString("}")
+		return &graphqlBody{encoded: head.Bytes()}, nil
+	}
+	head.WriteString(`,"variables":`)
+
+	b := &graphqlBody{prefix: head.Bytes()}
+	variables := g.Variables
+	if sample != nil {
+		tmpl, err := parseTemplate(sample, "variáveis GraphQL", g.Variables)
+		if err != nil {
+			return nil, err
+		}
+		b.variables = tmpl
+		if variables, err = render(tmpl, sample); err != nil {
+			return nil, err
+		}
+	}
+	if !json.Valid([]byte(variables)) {
+		return nil, errors.New("as variáveis GraphQL não são um JSON válido")
+	}
+	if b.variables == nil {
+		b.encoded = append(bytes.Clone(b.prefix), variables+"}"...)
+	}
+	return b, nil
+}
+
+// reader retorna o corpo da request preenchido com a linha de dados
+func (b *graphqlBody) reader(row map[string]string) (*bytes.Reader, error) {
+	if b.variables == nil {
+		return bytes.NewReader(b.encoded), nil
+	}
+	variables, err := render(b.variables, row)
+	if err != nil {
+		return nil, err
+	}
+	body := append(bytes.Clone(b.prefix), variables+"}"...)
+	return bytes.NewReader(body), nil
+}
+
+// encodeJSONString codifica s como uma string JSON, sem escapar <, > e &
+func encodeJSONString(s string) []byte {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	// Strings sempre podem ser codificadas
+	_ = encoder.Encode(s)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// checkGraphQLErrors procura um array "errors" não vazio no objeto de
+// resposta, sem decodificar o campo "data". Quando o corpo guardado foi
+// cortado por MaxCapturedBody antes do campo aparecer, a resposta não é
+// considerada falha.
+func checkGraphQLErrors(body []byte, truncated bool) (string, error) {
+	invalid := func() (string, error) {
+		if truncated {
+			return "", nil
+		}
+		return ErrorInvalidJSON, errors.New("a resposta GraphQL não é um objeto JSON válido")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return invalid()
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return invalid()
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return invalid()
+		}
+		if token != "errors" {
+			continue
+		}
+		if messages := graphqlErrorMessages(value); len(messages) > 0 {
+			return ErrorGraphQL, &GraphQLError{Messages: messages}
+		}
+	}
+	return "", nil
+}
+
+// graphqlErrorMessages lê as mensagens do campo "errors". Um valor fora do
+// formato da especificação (um array de objetos com "message") também conta
+// como erro, usando o próprio JSON como mensagem.
+func graphqlErrorMessages(value json.RawMessage) []string {
+	var errs []struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(value, &errs); err != nil {
+		return []string{truncateMessage(string(value))}
+	}
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = truncateMessage(e.Message)
+	}
+	return messages
+}
+
+// truncateMessage limita o tamanho das mensagens usadas como chave no
+// relatório, como em classifyError
+func truncateMessage(message string) string {
+	if len(message) > maxErrorMessageLength {
+		return message[:maxErrorMessageLength] + "..."
+	}
+	return message
+}
@@ -0,0 +1,351 @@
+package stress
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GRPCCall descreve as chamadas unárias do modo gRPC, que substitui as
+// requests HTTP: os workers dividem os canais de Conns em rodízio, e o corpo de cada
+// chamada é StressTest.Body em JSON (com os mesmos templates), convertido
+// na mensagem de entrada do método. StressTest.Header, BasicAuth e
+// BearerToken são enviados como metadata, assim como ClientIDHeader. O código de status de cada
+// chamada fica em Result.GRPCCode e Report.GRPCCodes; apenas OK conta como
+// sucesso.
+type GRPCCall struct {
+	// Conns são os canais usados pelos workers; cada canal multiplexa as
+	// chamadas em uma única conexão HTTP/2
+	Conns  []*grpc.ClientConn
+	Method protoreflect.MethodDescriptor
+	// Timeout é o deadline de cada chamada (0 = sem limite)
+	Timeout time.Duration
+}
+
+// fullMethod retorna o nome no formato usado na invocação ("/pkg.Svc/Método")
+func (c *GRPCCall) fullMethod() string {
+	return "/" + string(c.Method.Parent().FullName()) + "/" + string(c.Method.Name())
+}
+
+// label identifica o método no relatório ("pkg.Svc/Método")
+func (c *GRPCCall) label() string {
+	return strings.TrimPrefix(c.fullMethod(), "/")
+}
+
+// GRPCDialConfig define como DialGRPC conecta ao servidor e encontra o método
+type GRPCDialConfig struct {
+	// Target é o endereço do servidor ("host:porta")
+	Target string
+	// Method é o nome completo do método (ver ParseGRPCMethod)
+	Method string
+	// Protoset, quando definido, é o conjunto de descritores usado no lugar
+	// da reflection do servidor (ver LoadProtoset)
+	Protoset string
+	// Plaintext desativa o TLS; caso contrário é usado TLS, com a
+	// configuração opcional TLS
+	Plaintext bool
+	TLS       *tls.Config
+	// Connections é a quantidade de canais abertos (0 = 1)
+	Connections int
+	Timeout     time.Duration
+}
+
+// DialGRPC abre os canais e resolve o método pela reflection do servidor ou
+// pelo protoset. Os canais conectam sob demanda, então um servidor
+// inacessível só é detectado aqui quando a reflection é usada.
+func DialGRPC(ctx context.Context, cfg GRPCDialConfig) (*GRPCCall, error) {
+	service, method, err := ParseGRPCMethod(cfg.Method)
+	if err != nil {
+		return nil, err
+	}
+	creds := credentials.NewTLS(cfg.TLS)
+	if cfg.Plaintext {
+		creds = insecure.NewCredentials()
+	}
+	n := max(cfg.Connections, 1)
+	call := &GRPCCall{Timeout: cfg.Timeout}
+	for range n {
+		conn, err := grpc.NewClient(cfg.Target, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			call.Close()
+			return nil, err
+		}
+		call.Conns = append(call.Conns, conn)
+	}
+
+	var files *protoregistry.Files
+	if cfg.Protoset != "" {
+		files, err = LoadProtoset(cfg.Protoset)
+	} else {
+		files, err = ReflectGRPCFiles(ctx, call.Conns[0], service)
+	}
+	if err == nil {
+		call.Method, err = FindGRPCMethod(files, service, method)
+	}
+	if err != nil {
+		call.Close()
+		return nil, err
+	}
+	return call, nil
+}
+
+// Close fecha os canais
+func (c *GRPCCall) Close() error {
+	var errs []error
+	for _, conn := range c.Conns {
+		errs = append(errs, conn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// ParseGRPCMethod separa "pkg.Servico/Metodo" ou "pkg.Servico.Metodo" no
+// nome completo do serviço e no nome do método
+func ParseGRPCMethod(name string) (service, method string, err error) {
+	name = strings.TrimPrefix(name, "/")
+	sep := strings.LastIndexAny(name, "/.")
+	if sep <= 0 || sep == len(name)-1 {
+		return "", "", fmt.Errorf("use o formato \"pacote.Servico/Metodo\": %q", name)
+	}
+	return name[:sep], name[sep+1:], nil
+}
+
+// LoadProtoset lê um conjunto de descritores gerado com
+// "protoc --include_imports --descriptor_set_out=arquivo.protoset"
+func LoadProtoset(path string) (*protoregistry.Files, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("protoset inválido: %w", err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("protoset inválido: %w", err)
+	}
+	return files, nil
+}
+
+// ReflectGRPCFiles obtém pela reflection do servidor (grpc.reflection.v1)
+// os descritores do serviço e de todas as suas dependências
+func ReflectGRPCFiles(ctx context.Context, conn *grpc.ClientConn, service string) (*protoregistry.Files, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reflection indisponível: %w", err)
+	}
+	defer stream.CloseSend()
+
+	protos := make(map[string]*descriptorpb.FileDescriptorProto)
+	fetch := func(req *reflectionpb.ServerReflectionRequest) error {
+		if err := stream.Send(req); err != nil {
+			return fmt.Errorf("reflection indisponível: %w", err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("reflection indisponível: %w", err)
+		}
+		if errResp := resp.GetErrorResponse(); errResp != nil {
+			return fmt.Errorf("reflection: %s", errResp.GetErrorMessage())
+		}
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			file := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, file); err != nil {
+				return fmt.Errorf("reflection: descritor inválido: %w", err)
+			}
+			protos[file.GetName()] = file
+		}
+		return nil
+	}
+	if err := fetch(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	}); err != nil {
+		return nil, fmt.Errorf("serviço %s: %w", service, err)
+	}
+	// O servidor costuma enviar as dependências junto, mas não é obrigado a
+	for {
+		var missing []string
+		for _, file := range protos {
+			for _, dep := range file.GetDependency() {
+				if protos[dep] == nil {
+					missing = append(missing, dep)
+				}
+			}
+		}
+		if len(missing) == 0 {
+			break
+		}
+		for _, name := range missing {
+			if protos[name] != nil {
+				continue
+			}
+			if err := fetch(&reflectionpb.ServerReflectionRequest{
+				MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			}); err != nil {
+				return nil, err
+			}
+			if protos[name] == nil {
+				return nil, fmt.Errorf("reflection: o servidor não enviou %s", name)
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range protos {
+		set.File = append(set.File, file)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("reflection: %w", err)
+	}
+	return files, nil
+}
+
+// FindGRPCMethod procura um método unário nos descritores
+func FindGRPCMethod(files *protoregistry.Files, service, method string) (protoreflect.MethodDescriptor, error) {
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("serviço %s não encontrado", service)
+	}
+	svc, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s não é um serviço", service)
+	}
+	md := svc.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, fmt.Errorf("método %s não encontrado em %s", method, service)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("o método %s/%s usa streaming; apenas chamadas unárias são suportadas", service, method)
+	}
+	return md, nil
+}
+
+// grpcRequest monta a mensagem de entrada do método a partir do corpo JSON,
+// preenchido com a linha de dados
+func (st *StressTest) grpcRequest(state *runState, row map[string]string) (*dynamicpb.Message, error) {
+	body, err := st.renderBody(state, row)
+	if err != nil {
+		return nil, err
+	}
+	input := st.GRPC.Method.Input()
+	msg := dynamicpb.NewMessage(input)
+	if len(bytes.TrimSpace(body)) == 0 {
+		return msg, nil
+	}
+	if err := protojson.Unmarshal(body, msg); err != nil {
+		return nil, fmt.Errorf("corpo inválido para %s: %w", input.FullName(), err)
+	}
+	return msg, nil
+}
+
+// executeGRPC realiza uma chamada unária e mede sua duração
+func (st *StressTest) executeGRPC(ctx context.Context, workerID int, state *runState, row map[string]string) Result {
+	call := st.GRPC
+	result := Result{WorkerID: workerID, Timestamp: time.Now(), Target: call.label()}
+
+	req, err := st.grpcRequest(state, row)
+	if err != nil {
+		result.Error = err
+		result.ErrorCategory = ErrorInvalidJSON
+		return result
+	}
+	header := st.Header
+	if state.templates != nil {
+		if header, err = state.templates.headers(row); err != nil {
+			result.Error = err
+			return result
+		}
+	}
+	resp := dynamicpb.NewMessage(call.Method.Output())
+
+	callCtx := ctx
+	if call.Timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, call.Timeout)
+		defer cancel()
+	}
+	md := make(metadata.MD, len(header))
+	for name, values := range header {
+		md.Append(name, values...)
+	}
+	if st.BasicAuth != nil {
+		credentials := base64.StdEncoding.EncodeToString([]byte(st.BasicAuth.Username + ":" + st.BasicAuth.Password))
+		md.Set("authorization", "Basic "+credentials)
+	}
+	if st.BearerToken != nil {
+		md.Set("authorization", "Bearer "+st.BearerToken.Token())
+	}
+	if st.ClientIDHeader != "" {
+		md.Set(st.ClientIDHeader, WorkerName(workerID))
+	}
+	if len(md) > 0 {
+		callCtx = metadata.NewOutgoingContext(callCtx, md)
+	}
+
+	result.BytesSent = int64(proto.Size(req))
+	start := time.Now()
+	err = call.Conns[workerID%len(call.Conns)].Invoke(callCtx, call.fullMethod(), req, resp)
+	result.Duration = time.Since(start)
+	// Em chamadas unárias a resposta chega de uma vez
+	result.TTFB = result.Duration
+	code := status.Code(err)
+	result.GRPCCode = code.String()
+	if err != nil {
+		result.Error = err
+		result.ErrorCategory = grpcErrorCategory(code)
+		// Chamadas interrompidas pelo próprio teste não são falhas do serviço
+		result.Canceled = ctx.Err() != nil
+		return result
+	}
+	result.BytesRead = int64(proto.Size(resp))
+	return result
+}
+
+// grpcErrorCategory converte o código em uma categoria como
+// "grpc_deadline_exceeded"
+func grpcErrorCategory(code codes.Code) string {
+	var b strings.Builder
+	b.WriteString("grpc_")
+	for i, c := range code.String() {
+		if unicode.IsUpper(c) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(c))
+	}
+	return b.String()
+}
+
+// validGRPCCall verifica se o canal e o método foram definidos
+func validGRPCCall(call *GRPCCall) error {
+	switch {
+	case len(call.Conns) == 0 || slices.Contains(call.Conns, nil) || call.Method == nil:
+		return errors.New("GRPC requer Conns e Method")
+	case call.Timeout < 0:
+		return errors.New("o Timeout de GRPC não pode ser negativo")
+	}
+	return nil
+}
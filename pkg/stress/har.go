@@ -0,0 +1,180 @@
+package stress
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HAROptions controla quais entradas e headers de um HAR viram alvos
+type HAROptions struct {
+	// Hosts restringe os alvos às entradas desses hosts, com ou sem a porta
+	// (ex.: "api.exemplo.com" ou "localhost:8080"); vazio aceita todos
+	Hosts []string
+	// Cookies mantém o header Cookie gravado pelo navegador
+	Cookies bool
+	// Auth mantém os headers Authorization e Proxy-Authorization
+	Auth bool
+}
+
+// harSkippedHeaders são os headers gravados que não são reenviados: o
+// net/http define Host, Content-Length e os de conexão a partir da própria
+// request, e Accept-Encoding fica a cargo de StressTest.Compression
+var harSkippedHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Connection":        true,
+	"Keep-Alive":        true,
+	"Proxy-Connection":  true,
+	"Transfer-Encoding": true,
+	"Te":                true,
+	"Upgrade":           true,
+	"Accept-Encoding":   true,
+}
+
+type harFile struct {
+	Log *struct {
+		Entries []json.RawMessage `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request *struct {
+		Method   string         `json:"method"`
+		URL      string         `json:"url"`
+		Headers  []harNameValue `json:"headers"`
+		PostData *struct {
+			MimeType string         `json:"mimeType"`
+			Text     string         `json:"text"`
+			Params   []harNameValue `json:"params"`
+		} `json:"postData"`
+	} `json:"request"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ParseHAR lê um arquivo HAR (HTTP Archive, exportado pelas ferramentas de
+// desenvolvedor dos navegadores) e retorna um alvo por entrada, com o
+// método, a URL, os headers e o corpo gravados, na ordem da sessão. Entradas
+// que não são http:// ou https:// (data:, blob:, ws:) são ignoradas. Os
+// erros indicam a entrada com problema pela posição em log.entries.
+func ParseHAR(r io.Reader, opts HAROptions) ([]Target, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var file harFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) {
+			return nil, fmt.Errorf("JSON inválido na linha %d: %v", 1+bytes.Count(data[:syntax.Offset], []byte("\n")), err)
+		}
+		return nil, fmt.Errorf("HAR inválido: %v", err)
+	}
+	if file.Log == nil {
+		return nil, errors.New("HAR inválido: falta o objeto log")
+	}
+
+	hosts := make(map[string]bool, len(opts.Hosts))
+	for _, host := range opts.Hosts {
+		hosts[strings.ToLower(host)] = true
+	}
+	var targets []Target
+	for i, raw := range file.Log.Entries {
+		target, ok, err := harTarget(raw, opts, hosts)
+		if err != nil {
+			return nil, fmt.Errorf("log.entries[%d]: %w", i, err)
+		}
+		if ok {
+			targets = append(targets, target)
+		}
+	}
+	switch {
+	case len(targets) > 0:
+		return targets, nil
+	case len(hosts) > 0:
+		return nil, fmt.Errorf("nenhuma das %d entradas é dos hosts %s", len(file.Log.Entries), strings.Join(opts.Hosts, ", "))
+	}
+	return nil, errors.New("o HAR não tem entradas http:// ou https://")
+}
+
+// harTarget converte uma entrada; ok é falso quando ela fica de fora pelo
+// esquema da URL ou pelo filtro de hosts
+func harTarget(raw json.RawMessage, opts HAROptions, hosts map[string]bool) (target Target, ok bool, err error) {
+	var entry harEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return Target{}, false, err
+	}
+	req := entry.Request
+	if req == nil {
+		return Target{}, false, errors.New("falta o objeto request")
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return Target{}, false, fmt.Errorf("URL inválida: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Target{}, false, nil
+	}
+	if u.Host == "" {
+		return Target{}, false, fmt.Errorf("%q não é uma URL absoluta", req.URL)
+	}
+	if len(hosts) > 0 && !hosts[strings.ToLower(u.Host)] && !hosts[strings.ToLower(u.Hostname())] {
+		return Target{}, false, nil
+	}
+	method := strings.ToUpper(req.Method)
+	if !ValidMethod(method) {
+		return Target{}, false, fmt.Errorf("método HTTP inválido: %q", req.Method)
+	}
+	// O fragmento nunca é enviado ao servidor e apenas separaria alvos
+	// iguais no relatório
+	u.Fragment = ""
+	target = Target{Method: method, URL: u.String(), Header: make(http.Header)}
+
+	for _, h := range req.Headers {
+		name := http.CanonicalHeaderKey(h.Name)
+		switch {
+		// Pseudo-headers do HTTP/2, como :authority e :path
+		case strings.HasPrefix(h.Name, ":"), harSkippedHeaders[name]:
+		case name == "Cookie" && !opts.Cookies:
+		case (name == "Authorization" || name == "Proxy-Authorization") && !opts.Auth:
+		default:
+			target.Header.Add(name, h.Value)
+		}
+	}
+
+	if post := req.PostData; post != nil {
+		switch {
+		case post.Text != "":
+			target.Body = []byte(post.Text)
+		case len(post.Params) > 0:
+			// Alguns navegadores gravam formulários apenas como params
+			if !strings.HasPrefix(post.MimeType, formContentType) {
+				return Target{}, false, fmt.Errorf("corpo %s gravado sem o texto não é suportado", post.MimeType)
+			}
+			var form strings.Builder
+			for i, param := range post.Params {
+				if i > 0 {
+					form.WriteByte('&')
+				}
+				form.WriteString(url.QueryEscape(param.Name) + "=" + url.QueryEscape(param.Value))
+			}
+			target.Body = []byte(form.String())
+		}
+		if target.Body != nil && post.MimeType != "" && target.Header.Get("Content-Type") == "" {
+			target.Header.Set("Content-Type", post.MimeType)
+		}
+	}
+	if target.Body != nil && method == http.MethodHead {
+		return Target{}, false, errors.New("requests HEAD não enviam corpo")
+	}
+	return target, true, nil
+}
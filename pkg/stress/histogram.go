@@ -0,0 +1,279 @@
+package stress
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Histogram registra durações em buckets log-lineares no estilo do
+// HdrHistogram. A memória ocupada é fixa e depende apenas da faixa de valores
+// e da precisão configuradas, não da quantidade de amostras registradas.
+type Histogram struct {
+	lowest  int64
+	highest int64
+	sigFigs int
+
+	unitMagnitude               int
+	subBucketHalfCountMagnitude int
+	subBucketCount              int
+	subBucketHalfCount          int
+	subBucketMask               int64
+
+	counts []int64
+	total  int64
+	min    int64
+	max    int64
+	// clamped conta as amostras acima de highest, registradas como highest
+	clamped int64
+}
+
+const (
+	// histogramLowest é o menor valor distinguível (1µs, em nanossegundos)
+	histogramLowest = int64(time.Microsecond)
+	// histogramHighest é o maior valor registrável (1h, em nanossegundos)
+	histogramHighest = int64(time.Hour)
+	// histogramSigFigs é a quantidade padrão de dígitos significativos
+	// preservados
+	histogramSigFigs = 3
+	// maxHistogramSigFigs limita a precisão, já que a memória ocupada cresce
+	// 10x a cada dígito
+	maxHistogramSigFigs = 5
+)
+
+// NewHistogram cria um histograma capaz de registrar valores entre lowest e
+// highest mantendo sigFigs dígitos significativos de precisão
+func NewHistogram(lowest, highest int64, sigFigs int) *Histogram {
+	largestSingleUnit := 2 * int64(math.Pow10(sigFigs))
+	subBucketCountMagnitude := int(math.Ceil(math.Log2(float64(largestSingleUnit))))
+	subBucketHalfCountMagnitude := subBucketCountMagnitude - 1
+	if subBucketHalfCountMagnitude < 0 {
+		subBucketHalfCountMagnitude = 0
+	}
+
+	h := &Histogram{
+		lowest:                      lowest,
+		highest:                     highest,
+		sigFigs:                     sigFigs,
+		unitMagnitude:               int(math.Floor(math.Log2(float64(lowest)))),
+		subBucketHalfCountMagnitude: subBucketHalfCountMagnitude,
+		subBucketCount:              1 << (subBucketHalfCountMagnitude + 1),
+		min:                         math.MaxInt64,
+	}
+	h.subBucketHalfCount = h.subBucketCount / 2
+	h.subBucketMask = int64(h.subBucketCount-1) << h.unitMagnitude
+
+	// Calcula quantos buckets são necessários para cobrir o valor máximo
+	smallestUntrackable := int64(h.subBucketCount) << h.unitMagnitude
+	bucketCount := 1
+	for smallestUntrackable <= highest {
+		if smallestUntrackable > math.MaxInt64/2 {
+			bucketCount++
+			break
+		}
+		smallestUntrackable <<= 1
+		bucketCount++
+	}
+
+	h.counts = make([]int64, (bucketCount+1)*h.subBucketHalfCount)
+	return h
+}
+
+// newDurationHistogram cria o histograma usado para latências. Zero em
+// highest ou sigFigs usa os valores padrão (1h e 3 dígitos).
+func newDurationHistogram(highest time.Duration, sigFigs int) *Histogram {
+	if highest <= 0 {
+		highest = time.Duration(histogramHighest)
+	}
+	if sigFigs <= 0 {
+		sigFigs = histogramSigFigs
+	}
+	return NewHistogram(histogramLowest, int64(highest), sigFigs)
+}
+
+// Record registra uma duração no histograma. Valores fora da faixa são
+// ajustados para os limites suportados; os acima do máximo são contados em
+// Clamped.
+func (h *Histogram) Record(d time.Duration) {
+	h.RecordN(d, 1)
+}
+
+// RecordN registra n amostras com a mesma duração, como Record
+func (h *Histogram) RecordN(d time.Duration, n int64) {
+	if n <= 0 {
+		return
+	}
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+	if v > h.highest {
+		v = h.highest
+		h.clamped += n
+	}
+
+	h.counts[h.countsIndexFor(v)] += n
+	h.total += n
+	if v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+}
+
+// TotalCount retorna a quantidade de amostras registradas
+func (h *Histogram) TotalCount() int64 {
+	return h.total
+}
+
+// Clamped retorna quantas amostras estavam acima do valor máximo do
+// histograma e foram registradas como o máximo
+func (h *Histogram) Clamped() int64 {
+	return h.clamped
+}
+
+// Highest retorna o maior valor registrável sem ajuste
+func (h *Histogram) Highest() time.Duration {
+	return time.Duration(h.highest)
+}
+
+// SigFigs retorna a quantidade de dígitos significativos preservados
+func (h *Histogram) SigFigs() int {
+	return h.sigFigs
+}
+
+// ValueAtQuantile retorna o valor abaixo do qual se encontram p% das
+// amostras (p entre 0 e 100, ex.: 99.9), usando o método nearest-rank. O
+// resultado é limitado ao mínimo e máximo observados, o que mantém o cálculo
+// exato para poucas amostras.
+func (h *Histogram) ValueAtQuantile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	if p > 100 {
+		p = 100
+	}
+
+	target := int64(math.Ceil(p / 100 * float64(h.total)))
+	if target < 1 {
+		target = 1
+	}
+
+	var seen int64
+	for i, count := range h.counts {
+		seen += count
+		if seen >= target {
+			v := h.highestEquivalentValue(h.valueFromIndex(i))
+			if v > h.max {
+				v = h.max
+			}
+			if v < h.min {
+				v = h.min
+			}
+			return time.Duration(v)
+		}
+	}
+	return time.Duration(h.max)
+}
+
+// Percentile retorna o valor abaixo do qual se encontram p% das amostras,
+// como ValueAtQuantile.
+//
+// Deprecated: use ValueAtQuantile.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	return h.ValueAtQuantile(p)
+}
+
+// HistogramBucket é uma posição do histograma com amostras: a menor duração
+// representada pela posição e a quantidade de amostras
+type HistogramBucket struct {
+	Value time.Duration
+	Count int64
+}
+
+// Buckets retorna as posições com amostras, em ordem crescente, para que o
+// histograma seja gravado e reconstruído com RecordN. Os valores são
+// limitados ao mínimo e ao máximo observados, que assim se preservam.
+func (h *Histogram) Buckets() []HistogramBucket {
+	var buckets []HistogramBucket
+	h.forEach(func(value, count int64) {
+		buckets = append(buckets, HistogramBucket{Value: time.Duration(value), Count: count})
+	})
+	return buckets
+}
+
+// Merge soma ao histograma as amostras de other. Com faixas e precisões
+// iguais, o resultado é o mesmo de registrar todas as amostras em um único
+// histograma; do contrário, cada posição de other é registrada pelo seu
+// valor, com a precisão do menos preciso dos dois.
+func (h *Histogram) Merge(other *Histogram) {
+	for _, bucket := range other.Buckets() {
+		h.RecordN(bucket.Value, bucket.Count)
+	}
+	// As amostras já ajustadas em other continuam contadas como ajustadas
+	if other.highest <= h.highest {
+		h.clamped += other.clamped
+	}
+}
+
+// emptyCopy cria um histograma vazio com a mesma faixa e precisão
+func (h *Histogram) emptyCopy() *Histogram {
+	copied := *h
+	copied.counts = make([]int64, len(h.counts))
+	copied.total, copied.clamped, copied.max = 0, 0, 0
+	copied.min = math.MaxInt64
+	return &copied
+}
+
+// forEach percorre as posições com amostras, informando o valor
+// representado (limitado ao mínimo e ao máximo observados) e a contagem
+func (h *Histogram) forEach(fn func(value, count int64)) {
+	for i, count := range h.counts {
+		if count > 0 {
+			fn(min(max(h.valueFromIndex(i), h.min), h.max), count)
+		}
+	}
+}
+
+func (h *Histogram) bucketIndex(v int64) int {
+	pow2Ceiling := 64 - bits.LeadingZeros64(uint64(v|h.subBucketMask))
+	return pow2Ceiling - h.unitMagnitude - (h.subBucketHalfCountMagnitude + 1)
+}
+
+func (h *Histogram) subBucketIndex(v int64, bucketIdx int) int {
+	return int(v >> uint(bucketIdx+h.unitMagnitude))
+}
+
+func (h *Histogram) countsIndex(bucketIdx, subBucketIdx int) int {
+	bucketBaseIdx := (bucketIdx + 1) << h.subBucketHalfCountMagnitude
+	return bucketBaseIdx + subBucketIdx - h.subBucketHalfCount
+}
+
+func (h *Histogram) countsIndexFor(v int64) int {
+	bucketIdx := h.bucketIndex(v)
+	return h.countsIndex(bucketIdx, h.subBucketIndex(v, bucketIdx))
+}
+
+// valueFromIndex retorna o menor valor representado pela posição i de counts
+func (h *Histogram) valueFromIndex(i int) int64 {
+	bucketIdx := (i >> h.subBucketHalfCountMagnitude) - 1
+	subBucketIdx := (i & (h.subBucketHalfCount - 1)) + h.subBucketHalfCount
+	if bucketIdx < 0 {
+		subBucketIdx -= h.subBucketHalfCount
+		bucketIdx = 0
+	}
+	return int64(subBucketIdx) << uint(bucketIdx+h.unitMagnitude)
+}
+
+func (h *Histogram) highestEquivalentValue(v int64) int64 {
+	bucketIdx := h.bucketIndex(v)
+	subBucketIdx := h.subBucketIndex(v, bucketIdx)
+	lowest := int64(subBucketIdx) << uint(bucketIdx+h.unitMagnitude)
+
+	adjustedBucket := bucketIdx
+	if subBucketIdx >= h.subBucketCount {
+		adjustedBucket++
+	}
+	return lowest + (int64(1) << uint(h.unitMagnitude+adjustedBucket)) - 1
+}
@@ -0,0 +1,102 @@
+package stress
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// newHTTP3Transport cria um RoundTripper HTTP/3 sobre QUIC. Não há fallback
+// para TCP: se o QUIC não puder ser estabelecido a request falha.
+func newHTTP3Transport(cfg TransportConfig) *http3.Transport {
+	return &http3.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: cfg.InsecureSkipVerify,
+			RootCAs:            cfg.RootCAs,
+			Certificates:       cfg.Certificates,
+			ServerName:         cfg.ServerName,
+		},
+		QUICConfig:         quicConfig(cfg),
+		DisableCompression: cfg.DisableCompression,
+	}
+}
+
+func quicConfig(cfg TransportConfig) *quic.Config {
+	return &quic.Config{
+		HandshakeIdleTimeout: cfg.QUICHandshakeTimeout,
+		MaxIdleTimeout:       cfg.QUICIdleTimeout,
+	}
+}
+
+// ProbeQUIC verifica antes do teste se é possível estabelecer uma conexão
+// QUIC com o servidor, evitando um relatório com 100% de falhas sem motivo
+// aparente
+func ProbeQUIC(ctx context.Context, rawURL string, cfg TransportConfig) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("HTTP/3 exige uma URL https://")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	tlsConfig := newHTTP3Transport(cfg).TLSClientConfig
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = u.Hostname()
+	}
+	tlsConfig.NextProtos = []string{http3.NextProtoH3}
+
+	conn, err := quic.DialAddr(ctx, net.JoinHostPort(u.Hostname(), port), tlsConfig, quicConfig(cfg))
+	if err != nil {
+		return fmt.Errorf("não foi possível estabelecer uma conexão QUIC (%s): %w", quicErrorCategory(err), err)
+	}
+	return conn.CloseWithError(0, "")
+}
+
+// quicErrorCategory classifica erros específicos do QUIC, retornando uma
+// string vazia para erros de outra natureza
+func quicErrorCategory(err error) string {
+	var (
+		handshakeTimeout *quic.HandshakeTimeoutError
+		idleTimeout      *quic.IdleTimeoutError
+		versionErr       *quic.VersionNegotiationError
+		resetErr         *quic.StatelessResetError
+		transportErr     *quic.TransportError
+		applicationErr   *quic.ApplicationError
+	)
+	switch {
+	case errors.As(err, &handshakeTimeout):
+		return "quic_handshake_timeout"
+	case errors.As(err, &idleTimeout):
+		return "quic_idle_timeout"
+	case errors.As(err, &versionErr):
+		return "quic_version_negotiation"
+	case errors.As(err, &resetErr):
+		return "quic_stateless_reset"
+	case errors.As(err, &transportErr):
+		return "quic_transport_error"
+	case errors.As(err, &applicationErr):
+		return "quic_application_error"
+	}
+	return ""
+}
+
+// NewRoundTripper cria o RoundTripper adequado à configuração: HTTP/3 sobre
+// QUIC quando solicitado, ou um http.Transport nos demais casos
+func NewRoundTripper(cfg TransportConfig) http.RoundTripper {
+	if cfg.HTTP3 {
+		return newHTTP3Transport(cfg)
+	}
+	return NewTransport(cfg)
+}
@@ -0,0 +1,127 @@
+package stress
+
+import (
+	"maps"
+	"time"
+)
+
+// IntervalStats resume o andamento do teste, entregue a OnInterval a cada
+// StressTest.ReportInterval
+type IntervalStats struct {
+	// Elapsed é o tempo desde o início do teste
+	Elapsed time.Duration
+	// Requests e Errors acumulam as requests concluídas e as falhas desde o
+	// início do teste
+	Requests int
+	Errors   int
+	// IntervalRequests e IntervalErrors contam apenas as requests concluídas
+	// desde o resumo anterior
+	IntervalRequests int
+	IntervalErrors   int
+	// RPS é a vazão desde o resumo anterior
+	RPS float64
+	// P95 é o percentil 95 das requests do intervalo que receberam resposta:
+	// o histograma é zerado a cada resumo, então o valor reflete apenas o
+	// intervalo, e não o teste inteiro (zero sem respostas). P50 e P99 são
+	// calculados da mesma forma.
+	P95 time.Duration
+	P50 time.Duration
+	P99 time.Duration
+	// StatusCodes acumula os status HTTP desde o início do teste; o mapa é
+	// uma cópia e pode ser guardado
+	StatusCodes map[int]int
+	// Latencies é a distribuição das durações desde o início do teste em
+	// StressTest.IntervalLatencyBuckets faixas, como em
+	// Report.LatencyDistribution (nil sem IntervalLatencyBuckets)
+	Latencies []LatencyBucket
+}
+
+// ErrorRate retorna a proporção de falhas entre as requests do intervalo
+func (s IntervalStats) ErrorRate() float64 {
+	if s.IntervalRequests == 0 {
+		return 0
+	}
+	return float64(s.IntervalErrors) / float64(s.IntervalRequests)
+}
+
+// intervalRecorder acumula as requests desde o último resumo periódico. Ele
+// é usado apenas pela goroutine do collector, que também gera os resumos,
+// então o estado lido é sempre consistente, sem locks.
+type intervalRecorder struct {
+	start        time.Time
+	last         time.Time
+	histogramMax time.Duration
+	requests     int
+	errors       int
+	durations    *durationRecorder
+}
+
+func newIntervalRecorder(st *StressTest, start time.Time) *intervalRecorder {
+	r := &intervalRecorder{start: start, last: start, histogramMax: st.HistogramMax}
+	r.reset()
+	return r
+}
+
+func (r *intervalRecorder) reset() {
+	r.requests, r.errors = 0, 0
+	r.durations = &durationRecorder{histogram: newDurationHistogram(r.histogramMax, targetHistogramSigFigs)}
+}
+
+// add registra uma request; measured indica se a duração entra nas métricas
+func (r *intervalRecorder) add(failed, measured bool, duration time.Duration) {
+	r.requests++
+	if failed {
+		r.errors++
+	}
+	if measured {
+		r.durations.add(duration)
+	}
+}
+
+// snapshot resume o intervalo encerrado em now e inicia o próximo. latencies
+// é o histograma das durações do teste inteiro, dividido em buckets faixas.
+func (r *intervalRecorder) snapshot(now time.Time, report *Report, latencies *Histogram, buckets int) IntervalStats {
+	stats := IntervalStats{
+		Elapsed:          now.Sub(r.start),
+		Requests:         report.TotalRequests,
+		Errors:           report.FailedRequests,
+		IntervalRequests: r.requests,
+		IntervalErrors:   r.errors,
+		StatusCodes:      maps.Clone(report.StatusCodes),
+	}
+	durations := r.durations.stats()
+	stats.P50, stats.P95, stats.P99 = durations.P50, durations.P95, durations.P99
+	if buckets > 0 {
+		stats.Latencies = (&Report{latencies: latencies}).LatencyDistribution(buckets)
+	}
+	if elapsed := now.Sub(r.last); elapsed > 0 {
+		stats.RPS = float64(r.requests) / elapsed.Seconds()
+	}
+	r.last = now
+	r.reset()
+	return stats
+}
+
+// collect incorpora os resultados até que results seja fechado e, com
+// ReportInterval e OnInterval definidos, entrega um resumo a cada intervalo
+func (c *collector) collect(st *StressTest, results <-chan Result) {
+	if c.interval == nil {
+		for result := range results {
+			c.add(result)
+		}
+		return
+	}
+	ticker := time.NewTicker(st.ReportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				return
+			}
+			c.add(result)
+		case now := <-ticker.C:
+			st.OnInterval(c.interval.snapshot(now, c.report, c.histogram, st.IntervalLatencyBuckets))
+		}
+	}
+}
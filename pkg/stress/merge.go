@@ -0,0 +1,660 @@
+package stress
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Merge incorpora ao relatório as métricas de other, de uma execução
+// simultânea do mesmo teste (ex.: o mesmo teste dividido entre várias
+// máquinas). As contagens, os mapas de status e os bytes são somados, as
+// vazões também (as execuções são consideradas paralelas, com TotalTime
+// sendo o da mais longa) e os mínimos e máximos são combinados.
+//
+// Os percentis das durações vêm da soma dos histogramas, tão precisos quanto
+// os de uma única execução, quando os dois relatórios os têm (ver
+// SetHistogram). As demais distribuições (TTFB, fases, WebSocket, SSE,
+// cenários, série no tempo) guardam apenas o resumo, então as médias são
+// ponderadas e seus percentis são os maiores entre os relatórios, um limite
+// superior.
+//
+// Diferenças de configuração, como métodos, alvos ou Settings distintos, não
+// impedem a combinação e são registradas em MergeConflicts. Os Thresholds do
+// relatório são reavaliados sobre o resultado.
+func (r *Report) Merge(other *Report) {
+	if r.Merged == 0 {
+		r.Merged = 1
+	}
+	r.Merged += max(other.Merged, 1)
+	r.MergeConflicts = append(r.MergeConflicts, other.MergeConflicts...)
+	r.mergeConflicts(other)
+
+	n1, n2 := r.measured(), other.measured()
+	r.mergeDurations(other, n1, n2)
+	r.TTFB = mergeDurationStats(r.TTFB, n1, other.TTFB, n2)
+
+	responses1 := int64(r.TotalRequests - r.TransportErrors)
+	responses2 := int64(other.TotalRequests - other.TransportErrors)
+	switch {
+	case responses1 == 0:
+		r.MinResponseSize, r.MaxResponseSize = other.MinResponseSize, other.MaxResponseSize
+	case responses2 > 0:
+		r.MinResponseSize = min(r.MinResponseSize, other.MinResponseSize)
+		r.MaxResponseSize = max(r.MaxResponseSize, other.MaxResponseSize)
+	}
+	r.AvgResponseSize = weightedAverage(r.AvgResponseSize, responses1, other.AvgResponseSize, responses2)
+
+	r.TotalRequests += other.TotalRequests
+	r.SuccessfulRequests += other.SuccessfulRequests
+	r.FailedRequests += other.FailedRequests
+	r.TransportErrors += other.TransportErrors
+	r.UnexpectedStatus += other.UnexpectedStatus
+	r.ApplicationErrors += other.ApplicationErrors
+	r.RedirectedRequests += other.RedirectedRequests
+	r.CanceledRequests += other.CanceledRequests
+	r.WarmupRequests += other.WarmupRequests
+	r.BytesSent += other.BytesSent
+	r.BytesReceived += other.BytesReceived
+	r.TruncatedResponses += other.TruncatedResponses
+	r.BodyLimitedResponses += other.BodyLimitedResponses
+	r.NewConnections += other.NewConnections
+	r.ReusedConnections += other.ReusedConnections
+	r.AssertionFailures = addCounts(r.AssertionFailures, other.AssertionFailures)
+	r.GraphQLErrors = addCounts(r.GraphQLErrors, other.GraphQLErrors)
+	r.ErrorCategories = addCounts(r.ErrorCategories, other.ErrorCategories)
+	r.StatusCodes = addCounts(r.StatusCodes, other.StatusCodes)
+	r.GRPCCodes = addCounts(r.GRPCCodes, other.GRPCCodes)
+	r.Protocols = addCounts(r.Protocols, other.Protocols)
+
+	r.DataExhausted = r.DataExhausted || other.DataExhausted
+	r.BodySkipped = r.BodySkipped || other.BodySkipped
+	if !r.Aborted && other.Aborted {
+		r.Aborted, r.AbortReason = true, other.AbortReason
+	}
+	if !r.Interrupted && other.Interrupted {
+		r.Interrupted, r.InterruptCause = true, other.InterruptCause
+	}
+
+	r.TotalTime = max(r.TotalTime, other.TotalTime)
+	r.PlannedDuration = max(r.PlannedDuration, other.PlannedDuration)
+	r.RampUp = max(r.RampUp, other.RampUp)
+	r.FullConcurrencyAt = max(r.FullConcurrencyAt, other.FullConcurrencyAt)
+	r.TargetRPS += other.TargetRPS
+	r.ArrivalRate += other.ArrivalRate
+	r.MaxInFlight += other.MaxInFlight
+	r.Arrivals += other.Arrivals
+	r.DroppedArrivals += other.DroppedArrivals
+	r.AchievedArrivalRate += other.AchievedArrivalRate
+	// Os picos das execuções podem não coincidir: a soma é um limite superior
+	r.PeakInFlight += other.PeakInFlight
+	r.RequestsPerSecond += other.RequestsPerSecond
+	r.SuccessfulRequestsPerSecond += other.SuccessfulRequestsPerSecond
+
+	r.mergeTargets(other)
+	r.Workers = append(r.Workers, other.Workers...)
+	r.mergeApdex(other)
+	r.mergeTimeline(other)
+	r.mergeStages(other)
+	// A comparação do pico é refeita sobre as etapas e a timeline combinadas
+	if r.Spike != nil || other.Spike != nil {
+		r.Spike = newSpikeReport(r)
+	}
+	r.Trend = mergeTrendReports(r.Trend, other.Trend)
+	r.ResponseTime = mergeResponseTimeStats(r.ResponseTime, other.ResponseTime)
+	r.Retries = mergeRetryStats(r.Retries, other.Retries)
+	r.mergeRetryAfter(other)
+	r.mergeSlowest(other)
+	// As buscas de StressTest.FindMax de execuções diferentes não se combinam
+	r.FindMax = nil
+	r.Phases = mergePhaseStats(r.Phases, other.Phases)
+	r.Compression = mergeCompressionStats(r.Compression, other.Compression)
+	r.WebSocket = mergeWebSocketStats(r.WebSocket, other.WebSocket)
+	r.SSE = mergeSSEStats(r.SSE, other.SSE)
+	r.Scenario = mergeScenarioStats(r.Scenario, other.Scenario)
+
+	for i, result := range r.Thresholds {
+		r.Thresholds[i] = result.Threshold.Evaluate(r)
+	}
+	slices.Sort(r.MergeConflicts)
+	r.MergeConflicts = slices.Compact(r.MergeConflicts)
+}
+
+// measured retorna a quantidade de durações consideradas nas métricas: a do
+// histograma ou, sem ele, as requests que receberam resposta
+func (r *Report) measured() int64 {
+	if r.latencies != nil {
+		return r.latencies.TotalCount()
+	}
+	return int64(r.TotalRequests - r.TransportErrors)
+}
+
+// mergeConflicts registra as diferenças de configuração entre os relatórios
+func (r *Report) mergeConflicts(other *Report) {
+	conflict := func(name, a, b string) {
+		if a != b {
+			r.MergeConflicts = append(r.MergeConflicts, fmt.Sprintf("%s: %q e %q", name, a, b))
+		}
+	}
+	conflict("método", r.Method, other.Method)
+	conflict("método gRPC", r.GRPCMethod, other.GRPCMethod)
+	conflict("status esperados", r.ExpectStatus.String(), other.ExpectStatus.String())
+	conflict("protocolo", r.ExpectedProtocol, other.ExpectedProtocol)
+	if r.Apdex != nil && other.Apdex != nil {
+		conflict("Apdex T", r.Apdex.T.String(), other.Apdex.T.String())
+	}
+	for _, key := range slices.Sorted(maps.Keys(other.Settings)) {
+		if value, ok := r.Settings[key]; ok {
+			conflict("configuração "+key, value, other.Settings[key])
+		} else {
+			r.MergeConflicts = append(r.MergeConflicts, fmt.Sprintf("configuração %s presente em apenas parte das execuções", key))
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(r.Settings)) {
+		if _, ok := other.Settings[key]; !ok {
+			r.MergeConflicts = append(r.MergeConflicts, fmt.Sprintf("configuração %s presente em apenas parte das execuções", key))
+		}
+	}
+	for _, label := range slices.Sorted(maps.Keys(other.Targets)) {
+		if _, ok := r.Targets[label]; !ok && len(r.Targets) > 0 {
+			r.MergeConflicts = append(r.MergeConflicts, fmt.Sprintf("alvo %s presente em apenas parte das execuções", label))
+		}
+	}
+	for _, label := range slices.Sorted(maps.Keys(r.Targets)) {
+		if _, ok := other.Targets[label]; !ok && len(other.Targets) > 0 {
+			r.MergeConflicts = append(r.MergeConflicts, fmt.Sprintf("alvo %s presente em apenas parte das execuções", label))
+		}
+	}
+	if r.TimelineInterval != other.TimelineInterval && len(r.Timeline) > 0 && len(other.Timeline) > 0 {
+		conflict("intervalo da série no tempo", r.TimelineInterval.String(), other.TimelineInterval.String())
+	}
+	if len(r.Stages) > 0 || len(other.Stages) > 0 {
+		conflict("durações das etapas", stageDurations(r.Stages), stageDurations(other.Stages))
+	}
+}
+
+// stageDurations descreve as durações das etapas para mergeConflicts
+func stageDurations(stages []*StageReport) string {
+	durations := make([]string, len(stages))
+	for i, stage := range stages {
+		durations[i] = stage.Stage.Duration.String()
+	}
+	return strings.Join(durations, ",")
+}
+
+// mergeDurations combina as métricas de duração do relatório, com n1 e n2
+// durações em cada um
+func (r *Report) mergeDurations(other *Report, n1, n2 int64) {
+	switch {
+	case n2 == 0:
+	case n1 == 0:
+		r.MinDuration, r.MaxDuration = other.MinDuration, other.MaxDuration
+	default:
+		r.MinDuration = min(r.MinDuration, other.MinDuration)
+		r.MaxDuration = max(r.MaxDuration, other.MaxDuration)
+	}
+	avg := weightedAverage(float64(r.AvgDuration), n1, float64(other.AvgDuration), n2)
+	// Variância amostral combinada a partir das médias e dos desvios de
+	// cada relatório, sem perda em relação ao cálculo sobre todas as
+	// amostras
+	if n := n1 + n2; n > 1 {
+		ss := 0.0
+		for _, part := range []struct {
+			n        int64
+			avg, std float64
+		}{{n1, float64(r.AvgDuration), float64(r.StdDevDuration)}, {n2, float64(other.AvgDuration), float64(other.StdDevDuration)}} {
+			if part.n > 0 {
+				ss += float64(part.n-1)*part.std*part.std + float64(part.n)*(part.avg-avg)*(part.avg-avg)
+			}
+		}
+		r.StdDevDuration = time.Duration(math.Sqrt(ss / float64(n-1)))
+	}
+	r.AvgDuration = time.Duration(avg)
+	r.CoefficientOfVariation = 0
+	if avg > 0 {
+		r.CoefficientOfVariation = float64(r.StdDevDuration) / avg
+	}
+
+	switch {
+	case n2 == 0 && other.latencies == nil:
+		return
+	case r.latencies != nil && other.latencies != nil:
+		r.latencies.Merge(other.latencies)
+	case n1 == 0 && other.latencies != nil:
+		r.latencies = other.latencies.emptyCopy()
+		r.latencies.Merge(other.latencies)
+	default:
+		// Sem os dois histogramas, os percentis são limites superiores
+		r.latencies = nil
+		r.ClampedDurations += other.ClampedDurations
+		r.P50, r.P90 = max(r.P50, other.P50), max(r.P90, other.P90)
+		r.P95, r.P99 = max(r.P95, other.P95), max(r.P99, other.P99)
+		r.MergeConflicts = append(r.MergeConflicts, "percentis aproximados: algum relatório não tem o histograma de durações")
+		return
+	}
+	r.HistogramMax = r.latencies.Highest()
+	r.ClampedDurations = r.latencies.Clamped()
+	r.P50 = r.latencies.ValueAtQuantile(50)
+	r.P90 = r.latencies.ValueAtQuantile(90)
+	r.P95 = r.latencies.ValueAtQuantile(95)
+	r.P99 = r.latencies.ValueAtQuantile(99)
+}
+
+func (r *Report) mergeTargets(other *Report) {
+	if len(other.Targets) > 0 && r.Targets == nil {
+		r.Targets = make(map[string]*TargetReport)
+	}
+	for label, target := range other.Targets {
+		current, ok := r.Targets[label]
+		if !ok {
+			current = &TargetReport{Weight: target.Weight}
+			r.Targets[label] = current
+		}
+		switch {
+		case current.latencies != nil && target.latencies != nil:
+			n1, n2 := current.latencies.TotalCount(), target.latencies.TotalCount()
+			current.latencies.Merge(target.latencies)
+			current.Durations = mergeDurationStats(current.Durations, n1, target.Durations, n2)
+			current.Durations.P50 = current.latencies.ValueAtQuantile(50)
+			current.Durations.P95 = current.latencies.ValueAtQuantile(95)
+			current.Durations.P99 = current.latencies.ValueAtQuantile(99)
+		case !ok && target.latencies != nil:
+			current.latencies = target.latencies.emptyCopy()
+			current.latencies.Merge(target.latencies)
+			current.Durations = target.Durations
+		default:
+			current.latencies = nil
+			current.Durations = mergeDurationStats(current.Durations, int64(current.Requests), target.Durations, int64(target.Requests))
+		}
+		current.Requests += target.Requests
+		current.SuccessfulRequests += target.SuccessfulRequests
+		current.FailedRequests += target.FailedRequests
+		current.StatusCodes = addCounts(current.StatusCodes, target.StatusCodes)
+	}
+}
+
+// mergeStages combina as etapas de mesma posição, somando as taxas e as
+// concorrências de cada execução como em ArrivalRate
+func (r *Report) mergeStages(other *Report) {
+	for i, stage := range other.Stages {
+		if i == len(r.Stages) {
+			r.Stages = append(r.Stages, &StageReport{Stage: Stage{Duration: stage.Stage.Duration}, Start: stage.Start})
+		}
+		current := r.Stages[i]
+		switch {
+		case current.latencies != nil && stage.latencies != nil:
+			n1, n2 := current.latencies.TotalCount(), stage.latencies.TotalCount()
+			current.latencies.Merge(stage.latencies)
+			current.Durations = mergeDurationStats(current.Durations, n1, stage.Durations, n2)
+			current.Durations.P50 = current.latencies.ValueAtQuantile(50)
+			current.Durations.P95 = current.latencies.ValueAtQuantile(95)
+			current.Durations.P99 = current.latencies.ValueAtQuantile(99)
+		case current.Requests == 0 && stage.latencies != nil:
+			current.latencies = stage.latencies.emptyCopy()
+			current.latencies.Merge(stage.latencies)
+			current.Durations = stage.Durations
+		default:
+			current.latencies = nil
+			current.Durations = mergeDurationStats(current.Durations, int64(current.Requests), stage.Durations, int64(stage.Requests))
+		}
+		current.Stage.Rate += stage.Stage.Rate
+		current.Stage.Concurrency += stage.Stage.Concurrency
+		current.Requests += stage.Requests
+		current.SuccessfulRequests += stage.SuccessfulRequests
+		current.FailedRequests += stage.FailedRequests
+		current.Arrivals += stage.Arrivals
+		current.DroppedArrivals += stage.DroppedArrivals
+		current.RequestsPerSecond += stage.RequestsPerSecond
+	}
+}
+
+// mergeRetryAfter soma as pausas de Retry-After; o limite é o do primeiro
+// relatório que o tem
+func (r *Report) mergeRetryAfter(other *Report) {
+	if other.RetryAfter == nil {
+		return
+	}
+	if r.RetryAfter == nil {
+		r.RetryAfter = &RetryAfterStats{Max: other.RetryAfter.Max}
+	}
+	r.RetryAfter.Pauses += other.RetryAfter.Pauses
+	r.RetryAfter.Wait += other.RetryAfter.Wait
+}
+
+// mergeSlowest mantém as requests mais lentas dos dois relatórios, até o
+// maior dos limites
+func (r *Report) mergeSlowest(other *Report) {
+	if other.Slowest == nil {
+		return
+	}
+	if r.Slowest == nil {
+		r.Slowest = &SlowestStats{}
+	}
+	r.Slowest.Max = max(r.Slowest.Max, other.Slowest.Max)
+	requests := append(slices.Clone(r.Slowest.Requests), other.Slowest.Requests...)
+	sortSlowest(requests)
+	r.Slowest.Requests = requests[:min(len(requests), r.Slowest.Max)]
+}
+
+func (r *Report) mergeApdex(other *Report) {
+	if other.Apdex == nil {
+		return
+	}
+	if r.Apdex == nil {
+		r.Apdex = &ApdexScore{T: other.Apdex.T}
+	}
+	apdex := r.Apdex
+	apdex.Satisfied += other.Apdex.Satisfied
+	apdex.Tolerating += other.Apdex.Tolerating
+	apdex.Frustrated += other.Apdex.Frustrated
+	if total := apdex.Satisfied + apdex.Tolerating + apdex.Frustrated; total > 0 {
+		apdex.Score = (float64(apdex.Satisfied) + float64(apdex.Tolerating)/2) / float64(total)
+	}
+}
+
+// mergeTimeline soma os intervalos de mesmo início; com intervalos de
+// tamanhos diferentes, a série do relatório é mantida
+func (r *Report) mergeTimeline(other *Report) {
+	if len(other.Timeline) == 0 {
+		return
+	}
+	if len(r.Timeline) == 0 {
+		r.Timeline, r.TimelineInterval = slices.Clone(other.Timeline), other.TimelineInterval
+		return
+	}
+	if r.TimelineInterval != other.TimelineInterval {
+		return
+	}
+	for i, point := range other.Timeline {
+		if i == len(r.Timeline) {
+			r.Timeline = append(r.Timeline, point)
+			continue
+		}
+		current := &r.Timeline[i]
+		current.Avg = time.Duration(weightedAverage(float64(current.Avg), int64(current.Requests-current.Errors),
+			float64(point.Avg), int64(point.Requests-point.Errors)))
+		current.Requests += point.Requests
+		current.Errors += point.Errors
+		current.P50 = max(current.P50, point.P50)
+		current.P95 = max(current.P95, point.P95)
+		current.P99 = max(current.P99, point.P99)
+		current.Max = max(current.Max, point.Max)
+		current.Resources = mergeResourceSamples(current.Resources, point.Resources)
+	}
+}
+
+// mergeResourceSamples soma o consumo dos processos de cada execução
+func mergeResourceSamples(a, b *ResourceSample) *ResourceSample {
+	switch {
+	case b == nil:
+		return a
+	case a == nil:
+		return b
+	}
+	return &ResourceSample{
+		RSS:        a.RSS + b.RSS,
+		HeapAlloc:  a.HeapAlloc + b.HeapAlloc,
+		Goroutines: a.Goroutines + b.Goroutines,
+		GCCycles:   a.GCCycles + b.GCCycles,
+		GCPause:    a.GCPause + b.GCPause,
+	}
+}
+
+// mergeRetryStats combina as tentativas e, pelos histogramas quando os dois
+// os têm, os tempos totais
+func mergeRetryStats(a, b *RetryStats) *RetryStats {
+	switch {
+	case b == nil:
+		return a
+	case a == nil:
+		merged := *b
+		if b.latencies != nil {
+			merged.latencies = b.latencies.emptyCopy()
+			merged.latencies.Merge(b.latencies)
+		}
+		return &merged
+	}
+	// A configuração é a do primeiro relatório
+	merged := &RetryStats{
+		Max:         a.Max,
+		BackoffBase: a.BackoffBase,
+		BackoffMax:  a.BackoffMax,
+		Status:      a.Status,
+		Requests:    a.Requests + b.Requests,
+		Attempts:    a.Attempts + b.Attempts,
+		Retried:     a.Retried + b.Retried,
+		Recovered:   a.Recovered + b.Recovered,
+		Exhausted:   a.Exhausted + b.Exhausted,
+		Backoff:     a.Backoff + b.Backoff,
+	}
+	merged.Durations = mergeDurationStats(a.Durations, int64(a.Requests), b.Durations, int64(b.Requests))
+	merged.P90 = max(a.P90, b.P90)
+	if a.latencies != nil && b.latencies != nil {
+		merged.latencies = a.latencies
+		merged.latencies.Merge(b.latencies)
+		merged.Durations.P50 = merged.latencies.ValueAtQuantile(50)
+		merged.Durations.P95 = merged.latencies.ValueAtQuantile(95)
+		merged.Durations.P99 = merged.latencies.ValueAtQuantile(99)
+		merged.P90 = merged.latencies.ValueAtQuantile(90)
+	}
+	return merged
+}
+
+// mergeResponseTimeStats combina os tempos de resposta pelos histogramas,
+// quando os dois os têm, como as métricas de duração do Report
+func mergeResponseTimeStats(a, b *ResponseTimeStats) *ResponseTimeStats {
+	switch {
+	case b == nil:
+		return a
+	case a == nil:
+		merged := *b
+		if b.latencies != nil {
+			merged.latencies = b.latencies.emptyCopy()
+			merged.latencies.Merge(b.latencies)
+		}
+		return &merged
+	}
+	merged := &ResponseTimeStats{
+		Requests:           a.Requests + b.Requests,
+		SynthesizedSamples: a.SynthesizedSamples + b.SynthesizedSamples,
+		ExpectedInterval:   max(a.ExpectedInterval, b.ExpectedInterval),
+		LateRequests:       a.LateRequests + b.LateRequests,
+		AvgDelay:           time.Duration(weightedAverage(float64(a.AvgDelay), int64(a.Requests), float64(b.AvgDelay), int64(b.Requests))),
+		MaxDelay:           max(a.MaxDelay, b.MaxDelay),
+	}
+	n1, n2 := int64(a.Requests)+a.SynthesizedSamples, int64(b.Requests)+b.SynthesizedSamples
+	merged.Durations = mergeDurationStats(a.Durations, n1, b.Durations, n2)
+	merged.P90 = max(a.P90, b.P90)
+	if a.latencies != nil && b.latencies != nil {
+		merged.latencies = a.latencies
+		merged.latencies.Merge(b.latencies)
+		merged.Durations.P50 = merged.latencies.ValueAtQuantile(50)
+		merged.Durations.P95 = merged.latencies.ValueAtQuantile(95)
+		merged.Durations.P99 = merged.latencies.ValueAtQuantile(99)
+		merged.P90 = merged.latencies.ValueAtQuantile(90)
+	}
+	return merged
+}
+
+// mergeTrendReports combina as tendências como as métricas do Report: o
+// maior P95, a taxa de erros ponderada pelas requests e o consumo somado
+// dos processos
+func mergeTrendReports(a, b *TrendReport) *TrendReport {
+	switch {
+	case b == nil:
+		return a
+	case a == nil:
+		merged := *b
+		return &merged
+	}
+	return &TrendReport{
+		Window:          max(a.Window, b.Window),
+		FirstRequests:   a.FirstRequests + b.FirstRequests,
+		LastRequests:    a.LastRequests + b.LastRequests,
+		FirstP95:        max(a.FirstP95, b.FirstP95),
+		LastP95:         max(a.LastP95, b.LastP95),
+		FirstErrorRate:  weightedAverage(a.FirstErrorRate, int64(a.FirstRequests), b.FirstErrorRate, int64(b.FirstRequests)),
+		LastErrorRate:   weightedAverage(a.LastErrorRate, int64(a.LastRequests), b.LastErrorRate, int64(b.LastRequests)),
+		FirstRSS:        a.FirstRSS + b.FirstRSS,
+		LastRSS:         a.LastRSS + b.LastRSS,
+		FirstGoroutines: a.FirstGoroutines + b.FirstGoroutines,
+		LastGoroutines:  a.LastGoroutines + b.LastGoroutines,
+	}
+}
+
+func mergePhaseStats(a, b *PhaseStats) *PhaseStats {
+	switch {
+	case b == nil:
+		return a
+	case a == nil:
+		merged := *b
+		return &merged
+	}
+	newA, newB := int64(a.NewConnections), int64(b.NewConnections)
+	allA, allB := newA+int64(a.ReusedConnections), newB+int64(b.ReusedConnections)
+	return &PhaseStats{
+		NewConnections:    a.NewConnections + b.NewConnections,
+		ReusedConnections: a.ReusedConnections + b.ReusedConnections,
+		DNS:               mergeDurationStats(a.DNS, newA, b.DNS, newB),
+		Connect:           mergeDurationStats(a.Connect, newA, b.Connect, newB),
+		TLS:               mergeDurationStats(a.TLS, newA, b.TLS, newB),
+		Server:            mergeDurationStats(a.Server, allA, b.Server, allB),
+	}
+}
+
+func mergeCompressionStats(a, b *CompressionStats) *CompressionStats {
+	switch {
+	case b == nil:
+		return a
+	case a == nil:
+		merged := *b
+		return &merged
+	}
+	merged := &CompressionStats{
+		CompressedResponses: a.CompressedResponses + b.CompressedResponses,
+		WireBytes:           a.WireBytes + b.WireBytes,
+		DecodedBytes:        a.DecodedBytes + b.DecodedBytes,
+		Decompression:       mergeDurationStats(a.Decompression, int64(a.CompressedResponses), b.Decompression, int64(b.CompressedResponses)),
+	}
+	if merged.WireBytes > 0 {
+		merged.Ratio = float64(merged.DecodedBytes) / float64(merged.WireBytes)
+	}
+	return merged
+}
+
+func mergeWebSocketStats(a, b *WebSocketStats) *WebSocketStats {
+	switch {
+	case b == nil:
+		return a
+	case a == nil:
+		merged := *b
+		merged.DisconnectReasons = maps.Clone(b.DisconnectReasons)
+		return &merged
+	}
+	return &WebSocketStats{
+		ConnectionsEstablished: a.ConnectionsEstablished + b.ConnectionsEstablished,
+		ConnectionFailures:     a.ConnectionFailures + b.ConnectionFailures,
+		Disconnects:            a.Disconnects + b.Disconnects,
+		DisconnectReasons:      addCounts(a.DisconnectReasons, b.DisconnectReasons),
+		MessagesSent:           a.MessagesSent + b.MessagesSent,
+		MessagesReceived:       a.MessagesReceived + b.MessagesReceived,
+		Handshake:              mergeDurationStats(a.Handshake, int64(a.ConnectionsEstablished), b.Handshake, int64(b.ConnectionsEstablished)),
+		RTT:                    mergeDurationStats(a.RTT, int64(a.MessagesSent), b.RTT, int64(b.MessagesSent)),
+	}
+}
+
+func mergeSSEStats(a, b *SSEStats) *SSEStats {
+	switch {
+	case b == nil:
+		return a
+	case a == nil:
+		merged := *b
+		merged.DisconnectReasons = maps.Clone(b.DisconnectReasons)
+		return &merged
+	}
+	merged := &SSEStats{
+		Connections:        a.Connections + b.Connections,
+		ConnectionFailures: a.ConnectionFailures + b.ConnectionFailures,
+		Disconnects:        a.Disconnects + b.Disconnects,
+		DisconnectReasons:  addCounts(a.DisconnectReasons, b.DisconnectReasons),
+		Events:             a.Events + b.Events,
+		EventsPerSecond:    a.EventsPerSecond + b.EventsPerSecond,
+		MalformedLines:     a.MalformedLines + b.MalformedLines,
+		FirstEvent:         mergeDurationStats(a.FirstEvent, int64(a.Connections), b.FirstEvent, int64(b.Connections)),
+		InterEvent:         mergeDurationStats(a.InterEvent, int64(a.Events), b.InterEvent, int64(b.Events)),
+	}
+	switch {
+	case a.Connections == 0:
+		merged.MinEventsPerConnection, merged.MaxEventsPerConnection = b.MinEventsPerConnection, b.MaxEventsPerConnection
+	case b.Connections == 0:
+		merged.MinEventsPerConnection, merged.MaxEventsPerConnection = a.MinEventsPerConnection, a.MaxEventsPerConnection
+	default:
+		merged.MinEventsPerConnection = min(a.MinEventsPerConnection, b.MinEventsPerConnection)
+		merged.MaxEventsPerConnection = max(a.MaxEventsPerConnection, b.MaxEventsPerConnection)
+	}
+	if merged.Connections > 0 {
+		merged.AvgEventsPerConnection = float64(merged.Events) / float64(merged.Connections)
+	}
+	return merged
+}
+
+func mergeScenarioStats(a, b *ScenarioStats) *ScenarioStats {
+	switch {
+	case b == nil:
+		return a
+	case a == nil:
+		merged := *b
+		merged.AbortedBySteps = maps.Clone(b.AbortedBySteps)
+		return &merged
+	}
+	return &ScenarioStats{
+		Iterations:          a.Iterations + b.Iterations,
+		CompletedIterations: a.CompletedIterations + b.CompletedIterations,
+		AbortedIterations:   a.AbortedIterations + b.AbortedIterations,
+		AbortedBySteps:      addCounts(a.AbortedBySteps, b.AbortedBySteps),
+		Durations:           mergeDurationStats(a.Durations, int64(a.CompletedIterations), b.Durations, int64(b.CompletedIterations)),
+	}
+}
+
+// mergeDurationStats combina dois resumos com n1 e n2 amostras: a média é
+// ponderada e os percentis são os maiores dos dois
+func mergeDurationStats(a DurationStats, n1 int64, b DurationStats, n2 int64) DurationStats {
+	switch {
+	case n2 == 0:
+		return a
+	case n1 == 0:
+		return b
+	}
+	return DurationStats{
+		Min: min(a.Min, b.Min),
+		Max: max(a.Max, b.Max),
+		Avg: time.Duration(weightedAverage(float64(a.Avg), n1, float64(b.Avg), n2)),
+		P50: max(a.P50, b.P50),
+		P95: max(a.P95, b.P95),
+		P99: max(a.P99, b.P99),
+	}
+}
+
+func weightedAverage(a float64, n1 int64, b float64, n2 int64) float64 {
+	if n1+n2 <= 0 {
+		return 0
+	}
+	return (a*float64(n1) + b*float64(n2)) / float64(n1+n2)
+}
+
+// addCounts soma as contagens de b em a, criando a quando necessário
+func addCounts[K comparable](a, b map[K]int) map[K]int {
+	if len(b) == 0 {
+		return a
+	}
+	if a == nil {
+		a = make(map[K]int, len(b))
+	}
+	for key, count := range b {
+		a[key] += count
+	}
+	return a
+}
@@ -0,0 +1,223 @@
+package stress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Multipart descreve um corpo multipart/form-data enviado em todas as
+// requests: os campos de texto vêm primeiro, seguidos dos arquivos, na
+// ordem informada. Os arquivos são lidos do disco a cada request, sem
+// serem mantidos em memória, e não devem mudar de tamanho durante o teste.
+type Multipart struct {
+	Fields []FormField
+	Files  []FormFile
+}
+
+// FormField é um campo de texto do formulário
+type FormField struct {
+	Name  string
+	Value string
+}
+
+// FormFile é um arquivo enviado no campo Field, com o nome base de Path e
+// o Content-Type deduzido da extensão
+type FormFile struct {
+	Field string
+	Path  string
+}
+
+// ParseFormField interpreta um campo no formato "nome=valor"
+func ParseFormField(text string) (FormField, error) {
+	name, value, ok := strings.Cut(text, "=")
+	if !ok || name == "" {
+		return FormField{}, fmt.Errorf("use o formato \"campo=valor\": %q", text)
+	}
+	return FormField{Name: name, Value: value}, nil
+}
+
+// ParseFormFile interpreta um arquivo no formato "campo=/caminho/do/arquivo"
+func ParseFormFile(text string) (FormFile, error) {
+	field, path, ok := strings.Cut(text, "=")
+	if !ok || field == "" || path == "" {
+		return FormFile{}, fmt.Errorf("use o formato \"campo=/caminho/do/arquivo\": %q", text)
+	}
+	return FormFile{Field: field, Path: path}, nil
+}
+
+func validMultipart(m *Multipart) bool {
+	if len(m.Fields) == 0 && len(m.Files) == 0 {
+		return false
+	}
+	for _, field := range m.Fields {
+		if field.Name == "" {
+			return false
+		}
+	}
+	for _, file := range m.Files {
+		if file.Field == "" || file.Path == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// multipartSegment é um trecho do corpo: bytes fixos (delimitadores,
+// headers das partes e campos de texto) ou o conteúdo de um arquivo
+type multipartSegment struct {
+	data []byte
+	path string
+}
+
+// multipartLayout é o corpo multipart pré-montado uma vez por teste, com o
+// mesmo boundary em todas as requests. Apenas os trechos fixos ficam em
+// memória; o tamanho total é conhecido de antemão, então as requests são
+// enviadas com Content-Length em vez de chunked.
+type multipartLayout struct {
+	segments    []multipartSegment
+	size        int64
+	contentType string
+}
+
+// newMultipartLayout monta os trechos fixos do corpo e verifica se todos os
+// arquivos podem ser abertos, para que o teste falhe antes de começar
+func newMultipartLayout(m *Multipart) (*multipartLayout, error) {
+	layout := &multipartLayout{}
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, field := range m.Fields {
+		if err := writer.WriteField(field.Name, field.Value); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range m.Files {
+		size, err := fileSize(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("arquivo do campo %s: %w", file.Field, err)
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			escapeQuotes(file.Field), escapeQuotes(filepath.Base(file.Path))))
+		header.Set("Content-Type", fileContentType(file.Path))
+		if _, err := writer.CreatePart(header); err != nil {
+			return nil, err
+		}
+		// O conteúdo do arquivo entra entre os headers da parte e o próximo
+		// delimitador, escritos pelo multipart.Writer no buffer
+		layout.add(multipartSegment{data: bytes.Clone(buf.Bytes())}, int64(buf.Len()))
+		layout.add(multipartSegment{path: file.Path}, size)
+		buf.Reset()
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	layout.add(multipartSegment{data: bytes.Clone(buf.Bytes())}, int64(buf.Len()))
+	layout.contentType = writer.FormDataContentType()
+	return layout, nil
+}
+
+func (l *multipartLayout) add(segment multipartSegment, size int64) {
+	if segment.path == "" && len(segment.data) == 0 {
+		return
+	}
+	l.segments = append(l.segments, segment)
+	l.size += size
+}
+
+// fileSize abre o arquivo, garantindo que ele pode ser lido, e retorna seu
+// tamanho
+func fileSize(path string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.IsDir() {
+		return 0, fmt.Errorf("%s é um diretório", path)
+	}
+	return info.Size(), nil
+}
+
+// fileContentType deduz o Content-Type pela extensão do arquivo
+func fileContentType(path string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// quoteEscaper escapa os nomes usados no Content-Disposition, como o
+// multipart.Writer faz em CreateFormFile
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// reader retorna um novo corpo para uma request
+func (l *multipartLayout) reader() io.ReadCloser {
+	return &multipartReader{segments: l.segments}
+}
+
+// multipartReader percorre os trechos do corpo, abrindo cada arquivo apenas
+// quando chega a vez dele
+type multipartReader struct {
+	segments []multipartSegment
+	current  io.Reader
+	file     *os.File
+}
+
+func (r *multipartReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.segments) == 0 {
+				return 0, io.EOF
+			}
+			segment := r.segments[0]
+			r.segments = r.segments[1:]
+			if segment.path == "" {
+				r.current = bytes.NewReader(segment.data)
+			} else {
+				file, err := os.Open(segment.path)
+				if err != nil {
+					return 0, err
+				}
+				r.file, r.current = file, file
+			}
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.closeFile()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *multipartReader) closeFile() {
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+}
+
+func (r *multipartReader) Close() error {
+	r.closeFile()
+	r.segments = nil
+	return nil
+}
@@ -0,0 +1,103 @@
+package stress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+// PortRange é um intervalo de portas locais, com Min e Max inclusivos
+type PortRange struct {
+	Min int
+	Max int
+}
+
+// IsZero indica se o intervalo não foi definido
+func (r PortRange) IsZero() bool {
+	return r.Min == 0 && r.Max == 0
+}
+
+// Size retorna a quantidade de portas do intervalo
+func (r PortRange) Size() int {
+	if r.IsZero() {
+		return 0
+	}
+	return r.Max - r.Min + 1
+}
+
+// String retorna o intervalo no formato aceito por ParsePortRange
+func (r PortRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// ParsePortRange interpreta um intervalo no formato "40000-49999"
+func ParsePortRange(text string) (PortRange, error) {
+	low, high, ok := strings.Cut(text, "-")
+	if !ok {
+		return PortRange{}, fmt.Errorf("use o formato \"min-max\": %q", text)
+	}
+	var r PortRange
+	var err error
+	if r.Min, err = strconv.Atoi(strings.TrimSpace(low)); err != nil {
+		return PortRange{}, fmt.Errorf("porta inválida em %q", text)
+	}
+	if r.Max, err = strconv.Atoi(strings.TrimSpace(high)); err != nil {
+		return PortRange{}, fmt.Errorf("porta inválida em %q", text)
+	}
+	if !r.valid() {
+		return PortRange{}, fmt.Errorf("intervalo de portas inválido %q: use portas entre 1 e 65535, com min <= max", text)
+	}
+	return r, nil
+}
+
+func (r PortRange) valid() bool {
+	return r.Min >= 1 && r.Max <= 65535 && r.Min <= r.Max
+}
+
+// Split divide o intervalo em n partes contíguas de mesmo tamanho e retorna
+// a parte i, para que cada worker use portas próprias. As portas que sobram
+// da divisão ficam sem uso.
+func (r PortRange) Split(i, n int) PortRange {
+	size := r.Size() / n
+	if size == 0 {
+		return r
+	}
+	low := r.Min + i*size
+	return PortRange{Min: low, Max: low + size - 1}
+}
+
+// portDialer abre as conexões TCP a partir das portas locais de um
+// intervalo, em rodízio, pulando as portas ocupadas
+type portDialer struct {
+	dialer *net.Dialer
+	ports  PortRange
+	next   atomic.Int64
+}
+
+func newPortDialer(dialer *net.Dialer, ports PortRange) *portDialer {
+	return &portDialer{dialer: dialer, ports: ports}
+}
+
+// errPortsExhausted indica que todas as portas do intervalo estão ocupadas
+var errPortsExhausted = errors.New("todas as portas locais do intervalo estão em uso")
+
+func (d *portDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	size := d.ports.Size()
+	for range size {
+		port := d.ports.Min + int(d.next.Add(1)-1)%size
+		dialer := *d.dialer
+		dialer.LocalAddr = &net.TCPAddr{Port: port}
+		conn, err := dialer.DialContext(ctx, network, addr)
+		// Portas em uso (inclusive em TIME_WAIT) são puladas
+		if errors.Is(err, syscall.EADDRINUSE) {
+			continue
+		}
+		return conn, err
+	}
+	return nil, fmt.Errorf("%w (%s)", errPortsExhausted, d.ports)
+}
@@ -0,0 +1,50 @@
+package stress
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// progress mantém os contadores atualizados pelo coletor e lidos
+// periodicamente pela goroutine que imprime o progresso
+type progress struct {
+	completed atomic.Int64
+	failed    atomic.Int64
+}
+
+// report imprime uma linha de progresso a cada interval, sobrescrevendo a
+// anterior, até que done seja fechado
+func (p *progress) report(w io.Writer, st *StressTest, start time.Time, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last int64
+	lastTick := start
+	for {
+		select {
+		case <-done:
+			p.print(w, st, start, float64(p.completed.Load())/time.Since(start).Seconds())
+			fmt.Fprintln(w)
+			return
+		case now := <-ticker.C:
+			completed := p.completed.Load()
+			rps := float64(completed-last) / now.Sub(lastTick).Seconds()
+			last, lastTick = completed, now
+			p.print(w, st, start, rps)
+		}
+	}
+}
+
+func (p *progress) print(w io.Writer, st *StressTest, start time.Time, rps float64) {
+	var total string
+	if duration := st.plannedDuration(); duration > 0 {
+		elapsed := time.Since(start).Truncate(time.Second)
+		total = fmt.Sprintf(" | Tempo: %v/%v", elapsed, duration)
+	} else {
+		total = fmt.Sprintf("/%d", st.Requests)
+	}
+	fmt.Fprintf(w, "\r\033[KRequests: %d%s | Erros: %d | RPS: %.1f",
+		p.completed.Load(), total, p.failed.Load(), rps)
+}
@@ -0,0 +1,112 @@
+package stress
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// randPlaceholder é substituído por um valor aleatório em cada request
+const randPlaceholder = "{{rand}}"
+
+// QueryParam é um parâmetro adicionado à query string de todas as requests.
+// Cada ocorrência de {{rand}} em Value recebe um valor aleatório diferente.
+type QueryParam struct {
+	Name  string
+	Value string
+}
+
+// ParseQueryParam interpreta um parâmetro no formato "nome=valor"
+func ParseQueryParam(raw string) (QueryParam, error) {
+	name, value, ok := strings.Cut(raw, "=")
+	if !ok || name == "" {
+		return QueryParam{}, fmt.Errorf("parâmetro de query inválido %q: use o formato \"nome=valor\"", raw)
+	}
+	return QueryParam{Name: name, Value: value}, nil
+}
+
+// queryBuilder acrescenta os parâmetros configurados à URL de cada request.
+// Os valores aleatórios vêm de um gerador com semente, então a mesma semente
+// reproduz a mesma sequência de URLs.
+type queryBuilder struct {
+	params []QueryParam
+	mu     sync.Mutex
+	rng    *rand.Rand
+}
+
+// newQueryBuilder retorna nil quando não há parâmetros a adicionar
+func newQueryBuilder(st *StressTest, seed uint64) *queryBuilder {
+	params := st.Query
+	if st.CacheBust != "" {
+		params = append([]QueryParam{{Name: st.CacheBust, Value: randPlaceholder}}, params...)
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	// A semente é deslocada para não repetir a sequência do sorteio de alvos
+	return &queryBuilder{params: params, rng: rand.New(rand.NewPCG(seed, seed+1))}
+}
+
+// random indica se algum parâmetro recebe valores aleatórios
+func (b *queryBuilder) random() bool {
+	if b == nil {
+		return false
+	}
+	for _, param := range b.params {
+		if strings.Contains(param.Value, randPlaceholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply acrescenta os parâmetros à URL sem reescrever a query original, que
+// é mantida exatamente como foi informada
+func (b *queryBuilder) apply(rawURL string) string {
+	if b == nil {
+		return rawURL
+	}
+	var query strings.Builder
+	for i, param := range b.params {
+		if i > 0 {
+			query.WriteByte('&')
+		}
+		query.WriteString(url.QueryEscape(param.Name))
+		query.WriteByte('=')
+		query.WriteString(url.QueryEscape(b.expand(param.Value)))
+	}
+
+	// O fragmento, se houver, continua no fim da URL
+	base, fragment, hasFragment := strings.Cut(rawURL, "#")
+	separator := "?"
+	if strings.Contains(base, "?") {
+		separator = "&"
+		if strings.HasSuffix(base, "?") || strings.HasSuffix(base, "&") {
+			separator = ""
+		}
+	}
+	result := base + separator + query.String()
+	if hasFragment {
+		result += "#" + fragment
+	}
+	return result
+}
+
+// expand substitui cada ocorrência de {{rand}} por um valor aleatório
+func (b *queryBuilder) expand(value string) string {
+	if !strings.Contains(value, randPlaceholder) {
+		return value
+	}
+	parts := strings.Split(value, randPlaceholder)
+	var expanded strings.Builder
+	expanded.WriteString(parts[0])
+	b.mu.Lock()
+	for _, part := range parts[1:] {
+		fmt.Fprintf(&expanded, "%016x", b.rng.Uint64())
+		expanded.WriteString(part)
+	}
+	b.mu.Unlock()
+	return expanded.String()
+}
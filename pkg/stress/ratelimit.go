@@ -0,0 +1,64 @@
+package stress
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter é um token bucket compartilhado entre os workers, limitando a
+// taxa combinada de requests independentemente da concorrência
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens gerados por segundo
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter cria um limitador de rps requests por segundo que permite
+// rajadas de até burst requests
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait bloqueia até que um token esteja disponível ou o contexto seja
+// cancelado. Quando o bucket está vazio o token é reservado antecipadamente,
+// de forma que cada chamada aguarda apenas a sua vez.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
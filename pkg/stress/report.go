@@ -0,0 +1,600 @@
+package stress
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Result representa o resultado de uma requisição individual
+type Result struct {
+	Timestamp time.Time
+	WorkerID  int
+	// Target identifica o alvo da request (ver Target.Label)
+	Target     string
+	StatusCode int
+	// GRPCCode é o código de status das chamadas gRPC (ex.: "OK",
+	// "Unavailable"), que substitui StatusCode
+	GRPCCode string
+	// Duration vai do envio da request até a leitura completa do corpo
+	Duration time.Duration
+	// ScheduleDelay é o atraso do envio em relação ao horário planejado da
+	// chegada no modelo aberto (ver ResponseTimeStats), preenchido apenas na
+	// primeira request de cada chegada
+	ScheduleDelay time.Duration
+	// TTFB vai do envio da request até o primeiro byte da resposta
+	TTFB time.Duration
+	// Phases é preenchido apenas com StressTest.Trace ativo
+	Phases Phases
+	// Connection indica se a request abriu uma conexão ou reaproveitou uma
+	// do pool
+	Connection ConnectionUse
+	BytesRead  int64
+	// Truncated indica que o corpo recebido foi menor que o Content-Length
+	// informado, geralmente porque o servidor fechou a conexão
+	Truncated bool
+	// BodyLimited indica que o corpo passou de StressTest.MaxBodyBytes e só
+	// os primeiros MaxBodyBytes foram lidos e contados em BytesRead
+	BodyLimited bool
+	// Compressed indica que a resposta veio com gzip e foi descomprimida pelo
+	// teste (StressTest.Compression = CompressionGzip). Nesse caso BytesRead
+	// conta os bytes comprimidos, DecodedBytes os descomprimidos e
+	// Decompression o tempo gasto descomprimindo, incluído em Duration.
+	Compressed    bool
+	DecodedBytes  int64
+	Decompression time.Duration
+	// BytesSent é o tamanho do corpo enviado na request
+	BytesSent  int64
+	Redirected bool
+	ProtoMajor int
+	ProtoMinor int
+	Error      error
+	// ErrorCategory classifica Error (ex.: "timeout", "dns", "tls")
+	ErrorCategory string
+	// Canceled indica que a request foi interrompida pelo encerramento do teste
+	Canceled bool
+	// Body guarda o início do corpo (até StressTest.MaxCapturedBody) das
+	// respostas reprovadas nas asserções
+	Body []byte
+	// WebSocket é preenchido no modo WebSocket (ver StressTest.WebSocket)
+	WebSocket *WebSocketResult
+	// SSE é preenchido no modo SSE (ver StressTest.SSE)
+	SSE *SSEResult
+	// Method, URL, RequestHeader e ResponseHeader descrevem a request HTTP
+	// executada e são preenchidos apenas com StressTest.RecordRequests
+	Method         string
+	URL            string
+	RequestHeader  http.Header
+	ResponseHeader http.Header
+	// Capture é preenchido nas falhas capturadas (ver
+	// StressTest.CaptureFailures)
+	Capture *FailureCapture
+	// Iteration é preenchido no último passo executado de cada iteração de
+	// um Scenario
+	Iteration *IterationResult
+	// Retry é preenchido com StressTest.Retry
+	Retry *RetryResult
+	// RetryAfter é a pausa pedida pelo header Retry-After de uma resposta 429
+	// ou 503, limitada por StressTest.RetryAfterMax, preenchida apenas com
+	// StressTest.RespectRetryAfter; RetryAfterPauses e RetryAfterWait contam
+	// as pausas feitas pelo worker nesta request, incluindo as tentativas
+	// anteriores, e o tempo pausado
+	RetryAfter       time.Duration
+	RetryAfterPauses int
+	RetryAfterWait   time.Duration
+	// TraceID e SpanID são os IDs, em hexadecimal, enviados no traceparent
+	// com StressTest.TraceContext; TraceSampled indica a flag sampled
+	TraceID      string
+	SpanID       string
+	TraceSampled bool
+}
+
+// ConnectionUse descreve a conexão usada por uma request
+type ConnectionUse uint8
+
+const (
+	// ConnectionUnknown é usado quando o transporte não informa a conexão
+	// (ex.: HTTP/3) ou a request falhou antes de obter uma
+	ConnectionUnknown ConnectionUse = iota
+	ConnectionNew
+	ConnectionReused
+)
+
+// WebSocketResult descreve um evento de uma conexão do modo WebSocket: o
+// handshake (com Duration sendo o tempo do handshake), o envio de uma
+// mensagem (com Duration sendo o round-trip até a resposta) ou, sem
+// StressTest.Body, as mensagens recebidas com a conexão ociosa. Apenas os
+// envios e os handshakes que falharam contam como requests.
+type WebSocketResult struct {
+	Handshake bool
+	Sent      bool
+	// Received conta as mensagens recebidas, inclusive as enviadas pelo
+	// servidor sem pedido
+	Received int
+	// Disconnected indica que a conexão caiu, com o motivo em Result.Error
+	Disconnected bool
+}
+
+// SSEResult descreve um evento de um stream do modo SSE: um lote com os
+// eventos recebidos desde o anterior ou, com End, o encerramento do stream,
+// que conta como request, com Duration sendo o tempo até o primeiro evento.
+// Os totais do stream ficam apenas no resultado com End.
+type SSEResult struct {
+	End bool
+	// Connected indica que o stream chegou a ser aberto
+	Connected bool
+	// Disconnected indica que o stream caiu antes do fim do teste, com o
+	// motivo em Result.Error
+	Disconnected bool
+	Events       int
+	// Malformed conta as linhas fora do formato do protocolo
+	Malformed int
+	// FirstEvent é o tempo da abertura do stream até o primeiro evento
+	FirstEvent time.Duration
+	// Gaps são os intervalos entre os eventos consecutivos do lote
+	Gaps []time.Duration
+}
+
+// IterationResult resume uma iteração de um Scenario
+type IterationResult struct {
+	Start time.Time
+	// Duration vai do início do primeiro passo ao fim do último executado
+	Duration time.Duration
+	// FailedStep é o rótulo do passo que falhou e interrompeu a iteração
+	// (vazio quando todos os passos foram concluídos)
+	FailedStep string
+}
+
+// Report contém todas as métricas do teste
+type Report struct {
+	Method             string
+	TotalRequests      int
+	SuccessfulRequests int
+	FailedRequests     int
+	// TransportErrors conta as falhas sem resposta, UnexpectedStatus as
+	// respostas com status fora de ExpectStatus e ApplicationErrors as
+	// respostas com status esperado reprovadas na verificação do corpo
+	// (asserções, extrações, erros GraphQL)
+	TransportErrors   int
+	UnexpectedStatus  int
+	ApplicationErrors int
+	// ExpectStatus repete StressTest.ExpectStatus (vazio = 2xx e 3xx)
+	ExpectStatus StatusRanges
+	// Apdex é calculado quando StressTest.ApdexT está definido
+	Apdex *ApdexScore
+	// Thresholds traz o resultado de cada StressTest.Thresholds, na mesma
+	// ordem
+	Thresholds []ThresholdResult
+	// AssertionFailures conta as falhas de cada asserção, indexadas pela
+	// descrição (BodyAssertion.String ou JSONAssertion.String)
+	AssertionFailures map[string]int
+	// GraphQLErrors conta as respostas por mensagem do primeiro erro GraphQL
+	GraphQLErrors map[string]int
+	// ErrorCategories agrupa os erros por categoria: os de transporte e os
+	// detectados na resposta, como ErrorExtraction
+	ErrorCategories    map[string]int
+	RedirectedRequests int
+	CanceledRequests   int
+	// WarmupRequests é a quantidade de requests da fase de aquecimento,
+	// excluídas de todas as demais métricas
+	WarmupRequests int
+	// DataExhausted indica que o teste parou ao fim das linhas de dados
+	// (StressTest.StopWhenDataExhausted)
+	DataExhausted bool
+	// Aborted indica que o teste foi interrompido por
+	// StressTest.AbortOnErrorRate ou AbortOnConsecutiveErrors, com o motivo
+	// em AbortReason
+	Aborted     bool
+	AbortReason string
+	Interrupted bool
+	// InterruptCause é a causa do cancelamento do contexto recebido por Run
+	// (ex.: context.DeadlineExceeded), definida quando Interrupted é true
+	InterruptCause error
+	TotalTime      time.Duration
+	TargetRPS      float64
+	// ArrivalRate, MaxInFlight e ArrivalDistribution repetem a configuração
+	// do modelo aberto (StressTest.ArrivalRate). Arrivals conta as chegadas
+	// geradas e DroppedArrivals as descartadas por encontrarem MaxInFlight
+	// requests em andamento, que não foram enviadas e não entram em
+	// TotalRequests. AchievedArrivalRate é a taxa de chegadas gerada de fato
+	// e PeakInFlight o maior número de chegadas em andamento ao mesmo tempo,
+	// que revela as rajadas de ArrivalPoisson. Com StressTest.Stages,
+	// ArrivalRate é a taxa média planejada das etapas.
+	ArrivalRate         float64
+	MaxInFlight         int
+	ArrivalDistribution ArrivalDistribution
+	Arrivals            int
+	DroppedArrivals     int
+	AchievedArrivalRate float64
+	PeakInFlight        int
+	RequestsPerSecond   float64
+	// SuccessfulRequestsPerSecond considera apenas as respostas 2xx/3xx
+	SuccessfulRequestsPerSecond float64
+	// PlannedDuration é a duração configurada no modo por duração
+	PlannedDuration time.Duration
+	// BytesSent e BytesReceived somam os corpos enviados e recebidos (sem
+	// os headers) por todas as requests concluídas
+	BytesSent     int64
+	BytesReceived int64
+	// Compression resume as respostas comprimidas quando StressTest.Compression
+	// é CompressionGzip
+	Compression *CompressionStats
+	// WebSocket resume as conexões quando StressTest.WebSocket está definido
+	WebSocket *WebSocketStats
+	// SSE resume os streams quando StressTest.SSE está definido
+	SSE *SSEStats
+	// MinResponseSize, MaxResponseSize e AvgResponseSize resumem o tamanho
+	// dos corpos das respostas recebidas
+	MinResponseSize int64
+	MaxResponseSize int64
+	AvgResponseSize float64
+	// TruncatedResponses conta as respostas com corpo menor que o
+	// Content-Length (ver Result.Truncated)
+	TruncatedResponses int
+	// BodyLimitedResponses conta as respostas abandonadas em
+	// StressTest.MaxBodyBytes, cujos tamanhos contam apenas até o limite
+	BodyLimitedResponses int
+	// BodySkipped repete StressTest.NoBodyRead: sem a leitura dos corpos,
+	// BytesReceived e os tamanhos das respostas não foram medidos
+	BodySkipped bool
+	// NewConnections e ReusedConnections contam as requests que abriram uma
+	// conexão e as que reaproveitaram uma do pool (ver Result.Connection)
+	NewConnections    int
+	ReusedConnections int
+	RampUp            time.Duration
+	FullConcurrencyAt time.Duration
+	// ThinkTime e ThinkTimeJitter repetem a pausa configurada entre as
+	// requests de cada worker, que limita a vazão possível
+	ThinkTime       time.Duration
+	ThinkTimeJitter time.Duration
+	Settings        map[string]string
+	// Targets detalha as requests por alvo, indexado por Target.Label, ou
+	// por passo (Step.Label) quando há um Scenario
+	Targets map[string]*TargetReport
+	// Scenario resume as iterações quando StressTest.Scenario está definido
+	Scenario *ScenarioStats
+	// Workers detalha as requests por worker, indexado por Result.WorkerID,
+	// quando StressTest.WorkerTransport ou ClientIDHeader está definido
+	Workers []WorkerReport
+	// Seed é a semente usada no sorteio ponderado dos alvos, nos valores
+	// aleatórios da query string, no sorteio dos User-Agents e nos
+	// intervalos de ArrivalPoisson (zero quando nada foi sorteado)
+	Seed        uint64
+	StatusCodes map[int]int
+	// GRPCMethod e GRPCCodes substituem Method e StatusCodes quando
+	// StressTest.GRPC está definido
+	GRPCMethod       string
+	GRPCCodes        map[string]int
+	Protocols        map[string]int
+	ExpectedProtocol string
+	MinDuration      time.Duration
+	MaxDuration      time.Duration
+	AvgDuration      time.Duration
+	// StdDevDuration e CoefficientOfVariation (desvio padrão / média) cobrem
+	// a mesma população de MinDuration, MaxDuration e AvgDuration: todas as
+	// requests que receberam resposta, com qualquer status HTTP, exceto as
+	// do ramp-up quando ExcludeRampUp está ativo
+	StdDevDuration         time.Duration
+	CoefficientOfVariation float64
+	// TTFB resume o tempo até o primeiro byte, sobre a mesma população das
+	// demais métricas de duração
+	TTFB DurationStats
+	// Phases agrega as fases das requests quando StressTest.Trace está ativo
+	Phases *PhaseStats
+	P50    time.Duration
+	P90    time.Duration
+	P95    time.Duration
+	P99    time.Duration
+	// As métricas de duração acima medem o tempo de serviço; ResponseTime
+	// traz o tempo de resposta corrigido para a omissão coordenada, apenas
+	// com ArrivalRate, Stages com Rate ou RPS
+	ResponseTime *ResponseTimeStats
+	// Retries traz as tentativas das requests com StressTest.Retry
+	Retries *RetryStats
+	// RetryAfter traz as pausas de StressTest.RespectRetryAfter
+	RetryAfter *RetryAfterStats
+	// Slowest traz as requests mais lentas com StressTest.SlowestRequests
+	Slowest *SlowestStats
+	// Timeline traz as requests agrupadas em intervalos de TimelineInterval,
+	// do início ao fim do teste, incluindo os intervalos sem requests
+	Timeline         []TimelinePoint
+	TimelineInterval time.Duration
+	// Stages traz as métricas de cada etapa de StressTest.Stages, pelas
+	// requests iniciadas durante ela, além dos totais do teste inteiro
+	Stages []*StageReport
+	// Spike compara o pico de StressTest.Spike com a base, antes e depois
+	Spike *SpikeReport
+	// Trend compara os primeiros e os últimos 10% de um teste com
+	// StressTest.Soak
+	Trend *TrendReport
+	// FindMax traz a busca de StressTest.FindMax, no Report da sonda do
+	// maior nível aprovado
+	FindMax *FindMaxReport
+	// HistogramMax é o maior valor registrável no histograma de durações;
+	// ClampedDurations conta as durações acima dele, registradas como o máximo
+	HistogramMax     time.Duration
+	ClampedDurations int64
+	// Merged é a quantidade de relatórios combinados por Merge (zero em um
+	// relatório de uma única execução), e MergeConflicts descreve as
+	// diferenças de configuração encontradas entre eles
+	Merged         int
+	MergeConflicts []string
+
+	latencies *Histogram
+}
+
+// Histogram retorna o histograma das durações consideradas nas métricas
+// (nil em um relatório sem durações)
+func (r *Report) Histogram() *Histogram {
+	return r.latencies
+}
+
+// SetHistogram define o histograma de durações de um relatório reconstruído
+// (ex.: lido de um arquivo), usado por ValueAtQuantile, pelas distribuições
+// e por Merge
+func (r *Report) SetHistogram(h *Histogram) {
+	r.latencies = h
+}
+
+// ApdexScore resume a satisfação dos usuários pelo índice Apdex: respostas
+// até T satisfazem, até 4T são toleradas e as demais, assim como as falhas,
+// frustram. Score = (Satisfied + Tolerating/2) / total.
+type ApdexScore struct {
+	T          time.Duration
+	Satisfied  int
+	Tolerating int
+	Frustrated int
+	Score      float64
+}
+
+// ThresholdsPassed indica se nenhum dos Thresholds foi violado
+func (r *Report) ThresholdsPassed() bool {
+	for _, result := range r.Thresholds {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// WebSocketStats resume as conexões do modo WebSocket. As métricas de
+// duração do Report medem o round-trip das mensagens.
+type WebSocketStats struct {
+	ConnectionsEstablished int
+	// ConnectionFailures conta os handshakes que falharam ou foram recusados
+	ConnectionFailures int
+	// Disconnects conta as conexões encerradas antes do fim do teste, por
+	// categoria em DisconnectReasons
+	Disconnects       int
+	DisconnectReasons map[string]int
+	MessagesSent      int
+	MessagesReceived  int
+	Handshake         DurationStats
+	// RTT resume o round-trip das mensagens respondidas
+	RTT DurationStats
+}
+
+// SSEStats resume os streams do modo SSE. As métricas de duração do Report
+// medem o tempo até o primeiro evento de cada stream.
+type SSEStats struct {
+	// Connections conta os streams abertos e ConnectionFailures as aberturas
+	// que falharam ou foram recusadas
+	Connections        int
+	ConnectionFailures int
+	// Disconnects conta os streams encerrados antes do fim do teste, por
+	// categoria em DisconnectReasons
+	Disconnects       int
+	DisconnectReasons map[string]int
+	Events            int
+	EventsPerSecond   float64
+	MalformedLines    int
+	// MinEventsPerConnection, MaxEventsPerConnection e
+	// AvgEventsPerConnection resumem os eventos recebidos por stream
+	MinEventsPerConnection int
+	MaxEventsPerConnection int
+	AvgEventsPerConnection float64
+	FirstEvent             DurationStats
+	// InterEvent resume o intervalo entre eventos consecutivos do mesmo
+	// stream
+	InterEvent DurationStats
+}
+
+// hasHTTPStatus indica se StatusCode define o sucesso do resultado: as
+// chamadas gRPC usam GRPCCode, e as mensagens WebSocket não têm status
+func (r Result) hasHTTPStatus() bool {
+	return r.GRPCCode == "" && (r.WebSocket == nil || r.WebSocket.Handshake)
+}
+
+// CompressionStats resume as respostas descomprimidas pelo teste
+type CompressionStats struct {
+	CompressedResponses int
+	// WireBytes soma os corpos comprimidos, como trafegaram, e DecodedBytes
+	// os mesmos corpos descomprimidos
+	WireBytes    int64
+	DecodedBytes int64
+	// Ratio é DecodedBytes / WireBytes
+	Ratio float64
+	// Decompression resume o tempo gasto descomprimindo cada resposta,
+	// separado do tempo de rede
+	Decompression DurationStats
+}
+
+// ScenarioStats resume as iterações de um Scenario. Iterações interrompidas
+// pelo fim do teste não entram em nenhuma contagem.
+type ScenarioStats struct {
+	Iterations          int
+	CompletedIterations int
+	AbortedIterations   int
+	// AbortedBySteps conta as iterações abortadas por rótulo do passo que
+	// falhou
+	AbortedBySteps map[string]int
+	// Durations resume a duração das iterações concluídas
+	Durations DurationStats
+}
+
+// TargetReport contém as métricas de um alvo do teste, calculadas como as
+// métricas equivalentes do Report
+type TargetReport struct {
+	Requests           int
+	SuccessfulRequests int
+	FailedRequests     int
+	// Weight é o peso configurado do alvo
+	Weight      int
+	StatusCodes map[int]int
+	Durations   DurationStats
+
+	latencies *Histogram
+}
+
+// Histogram retorna o histograma das durações do alvo, como Report.Histogram
+func (t *TargetReport) Histogram() *Histogram {
+	return t.latencies
+}
+
+// SetHistogram define o histograma de durações do alvo, como
+// Report.SetHistogram
+func (t *TargetReport) SetHistogram(h *Histogram) {
+	t.latencies = h
+}
+
+// SuccessRate retorna a fração das requests do alvo com sucesso (2xx/3xx)
+func (t *TargetReport) SuccessRate() float64 {
+	if t.Requests == 0 {
+		return 0
+	}
+	return float64(t.SuccessfulRequests) / float64(t.Requests)
+}
+
+// DurationStats resume uma distribuição de durações
+type DurationStats struct {
+	Min time.Duration
+	Max time.Duration
+	Avg time.Duration
+	P50 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+}
+
+// PhaseStats agrega as fases das requests. DNS, Connect e TLS consideram
+// apenas as requests que abriram uma nova conexão, para que as conexões
+// reaproveitadas (com tempo zero nessas fases) não reduzam as médias.
+type PhaseStats struct {
+	NewConnections    int
+	ReusedConnections int
+	DNS               DurationStats
+	Connect           DurationStats
+	TLS               DurationStats
+	Server            DurationStats
+}
+
+// ReceivedBytesPerSecond retorna a vazão média de dados recebidos
+func (r *Report) ReceivedBytesPerSecond() float64 {
+	if r.TotalTime <= 0 {
+		return 0
+	}
+	return float64(r.BytesReceived) / r.TotalTime.Seconds()
+}
+
+// DroppedArrivalRate retorna a fração das chegadas do modelo aberto que foi
+// descartada
+func (r *Report) DroppedArrivalRate() float64 {
+	if r.Arrivals == 0 {
+		return 0
+	}
+	return float64(r.DroppedArrivals) / float64(r.Arrivals)
+}
+
+// SentBytesPerSecond retorna a vazão média de dados enviados
+func (r *Report) SentBytesPerSecond() float64 {
+	if r.TotalTime <= 0 {
+		return 0
+	}
+	return float64(r.BytesSent) / r.TotalTime.Seconds()
+}
+
+// ValueAtQuantile retorna a duração abaixo da qual se encontram p% das
+// requests consideradas nas métricas de duração (p entre 0 e 100, ex.: 99.9)
+func (r *Report) ValueAtQuantile(p float64) time.Duration {
+	if r.latencies == nil {
+		return 0
+	}
+	return r.latencies.ValueAtQuantile(p)
+}
+
+// LatencyBucket é uma faixa da distribuição de durações, com as requests
+// de duração entre From (inclusive) e To
+type LatencyBucket struct {
+	From  time.Duration
+	To    time.Duration
+	Count int64
+}
+
+// LatencyDistribution divide as durações consideradas nas métricas em n
+// faixas de largura logarítmica entre a menor e a maior duração, com a
+// última faixa incluindo a maior. A contagem usa o histograma de
+// latências, então as faixas têm a precisão de HistogramSigFigs.
+func (r *Report) LatencyDistribution(n int) []LatencyBucket {
+	if r.latencies == nil || r.latencies.TotalCount() == 0 || n < 1 {
+		return nil
+	}
+	lowest := max(r.latencies.min, histogramLowest)
+	highest := max(r.latencies.max, lowest+1)
+	ratio := math.Pow(float64(highest)/float64(lowest), 1/float64(n))
+	buckets := make([]LatencyBucket, n)
+	for i := range buckets {
+		buckets[i].From = time.Duration(float64(lowest) * math.Pow(ratio, float64(i)))
+		buckets[i].To = time.Duration(float64(lowest) * math.Pow(ratio, float64(i+1)))
+	}
+	buckets[0].From = time.Duration(r.latencies.min)
+	buckets[n-1].To = time.Duration(highest)
+	r.countLatencies(buckets)
+	return buckets
+}
+
+// LatencyBuckets divide as durações consideradas nas métricas nas faixas
+// delimitadas por bounds, em ordem crescente: antes do primeiro limite,
+// entre cada par e a partir do último, até a maior duração. Como em
+// LatencyDistribution, a contagem usa o histograma de latências.
+func (r *Report) LatencyBuckets(bounds []time.Duration) []LatencyBucket {
+	if r.latencies == nil || r.latencies.TotalCount() == 0 {
+		return nil
+	}
+	buckets := make([]LatencyBucket, len(bounds)+1)
+	for i, bound := range bounds {
+		buckets[i].To = bound
+		buckets[i+1].From = bound
+	}
+	buckets[len(bounds)].To = max(time.Duration(r.latencies.max), buckets[len(bounds)].From)
+	r.countLatencies(buckets)
+	return buckets
+}
+
+// countLatencies soma as amostras do histograma na primeira faixa cujo To
+// é maior que o valor, ou na última
+func (r *Report) countLatencies(buckets []LatencyBucket) {
+	r.latencies.forEach(func(value, count int64) {
+		i, _ := slices.BinarySearchFunc(buckets[:len(buckets)-1], time.Duration(value), func(bucket LatencyBucket, d time.Duration) int {
+			if bucket.To <= d {
+				return -1
+			}
+			return 1
+		})
+		buckets[i].Count += count
+	})
+}
+
+// ProtocolMismatches retorna quantas respostas usaram um protocolo diferente
+// de ExpectedProtocol
+func (r *Report) ProtocolMismatches() int {
+	if r.ExpectedProtocol == "" {
+		return 0
+	}
+	var mismatches int
+	for protocol, count := range r.Protocols {
+		if protocol != r.ExpectedProtocol {
+			mismatches += count
+		}
+	}
+	return mismatches
+}
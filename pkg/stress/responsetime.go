@@ -0,0 +1,117 @@
+package stress
+
+import "time"
+
+// lateTolerance é o atraso de envio a partir do qual uma request do modelo
+// aberto conta em ResponseTimeStats.LateRequests, acima da imprecisão dos
+// timers
+const lateTolerance = time.Millisecond
+
+// ResponseTimeStats resume o tempo de resposta dos testes com um ritmo
+// planejado (StressTest.ArrivalRate, Stages com Rate ou RPS), corrigido para
+// a omissão coordenada: quando o alvo trava, os workers param de enviar e a
+// espera das requests que deveriam ter saído nesse período some das métricas
+// de duração do Report, que medem o tempo de serviço, do envio real até a
+// resposta. O tempo de resposta inclui essa espera, como a percebida por um
+// usuário que chegou no horário planejado.
+//
+// No modelo aberto, cada request é medida a partir do horário planejado da
+// sua chegada. No fechado, com RPS, as amostras das requests que não
+// chegaram a ser enviadas durante uma resposta lenta são sintetizadas como no
+// HdrHistogram: uma resposta de duração d registra também d-i, d-2i... até i,
+// sendo i o intervalo esperado entre as requests de cada worker
+// (Concurrency/RPS).
+type ResponseTimeStats struct {
+	Durations DurationStats
+	P90       time.Duration
+	// Requests conta as requests medidas; SynthesizedSamples, as amostras
+	// acrescentadas no modelo fechado, com ExpectedInterval
+	Requests           int
+	SynthesizedSamples int64
+	ExpectedInterval   time.Duration
+	// LateRequests conta as requests do modelo aberto enviadas mais de 1ms
+	// depois do horário planejado; AvgDelay e MaxDelay resumem o atraso de
+	// todas
+	LateRequests int
+	AvgDelay     time.Duration
+	MaxDelay     time.Duration
+
+	latencies *Histogram
+}
+
+// Histogram retorna o histograma dos tempos de resposta, como
+// Report.Histogram
+func (s *ResponseTimeStats) Histogram() *Histogram {
+	return s.latencies
+}
+
+// SetHistogram define o histograma dos tempos de resposta, como
+// Report.SetHistogram
+func (s *ResponseTimeStats) SetHistogram(h *Histogram) {
+	s.latencies = h
+}
+
+// scheduleEmit repassa os resultados de uma iteração a emit, com o atraso de
+// envio da primeira request em relação ao horário planejado scheduled (zero
+// sem ritmo planejado)
+func scheduleEmit(emit func(Result), scheduled time.Time) func(Result) {
+	if scheduled.IsZero() {
+		return emit
+	}
+	return func(result Result) {
+		if !scheduled.IsZero() {
+			result.ScheduleDelay = max(result.firstAttempt().Sub(scheduled), 0)
+			scheduled = time.Time{}
+		}
+		emit(result)
+	}
+}
+
+// responseTimeRecorder acumula Report.ResponseTime
+type responseTimeRecorder struct {
+	stats     *ResponseTimeStats
+	durations *durationRecorder
+	delays    time.Duration
+}
+
+// newResponseTimeRecorder retorna nil sem ritmo planejado, e nos modos
+// WebSocket e SSE, em que as durações não medem requests
+func newResponseTimeRecorder(st *StressTest) *responseTimeRecorder {
+	if (!st.openModel() && st.RPS == 0) || st.WebSocket != nil || st.SSE != nil {
+		return nil
+	}
+	r := &responseTimeRecorder{stats: &ResponseTimeStats{}, durations: newDurationRecorder(st)}
+	if !st.openModel() {
+		r.stats.ExpectedInterval = time.Duration(float64(st.workers()) / st.RPS * float64(time.Second))
+	}
+	return r
+}
+
+// add registra uma request com duração medida
+func (r *responseTimeRecorder) add(result Result) {
+	r.stats.Requests++
+	// Com StressTest.Retry, a espera do usuário inclui as retentativas
+	duration := result.totalDuration()
+	r.durations.add(duration + result.ScheduleDelay)
+	r.delays += result.ScheduleDelay
+	r.stats.MaxDelay = max(r.stats.MaxDelay, result.ScheduleDelay)
+	if result.ScheduleDelay > lateTolerance {
+		r.stats.LateRequests++
+	}
+	if interval := r.stats.ExpectedInterval; interval > 0 {
+		for missing := duration - interval; missing >= interval; missing -= interval {
+			r.durations.add(missing)
+			r.stats.SynthesizedSamples++
+		}
+	}
+}
+
+func (r *responseTimeRecorder) finish() *ResponseTimeStats {
+	r.stats.Durations = r.durations.stats()
+	r.stats.P90 = r.durations.histogram.ValueAtQuantile(90)
+	r.stats.latencies = r.durations.histogram
+	if r.stats.Requests > 0 {
+		r.stats.AvgDelay = r.delays / time.Duration(r.stats.Requests)
+	}
+	return r.stats
+}
@@ -0,0 +1,283 @@
+package stress
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Padrões de Retry para os campos zerados
+const (
+	defaultRetryBackoffBase = 100 * time.Millisecond
+	defaultRetryBackoffMax  = 5 * time.Second
+)
+
+// Retry repete as requests HTTP que falham por erro de transporte (ex.:
+// conexão recusada, timeout) e, opcionalmente, as que recebem um dos
+// status de Status (ex.: 502-504), até Max vezes além da primeira tentativa.
+// Antes da n-ésima retentativa o worker espera um backoff exponencial com
+// jitter: um tempo sorteado entre a metade e o total de
+// min(BackoffMax, BackoffBase * 2^(n-1)). As esperas respeitam o
+// encerramento do teste.
+//
+// Cada request lógica conta uma vez no Report, com o resultado da última
+// tentativa: as métricas de duração medem essa tentativa, e Report.Retries
+// traz as tentativas e o tempo total de cada request, com as anteriores e
+// as esperas.
+type Retry struct {
+	Max int
+	// BackoffBase e BackoffMax são a espera da primeira retentativa e o
+	// limite das seguintes (padrão: 100ms e 5s)
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Status são os status HTTP repetidos além dos erros de transporte
+	// (vazio = apenas erros de transporte)
+	Status StatusRanges
+}
+
+// ParseRetryBackoff interpreta o backoff no formato "base,max" (ex.:
+// "100ms,5s"), com max opcional
+func ParseRetryBackoff(text string) (base, limit time.Duration, err error) {
+	first, second, hasMax := strings.Cut(text, ",")
+	if base, err = time.ParseDuration(strings.TrimSpace(first)); err != nil {
+		return 0, 0, fmt.Errorf("base inválida %q", first)
+	}
+	if hasMax {
+		if limit, err = time.ParseDuration(strings.TrimSpace(second)); err != nil {
+			return 0, 0, fmt.Errorf("máximo inválido %q", second)
+		}
+	}
+	switch {
+	case base <= 0:
+		return 0, 0, errors.New("a base deve ser positiva")
+	case hasMax && limit < base:
+		return 0, 0, errors.New("o máximo deve ser ao menos a base")
+	}
+	return base, limit, nil
+}
+
+func validRetry(r Retry) error {
+	switch {
+	case r.Max < 1:
+		return errors.New("Retry requer Max de ao menos 1")
+	case r.BackoffBase < 0 || r.BackoffMax < 0:
+		return errors.New("BackoffBase e BackoffMax não podem ser negativos")
+	case r.BackoffMax > 0 && r.BackoffMax < cmp.Or(r.BackoffBase, defaultRetryBackoffBase):
+		return errors.New("BackoffMax deve ser ao menos BackoffBase")
+	case !validStatusRanges(r.Status):
+		return errors.New("Retry.Status deve conter intervalos de status entre 100 e 599, com Min <= Max")
+	}
+	return nil
+}
+
+// retryable indica se o resultado de uma tentativa deve ser repetido, quando
+// ainda restam retentativas
+func (r *Retry) retryable(result Result) bool {
+	if result.Canceled {
+		return false
+	}
+	if result.Error != nil && result.StatusCode == 0 {
+		// Sem categoria, o erro é da montagem da request e se repetiria
+		return result.ErrorCategory != ""
+	}
+	return r.Status.Contains(result.StatusCode)
+}
+
+// backoff sorteia a espera antes da retentativa n (a partir de 1)
+func (r *Retry) backoff(n int) time.Duration {
+	base := cmp.Or(r.BackoffBase, defaultRetryBackoffBase)
+	limit := max(cmp.Or(r.BackoffMax, defaultRetryBackoffMax), base)
+	ceiling := limit
+	// O deslocamento é limitado para não transbordar
+	if n-1 < 32 && base<<(n-1) < limit {
+		ceiling = base << (n - 1)
+	}
+	return ceiling/2 + time.Duration(rand.Int64N(int64(ceiling-ceiling/2)+1))
+}
+
+// RetryResult descreve as tentativas de uma request com StressTest.Retry
+type RetryResult struct {
+	// Attempts conta as tentativas, incluindo a primeira
+	Attempts int
+	// Total vai do envio da primeira tentativa até o fim da última, com as
+	// esperas; Backoff é a soma das esperas, incluindo as pausas de
+	// Retry-After antes das retentativas
+	Total   time.Duration
+	Backoff time.Duration
+	// Exhausted indica que a última tentativa também falhou de forma
+	// repetível, esgotando as retentativas
+	Exhausted bool
+}
+
+// executeWithRetry executa a request com as retentativas de StressTest.Retry,
+// retornando o resultado da última tentativa. As retentativas que só
+// começariam depois do fim do modo por duração não são feitas, e com o teste
+// cancelado durante uma espera o resultado da tentativa anterior é marcado
+// como Canceled. Com StressTest.RespectRetryAfter, a pausa pedida pela
+// resposta substitui o backoff da retentativa ou, sem ela, é feita antes de
+// o resultado ser entregue, ocupando o worker até a próxima request.
+func (st *StressTest) executeWithRetry(ctx context.Context, workerID int, client *http.Client, state *runState, spec requestSpec, data map[string]string) Result {
+	retry := st.Retry
+	start := time.Now()
+	var info *RetryResult
+	if retry != nil {
+		info = &RetryResult{}
+	}
+	// pauses e paused acumulam as pausas de Retry-After das tentativas
+	var pauses int
+	var paused time.Duration
+	for attempt := 1; ; attempt++ {
+		more := retry != nil && attempt <= retry.Max
+		result := st.execute(ctx, workerID, client, state, spec, data, more)
+		retrying := more && retry.retryable(result)
+		if info != nil {
+			info.Attempts = attempt
+			info.Total = time.Since(start)
+			info.Exhausted = !more && retry.retryable(result)
+			result.Retry = info
+		}
+		wait := result.RetryAfter
+		if wait > 0 {
+			pauses++
+		} else if retrying {
+			wait = retry.backoff(attempt)
+		}
+		// Como as pausas de think, a espera não ultrapassa o fim do modo por
+		// duração, e a retentativa não chega a ser feita
+		if !state.deadline.IsZero() {
+			wait = min(wait, time.Until(state.deadline))
+		}
+		waitStart := time.Now()
+		ok := sleepContext(ctx, wait)
+		waited := max(wait, 0)
+		if !ok {
+			waited = time.Since(waitStart)
+		}
+		if result.RetryAfter > 0 {
+			paused += waited
+		}
+		result.RetryAfterPauses, result.RetryAfterWait = pauses, paused
+		switch {
+		case !retrying:
+			return result
+		case !ok:
+			result.Canceled = true
+			return result
+		}
+		info.Backoff += waited
+		if !state.deadline.IsZero() && !time.Now().Before(state.deadline) {
+			return result
+		}
+	}
+}
+
+// firstAttempt retorna o envio da primeira tentativa do resultado
+func (r Result) firstAttempt() time.Time {
+	if r.Retry == nil {
+		return r.Timestamp
+	}
+	return r.Timestamp.Add(r.Duration - r.Retry.Total)
+}
+
+// totalDuration retorna a duração da request com as retentativas
+func (r Result) totalDuration() time.Duration {
+	if r.Retry == nil {
+		return r.Duration
+	}
+	return r.Retry.Total
+}
+
+// RetryStats resume as tentativas das requests com StressTest.Retry.
+// Requests conta as requests lógicas, como Report.TotalRequests, e Attempts
+// as tentativas enviadas; Retried, as requests repetidas ao menos uma vez,
+// das quais Recovered terminaram com sucesso e Exhausted esgotaram as
+// retentativas. Durations e P90 medem o tempo total das requests, com as
+// tentativas anteriores e as esperas, sobre a mesma população das métricas
+// de duração do Report, que medem apenas a última tentativa.
+type RetryStats struct {
+	// Max, BackoffBase, BackoffMax e Status repetem a configuração de
+	// StressTest.Retry, com os padrões aplicados
+	Max         int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	Status      StatusRanges
+	Requests    int
+	Attempts    int
+	Retried     int
+	Recovered   int
+	Exhausted   int
+	// Backoff é a soma das esperas entre as tentativas
+	Backoff   time.Duration
+	Durations DurationStats
+	P90       time.Duration
+
+	latencies *Histogram
+}
+
+// Histogram retorna o histograma dos tempos totais, como Report.Histogram
+func (s *RetryStats) Histogram() *Histogram {
+	return s.latencies
+}
+
+// SetHistogram define o histograma dos tempos totais, como
+// Report.SetHistogram
+func (s *RetryStats) SetHistogram(h *Histogram) {
+	s.latencies = h
+}
+
+// retryRecorder acumula Report.Retries
+type retryRecorder struct {
+	stats     *RetryStats
+	durations *durationRecorder
+}
+
+// newRetryRecorder retorna nil sem StressTest.Retry
+func newRetryRecorder(st *StressTest) *retryRecorder {
+	if st.Retry == nil {
+		return nil
+	}
+	base := cmp.Or(st.Retry.BackoffBase, defaultRetryBackoffBase)
+	stats := &RetryStats{
+		Max:         st.Retry.Max,
+		BackoffBase: base,
+		BackoffMax:  max(cmp.Or(st.Retry.BackoffMax, defaultRetryBackoffMax), base),
+		Status:      st.Retry.Status,
+	}
+	return &retryRecorder{stats: stats, durations: newDurationRecorder(st)}
+}
+
+// add registra uma request concluída; failed indica se ela conta como falha
+func (r *retryRecorder) add(result Result, failed bool) {
+	if result.Retry == nil {
+		return
+	}
+	r.stats.Requests++
+	r.stats.Attempts += result.Retry.Attempts
+	r.stats.Backoff += result.Retry.Backoff
+	if result.Retry.Attempts > 1 {
+		r.stats.Retried++
+		if !failed {
+			r.stats.Recovered++
+		}
+	}
+	if result.Retry.Exhausted {
+		r.stats.Exhausted++
+	}
+}
+
+// measure registra o tempo total de uma request com duração medida
+func (r *retryRecorder) measure(result Result) {
+	r.durations.add(result.totalDuration())
+}
+
+func (r *retryRecorder) finish() *RetryStats {
+	r.stats.Durations = r.durations.stats()
+	r.stats.P90 = r.durations.histogram.ValueAtQuantile(90)
+	r.stats.latencies = r.durations.histogram
+	return r.stats
+}
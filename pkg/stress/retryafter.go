@@ -0,0 +1,54 @@
+package stress
+
+import (
+	"cmp"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultRetryAfterMax é o limite de cada espera sem StressTest.RetryAfterMax
+const defaultRetryAfterMax = time.Minute
+
+// RetryAfterStats resume as pausas dos workers para respeitar o header
+// Retry-After das respostas 429 e 503, com StressTest.RespectRetryAfter
+type RetryAfterStats struct {
+	// Max é o limite de cada pausa, com o padrão aplicado
+	Max time.Duration
+	// Pauses conta as pausas, antes de uma retentativa ou da próxima
+	// request do worker, e Wait é o tempo total que os workers pausaram
+	Pauses int
+	Wait   time.Duration
+}
+
+// retryAfterMax retorna o limite de cada pausa de Retry-After
+func (st *StressTest) retryAfterMax() time.Duration {
+	return cmp.Or(st.RetryAfterMax, defaultRetryAfterMax)
+}
+
+// retryAfter retorna a pausa pedida por uma resposta 429 ou 503, em segundos
+// ou como uma data HTTP no header Retry-After, limitada por RetryAfterMax (0
+// sem o header ou com um valor inválido)
+func (st *StressTest) retryAfter(status int, header http.Header, now time.Time) time.Duration {
+	if !st.RespectRetryAfter || (status != http.StatusTooManyRequests && status != http.StatusServiceUnavailable) {
+		return 0
+	}
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	limit := st.retryAfterMax()
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// Os segundos são limitados antes da conversão para não transbordar
+		if seconds < 0 {
+			return 0
+		}
+		return min(time.Duration(min(seconds, int64(limit/time.Second)+1))*time.Second, limit)
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0
+	}
+	return min(max(at.Sub(now), 0), limit)
+}
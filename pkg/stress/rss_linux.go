@@ -0,0 +1,27 @@
+//go:build linux
+
+package stress
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// residentMemory lê a memória residente do processo em /proc/self/statm, em
+// páginas
+func residentMemory() (uint64, bool) {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, false
+	}
+	fields := bytes.Fields(data)
+	if len(fields) < 2 {
+		return 0, false
+	}
+	pages, err := strconv.ParseUint(string(fields[1]), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return pages * uint64(os.Getpagesize()), true
+}
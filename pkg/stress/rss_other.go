@@ -0,0 +1,8 @@
+//go:build !linux
+
+package stress
+
+// residentMemory não é suportado fora do Linux; ResourceSample.RSS fica zero
+func residentMemory() (uint64, bool) {
+	return 0, false
+}
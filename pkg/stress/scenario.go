@@ -0,0 +1,243 @@
+package stress
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Scenario é uma sequência de passos executada em ordem por cada worker. Cada
+// execução completa é uma iteração: Requests e Concurrency passam a contar
+// iterações, e não requests individuais.
+type Scenario struct {
+	Steps []Step
+}
+
+// Step é uma request de um cenário. Os headers do passo são somados aos de
+// StressTest.Header, substituindo os de mesmo nome. A URL, os headers e o
+// corpo podem usar as colunas de StressTest.Data e as variáveis extraídas
+// pelos passos anteriores da mesma iteração.
+type Step struct {
+	Name   string
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+	// Extract define os valores extraídos da resposta para os passos
+	// seguintes; uma extração que falha aborta a iteração
+	Extract []Extractor
+}
+
+// Label identifica o passo no relatório: o nome ou, sem nome, "MÉTODO URL"
+func (s Step) Label() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Method + " " + s.URL
+}
+
+// scenarioFile é o formato JSON aceito por ParseScenario
+type scenarioFile struct {
+	Steps []struct {
+		Name    string            `json:"name"`
+		Method  string            `json:"method"`
+		URL     string            `json:"url"`
+		Headers map[string]string `json:"headers"`
+		Body    *string           `json:"body"`
+		Extract []struct {
+			Var    string `json:"var"`
+			JSON   string `json:"json"`
+			Regex  string `json:"regex"`
+			Header string `json:"header"`
+		} `json:"extract"`
+	} `json:"steps"`
+}
+
+// ParseScenario lê um cenário em JSON no formato
+//
+//	{"steps": [{"name": "login", "method": "POST", "url": "/login",
+//	  "headers": {"Content-Type": "application/json"}, "body": "{...}",
+//	  "extract": [{"var": "token", "json": "$.token"}]}]}
+//
+// Cada extração define "var" e uma fonte: "json", "regex" ou "header".
+// Passos sem método usam GET. URLs relativas são resolvidas contra baseURL.
+func ParseScenario(r io.Reader, baseURL string) (*Scenario, error) {
+	var file scenarioFile
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("cenário inválido: %w", err)
+	}
+	if len(file.Steps) == 0 {
+		return nil, errors.New("o cenário não tem passos")
+	}
+
+	var base *url.URL
+	if baseURL != "" {
+		var err error
+		if base, err = parseHTTPURL(baseURL); err != nil {
+			return nil, fmt.Errorf("URL base inválida: %w", err)
+		}
+	}
+	scenario := &Scenario{}
+	names := make(map[string]bool)
+	for i, s := range file.Steps {
+		method := strings.ToUpper(s.Method)
+		if method == "" {
+			method = http.MethodGet
+		}
+		target, err := resolveTarget(Target{Method: method, URL: s.URL}, base)
+		if err != nil {
+			return nil, fmt.Errorf("passo %d: %w", i+1, err)
+		}
+		step := Step{Name: s.Name, Method: target.Method, URL: target.URL}
+		if step.Name != "" {
+			if names[step.Name] {
+				return nil, fmt.Errorf("passo %d: nome repetido %q", i+1, step.Name)
+			}
+			names[step.Name] = true
+		}
+		if len(s.Headers) > 0 {
+			step.Header = make(http.Header, len(s.Headers))
+			for name, value := range s.Headers {
+				if !ValidHeaderName(name) {
+					return nil, fmt.Errorf("passo %d: nome de header inválido %q", i+1, name)
+				}
+				step.Header.Set(name, value)
+			}
+		}
+		if s.Body != nil {
+			step.Body = []byte(*s.Body)
+		}
+		for _, e := range s.Extract {
+			extractor := Extractor{Var: e.Var, JSONPath: e.JSON, Header: e.Header}
+			if e.Regex != "" {
+				if extractor.Regex, err = regexp.Compile(e.Regex); err != nil {
+					return nil, fmt.Errorf("passo %d: regex inválida para %s: %w", i+1, e.Var, err)
+				}
+			}
+			if err := validExtractor(extractor); err != nil {
+				return nil, fmt.Errorf("passo %d: %w", i+1, err)
+			}
+			step.Extract = append(step.Extract, extractor)
+		}
+		scenario.Steps = append(scenario.Steps, step)
+	}
+	return scenario, nil
+}
+
+// validScenario verifica se todos os passos têm URL, um método válido e
+// extratores válidos
+func validScenario(scenario *Scenario) bool {
+	if len(scenario.Steps) == 0 {
+		return false
+	}
+	for _, step := range scenario.Steps {
+		if step.URL == "" || !ValidMethod(step.Method) {
+			return false
+		}
+		for _, extractor := range step.Extract {
+			if validExtractor(extractor) != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// extracts indica se algum passo extrai valores da resposta
+func extracts(scenario *Scenario) bool {
+	for _, step := range scenario.Steps {
+		if len(step.Extract) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// extractsFromBody indica se algum passo extrai valores do corpo
+func extractsFromBody(scenario *Scenario) bool {
+	for _, step := range scenario.Steps {
+		if needsBody(step.Extract) {
+			return true
+		}
+	}
+	return false
+}
+
+// newStepRequests prepara a request de cada passo, com os headers globais
+// combinados aos do passo e os templates compilados quando há dados ou
+// variáveis. Os templates são validados com as colunas de dados e as
+// variáveis dos passos anteriores, para que referências a variáveis
+// inexistentes ou ainda não extraídas sejam detectadas antes do teste.
+func newStepRequests(st *StressTest) ([]requestSpec, error) {
+	specs := make([]requestSpec, len(st.Scenario.Steps))
+	var sample map[string]string
+	switch {
+	case st.Data != nil:
+		sample = st.Data.row(0)
+	case extracts(st.Scenario):
+		sample = make(map[string]string)
+	}
+	for i, step := range st.Scenario.Steps {
+		header := st.Header.Clone()
+		if header == nil && step.Header != nil {
+			header = make(http.Header, len(step.Header))
+		}
+		for name, values := range step.Header {
+			header[name] = values
+		}
+		templates, err := newRequestTemplates(sample, []string{step.URL}, header, step.Body)
+		if err != nil {
+			return nil, fmt.Errorf("passo %s: %w", step.Label(), err)
+		}
+		specs[i] = requestSpec{
+			target:    Target{Method: step.Method, URL: step.URL},
+			label:     step.Label(),
+			header:    header,
+			body:      step.Body,
+			templates: templates,
+			extract:   step.Extract,
+		}
+		for _, extractor := range step.Extract {
+			sample[extractor.Var] = ""
+		}
+	}
+	return specs, nil
+}
+
+// runScenario executa uma iteração do cenário, entregando o resultado de cada
+// passo a emit. Um passo com erro de transporte, falha de extração ou status
+// inesperado (ver StressTest.ExpectStatus) interrompe a iteração; o último resultado emitido carrega
+// o IterationResult.
+func (st *StressTest) runScenario(ctx context.Context, workerID int, client *http.Client, state *runState, row map[string]string, emit func(Result)) {
+	// As variáveis extraídas se somam à linha de dados, valendo até o fim
+	// da iteração
+	vars := make(map[string]string, len(row))
+	maps.Copy(vars, row)
+	start := time.Now()
+	for i, spec := range state.steps {
+		result := st.executeWithRetry(ctx, workerID, client, state, spec, vars)
+		failed := result.Error != nil || !st.expectedStatus().Contains(result.StatusCode)
+		last := failed || i == len(state.steps)-1
+		// Iterações canceladas pelo fim do teste não contam como abortadas
+		if last && !result.Canceled {
+			result.Iteration = &IterationResult{Start: start, Duration: time.Since(start)}
+			if failed {
+				result.Iteration.FailedStep = spec.label
+			}
+		}
+		emit(result)
+		if last {
+			return
+		}
+	}
+}
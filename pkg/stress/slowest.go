@@ -0,0 +1,98 @@
+package stress
+
+import (
+	"cmp"
+	"container/heap"
+	"slices"
+	"time"
+)
+
+// SlowRequest é uma das requests mais lentas do teste; com
+// StressTest.TraceContext, TraceID localiza a request no backend de traces
+// (ex.: Jaeger)
+type SlowRequest struct {
+	Timestamp  time.Time
+	Duration   time.Duration
+	WorkerID   int
+	Target     string
+	StatusCode int
+	// ErrorCategory é a categoria do erro, vazia nas requests sem erro
+	ErrorCategory string
+	// TraceSampled indica a flag sampled do traceparent (ver
+	// Result.TraceSampled)
+	TraceID      string
+	TraceSampled bool
+}
+
+// SlowestStats traz as Max requests mais lentas do teste, da mais lenta para
+// a mais rápida, sobre a mesma população das métricas de duração do Report
+type SlowestStats struct {
+	Max      int
+	Requests []SlowRequest
+}
+
+// slowHeap mantém as requests mais lentas com a mais rápida no topo, para
+// ser substituída quando chega uma mais lenta
+type slowHeap []SlowRequest
+
+func (h slowHeap) Len() int           { return len(h) }
+func (h slowHeap) Less(i, j int) bool { return h[i].Duration < h[j].Duration }
+func (h slowHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *slowHeap) Push(x any)        { *h = append(*h, x.(SlowRequest)) }
+func (h *slowHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// slowestRecorder acumula Report.Slowest com memória limitada a max
+// requests
+type slowestRecorder struct {
+	max      int
+	requests slowHeap
+}
+
+// newSlowestRecorder retorna nil sem StressTest.SlowestRequests
+func newSlowestRecorder(st *StressTest) *slowestRecorder {
+	if st.SlowestRequests == 0 {
+		return nil
+	}
+	return &slowestRecorder{max: st.SlowestRequests}
+}
+
+// add registra uma request com duração medida
+func (r *slowestRecorder) add(result Result) {
+	if len(r.requests) == r.max && result.Duration <= r.requests[0].Duration {
+		return
+	}
+	request := SlowRequest{
+		Timestamp:     result.Timestamp,
+		Duration:      result.Duration,
+		WorkerID:      result.WorkerID,
+		Target:        result.Target,
+		StatusCode:    result.StatusCode,
+		ErrorCategory: result.ErrorCategory,
+		TraceID:       result.TraceID,
+		TraceSampled:  result.TraceSampled,
+	}
+	if len(r.requests) < r.max {
+		heap.Push(&r.requests, request)
+		return
+	}
+	r.requests[0] = request
+	heap.Fix(&r.requests, 0)
+}
+
+func (r *slowestRecorder) finish() *SlowestStats {
+	requests := slices.Clone([]SlowRequest(r.requests))
+	sortSlowest(requests)
+	return &SlowestStats{Max: r.max, Requests: requests}
+}
+
+// sortSlowest ordena as requests da mais lenta para a mais rápida
+func sortSlowest(requests []SlowRequest) {
+	slices.SortStableFunc(requests, func(a, b SlowRequest) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+}
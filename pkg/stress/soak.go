@@ -0,0 +1,209 @@
+package stress
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// trendWindow é a fração da duração planejada comparada no início e no fim
+// do teste por Report.Trend
+const trendWindow = 10
+
+// ResourceSample é o consumo do próprio processo do teste em um intervalo da
+// timeline, amostrado com StressTest.Soak ao fim do intervalo, para separar
+// a degradação do alvo da do gerador de carga
+type ResourceSample struct {
+	// RSS é a memória residente do processo em bytes (0 fora do Linux)
+	RSS        uint64
+	HeapAlloc  uint64
+	Goroutines int
+	// GCCycles e GCPause são os ciclos do coletor de lixo concluídos no
+	// intervalo e a soma das suas pausas
+	GCCycles uint32
+	GCPause  time.Duration
+}
+
+// resourceSampler amostra o processo a cada intervalo da timeline
+type resourceSampler struct {
+	interval time.Duration
+	stopped  chan struct{}
+	finished chan struct{}
+
+	mu      sync.Mutex
+	samples []ResourceSample
+	// numGC e pauseTotal são os acumulados da amostra anterior
+	numGC      uint32
+	pauseTotal uint64
+}
+
+func newResourceSampler(interval time.Duration) *resourceSampler {
+	if interval == 0 {
+		interval = defaultTimelineInterval
+	}
+	s := &resourceSampler{interval: interval, stopped: make(chan struct{}), finished: make(chan struct{})}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	s.numGC, s.pauseTotal = stats.NumGC, stats.PauseTotalNs
+	return s
+}
+
+// run amostra o processo ao fim de cada intervalo contado a partir de start
+// até stop
+func (s *resourceSampler) run(start time.Time) {
+	defer close(s.finished)
+	for next := start.Add(s.interval); ; next = next.Add(s.interval) {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			s.sample()
+		case <-s.stopped:
+			timer.Stop()
+			// O último intervalo, incompleto, termina com o teste
+			s.sample()
+			return
+		}
+	}
+}
+
+func (s *resourceSampler) sample() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	rss, _ := residentMemory()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = append(s.samples, ResourceSample{
+		RSS:        rss,
+		HeapAlloc:  stats.HeapAlloc,
+		Goroutines: runtime.NumGoroutine(),
+		GCCycles:   stats.NumGC - s.numGC,
+		GCPause:    time.Duration(stats.PauseTotalNs - s.pauseTotal),
+	})
+	s.numGC, s.pauseTotal = stats.NumGC, stats.PauseTotalNs
+}
+
+// stop encerra a amostragem e associa cada amostra ao seu intervalo da
+// timeline
+func (s *resourceSampler) stop(timeline []TimelinePoint) {
+	close(s.stopped)
+	<-s.finished
+	for i := range min(len(timeline), len(s.samples)) {
+		timeline[i].Resources = &s.samples[i]
+	}
+}
+
+// TrendReport compara o início e o fim de um teste com StressTest.Soak: as
+// requests concluídas nos primeiros e nos últimos 10% da duração planejada e
+// o consumo médio do próprio processo nos mesmos trechos da timeline
+type TrendReport struct {
+	// Window é a duração de cada trecho comparado
+	Window         time.Duration
+	FirstRequests  int
+	LastRequests   int
+	FirstP95       time.Duration
+	LastP95        time.Duration
+	FirstErrorRate float64
+	LastErrorRate  float64
+	FirstRSS       uint64
+	LastRSS        uint64
+	// FirstGoroutines e LastGoroutines são as médias das amostras
+	FirstGoroutines int
+	LastGoroutines  int
+}
+
+// P95Change retorna a variação relativa do P95 entre o início e o fim
+// (ex.: 0.25 para um P95 25% maior no fim)
+func (t *TrendReport) P95Change() float64 {
+	if t.FirstP95 == 0 {
+		return 0
+	}
+	return float64(t.LastP95)/float64(t.FirstP95) - 1
+}
+
+// trendRecorder acumula as requests dos dois trechos de Report.Trend
+type trendRecorder struct {
+	start  time.Time
+	window time.Duration
+	// lastFrom e end são o início do último trecho e o fim planejado do
+	// teste, contados a partir de start
+	lastFrom    time.Duration
+	end         time.Duration
+	first, last trendWindowRecorder
+}
+
+type trendWindowRecorder struct {
+	requests, failed int
+	durations        *durationRecorder
+}
+
+func newTrendRecorder(st *StressTest, start time.Time) *trendRecorder {
+	planned := st.plannedDuration()
+	r := &trendRecorder{start: start, window: planned / trendWindow, lastFrom: planned - planned/trendWindow, end: planned}
+	r.first.durations = &durationRecorder{histogram: newDurationHistogram(st.HistogramMax, targetHistogramSigFigs)}
+	r.last.durations = &durationRecorder{histogram: newDurationHistogram(st.HistogramMax, targetHistogramSigFigs)}
+	return r
+}
+
+// add registra uma request pelo instante em que terminou, como a timeline
+func (r *trendRecorder) add(result Result, failed, measured bool) {
+	var window *trendWindowRecorder
+	switch elapsed := result.Timestamp.Add(result.Duration).Sub(r.start); {
+	case elapsed < r.window:
+		window = &r.first
+	case elapsed >= r.lastFrom:
+		window = &r.last
+	default:
+		return
+	}
+	window.requests++
+	if failed {
+		window.failed++
+	}
+	if measured {
+		window.durations.add(result.Duration)
+	}
+}
+
+// finish calcula a tendência; o consumo do processo vem dos intervalos da
+// timeline que se sobrepõem a cada trecho, já com as amostras de
+// resourceSampler, sem os intervalos depois do fim planejado, em que os
+// workers já estão encerrando
+func (r *trendRecorder) finish(timeline []TimelinePoint, interval time.Duration) *TrendReport {
+	trend := &TrendReport{
+		Window:        r.window,
+		FirstRequests: r.first.requests,
+		LastRequests:  r.last.requests,
+		FirstP95:      r.first.durations.stats().P95,
+		LastP95:       r.last.durations.stats().P95,
+	}
+	if r.first.requests > 0 {
+		trend.FirstErrorRate = float64(r.first.failed) / float64(r.first.requests)
+	}
+	if r.last.requests > 0 {
+		trend.LastErrorRate = float64(r.last.failed) / float64(r.last.requests)
+	}
+	var firstRSS, lastRSS uint64
+	var firstGoroutines, lastGoroutines, first, last int
+	for _, point := range timeline {
+		if point.Resources == nil || point.Start >= r.end {
+			continue
+		}
+		switch {
+		case point.Start < r.window:
+			firstRSS += point.Resources.RSS
+			firstGoroutines += point.Resources.Goroutines
+			first++
+		case point.Start+interval > r.lastFrom:
+			lastRSS += point.Resources.RSS
+			lastGoroutines += point.Resources.Goroutines
+			last++
+		}
+	}
+	if first > 0 {
+		trend.FirstRSS, trend.FirstGoroutines = firstRSS/uint64(first), firstGoroutines/first
+	}
+	if last > 0 {
+		trend.LastRSS, trend.LastGoroutines = lastRSS/uint64(last), lastGoroutines/last
+	}
+	return trend
+}
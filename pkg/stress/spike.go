@@ -0,0 +1,148 @@
+package stress
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Spike é um perfil de pico para StressTest.Spike, executado como três
+// etapas de Stages no modelo aberto: a taxa Base até Start, um salto abrupto
+// para Peak durante PeakDuration e a volta a Base até completar Total, para
+// observar a recuperação do alvo (ex.: de um autoscaler ou circuit breaker).
+type Spike struct {
+	Base         float64
+	Peak         float64
+	PeakDuration time.Duration
+	Total        time.Duration
+	// Start é o início do pico (0 = um quarto do tempo fora do pico, que
+	// deixa a maior parte dele para a recuperação)
+	Start time.Duration
+}
+
+// ParseSpike interpreta um perfil de pico no formato
+// "base=100,peak=2000,peak-duration=20s,total=5m", com start opcional
+func ParseSpike(text string) (Spike, error) {
+	var spike Spike
+	for _, item := range strings.Split(text, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return Spike{}, fmt.Errorf("item inválido %q: use chave=valor", item)
+		}
+		var err error
+		switch key = strings.TrimSpace(key); key {
+		case "base":
+			spike.Base, err = strconv.ParseFloat(value, 64)
+		case "peak":
+			spike.Peak, err = strconv.ParseFloat(value, 64)
+		case "peak-duration":
+			spike.PeakDuration, err = time.ParseDuration(value)
+		case "total":
+			spike.Total, err = time.ParseDuration(value)
+		case "start":
+			spike.Start, err = time.ParseDuration(value)
+		default:
+			return Spike{}, fmt.Errorf("chave desconhecida %q: use base, peak, peak-duration, total ou start", key)
+		}
+		if err != nil {
+			return Spike{}, fmt.Errorf("valor inválido para %s: %q", key, value)
+		}
+	}
+	return spike, validSpike(spike)
+}
+
+// String descreve o pico no formato de ParseSpike
+func (s Spike) String() string {
+	text := fmt.Sprintf("base=%s,peak=%s,peak-duration=%v,total=%v",
+		strconv.FormatFloat(s.Base, 'f', -1, 64), strconv.FormatFloat(s.Peak, 'f', -1, 64), s.PeakDuration, s.Total)
+	if s.Start > 0 {
+		text += fmt.Sprintf(",start=%v", s.Start)
+	}
+	return text
+}
+
+// Stages retorna as etapas do pico: a base antes, o pico e a base depois
+func (s Spike) Stages() []Stage {
+	start := s.Start
+	if start == 0 {
+		start = (s.Total - s.PeakDuration) / 4
+	}
+	return []Stage{
+		{Duration: start, Rate: s.Base},
+		{Duration: s.PeakDuration, Rate: s.Peak},
+		{Duration: s.Total - start - s.PeakDuration, Rate: s.Base},
+	}
+}
+
+func validSpike(s Spike) error {
+	switch {
+	case s.Base <= 0 || s.Peak <= s.Base:
+		return errors.New("o pico requer base maior que zero e peak maior que base")
+	case s.PeakDuration <= 0 || s.Start < 0:
+		return errors.New("o pico requer peak-duration positiva e start não negativo")
+	case s.Total <= s.Start+s.PeakDuration:
+		return errors.New("total deve ser maior que start somado a peak-duration, deixando tempo para a recuperação")
+	}
+	return nil
+}
+
+// spikeRecoveryLatency e spikeRecoveryErrors definem a volta ao normal
+// depois do pico: um intervalo da timeline com P99 até 50% acima do P99 da
+// base e taxa de erros até 1 ponto percentual acima da dela
+const (
+	spikeRecoveryLatency = 1.5
+	spikeRecoveryErrors  = 0.01
+)
+
+// SpikeReport compara as métricas do pico de StressTest.Spike com as da base
+// até ele e as da base depois dele, calculadas sobre as requests iniciadas em
+// cada período
+type SpikeReport struct {
+	BaselineErrorRate float64
+	BaselineP99       time.Duration
+	PeakErrorRate     float64
+	PeakP99           time.Duration
+	AfterErrorRate    float64
+	AfterP99          time.Duration
+	// RecoveryTime é o tempo, a partir do fim do pico, até o intervalo da
+	// timeline a partir do qual todos ficaram normais: P99 até 50% acima do
+	// P99 da base e taxa de erros até 1 ponto percentual acima da dela.
+	// Recovered é false quando o teste terminou antes disso.
+	RecoveryTime time.Duration
+	Recovered    bool
+}
+
+// newSpikeReport calcula o SpikeReport a partir das três etapas do pico em
+// Report.Stages e da timeline
+func newSpikeReport(r *Report) *SpikeReport {
+	if len(r.Stages) != 3 {
+		return nil
+	}
+	base, peak, after := r.Stages[0], r.Stages[1], r.Stages[2]
+	spike := &SpikeReport{
+		BaselineErrorRate: base.ErrorRate(),
+		BaselineP99:       base.Durations.P99,
+		PeakErrorRate:     peak.ErrorRate(),
+		PeakP99:           peak.Durations.P99,
+		AfterErrorRate:    after.ErrorRate(),
+		AfterP99:          after.Durations.P99,
+	}
+	latency := time.Duration(float64(base.Durations.P99) * spikeRecoveryLatency)
+	errorRate := base.ErrorRate() + spikeRecoveryErrors
+	normal := func(point TimelinePoint) bool {
+		return point.Requests > 0 && point.P99 <= latency && float64(point.Errors)/float64(point.Requests) <= errorRate
+	}
+	// Procura, do fim para o início, o primeiro intervalo de uma sequência
+	// normal que vai até o fim do teste. O intervalo em que o pico termina
+	// conta a partir do fim dele.
+	for i := len(r.Timeline) - 1; i >= 0 && r.Timeline[i].Start+r.TimelineInterval > after.Start; i-- {
+		if !normal(r.Timeline[i]) {
+			break
+		}
+		spike.Recovered = true
+		spike.RecoveryTime = max(r.Timeline[i].Start-after.Start, 0)
+	}
+	return spike
+}
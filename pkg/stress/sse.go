@@ -0,0 +1,364 @@
+package stress
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+// SSE ativa o modo Server-Sent Events: cada worker abre um stream
+// text/event-stream com as requests do teste (URL ou Targets, headers e
+// corpo) e o mantém aberto até o fim de Duration, contando os eventos
+// recebidos. Client.Timeout limita apenas a espera pelos headers. Cada
+// stream conta como uma request, concluída quando ele é encerrado, com o
+// tempo até o primeiro evento em Duration. Streams encerrados pelo servidor
+// antes do fim do teste e streams com linhas malformadas contam como falhas,
+// e os encerrados são reabertos na iteração seguinte. As métricas dos
+// eventos ficam em Report.SSE.
+type SSE struct {
+	// MaxLineSize limita o tamanho de cada linha do stream (0 = 1 MiB); uma
+	// linha maior encerra o stream como malformado
+	MaxLineSize int
+}
+
+// defaultMaxSSELine é o limite usado quando SSE.MaxLineSize não é informado
+const defaultMaxSSELine = 1 << 20
+
+// ssePoll é quanto cada iteração aguarda eventos, para que o fim de Duration
+// seja percebido logo
+const ssePoll = 250 * time.Millisecond
+
+// Categorias dos erros específicos dos streams SSE
+const (
+	// ErrorSSEDisconnected indica que o servidor encerrou o stream antes do
+	// fim do teste
+	ErrorSSEDisconnected = "sse_disconnected"
+	// ErrorSSEMalformed indica uma resposta que não é text/event-stream ou
+	// linhas fora do formato do protocolo
+	ErrorSSEMalformed = "sse_malformed"
+)
+
+// sseFrame é um evento completo ou uma linha malformada lida do stream
+type sseFrame struct {
+	at        time.Time
+	malformed bool
+}
+
+// sseSession é o stream de um worker. Uma goroutine lê as linhas
+// continuamente, entregando os eventos em frames.
+type sseSession struct {
+	body   io.ReadCloser
+	cancel context.CancelFunc
+	frames chan sseFrame
+	// done é fechado quando a leitura termina, com o motivo em err (nil
+	// quando o servidor encerrou o stream)
+	done chan struct{}
+	err  error
+	stop chan struct{}
+	// bytes é atualizado pela goroutine de leitura e só é lido após done
+	bytes int64
+
+	// result é o resultado final do stream, acumulado pelo worker
+	result Result
+	start  time.Time
+	last   time.Time
+}
+
+func newSSESession(body io.ReadCloser, cancel context.CancelFunc, maxLineSize int) *sseSession {
+	if maxLineSize == 0 {
+		maxLineSize = defaultMaxSSELine
+	}
+	s := &sseSession{
+		body:   body,
+		cancel: cancel,
+		frames: make(chan sseFrame, 64),
+		done:   make(chan struct{}),
+		stop:   make(chan struct{}),
+	}
+	go s.read(maxLineSize)
+	return s
+}
+
+// read interpreta o stream linha a linha: um evento é entregue na linha em
+// branco que encerra um bloco com ao menos um campo data, como no
+// EventSource dos navegadores
+func (s *sseSession) read(maxLineSize int) {
+	defer close(s.done)
+	scanner := bufio.NewScanner(s.body)
+	// O limite efetivo é o maior entre a capacidade inicial e maxLineSize
+	scanner.Buffer(make([]byte, 0, min(4096, maxLineSize)), maxLineSize)
+	var data bool
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		s.bytes += int64(len(line)) + 1
+		var frame sseFrame
+		switch {
+		case len(line) == 0:
+			if !data {
+				continue
+			}
+			data = false
+		case line[0] == ':':
+			// Comentários costumam ser usados como keep-alive
+			continue
+		default:
+			field, value, _ := bytes.Cut(line, []byte(":"))
+			if string(field) == "data" {
+				data = true
+			}
+			if validSSEField(string(field), bytes.TrimPrefix(value, []byte(" "))) {
+				continue
+			}
+			frame.malformed = true
+		}
+		frame.at = time.Now()
+		select {
+		case s.frames <- frame:
+		case <-s.stop:
+			return
+		}
+	}
+	s.err = scanner.Err()
+	if errors.Is(s.err, bufio.ErrTooLong) {
+		s.err = fmt.Errorf("linha maior que o limite de %d bytes: %w", maxLineSize, s.err)
+		select {
+		case s.frames <- sseFrame{at: time.Now(), malformed: true}:
+		case <-s.stop:
+		}
+	}
+}
+
+// validSSEField verifica um campo do protocolo: data, event, id (sem NUL) e
+// retry (apenas dígitos), com a linha em UTF-8
+func validSSEField(field string, value []byte) bool {
+	if !utf8.ValidString(field) || !utf8.Valid(value) {
+		return false
+	}
+	switch field {
+	case "data", "event":
+		return true
+	case "id":
+		return bytes.IndexByte(value, 0) < 0
+	case "retry":
+		if len(value) == 0 {
+			return false
+		}
+		for _, c := range value {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// close interrompe a leitura e fecha o stream
+func (s *sseSession) close() {
+	close(s.stop)
+	s.cancel()
+	s.body.Close()
+	<-s.done
+}
+
+// runSSE executa uma iteração do modo SSE, abrindo o stream do worker quando
+// ainda não há um
+func (st *StressTest) runSSE(ctx context.Context, workerID int, client *http.Client, state *runState, row map[string]string, emit func(Result)) {
+	session := state.streams[workerID]
+	if session == nil {
+		var result Result
+		session, result = st.openSSE(ctx, workerID, client, state, row)
+		if session == nil {
+			emit(result)
+			return
+		}
+		state.streams[workerID] = session
+	}
+	batch, ended := st.awaitSSE(ctx, session)
+	if batch.SSE.Events > 0 || batch.SSE.Malformed > 0 {
+		emit(batch)
+	}
+	if ended {
+		state.streams[workerID] = nil
+		session.close()
+		if session.err == nil {
+			session.result.Error = errors.New("o servidor encerrou o stream")
+			session.result.ErrorCategory = ErrorSSEDisconnected
+		} else {
+			session.result.Error = session.err
+			session.result.ErrorCategory = classifyError(session.err)
+			if errors.Is(session.err, bufio.ErrTooLong) {
+				session.result.ErrorCategory = ErrorSSEMalformed
+			}
+			session.result.Canceled = ctx.Err() != nil
+		}
+		session.result.SSE.Disconnected = true
+		emit(st.finishSSE(session))
+	}
+}
+
+// openSSE abre o stream, retornando a sessão quando o servidor responde com
+// status esperado e Content-Type text/event-stream
+func (st *StressTest) openSSE(ctx context.Context, workerID int, client *http.Client, state *runState, row map[string]string) (*sseSession, Result) {
+	spec := st.nextRequest(state)
+	result := Result{WorkerID: workerID, Timestamp: time.Now(), Target: spec.label, SSE: &SSEResult{End: true}}
+	streamCtx, cancel := context.WithCancel(ctx)
+	req, err := st.newRequest(streamCtx, state, spec, row)
+	if err != nil {
+		cancel()
+		result.Error = err
+		return nil, result
+	}
+	if st.ClientIDHeader != "" {
+		req.Header.Set(st.ClientIDHeader, WorkerName(workerID))
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	if req.Header.Get("Cache-Control") == "" {
+		req.Header.Set("Cache-Control", "no-cache")
+	}
+
+	// O stream fica aberto até o fim do teste, então Client.Timeout vale
+	// apenas até a chegada dos headers
+	stream := *client
+	stream.Timeout = 0
+	var timer *time.Timer
+	if client.Timeout > 0 {
+		timer = time.AfterFunc(client.Timeout, cancel)
+	}
+	result.BytesSent = max(req.ContentLength, 0)
+	start := time.Now()
+	resp, err := stream.Do(req)
+	result.TTFB = time.Since(start)
+	if timer != nil && !timer.Stop() {
+		if err == nil {
+			resp.Body.Close()
+		}
+		cancel()
+		result.Error = fmt.Errorf("headers não recebidos em %v", client.Timeout)
+		result.ErrorCategory = ErrorTimeout
+		return nil, result
+	}
+	if err != nil {
+		cancel()
+		result.Error = err
+		result.ErrorCategory = classifyError(err)
+		result.Canceled = ctx.Err() != nil
+		return nil, result
+	}
+	result.StatusCode = resp.StatusCode
+	result.ProtoMajor = resp.ProtoMajor
+	result.ProtoMinor = resp.ProtoMinor
+	if !st.expectedStatus().Contains(resp.StatusCode) {
+		resp.Body.Close()
+		cancel()
+		return nil, result
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		resp.Body.Close()
+		cancel()
+		result.Error = fmt.Errorf("Content-Type %q não é text/event-stream", resp.Header.Get("Content-Type"))
+		result.ErrorCategory = ErrorSSEMalformed
+		return nil, result
+	}
+	result.SSE.Connected = true
+	session := newSSESession(resp.Body, cancel, st.SSE.MaxLineSize)
+	session.result = result
+	session.start = start
+	return session, result
+}
+
+// awaitSSE recebe os eventos por até ssePoll, retornando o lote e se o
+// stream foi encerrado
+func (st *StressTest) awaitSSE(ctx context.Context, session *sseSession) (Result, bool) {
+	batch := Result{WorkerID: session.result.WorkerID, Timestamp: time.Now(), Target: session.result.Target, SSE: &SSEResult{}}
+	timer := time.NewTimer(ssePoll)
+	defer timer.Stop()
+	for {
+		select {
+		case frame := <-session.frames:
+			session.add(batch.SSE, frame)
+		case <-session.done:
+			// Os eventos lidos antes do encerramento ainda estão no canal
+			session.drain(batch.SSE)
+			return batch, true
+		case <-timer.C:
+			return batch, false
+		case <-ctx.Done():
+			return batch, false
+		}
+	}
+}
+
+// add registra um frame no lote e nos totais do stream
+func (s *sseSession) add(batch *SSEResult, frame sseFrame) {
+	total := s.result.SSE
+	if frame.malformed {
+		batch.Malformed++
+		total.Malformed++
+		return
+	}
+	if total.Events == 0 {
+		batch.FirstEvent = frame.at.Sub(s.start)
+		total.FirstEvent = batch.FirstEvent
+	} else {
+		batch.Gaps = append(batch.Gaps, frame.at.Sub(s.last))
+	}
+	s.last = frame.at
+	batch.Events++
+	total.Events++
+}
+
+// drain registra no lote os frames que já estão no canal
+func (s *sseSession) drain(batch *SSEResult) {
+	for {
+		select {
+		case frame := <-s.frames:
+			s.add(batch, frame)
+		default:
+			return
+		}
+	}
+}
+
+// finishSSE completa o resultado final do stream. Sem Error, linhas
+// malformadas reprovam o stream.
+func (st *StressTest) finishSSE(session *sseSession) Result {
+	result := session.result
+	result.BytesRead = session.bytes
+	result.Duration = result.SSE.FirstEvent
+	if result.Error == nil && result.SSE.Malformed > 0 {
+		result.Error = fmt.Errorf("%d linhas malformadas no stream", result.SSE.Malformed)
+		result.ErrorCategory = ErrorSSEMalformed
+	}
+	return result
+}
+
+// closeSSE encerra o stream do worker ao fim do teste, entregando a emit o
+// resultado final com os eventos ainda não contabilizados
+func (st *StressTest) closeSSE(state *runState, workerID int, emit func(Result)) {
+	if st.SSE == nil {
+		return
+	}
+	session := state.streams[workerID]
+	if session == nil {
+		return
+	}
+	state.streams[workerID] = nil
+	session.close()
+	// Os eventos já lidos entram em um último lote
+	batch := Result{WorkerID: workerID, Timestamp: time.Now(), Target: session.result.Target, SSE: &SSEResult{}}
+	session.drain(batch.SSE)
+	if batch.SSE.Events > 0 || batch.SSE.Malformed > 0 {
+		emit(batch)
+	}
+	emit(st.finishSSE(session))
+}
@@ -0,0 +1,243 @@
+package stress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stage é uma etapa do perfil de carga de StressTest.Stages: durante
+// Duration, o teste mantém a taxa de chegadas Rate (modelo aberto, ver
+// StressTest.ArrivalRate) ou Concurrency workers ativos (modelo fechado).
+// Todas as etapas de um teste usam o mesmo modelo.
+type Stage struct {
+	Duration    time.Duration
+	Rate        float64
+	Concurrency int
+}
+
+// String descreve a etapa no formato de ParseStages
+func (s Stage) String() string {
+	if s.Rate > 0 {
+		return s.Duration.String() + ":" + strconv.FormatFloat(s.Rate, 'f', -1, 64)
+	}
+	return s.Duration.String() + ":" + strconv.Itoa(s.Concurrency)
+}
+
+// ParseStages interpreta etapas no formato "duração:valor" separadas por
+// vírgula, como "1m:100,1m:200,1m:400". Com concurrency, os valores são a
+// concorrência de cada etapa; sem, a taxa de chegadas.
+func ParseStages(text string, concurrency bool) ([]Stage, error) {
+	var stages []Stage
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		duration, value, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("etapa inválida %q: use o formato duração:valor", item)
+		}
+		var stage Stage
+		var err error
+		if stage.Duration, err = time.ParseDuration(strings.TrimSpace(duration)); err != nil || stage.Duration <= 0 {
+			return nil, fmt.Errorf("duração inválida na etapa %q: deve ser positiva, como 30s ou 1m", item)
+		}
+		value = strings.TrimSpace(value)
+		if concurrency {
+			stage.Concurrency, err = strconv.Atoi(value)
+			if err != nil || stage.Concurrency <= 0 {
+				return nil, fmt.Errorf("concorrência inválida na etapa %q: deve ser um inteiro maior que zero", item)
+			}
+		} else {
+			stage.Rate, err = strconv.ParseFloat(value, 64)
+			if err != nil || stage.Rate <= 0 {
+				return nil, fmt.Errorf("taxa inválida na etapa %q: deve ser maior que zero", item)
+			}
+		}
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
+
+// validStages verifica se as etapas têm duração positiva e usam, todas, uma
+// taxa ou uma concorrência maior que zero
+func validStages(stages []Stage) error {
+	for i, stage := range stages {
+		switch {
+		case stage.Duration <= 0:
+			return fmt.Errorf("a etapa %d de Stages deve ter duração positiva", i+1)
+		case (stage.Rate > 0) == (stage.Concurrency > 0) || stage.Rate < 0 || stage.Concurrency < 0:
+			return fmt.Errorf("a etapa %d de Stages deve definir exatamente um entre Rate e Concurrency, maior que zero", i+1)
+		case (stage.Rate > 0) != (stages[0].Rate > 0):
+			return errors.New("as etapas de Stages devem usar todas Rate ou todas Concurrency")
+		}
+	}
+	return nil
+}
+
+// StageReport resume as requests iniciadas durante uma etapa de
+// StressTest.Stages, calculadas como as métricas equivalentes do Report
+type StageReport struct {
+	Stage Stage
+	// Start é o início da etapa, contado a partir do início do teste
+	Start              time.Duration
+	Requests           int
+	SuccessfulRequests int
+	FailedRequests     int
+	// Arrivals e DroppedArrivals contam as chegadas da etapa no modelo
+	// aberto, como em Report
+	Arrivals        int
+	DroppedArrivals int
+	// RequestsPerSecond divide as requests iniciadas na etapa pela duração
+	// dela
+	RequestsPerSecond float64
+	Durations         DurationStats
+
+	latencies *Histogram
+}
+
+// Histogram retorna o histograma das durações da etapa, como
+// Report.Histogram
+func (s *StageReport) Histogram() *Histogram {
+	return s.latencies
+}
+
+// SetHistogram define o histograma de durações da etapa, como
+// Report.SetHistogram
+func (s *StageReport) SetHistogram(h *Histogram) {
+	s.latencies = h
+}
+
+// ErrorRate retorna a fração das requests da etapa com falha
+func (s *StageReport) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.FailedRequests) / float64(s.Requests)
+}
+
+// stagePlan localiza as etapas de StressTest.Stages no tempo do teste
+type stagePlan struct {
+	stages []Stage
+	// ends é o fim de cada etapa, contado a partir do início do teste
+	ends []time.Duration
+}
+
+// newStagePlan retorna nil sem etapas
+func newStagePlan(stages []Stage) *stagePlan {
+	if len(stages) == 0 {
+		return nil
+	}
+	p := &stagePlan{stages: stages}
+	var end time.Duration
+	for _, stage := range stages {
+		end += stage.Duration
+		p.ends = append(p.ends, end)
+	}
+	return p
+}
+
+// total retorna a duração somada das etapas
+func (p *stagePlan) total() time.Duration {
+	return p.ends[len(p.ends)-1]
+}
+
+// start retorna o início da etapa i
+func (p *stagePlan) start(i int) time.Duration {
+	if i == 0 {
+		return 0
+	}
+	return p.ends[i-1]
+}
+
+// at retorna a etapa em vigor em elapsed; depois do fim, a última
+func (p *stagePlan) at(elapsed time.Duration) int {
+	for i, end := range p.ends {
+		if elapsed < end {
+			return i
+		}
+	}
+	return len(p.ends) - 1
+}
+
+// maxConcurrency retorna a maior concorrência das etapas do modelo fechado
+func (p *stagePlan) maxConcurrency() int {
+	var highest int
+	for _, stage := range p.stages {
+		highest = max(highest, stage.Concurrency)
+	}
+	return highest
+}
+
+// meanRate retorna a taxa de chegadas média das etapas do modelo aberto,
+// ponderada pela duração de cada uma
+func (p *stagePlan) meanRate() float64 {
+	var arrivals float64
+	for _, stage := range p.stages {
+		arrivals += stage.Rate * stage.Duration.Seconds()
+	}
+	return arrivals / p.total().Seconds()
+}
+
+// wait mantém o worker parado enquanto a etapa em vigor do modelo fechado
+// tem menos workers ativos que workerID+1, retornando false quando o teste
+// terminou ou foi cancelado. Um worker desativado conclui a request em
+// andamento antes de parar.
+func (p *stagePlan) wait(ctx context.Context, workerID int, start time.Time) bool {
+	for {
+		elapsed := time.Since(start)
+		if elapsed >= p.total() {
+			return false
+		}
+		i := p.at(elapsed)
+		if workerID < p.stages[i].Concurrency {
+			return true
+		}
+		if !sleepContext(ctx, p.ends[i]-elapsed) {
+			return false
+		}
+	}
+}
+
+// stageRecorder acumula as métricas de uma etapa
+type stageRecorder struct {
+	report    *StageReport
+	durations *durationRecorder
+}
+
+// newStageRecorders cria as métricas de cada etapa em Report.Stages
+func newStageRecorders(plan *stagePlan, report *Report, histogramMax time.Duration) []*stageRecorder {
+	if plan == nil {
+		return nil
+	}
+	recorders := make([]*stageRecorder, len(plan.stages))
+	for i, stage := range plan.stages {
+		recorders[i] = &stageRecorder{
+			report:    &StageReport{Stage: stage, Start: plan.start(i)},
+			durations: &durationRecorder{histogram: newDurationHistogram(histogramMax, targetHistogramSigFigs)},
+		}
+		report.Stages = append(report.Stages, recorders[i].report)
+	}
+	return recorders
+}
+
+// add registra uma request na etapa em que ela foi iniciada
+func (r *stageRecorder) add(result Result, failed, measured bool) {
+	r.report.Requests++
+	if failed {
+		r.report.FailedRequests++
+	} else {
+		r.report.SuccessfulRequests++
+	}
+	if measured {
+		r.durations.add(result.Duration)
+	}
+}
+
+// finish completa as métricas que dependem de todas as requests da etapa
+func (r *stageRecorder) finish() {
+	r.report.Durations = r.durations.stats()
+	r.report.latencies = r.durations.histogram
+	r.report.RequestsPerSecond = float64(r.report.Requests) / r.report.Stage.Duration.Seconds()
+}
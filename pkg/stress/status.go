@@ -0,0 +1,105 @@
+package stress
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StatusRange é um intervalo fechado de códigos de status HTTP
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// StatusRanges é um conjunto de intervalos de status esperados
+type StatusRanges []StatusRange
+
+// defaultExpectStatus são os status esperados sem StressTest.ExpectStatus: as
+// faixas 2xx e 3xx. Redirecionamentos que chegam até aqui são respostas
+// finais (não seguidas pelo client) e não um erro do serviço.
+var defaultExpectStatus = StatusRanges{{Min: 200, Max: 399}}
+
+// ParseStatusRanges interpreta uma lista separada por vírgulas de status e
+// intervalos, como "200-204,404". Apenas códigos entre 100 e 599 são aceitos.
+func ParseStatusRanges(text string) (StatusRanges, error) {
+	var ranges StatusRanges
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		low, high, isRange := strings.Cut(part, "-")
+		min, err := parseStatusCode(low)
+		if err != nil {
+			return nil, err
+		}
+		max := min
+		if isRange {
+			if max, err = parseStatusCode(high); err != nil {
+				return nil, err
+			}
+			if max < min {
+				return nil, fmt.Errorf("intervalo de status invertido: %s", part)
+			}
+		}
+		ranges = append(ranges, StatusRange{Min: min, Max: max})
+	}
+	return ranges, nil
+}
+
+func parseStatusCode(text string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || code < 100 || code > 599 {
+		return 0, fmt.Errorf("status HTTP inválido %q: use códigos entre 100 e 599", text)
+	}
+	return code, nil
+}
+
+// Contains indica se code está em algum dos intervalos
+func (r StatusRanges) Contains(code int) bool {
+	for _, sr := range r {
+		if code >= sr.Min && code <= sr.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// String retorna os intervalos no formato aceito por ParseStatusRanges
+func (r StatusRanges) String() string {
+	parts := make([]string, len(r))
+	for i, sr := range r {
+		parts[i] = strconv.Itoa(sr.Min)
+		if sr.Max != sr.Min {
+			parts[i] += "-" + strconv.Itoa(sr.Max)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// validStatusRanges verifica os intervalos montados sem ParseStatusRanges
+func validStatusRanges(r StatusRanges) bool {
+	for _, sr := range r {
+		if sr.Min < 100 || sr.Max > 599 || sr.Max < sr.Min {
+			return false
+		}
+	}
+	return true
+}
+
+// expectedStatus retorna os status considerados sucesso no teste
+func (st *StressTest) expectedStatus() StatusRanges {
+	if len(st.ExpectStatus) == 0 {
+		return defaultExpectStatus
+	}
+	return st.ExpectStatus
+}
+
+// Failed indica se o resultado conta como falha no Report: erros ou, nas
+// respostas HTTP, status fora de ExpectStatus. Útil em OnResult para
+// classificar os resultados como o relatório.
+func (st *StressTest) Failed(result Result) bool {
+	return failedResult(result, st.expectedStatus())
+}
+
+func failedResult(result Result, expected StatusRanges) bool {
+	return result.Error != nil || (result.hasHTTPStatus() && !expected.Contains(result.StatusCode))
+}
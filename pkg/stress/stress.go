@@ -0,0 +1,1310 @@
+package stress
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StressTest representa a configuração do teste de carga
+type StressTest struct {
+	URL    string
+	Method string
+	// Targets, quando definido, substitui URL e Method: as requests são
+	// distribuídas entre os alvos em rodízio ou, quando os pesos diferem,
+	// por sorteio ponderado
+	Targets     []Target
+	Body        []byte
+	ContentType string
+	// Form, quando definido, substitui Body por um corpo
+	// application/x-www-form-urlencoded com os campos na ordem informada. Os
+	// valores aceitam os mesmos templates de Body, e o Content-Type é
+	// definido quando não vem de Header ou ContentType.
+	Form []FormField
+	// GraphQL, quando definido, substitui Body pelo corpo da operação
+	// GraphQL e verifica o campo "errors" das respostas; requer Method POST
+	GraphQL *GraphQL
+	// GRPC, quando definido, substitui as requests HTTP por chamadas gRPC
+	// unárias, dispensando URL (ver GRPCCall)
+	GRPC *GRPCCall
+	// WebSocket, quando definido, substitui as requests HTTP por conexões
+	// WebSocket com URL, uma por worker, enviando Body como mensagem
+	WebSocket *WebSocket
+	// SSE, quando definido, mantém um stream text/event-stream aberto por
+	// worker, contando os eventos recebidos em vez de medir as respostas
+	SSE *SSE
+	// Multipart, quando definido, substitui Body por um corpo
+	// multipart/form-data, com o Content-Type e o boundary correspondentes
+	Multipart *Multipart
+	Header    http.Header
+	// UserAgents define o User-Agent das requests no lugar do padrão do Go:
+	// com um valor, fixo; com vários, em rodízio ou, com RandomUserAgent, por
+	// sorteio (reproduzível com Seed). Um User-Agent definido em Header ou
+	// nos passos do Scenario tem precedência.
+	UserAgents      []string
+	RandomUserAgent bool
+	// Compression controla o Accept-Encoding e a descompressão das respostas
+	// (ver CompressionGzip). CompressionNone só evita o gzip automático com
+	// um transporte criado com TransportConfig.DisableCompression.
+	Compression Compression
+	// Host, quando definido, substitui o header Host de todas as requests
+	Host        string
+	BasicAuth   *BasicAuth
+	BearerToken *BearerToken
+	Requests    int
+	Concurrency int
+	// Duration, quando maior que zero, substitui Requests: os workers enviam
+	// requests até o prazo terminar
+	Duration time.Duration
+	// GracePeriod é o tempo dado às requests em andamento após Duration
+	GracePeriod time.Duration
+	// RPS limita a taxa combinada de requests por segundo (0 = sem limite)
+	RPS float64
+	// Burst é a quantidade de requests que podem ser enviadas em rajada
+	Burst int
+	// ArrivalRate, quando maior que zero, troca o modelo fechado, em que cada
+	// worker só envia a próxima request após a resposta, pelo modelo aberto:
+	// ArrivalRate requests por segundo (iterações, com Scenario) são
+	// iniciadas em horários fixos, mesmo que o alvo fique lento. Concurrency
+	// é ignorado e MaxInFlight workers executam as chegadas, o que limita as
+	// requests em andamento; uma chegada que encontra o limite atingido é
+	// descartada e contada em Report.DroppedArrivals. Com Requests, as
+	// chegadas descartadas também contam no total.
+	ArrivalRate float64
+	MaxInFlight int
+	// ArrivalDistribution define os intervalos entre as chegadas de
+	// ArrivalRate (vazio = ArrivalConstant). Os intervalos de ArrivalPoisson
+	// são sorteados com Seed.
+	ArrivalDistribution ArrivalDistribution
+	// Stages, quando definido, substitui Requests e Duration por um perfil
+	// de carga em etapas percorridas em ordem, cada uma com a sua taxa de
+	// chegadas (modelo aberto, com MaxInFlight e ArrivalDistribution como em
+	// ArrivalRate) ou a sua concorrência (modelo fechado, em que Concurrency
+	// é ignorado). O Report traz as métricas de cada etapa em Stages.
+	Stages []Stage
+	// Spike, quando definido, gera as etapas de um perfil de pico no lugar
+	// de Stages e compara o pico com a base em Report.Spike
+	Spike *Spike
+	// Soak amostra, a cada intervalo da timeline, o consumo do próprio
+	// processo (memória, goroutines e coletor de lixo) em
+	// TimelinePoint.Resources e compara o início com o fim do teste em
+	// Report.Trend, para testes longos que procuram vazamentos e degradação.
+	// Requer Duration, Stages ou Spike.
+	Soak bool
+	// RampUp distribui linearmente o início dos workers ao longo do período
+	RampUp time.Duration
+	// ExcludeRampUp remove das métricas de duração as requests iniciadas
+	// durante o RampUp
+	ExcludeRampUp bool
+	// NoBodyRead fecha a resposta sem ler o corpo: Duration passa a medir
+	// apenas até os headers e nenhum byte recebido é contabilizado. Útil para
+	// payloads enormes, ao custo de não reaproveitar a conexão no HTTP/1.1,
+	// o que aparece em Report.NewConnections.
+	NoBodyRead bool
+	// MaxBodyBytes limita os bytes lidos de cada corpo (0 = sem limite). Uma
+	// resposta maior tem a conexão abandonada no limite, em vez de baixar o
+	// restante, e falha na categoria ErrorBodyLimit, sem passar pelas
+	// asserções e extrações, que veriam um documento incompleto.
+	MaxBodyBytes int64
+	// Trace registra em cada Result o tempo das fases da request (DNS,
+	// conexão, TLS e servidor), agregadas em Report.Phases
+	Trace bool
+	// Data, quando definido, fornece uma linha por request para os
+	// templates no estilo text/template (ex.: {{.user_id}}) da URL, dos
+	// headers e do corpo. As linhas são usadas em ordem e recomeçam do
+	// início ao acabar, a menos que StopWhenDataExhausted esteja ativo.
+	Data                  *DataSet
+	StopWhenDataExhausted bool
+	// CacheBust, quando definido, é o nome de um parâmetro de query com um
+	// valor aleatório diferente em cada request, evitando respostas de cache
+	CacheBust string
+	// Query são parâmetros adicionados à query string de todas as requests
+	Query []QueryParam
+	// Seed inicializa o sorteio ponderado dos Targets, os valores
+	// aleatórios de CacheBust e Query, o sorteio dos UserAgents e os
+	// intervalos de ArrivalPoisson, tornando a sequência reproduzível
+	// (0 = semente aleatória, registrada em Report.Seed)
+	Seed uint64
+	// ThinkTime é a pausa de cada worker entre uma request e a seguinte,
+	// simulando usuários reais; ThinkTimeJitter sorteia a pausa
+	// uniformemente em ThinkTime ± ThinkTimeJitter. A pausa não conta na
+	// Duration das requests.
+	ThinkTime       time.Duration
+	ThinkTimeJitter time.Duration
+	// WarmupRequests ou WarmupDuration definem uma fase de aquecimento
+	// executada antes do teste, com a mesma configuração, cujas requests não
+	// entram em nenhuma métrica do Report nem são enviadas a OnResult
+	WarmupRequests int
+	WarmupDuration time.Duration
+	// HistogramMax é a maior duração registrada com precisão no histograma
+	// de latências (0 = 1h); durações maiores são contadas como o máximo
+	HistogramMax time.Duration
+	// HistogramSigFigs é a quantidade de dígitos significativos preservados
+	// nos percentis, de 1 a 5 (0 = 3)
+	HistogramSigFigs int
+	// TimelineInterval é a duração dos intervalos de Report.Timeline, em que
+	// as requests são agrupadas pelo instante em que terminaram (0 = 1s,
+	// mínimo de 10ms)
+	TimelineInterval time.Duration
+	// Client é o client usado em todas as requests e pode ser substituído
+	// para injetar um transporte próprio
+	Client *http.Client
+	// WorkerTransport, quando definido, cria um transporte exclusivo para
+	// cada worker, que passa a ter as próprias conexões em vez de dividir o
+	// pool de Client. Cada worker mantém ao menos uma conexão aberta por
+	// host, então a concorrência define a quantidade de descritores de
+	// arquivo usados. As métricas por worker ficam em Report.Workers.
+	WorkerTransport func(workerID int) http.RoundTripper
+	// ClientIDHeader, quando definido, é o nome de um header enviado em todas
+	// as requests com a identidade estável do worker (ver WorkerName),
+	// útil para exercitar o roteamento com afinidade de sessão
+	ClientIDHeader string
+	// TraceContext, quando definido, envia em cada request HTTP (inclusive
+	// os passos de Scenario) um header traceparent do W3C com IDs novos
+	TraceContext *TraceContext
+	// SlowestRequests é a quantidade de requests mais lentas mantidas em
+	// Report.Slowest, com o trace ID de TraceContext (0 = nenhuma)
+	SlowestRequests int
+	// OnResult, quando definido, é chamado para cada request concluída,
+	// inclusive as canceladas. As chamadas acontecem em uma única goroutine,
+	// na ordem em que os resultados chegam.
+	OnResult func(Result)
+	// CaptureFailures guarda em Result.Capture a request e a resposta das
+	// primeiras CaptureFailures requests HTTP com falha (0 = desativado),
+	// excluídas as do aquecimento. Enquanto há vagas, o início do corpo das
+	// respostas com status inesperado é mantido em memória.
+	CaptureFailures int
+	// RecordRequests preenche em cada Result o método, a URL e os headers da
+	// request e da resposta, como foram enviados e recebidos, para logs
+	// detalhados em OnResult
+	RecordRequests bool
+	// Progress, quando definido, recebe uma linha de progresso por segundo
+	Progress io.Writer
+	// ReportInterval e OnInterval, quando definidos, entregam a OnInterval um
+	// resumo do andamento a cada intervalo, sem alterar o Report final.
+	// OnInterval é chamado pela mesma goroutine que agrega os resultados e
+	// deve retornar rapidamente.
+	ReportInterval time.Duration
+	OnInterval     func(IntervalStats)
+	// IntervalLatencyBuckets, quando maior que zero, inclui em cada
+	// IntervalStats a distribuição das durações nessa quantidade de faixas
+	IntervalLatencyBuckets int
+	// Gauges, quando definido, acompanha as requests em andamento e os
+	// workers ativos durante o teste
+	Gauges *Gauges
+	// Settings registra opções da configuração (ex.: do transporte) que devem
+	// constar no relatório para que a execução seja reproduzível
+	Settings map[string]string
+	// AbortOnErrorRate, quando maior que zero, interrompe o teste assim que a
+	// taxa de falhas das últimas AbortWindow requests (0 = 200) ultrapassa o
+	// limite (ex.: 0.5); AbortOnConsecutiveErrors faz o mesmo após N falhas
+	// seguidas. O Report parcial indica o motivo em AbortReason.
+	AbortOnErrorRate         float64
+	AbortWindow              int
+	AbortOnConsecutiveErrors int
+	// ApdexT é o tempo de resposta satisfatório usado no índice Apdex
+	// (0 = não calcula)
+	ApdexT time.Duration
+	// Thresholds são avaliados sobre o Report final; os resultados ficam em
+	// Report.Thresholds
+	Thresholds []Threshold
+	// Assertions e JSONAssertions são verificadas no corpo de todas as
+	// respostas com status esperado; MaxCapturedBody limita quantos bytes do corpo são guardados
+	// para as asserções e as extrações dos cenários (0 = 1 MiB)
+	Assertions      []BodyAssertion
+	JSONAssertions  []JSONAssertion
+	MaxCapturedBody int64
+	// ExpectStatus define os status HTTP considerados sucesso (vazio = 2xx e
+	// 3xx); os demais contam como falha por status inesperado
+	ExpectStatus StatusRanges
+	// Retry, quando definido, repete as requests HTTP que falham por erro de
+	// transporte ou com os status de Retry.Status, com backoff exponencial
+	Retry *Retry
+	// RespectRetryAfter faz o worker que recebe uma resposta 429 ou 503 com
+	// o header Retry-After pausar pelo tempo indicado, em segundos ou como
+	// uma data HTTP, limitado por RetryAfterMax (0 = 1 minuto), antes da
+	// retentativa de Retry ou da próxima request
+	RespectRetryAfter bool
+	RetryAfterMax     time.Duration
+	// Cookies dá a cada worker um cookie jar próprio, simulando usuários
+	// com sessões independentes; InitialCookies são registrados nos jars de
+	// todos os workers para os hosts dos alvos. Cenários sempre usam jars.
+	Cookies        bool
+	InitialCookies []*http.Cookie
+	// Scenario, quando definido, substitui URL, Method, Targets e Body: cada
+	// worker executa os passos em ordem, com um cookie jar próprio
+	// compartilhado entre eles, e Requests conta iterações do cenário
+	Scenario *Scenario
+	// ExpectedProtocol, quando definido (ex.: "HTTP/2.0"), faz o relatório
+	// destacar as respostas que usaram outro protocolo
+	ExpectedProtocol string
+}
+
+// validMethods lista os métodos HTTP aceitos em StressTest.Method
+var validMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodPatch:   true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+}
+
+// ValidMethod indica se method é um dos métodos HTTP suportados
+func ValidMethod(method string) bool {
+	return validMethods[method]
+}
+
+// ValidHeaderName verifica se o nome contém apenas caracteres permitidos
+// em um token HTTP (RFC 7230)
+func ValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewStressTest cria uma nova instância de StressTest
+func NewStressTest(url string, requests, concurrency int) *StressTest {
+	return &StressTest{
+		URL:         url,
+		Method:      http.MethodGet,
+		Requests:    requests,
+		Concurrency: concurrency,
+		GracePeriod: 5 * time.Second,
+		Burst:       1,
+		Client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: NewTransport(DefaultTransportConfig().WithPoolForConcurrency(concurrency)),
+		},
+	}
+}
+
+// runState reúne o estado compartilhado pelos workers durante uma execução
+type runState struct {
+	targets   *targetSelector
+	query     *queryBuilder
+	data      *dataFeeder
+	templates *requestTemplates
+	// userAgents é nil quando StressTest.UserAgents está vazio
+	userAgents *userAgentSelector
+	// multipart é o corpo pré-montado de StressTest.Multipart
+	multipart *multipartLayout
+	// form e graphql montam os corpos de StressTest.Form e GraphQL
+	form    *formBody
+	graphql *graphqlBody
+	// targetRequests tem a request de cada alvo quando algum alvo define
+	// headers ou corpo próprios
+	targetRequests []requestSpec
+	// steps tem a request de cada passo quando há um Scenario
+	steps []requestSpec
+	// sockets guarda a conexão de cada worker no modo WebSocket
+	sockets []*webSocketSession
+	// streams guarda o stream de cada worker no modo SSE
+	streams []*sseSession
+	// captured conta as vagas de StressTest.CaptureFailures já reservadas
+	captured atomic.Int64
+	// deadline é o fim do modo por duração, depois do qual as retentativas
+	// de StressTest.Retry não começam
+	deadline time.Time
+}
+
+// requestSpec descreve como montar uma request: o alvo, o rótulo usado no
+// relatório, os headers e o corpo, com os templates correspondentes quando
+// há dados
+type requestSpec struct {
+	target Target
+	label  string
+	header http.Header
+	body   []byte
+	// multipart, form e graphql substituem body quando StressTest.Multipart,
+	// Form ou GraphQL estão definidos
+	multipart *multipartLayout
+	form      *formBody
+	graphql   *graphqlBody
+	templates *requestTemplates
+	// extract são as extrações do passo, gravadas nos dados da request
+	extract []Extractor
+}
+
+// nextRequest escolhe o próximo alvo das requests avulsas, fora de cenários
+func (st *StressTest) nextRequest(state *runState) requestSpec {
+	if state.targetRequests != nil {
+		return state.targetRequests[state.targets.nextIndex()]
+	}
+	target := state.targets.next()
+	return requestSpec{
+		target:    target,
+		label:     target.Label(),
+		header:    st.Header,
+		body:      st.Body,
+		multipart: state.multipart,
+		form:      state.form,
+		graphql:   state.graphql,
+		templates: state.templates,
+	}
+}
+
+// iterate executa uma unidade de trabalho do worker: uma request ou, com
+// Scenario, uma iteração completa do cenário
+func (st *StressTest) iterate(ctx context.Context, workerID int, client *http.Client, state *runState, row map[string]string, emit func(Result)) {
+	if st.Scenario != nil {
+		st.runScenario(ctx, workerID, client, state, row, emit)
+		return
+	}
+	if st.GRPC != nil {
+		emit(st.executeGRPC(ctx, workerID, state, row))
+		return
+	}
+	if st.WebSocket != nil {
+		st.runWebSocket(ctx, workerID, client, state, row, emit)
+		return
+	}
+	if st.SSE != nil {
+		st.runSSE(ctx, workerID, client, state, row, emit)
+		return
+	}
+	emit(st.executeWithRetry(ctx, workerID, client, state, st.nextRequest(state), row))
+}
+
+// workerClient retorna o client de um worker: com cookies ou cenários, uma
+// cópia com cookie jar próprio, para que os cookies recebidos valham nas
+// requests seguintes sem vazar entre os workers, e com WorkerTransport, o
+// transporte exclusivo do worker
+func (st *StressTest) workerClient(workerID int) *http.Client {
+	if !st.usesCookieJar() && st.WorkerTransport == nil {
+		return st.Client
+	}
+	c := *st.Client
+	if st.usesCookieJar() {
+		c.Jar = st.newCookieJar()
+	}
+	if st.WorkerTransport != nil {
+		c.Transport = st.WorkerTransport(workerID)
+	}
+	return &c
+}
+
+// releaseClient fecha as conexões ociosas de um client exclusivo do worker
+func (st *StressTest) releaseClient(client *http.Client) {
+	if st.WorkerTransport != nil {
+		client.CloseIdleConnections()
+	}
+}
+
+// dispatcher controla se os workers ainda podem iniciar novas requests,
+// tanto no modo por quantidade quanto no modo por duração
+type dispatcher struct {
+	limit    int64
+	issued   atomic.Int64
+	deadline time.Time
+}
+
+// next reserva a próxima request, retornando false quando o teste terminou
+// ou foi cancelado
+func (d *dispatcher) next(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if !d.deadline.IsZero() {
+		return time.Now().Before(d.deadline)
+	}
+	return d.issued.Add(1) <= d.limit
+}
+
+// exhausted indica, sem reservar uma request, se o teste já terminou
+func (d *dispatcher) exhausted(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	if !d.deadline.IsZero() {
+		return !time.Now().Before(d.deadline)
+	}
+	return d.issued.Load() >= d.limit
+}
+
+// Validate verifica se a configuração permite executar o teste, sem
+// executá-lo. Run faz a mesma verificação antes de começar.
+func (st *StressTest) Validate() error {
+	return st.validate()
+}
+
+// validate verifica se a configuração permite executar o teste
+func (st *StressTest) validate() error {
+	switch {
+	case st.URL == "" && len(st.Targets) == 0 && st.Scenario == nil && st.GRPC == nil:
+		return errors.New("URL não informada")
+	case st.Scenario != nil && !validScenario(st.Scenario):
+		return errors.New("o Scenario deve ter ao menos um passo, todos com URL, um método HTTP válido e extratores válidos")
+	case st.Scenario != nil && st.NoBodyRead && extractsFromBody(st.Scenario):
+		return errors.New("NoBodyRead impede extrair valores do corpo das respostas")
+	case st.ArrivalRate == 0 && len(st.loadStages()) == 0 && st.Concurrency <= 0:
+		return errors.New("a concorrência deve ser maior que zero")
+	case st.Spike != nil && len(st.Stages) > 0:
+		return errors.New("use apenas um entre Stages e Spike")
+	case st.Spike != nil && validSpike(*st.Spike) != nil:
+		return validSpike(*st.Spike)
+	case validStages(st.loadStages()) != nil:
+		return validStages(st.loadStages())
+	case st.Soak && st.plannedDuration() == 0:
+		return errors.New("Soak requer Duration, Stages ou Spike")
+	case st.ArrivalRate < 0 || st.MaxInFlight < 0:
+		return errors.New("ArrivalRate e MaxInFlight não podem ser negativos")
+	case st.ArrivalRate > 0 && len(st.loadStages()) > 0:
+		return errors.New("use apenas um entre ArrivalRate e Stages ou Spike")
+	case st.openModel() && st.MaxInFlight == 0:
+		return errors.New("ArrivalRate e Stages com Rate requerem MaxInFlight maior que zero")
+	case st.openModel() && (st.RPS > 0 || st.RampUp > 0 || st.ThinkTime > 0 || st.ThinkTimeJitter > 0):
+		return errors.New("RPS, RampUp e ThinkTime não se aplicam a ArrivalRate, que já define o ritmo das requests")
+	case !st.ArrivalDistribution.valid():
+		return fmt.Errorf("ArrivalDistribution inválido: %q", st.ArrivalDistribution)
+	case st.ArrivalDistribution != "" && !st.openModel():
+		return errors.New("ArrivalDistribution requer ArrivalRate ou Stages com Rate")
+	case st.openModel() && (st.WebSocket != nil || st.SSE != nil):
+		return errors.New("WebSocket e SSE mantêm uma conexão por worker e não podem ser usados com ArrivalRate")
+	case len(st.loadStages()) > 0 && (st.Requests > 0 || st.Duration > 0):
+		return errors.New("Stages já define a duração do teste e não pode ser usado com Requests ou Duration")
+	case len(st.loadStages()) > 0 && (st.RampUp > 0 || st.WarmupRequests > 0 || st.WarmupDuration > 0):
+		return errors.New("RampUp e o aquecimento não se aplicam a Stages; use uma primeira etapa com menos carga")
+	case len(st.loadStages()) > 0 && (st.WebSocket != nil || st.SSE != nil):
+		return errors.New("WebSocket e SSE mantêm uma conexão por worker e não podem ser usados com Stages")
+	case st.Requests <= 0 && st.Duration <= 0 && len(st.loadStages()) == 0:
+		return errors.New("informe Requests, Duration ou Stages")
+	case st.Requests > 0 && st.Duration > 0:
+		return errors.New("use apenas um entre Requests e Duration")
+	case !ValidMethod(st.Method):
+		return fmt.Errorf("método HTTP inválido: %s", st.Method)
+	case st.Method == http.MethodHead && (st.Body != nil || st.Multipart != nil || len(st.Form) > 0):
+		return errors.New("requests HEAD não enviam corpo")
+	case st.Method == http.MethodHead && (len(st.Assertions) > 0 || len(st.JSONAssertions) > 0):
+		return errors.New("respostas a HEAD não têm corpo para as asserções")
+	case !validTargets(st.Targets):
+		return errors.New("todos os Targets devem ter URL, um método HTTP válido e peso não negativo")
+	case st.RPS < 0 || (st.RPS > 0 && st.Burst < 1):
+		return errors.New("RPS não pode ser negativo e Burst deve ser ao menos 1")
+	case st.RampUp < 0 || st.GracePeriod < 0:
+		return errors.New("RampUp e GracePeriod não podem ser negativos")
+	case st.ThinkTime < 0 || st.ThinkTimeJitter < 0:
+		return errors.New("ThinkTime e ThinkTimeJitter não podem ser negativos")
+	case st.WarmupRequests < 0 || st.WarmupDuration < 0:
+		return errors.New("WarmupRequests e WarmupDuration não podem ser negativos")
+	case st.WarmupRequests > 0 && st.WarmupDuration > 0:
+		return errors.New("use apenas um entre WarmupRequests e WarmupDuration")
+	case st.HistogramSigFigs < 0 || st.HistogramSigFigs > maxHistogramSigFigs:
+		return fmt.Errorf("HistogramSigFigs deve estar entre 1 e %d", maxHistogramSigFigs)
+	case st.HistogramMax < 0 || (st.HistogramMax > 0 && st.HistogramMax < 2*time.Duration(histogramLowest)):
+		return fmt.Errorf("HistogramMax deve ser ao menos %v", 2*time.Duration(histogramLowest))
+	case st.ReportInterval < 0:
+		return errors.New("ReportInterval não pode ser negativo")
+	case st.TimelineInterval < 0 || (st.TimelineInterval > 0 && st.TimelineInterval < minTimelineInterval):
+		return fmt.Errorf("TimelineInterval deve ser ao menos %v", minTimelineInterval)
+	case st.AbortOnErrorRate < 0 || st.AbortOnErrorRate >= 1:
+		return errors.New("AbortOnErrorRate deve estar entre 0 e 1")
+	case st.AbortWindow < 0 || st.AbortOnConsecutiveErrors < 0:
+		return errors.New("AbortWindow e AbortOnConsecutiveErrors não podem ser negativos")
+	case st.ApdexT < 0:
+		return errors.New("ApdexT não pode ser negativo")
+	case !validThresholds(st.Thresholds):
+		return errors.New("todos os Thresholds devem ter uma métrica conhecida e um operador <, <=, > ou >=")
+	case st.ApdexT == 0 && thresholdsUse(st.Thresholds, "apdex"):
+		return errors.New("o Threshold de apdex requer ApdexT")
+	case !validAssertions(st.Assertions, st.JSONAssertions):
+		return errors.New("cada BodyAssertion deve definir exatamente um entre Contains e Regex, e cada JSONAssertion um caminho válido")
+	case (len(st.Assertions) > 0 || len(st.JSONAssertions) > 0) && st.NoBodyRead:
+		return errors.New("NoBodyRead impede verificar o corpo das respostas")
+	case st.CaptureFailures < 0:
+		return errors.New("CaptureFailures não pode ser negativo")
+	case st.MaxBodyBytes < 0:
+		return errors.New("MaxBodyBytes não pode ser negativo")
+	case st.MaxCapturedBody < 0:
+		return errors.New("MaxCapturedBody não pode ser negativo")
+	case !validStatusRanges(st.ExpectStatus):
+		return errors.New("ExpectStatus deve conter intervalos de status entre 100 e 599, com Min <= Max")
+	case st.Retry != nil && validRetry(*st.Retry) != nil:
+		return validRetry(*st.Retry)
+	case st.Retry != nil && (st.GRPC != nil || st.WebSocket != nil || st.SSE != nil):
+		return errors.New("Retry se aplica apenas às requests HTTP, não aos modos GRPC, WebSocket e SSE")
+	case st.RetryAfterMax < 0 || (st.RetryAfterMax > 0 && !st.RespectRetryAfter):
+		return errors.New("RetryAfterMax não pode ser negativo e requer RespectRetryAfter")
+	case st.RespectRetryAfter && (st.GRPC != nil || st.WebSocket != nil || st.SSE != nil):
+		return errors.New("RespectRetryAfter se aplica apenas às requests HTTP, não aos modos GRPC, WebSocket e SSE")
+	case st.Data != nil && !validDataSet(st.Data):
+		return errors.New("Data deve ter ao menos uma linha, todas com uma coluna por campo")
+	case st.Multipart != nil && !validMultipart(st.Multipart):
+		return errors.New("Multipart deve ter ao menos um campo ou arquivo, todos com nome e os arquivos com caminho")
+	case st.Multipart != nil && (st.Body != nil || st.Scenario != nil):
+		return errors.New("Multipart não pode ser usado junto com Body ou Scenario")
+	case !validForm(st.Form):
+		return errors.New("todos os campos de Form devem ter nome")
+	case len(st.Form) > 0 && (st.Body != nil || st.Multipart != nil || st.Scenario != nil):
+		return errors.New("Form não pode ser usado junto com Body, Multipart ou Scenario")
+	case (st.Multipart != nil || len(st.Form) > 0) && slices.ContainsFunc(st.Targets, func(t Target) bool { return t.Body != nil }):
+		return errors.New("Multipart e Form não podem ser usados junto com Targets que definem Body")
+	case st.GraphQL != nil && st.GraphQL.Query == "":
+		return errors.New("GraphQL requer uma Query")
+	case st.GraphQL != nil && (st.Body != nil || st.Multipart != nil || len(st.Form) > 0 || st.Scenario != nil || len(st.Targets) > 0):
+		return errors.New("GraphQL não pode ser usado junto com Body, Multipart, Form, Scenario ou Targets")
+	case st.GraphQL != nil && st.Method != http.MethodPost:
+		return errors.New("GraphQL requer Method POST")
+	case st.GraphQL != nil && st.NoBodyRead:
+		return errors.New("NoBodyRead impede verificar os erros das respostas GraphQL")
+	case st.GRPC != nil && validGRPCCall(st.GRPC) != nil:
+		return validGRPCCall(st.GRPC)
+	case st.GRPC != nil && (len(st.Targets) > 0 || st.Scenario != nil || st.Multipart != nil || len(st.Form) > 0 || st.GraphQL != nil):
+		return errors.New("GRPC não pode ser usado junto com Targets, Scenario, Multipart, Form ou GraphQL")
+	case st.GRPC != nil && (len(st.Assertions) > 0 || len(st.JSONAssertions) > 0 || st.Trace || st.NoBodyRead):
+		return errors.New("asserções, Trace e NoBodyRead não se aplicam às chamadas GRPC")
+	case st.GRPC != nil && (len(st.UserAgents) > 0 || st.Compression != CompressionAuto || st.usesCookieJar() || st.WorkerTransport != nil):
+		return errors.New("UserAgents, Compression, cookies e WorkerTransport não se aplicam às chamadas GRPC")
+	case st.WebSocket != nil && !validWebSocketURL(st.URL):
+		return errors.New("WebSocket requer uma URL ws:// ou wss://")
+	case st.WebSocket != nil && st.WebSocket.MaxMessageSize < 0:
+		return errors.New("MaxMessageSize não pode ser negativo")
+	case st.WebSocket != nil && st.Body == nil && st.Duration == 0:
+		return errors.New("sem Body o WebSocket apenas mantém as conexões abertas e requer Duration")
+	case st.WebSocket != nil && (len(st.Targets) > 0 || st.Scenario != nil || st.Multipart != nil || len(st.Form) > 0 || st.GraphQL != nil || st.GRPC != nil):
+		return errors.New("WebSocket não pode ser usado junto com Targets, Scenario, Multipart, Form, GraphQL ou GRPC")
+	case st.WebSocket != nil && (len(st.Assertions) > 0 || len(st.JSONAssertions) > 0 || st.Trace || st.NoBodyRead || st.Compression != CompressionAuto):
+		return errors.New("asserções, Trace, NoBodyRead e Compression não se aplicam ao WebSocket")
+	case st.SSE != nil && st.SSE.MaxLineSize < 0:
+		return errors.New("MaxLineSize não pode ser negativo")
+	case st.SSE != nil && st.Duration == 0:
+		return errors.New("o SSE mantém os streams abertos até o fim do teste e requer Duration")
+	case st.SSE != nil && (st.Scenario != nil || st.GRPC != nil || st.WebSocket != nil):
+		return errors.New("SSE não pode ser usado junto com Scenario, GRPC ou WebSocket")
+	case st.SSE != nil && (len(st.Assertions) > 0 || len(st.JSONAssertions) > 0 || st.Trace || st.NoBodyRead || st.Compression != CompressionAuto):
+		return errors.New("asserções, Trace, NoBodyRead e Compression não se aplicam ao SSE")
+	case !st.Compression.valid():
+		return fmt.Errorf("Compression inválido: %q", st.Compression)
+	case !validUserAgents(st.UserAgents):
+		return errors.New("os UserAgents não podem ser vazios nem conter quebras de linha")
+	case st.Client == nil:
+		return errors.New("Client não informado")
+	case st.ClientIDHeader != "" && !ValidHeaderName(st.ClientIDHeader):
+		return fmt.Errorf("nome de header inválido em ClientIDHeader: %q", st.ClientIDHeader)
+	case st.TraceContext != nil && (st.TraceContext.SampleRate < 0 || st.TraceContext.SampleRate > 1):
+		return errors.New("TraceContext.SampleRate deve estar entre 0 e 1")
+	case st.SlowestRequests < 0:
+		return errors.New("SlowestRequests não pode ser negativo")
+	}
+	return nil
+}
+
+func validTargets(targets []Target) bool {
+	for _, target := range targets {
+		if target.URL == "" || !ValidMethod(target.Method) || target.Weight < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func validDataSet(data *DataSet) bool {
+	if len(data.Rows) == 0 {
+		return false
+	}
+	for _, row := range data.Rows {
+		if len(row) != len(data.Fields) {
+			return false
+		}
+	}
+	return true
+}
+
+// Run executa o teste de carga. Se ctx for cancelado, os workers param de
+// iniciar novas requests, as requests em andamento são interrompidas e o
+// relatório parcial é retornado com Interrupted marcado. O mesmo vale para o
+// prazo de ctx (ex.: o t.Deadline de um teste), que pode ser identificado
+// em InterruptCause. Um erro é retornado
+// apenas quando a configuração é inválida e o teste nem chega a começar.
+func (st *StressTest) Run(ctx context.Context) (*Report, error) {
+	if err := st.validate(); err != nil {
+		return nil, err
+	}
+
+	results := make(chan Result, st.workers())
+	var wg sync.WaitGroup
+	report := &Report{
+		Method:            st.Method,
+		TargetRPS:         st.RPS,
+		ArrivalRate:       st.ArrivalRate,
+		MaxInFlight:       st.MaxInFlight,
+		PlannedDuration:   st.plannedDuration(),
+		ThinkTime:         st.ThinkTime,
+		ThinkTimeJitter:   st.ThinkTimeJitter,
+		RampUp:            st.RampUp,
+		BodySkipped:       st.NoBodyRead,
+		Settings:          st.Settings,
+		ExpectedProtocol:  st.ExpectedProtocol,
+		ExpectStatus:      st.ExpectStatus,
+		StatusCodes:       make(map[int]int),
+		Protocols:         make(map[string]int),
+		ErrorCategories:   make(map[string]int),
+		AssertionFailures: make(map[string]int),
+		GraphQLErrors:     make(map[string]int),
+		GRPCCodes:         make(map[string]int),
+		Targets:           make(map[string]*TargetReport),
+		Workers:           st.newWorkerReports(),
+		MinDuration:       time.Duration(1<<63 - 1), // Inicializa com o maior valor possível
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if st.BearerToken != nil {
+		go st.BearerToken.watch(ctx)
+	}
+
+	var limiter *rateLimiter
+	if st.RPS > 0 {
+		limiter = newRateLimiter(st.RPS, st.Burst)
+	}
+
+	if st.openModel() {
+		report.ArrivalDistribution = cmp.Or(st.ArrivalDistribution, ArrivalConstant)
+	}
+	if stages := newStagePlan(st.loadStages()); stages != nil && st.openModel() {
+		report.ArrivalRate = stages.meanRate()
+	}
+	report.Seed = st.Seed
+	if report.Seed == 0 {
+		report.Seed = rand.Uint64()
+	}
+	state := &runState{
+		targets: newTargetSelector(st, report.Seed),
+		query:   newQueryBuilder(st, report.Seed),
+		data:    newDataFeeder(st),
+
+		userAgents: newUserAgentSelector(st, report.Seed),
+	}
+	urls := make([]string, len(state.targets.targets))
+	for i, target := range state.targets.targets {
+		urls[i] = target.URL
+	}
+	var sample map[string]string
+	if st.Data != nil {
+		sample = st.Data.row(0)
+	}
+	templates, err := newRequestTemplates(sample, urls, st.Header, st.Body)
+	if err != nil {
+		return nil, err
+	}
+	state.templates = templates
+	if st.Multipart != nil {
+		if state.multipart, err = newMultipartLayout(st.Multipart); err != nil {
+			return nil, err
+		}
+	}
+	if len(st.Form) > 0 {
+		if state.form, err = newFormBody(sample, st.Form); err != nil {
+			return nil, err
+		}
+	}
+	if st.GraphQL != nil {
+		if state.graphql, err = newGraphQLBody(sample, st.GraphQL); err != nil {
+			return nil, err
+		}
+	}
+	if state.targetRequests, err = newTargetRequests(st, state, sample); err != nil {
+		return nil, err
+	}
+	if st.Scenario != nil {
+		if state.steps, err = newStepRequests(st); err != nil {
+			return nil, err
+		}
+	}
+	if st.WebSocket != nil {
+		state.sockets = make([]*webSocketSession, st.workers())
+	}
+	if st.SSE != nil {
+		state.streams = make([]*sseSession, st.workers())
+	}
+	if st.GRPC != nil {
+		report.GRPCMethod = st.GRPC.label()
+		// Um corpo incompatível com a mensagem de entrada falharia em todas
+		// as chamadas
+		if _, err := st.grpcRequest(state, sample); err != nil {
+			return nil, err
+		}
+	}
+	// A semente só é registrada quando influencia as requests
+	if state.targets.rng == nil && !state.query.random() && !state.userAgents.random() && st.ArrivalDistribution != ArrivalPoisson {
+		report.Seed = 0
+	}
+	if st.WarmupRequests > 0 || st.WarmupDuration > 0 {
+		report.WarmupRequests = st.warmup(ctx, limiter, state, report.Seed)
+		// As falhas do aquecimento não chegam a OnResult
+		state.captured.Store(0)
+	}
+
+	// Inicia o timer, que não inclui o aquecimento
+	startTime := time.Now()
+	var sampler *resourceSampler
+	if st.Soak {
+		sampler = newResourceSampler(st.TimelineInterval)
+		go sampler.run(startTime)
+	}
+
+	dispatch := &dispatcher{limit: int64(st.Requests)}
+	if duration := st.plannedDuration(); duration > 0 {
+		dispatch.deadline = startTime.Add(duration)
+		state.deadline = dispatch.deadline
+		// Requests em andamento no fim do teste têm até GracePeriod para terminar
+		timer := time.AfterFunc(duration+st.GracePeriod, cancel)
+		defer timer.Stop()
+	}
+	var arrivals *arrivalSchedule
+	if st.openModel() {
+		arrivals = newArrivalSchedule(st, report.Seed)
+		go arrivals.run(ctx, dispatch, startTime)
+	}
+	// No modelo fechado, as etapas ativam e desativam os workers
+	var stages *stagePlan
+	if !st.openModel() {
+		stages = newStagePlan(st.loadStages())
+	}
+
+	// Inicia as goroutines de teste. Com RampUp, o worker i aguarda
+	// i*RampUp/Concurrency antes de começar.
+	var started atomic.Int64
+	var fullConcurrencyAt atomic.Int64
+	for i := 0; i < st.workers(); i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			client := st.workerClient(workerID)
+			defer st.releaseClient(client)
+			defer st.closeWebSocket(state, workerID)
+			emit := func(result Result) {
+				results <- result
+			}
+			// O stream SSE é encerrado com um último resultado
+			defer st.closeSSE(state, workerID, emit)
+			delay := st.RampUp * time.Duration(workerID) / time.Duration(st.workers())
+			if !sleepContext(ctx, delay) {
+				return
+			}
+			if started.Add(1) == int64(st.workers()) {
+				fullConcurrencyAt.Store(int64(time.Since(startTime)))
+			}
+			if st.Gauges != nil {
+				st.Gauges.activeWorkers.Add(1)
+				defer st.Gauges.activeWorkers.Add(-1)
+			}
+
+			for {
+				if stages != nil && !stages.wait(ctx, workerID, startTime) {
+					return
+				}
+				scheduled, ok := st.nextArrival(ctx, limiter, dispatch, arrivals)
+				if !ok {
+					return
+				}
+				row, ok := state.data.next()
+				if !ok {
+					arrivals.stop()
+					return
+				}
+				if st.Gauges != nil {
+					st.Gauges.inFlight.Add(1)
+				}
+				// O tempo de resposta é medido a partir do horário planejado
+				st.iterate(ctx, workerID, client, state, row, scheduleEmit(emit, scheduled))
+				arrivals.done()
+				if st.Gauges != nil {
+					st.Gauges.inFlight.Add(-1)
+				}
+				if !st.think(ctx, dispatch) {
+					return
+				}
+			}
+		}(i)
+	}
+
+	// Fecha o canal de resultados quando todos os workers terminarem
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var counters progress
+	progressDone := make(chan struct{})
+	progressStopped := make(chan struct{})
+	if st.Progress != nil {
+		go func() {
+			defer close(progressStopped)
+			counters.report(st.Progress, st, startTime, time.Second, progressDone)
+		}()
+	} else {
+		close(progressStopped)
+	}
+
+	// Os resultados são agregados em uma goroutine dedicada enquanto os
+	// workers executam; o canal limitado a Concurrency mantém a memória
+	// proporcional à concorrência e não à quantidade de requests
+	collect := newCollector(st, report, &counters, startTime, cancel)
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		collect.collect(st, results)
+	}()
+	<-collected
+
+	close(progressDone)
+	<-progressStopped
+
+	// Calcula o tempo total e as métricas finais
+	report.TotalTime = time.Since(startTime)
+	if parent.Err() != nil {
+		report.Interrupted = true
+		report.InterruptCause = context.Cause(parent)
+	}
+	report.FullConcurrencyAt = time.Duration(fullConcurrencyAt.Load())
+	if arrivals != nil {
+		<-arrivals.finished
+		report.Arrivals = arrivals.arrivals
+		report.DroppedArrivals = arrivals.dropped
+		report.PeakInFlight = arrivals.peakInFlight
+		if arrivals.elapsed > 0 {
+			report.AchievedArrivalRate = float64(arrivals.arrivals) / arrivals.elapsed.Seconds()
+		}
+		for i, stage := range report.Stages {
+			stage.Arrivals = arrivals.stageArrivals[i]
+			stage.DroppedArrivals = arrivals.stageDropped[i]
+		}
+	}
+	if report.TotalTime > 0 {
+		report.RequestsPerSecond = float64(report.TotalRequests) / report.TotalTime.Seconds()
+		report.SuccessfulRequestsPerSecond = float64(report.SuccessfulRequests) / report.TotalTime.Seconds()
+	}
+	collect.finish()
+	if sampler != nil {
+		sampler.stop(report.Timeline)
+		report.Trend = collect.trend.finish(report.Timeline, report.TimelineInterval)
+	}
+	if st.Spike != nil {
+		report.Spike = newSpikeReport(report)
+	}
+	if state.data != nil {
+		report.DataExhausted = state.data.exhausted.Load()
+	}
+	for _, threshold := range st.Thresholds {
+		report.Thresholds = append(report.Thresholds, threshold.Evaluate(report))
+	}
+
+	return report, nil
+}
+
+// warmup executa as requests de aquecimento com a mesma concorrência e
+// configuração do teste, descartando os resultados. Retorna quantas requests
+// foram concluídas.
+func (st *StressTest) warmup(ctx context.Context, limiter *rateLimiter, state *runState, seed uint64) int {
+	start := time.Now()
+	dispatch := &dispatcher{limit: int64(st.WarmupRequests)}
+	if st.WarmupDuration > 0 {
+		dispatch.deadline = start.Add(st.WarmupDuration)
+	}
+	// No modelo aberto o aquecimento segue a mesma taxa de chegadas
+	var arrivals *arrivalSchedule
+	if st.ArrivalRate > 0 {
+		arrivals = newArrivalSchedule(st, seed)
+		go arrivals.run(ctx, dispatch, start)
+	}
+
+	var completed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < st.workers(); i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			client := st.workerClient(workerID)
+			defer st.releaseClient(client)
+			defer st.closeWebSocket(state, workerID)
+			emit := func(result Result) {
+				if !result.Canceled {
+					completed.Add(1)
+				}
+			}
+			defer st.closeSSE(state, workerID, emit)
+			for {
+				if _, ok := st.nextArrival(ctx, limiter, dispatch, arrivals); !ok {
+					return
+				}
+				row, ok := state.data.next()
+				if !ok {
+					arrivals.stop()
+					return
+				}
+				st.iterate(ctx, workerID, client, state, row, emit)
+				arrivals.done()
+				if !st.think(ctx, dispatch) {
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	return int(completed.Load())
+}
+
+// workers retorna a quantidade de workers: MaxInFlight no modelo aberto
+// (ver ArrivalRate), a maior concorrência das etapas de Stages no fechado e,
+// sem etapas, Concurrency
+func (st *StressTest) workers() int {
+	if st.openModel() {
+		return st.MaxInFlight
+	}
+	if stages := newStagePlan(st.loadStages()); stages != nil {
+		return stages.maxConcurrency()
+	}
+	return st.Concurrency
+}
+
+// openModel indica se as requests seguem uma taxa de chegadas, de
+// ArrivalRate ou das etapas de Stages
+func (st *StressTest) openModel() bool {
+	stages := st.loadStages()
+	return st.ArrivalRate > 0 || (len(stages) > 0 && stages[0].Rate > 0)
+}
+
+// loadStages retorna as etapas do teste: as de Spike, quando definido, ou
+// Stages
+func (st *StressTest) loadStages() []Stage {
+	if st.Spike != nil {
+		return st.Spike.Stages()
+	}
+	return st.Stages
+}
+
+// plannedDuration retorna a duração prevista do teste: a soma das etapas de
+// Stages ou Duration (0 no modo por quantidade)
+func (st *StressTest) plannedDuration() time.Duration {
+	if stages := newStagePlan(st.loadStages()); stages != nil {
+		return stages.total()
+	}
+	return st.Duration
+}
+
+// nextArrival aguarda a vez do worker iniciar a próxima request: a próxima
+// chegada no modelo aberto, retornando o seu horário planejado, ou, no
+// fechado, o token de RPS e a reserva em dispatch, sem horário. Retorna false
+// quando o teste terminou ou foi cancelado.
+func (st *StressTest) nextArrival(ctx context.Context, limiter *rateLimiter, dispatch *dispatcher, arrivals *arrivalSchedule) (time.Time, bool) {
+	if arrivals != nil {
+		return arrivals.wait(ctx)
+	}
+	// O token é obtido antes de reservar a request para que a espera não
+	// ultrapasse o prazo do modo por duração
+	if limiter != nil && limiter.Wait(ctx) != nil {
+		return time.Time{}, false
+	}
+	return time.Time{}, dispatch.next(ctx)
+}
+
+// think aguarda o ThinkTime entre duas requests do mesmo worker, sorteado
+// uniformemente em ThinkTime ± ThinkTimeJitter. Retorna false se o teste
+// terminou, evitando esperar sem necessidade após a última request.
+func (st *StressTest) think(ctx context.Context, dispatch *dispatcher) bool {
+	if st.ThinkTime <= 0 && st.ThinkTimeJitter <= 0 {
+		return true
+	}
+	if dispatch.exhausted(ctx) {
+		return false
+	}
+	delay := st.ThinkTime
+	if st.ThinkTimeJitter > 0 {
+		delay += time.Duration(rand.Int64N(int64(2*st.ThinkTimeJitter)+1)) - st.ThinkTimeJitter
+	}
+	// Pausas longas (ex.: o intervalo entre mensagens WebSocket) não
+	// ultrapassam o fim do modo por duração
+	if !dispatch.deadline.IsZero() {
+		delay = min(delay, time.Until(dispatch.deadline))
+	}
+	return sleepContext(ctx, delay)
+}
+
+// ProtocolName formata a versão do protocolo como em http.Response.Proto
+func ProtocolName(major, minor int) string {
+	return fmt.Sprintf("HTTP/%d.%d", major, minor)
+}
+
+// sleepContext aguarda d ou até o contexto ser cancelado, retornando false
+// no caso de cancelamento
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// newRequest monta a request HTTP para o alvo a partir da configuração do
+// teste, preenchendo os templates com row quando há dados
+func (st *StressTest) newRequest(ctx context.Context, state *runState, spec requestSpec, row map[string]string) (*http.Request, error) {
+	rawURL := spec.target.URL
+	// Cada request recebe seu próprio reader, já que o corpo é consumido no envio
+	var body io.Reader
+	switch {
+	case spec.multipart != nil:
+		body = spec.multipart.reader()
+	case spec.body != nil:
+		body = bytes.NewReader(spec.body)
+	}
+	header := spec.header
+	if spec.templates != nil {
+		var err error
+		if rawURL, err = spec.templates.url(rawURL, row); err != nil {
+			return nil, err
+		}
+		if spec.body != nil {
+			if body, err = spec.templates.bodyReader(row); err != nil {
+				return nil, err
+			}
+		}
+		if header, err = spec.templates.headers(row); err != nil {
+			return nil, err
+		}
+	}
+	if spec.form != nil {
+		var err error
+		if body, err = spec.form.reader(row); err != nil {
+			return nil, err
+		}
+	}
+	if spec.graphql != nil {
+		var err error
+		if body, err = spec.graphql.reader(row); err != nil {
+			return nil, err
+		}
+	}
+	// Os parâmetros extras não entram no Label, mantendo o agrupamento por alvo
+	rawURL = state.query.apply(rawURL)
+
+	req, err := http.NewRequestWithContext(ctx, spec.target.Method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if header != nil {
+		req.Header = header.Clone()
+		// O net/http ignora o header Host, que precisa ir em req.Host
+		if host := req.Header.Get("Host"); host != "" {
+			req.Host = host
+		}
+	}
+	// O User-Agent configurado só vale quando os headers não definem outro
+	if agent := state.userAgents.next(); agent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", agent)
+	}
+	if encoding := st.Compression.acceptEncoding(); encoding != "" && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", encoding)
+	}
+	if st.Host != "" {
+		req.Host = st.Host
+	}
+	if spec.multipart != nil {
+		// O corpo é lido do disco a cada request, então o tamanho vem do
+		// layout e GetBody permite reenviá-lo em redirecionamentos 307/308
+		req.ContentLength = spec.multipart.size
+		req.GetBody = func() (io.ReadCloser, error) {
+			return spec.multipart.reader(), nil
+		}
+		req.Header.Set("Content-Type", spec.multipart.contentType)
+	}
+	if st.ContentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", st.ContentType)
+	}
+	if spec.form != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", formContentType)
+	}
+	if spec.graphql != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", graphqlContentType)
+	}
+	if st.BasicAuth != nil {
+		req.SetBasicAuth(st.BasicAuth.Username, st.BasicAuth.Password)
+	}
+	if st.BearerToken != nil {
+		req.Header.Set("Authorization", "Bearer "+st.BearerToken.Token())
+	}
+	return req, nil
+}
+
+// execute realiza uma única request e mede sua duração. Os templates são
+// preenchidos com data, que recebe os valores extraídos da resposta. Com
+// retrying, restam retentativas (ver executeWithRetry) e as falhas que serão
+// repetidas não são capturadas.
+func (st *StressTest) execute(ctx context.Context, workerID int, client *http.Client, state *runState, spec requestSpec, data map[string]string, retrying bool) Result {
+	result := Result{WorkerID: workerID, Timestamp: time.Now(), Target: spec.label}
+
+	var trace requestTrace
+	var redirects int
+	reqCtx := context.WithValue(ctx, redirectCountKey{}, &redirects)
+	req, err := st.newRequest(httptrace.WithClientTrace(reqCtx, trace.clientTrace(st.Trace)), state, spec, data)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	if st.ClientIDHeader != "" {
+		req.Header.Set(st.ClientIDHeader, WorkerName(workerID))
+	}
+	if st.TraceContext != nil {
+		st.TraceContext.inject(req.Header, &result)
+	}
+	if st.RecordRequests {
+		result.Method = req.Method
+		result.URL = req.URL.String()
+		result.RequestHeader = req.Header
+	}
+
+	// Com templates o tamanho do corpo varia entre as requests
+	result.BytesSent = max(req.ContentLength, 0)
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Duration = time.Since(start)
+		result.Error = err
+		result.ErrorCategory = classifyError(err)
+		// Requests interrompidas pelo próprio teste não são falhas do serviço
+		result.Canceled = ctx.Err() != nil
+		if !retrying || !st.Retry.retryable(result) {
+			st.captureFailure(state, &result, req, nil, nil)
+		}
+		return result
+	}
+
+	// Transportes sem suporte a httptrace (ex.: HTTP/3) não informam o
+	// primeiro byte; nesse caso vale o momento em que os headers chegaram
+	result.TTFB = time.Since(start)
+	if at := trace.firstByteAt(); !at.IsZero() {
+		result.TTFB = at.Sub(start)
+	}
+
+	// O corpo é lido por completo para contabilizar os bytes recebidos,
+	// medir o tempo total da resposta e permitir o reuso da conexão
+	body := limitedBuffer{limit: st.capturedBodyLimit()}
+	if !st.NoBodyRead {
+		// O corpo só é guardado quando alguma asserção ou extração precisa dele
+		var dst io.Writer = io.Discard
+		switch {
+		case st.checksBody(spec):
+			dst = &body
+		case st.capturing(state) && !st.expectedStatus().Contains(resp.StatusCode):
+			body.limit = CapturedFailureBody
+			dst = &body
+		}
+		// O limite vale para os bytes que trafegam; o byte além dele apenas
+		// revela que o corpo é maior
+		var reader io.Reader = resp.Body
+		if st.MaxBodyBytes > 0 {
+			reader = io.LimitReader(resp.Body, st.MaxBodyBytes+1)
+		}
+		var decoder *gzipDecoder
+		if st.Compression == CompressionGzip && strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+			decoder = newGzipDecoder(reader)
+			reader = decoder
+		}
+		var err error
+		result.BytesRead, err = io.Copy(dst, reader)
+		// BytesRead conta os bytes que trafegaram; os descomprimidos ficam à parte
+		if decoder != nil {
+			result.Compressed = true
+			result.DecodedBytes = result.BytesRead
+			result.BytesRead = decoder.wire.n
+			result.Decompression = decoder.decompression()
+		}
+		switch {
+		case st.MaxBodyBytes > 0 && result.BytesRead > st.MaxBodyBytes:
+			// O restante não é baixado: fechar o corpo abandona a conexão
+			result.BytesRead = st.MaxBodyBytes
+			result.BodyLimited = true
+			result.Error = fmt.Errorf("corpo maior que o limite de %d bytes", st.MaxBodyBytes)
+			result.ErrorCategory = ErrorBodyLimit
+		case decoder != nil && isDecompressionError(err):
+			result.Error = fmt.Errorf("corpo gzip inválido: %w", err)
+			result.ErrorCategory = ErrorDecompression
+		}
+		// Respostas a HEAD informam o Content-Length sem enviar o corpo
+		short := resp.ContentLength >= 0 && result.BytesRead < resp.ContentLength && req.Method != http.MethodHead
+		result.Truncated = !result.BodyLimited && (short || errors.Is(err, io.ErrUnexpectedEOF))
+	}
+	resp.Body.Close()
+	result.Duration = time.Since(start)
+	if st.Trace {
+		result.Phases = trace.phases()
+	}
+	result.Connection = trace.connection()
+	if st.RecordRequests {
+		result.ResponseHeader = resp.Header
+	}
+	result.StatusCode = resp.StatusCode
+	result.ProtoMajor = resp.ProtoMajor
+	result.ProtoMinor = resp.ProtoMinor
+	// Quando redirecionamentos são seguidos, Duration e TTFB cobrem toda a
+	// cadeia, até o primeiro byte da última resposta
+	result.Redirected = redirects > 0
+	if result.Error == nil && st.expectedStatus().Contains(resp.StatusCode) {
+		st.checkResponse(&result, spec, resp.Header, body.Bytes(), data)
+	}
+	result.RetryAfter = st.retryAfter(resp.StatusCode, resp.Header, time.Now())
+	if (result.Error != nil || !st.expectedStatus().Contains(resp.StatusCode)) && (!retrying || !st.Retry.retryable(result)) {
+		st.captureFailure(state, &result, req, resp, body.Bytes())
+	}
+	return result
+}
+
+// checkResponse aplica as asserções e as extrações a uma resposta com status
+// esperado, registrando em result a primeira falha
+func (st *StressTest) checkResponse(result *Result, spec requestSpec, header http.Header, body []byte, data map[string]string) {
+	if category, err := st.checkAssertions(body); err != nil {
+		result.Error = err
+		result.ErrorCategory = category
+		// O corpo fica disponível em OnResult para depuração
+		result.Body = bytes.Clone(body)
+		return
+	}
+	if st.GraphQL != nil {
+		truncated := int64(len(body)) >= st.capturedBodyLimit()
+		if category, err := checkGraphQLErrors(body, truncated); err != nil {
+			result.Error = err
+			result.ErrorCategory = category
+			result.Body = bytes.Clone(body)
+			return
+		}
+	}
+	for _, extractor := range spec.extract {
+		value, err := extractor.extract(header, body)
+		if err != nil {
+			result.Error = err
+			result.ErrorCategory = ErrorExtraction
+			return
+		}
+		data[extractor.Var] = value
+	}
+}
@@ -0,0 +1,158 @@
+package stress
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// runTest executa o teste e falha em caso de erro
+func runTest(t testing.TB, st *StressTest) *Report {
+	t.Helper()
+	report, err := st.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return report
+}
+
+func TestValidHeaderName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"X-Custom", true},
+		{"x_custom.v2", true},
+		{"!#$%&'*+-.^_`|~", true},
+		{"", false},
+		{"X Custom", false},
+		{"X-Custom:", false},
+		{"Ação", false},
+		{"X-Custom\r\n", false},
+	}
+	for _, tt := range tests {
+		if got := ValidHeaderName(tt.name); got != tt.want {
+			t.Errorf("ValidHeaderName(%q) = %v, esperava %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunSendsHeaders(t *testing.T) {
+	var mu sync.Mutex
+	var received []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Clone())
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	st := NewStressTest(server.URL, 4, 2)
+	st.Header = http.Header{
+		"X-Custom": {"valor"},
+		"X-Multi":  {"1", "2"},
+	}
+	report := runTest(t, st)
+	if report.SuccessfulRequests != 4 {
+		t.Fatalf("SuccessfulRequests = %d, esperava 4", report.SuccessfulRequests)
+	}
+	if len(received) != 4 {
+		t.Fatalf("o servidor recebeu %d requests, esperava 4", len(received))
+	}
+	for _, header := range received {
+		if got := header.Get("X-Custom"); got != "valor" {
+			t.Errorf("X-Custom = %q, esperava %q", got, "valor")
+		}
+		if got := header.Values("X-Multi"); !slices.Equal(got, []string{"1", "2"}) {
+			t.Errorf("X-Multi = %q, esperava [1 2]", got)
+		}
+	}
+}
+
+func TestRunExpectedStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		expect StatusRanges
+		ok     bool
+	}{
+		{status: http.StatusOK, ok: true},
+		{status: http.StatusCreated, ok: true},
+		{status: http.StatusNoContent, ok: true},
+		{status: http.StatusMovedPermanently, ok: true},
+		{status: http.StatusNotFound, ok: false},
+		{status: http.StatusInternalServerError, ok: false},
+		{status: http.StatusCreated, expect: StatusRanges{{Min: 200, Max: 200}}, ok: false},
+		{status: http.StatusNotFound, expect: StatusRanges{{Min: 200, Max: 299}, {Min: 404, Max: 404}}, ok: true},
+	}
+	for _, tt := range tests {
+		expect := cmp.Or(tt.expect.String(), "padrão")
+		t.Run(fmt.Sprintf("%d em %s", tt.status, expect), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status == http.StatusMovedPermanently {
+					w.Header().Set("Location", "/destino")
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			st := NewStressTest(server.URL, 3, 1)
+			// Os redirecionamentos não são seguidos, para que o 301 seja a
+			// resposta final
+			st.Client.CheckRedirect = RedirectPolicy(false, 0)
+			st.ExpectStatus = tt.expect
+			report := runTest(t, st)
+			if got := report.StatusCodes[tt.status]; got != 3 {
+				t.Fatalf("StatusCodes[%d] = %d, esperava 3", tt.status, got)
+			}
+			successful, failed := 3, 0
+			if !tt.ok {
+				successful, failed = 0, 3
+			}
+			if report.SuccessfulRequests != successful || report.FailedRequests != failed {
+				t.Errorf("SuccessfulRequests = %d e FailedRequests = %d, esperava %d e %d",
+					report.SuccessfulRequests, report.FailedRequests, successful, failed)
+			}
+			if got := st.Failed(Result{StatusCode: tt.status}); got == tt.ok {
+				t.Errorf("Failed(%d) = %v, esperava %v", tt.status, got, !tt.ok)
+			}
+		})
+	}
+}
+
+// heapAlloc retorna a memória em uso no heap após uma coleta
+func heapAlloc() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// BenchmarkRun mede as alocações de testes de tamanhos diferentes. O B/op
+// cresce com o trabalho de cada request, mas o coletor não guarda os
+// resultados: a memória retida pelo Report (B-retained) não depende da
+// quantidade de requests.
+func BenchmarkRun(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	for _, requests := range []int{1_000, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("requests=%d", requests), func(b *testing.B) {
+			b.ReportAllocs()
+			var retained uint64
+			for b.Loop() {
+				before := heapAlloc()
+				report := runTest(b, NewStressTest(server.URL, requests, 16))
+				retained += max(heapAlloc(), before) - before
+				runtime.KeepAlive(report)
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*requests), "ns/request")
+			b.ReportMetric(float64(retained)/float64(b.N), "B-retained")
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package stress
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Target é um endpoint exercitado pelo teste
+type Target struct {
+	Method string
+	URL    string
+	// Weight define a proporção de requests enviadas ao alvo em relação aos
+	// demais (0 equivale a 1)
+	Weight int
+	// Header e Body, quando definidos, valem apenas para o alvo (ex.: as
+	// entradas de um HAR). Os headers globais do teste prevalecem sobre os
+	// do alvo, e Body substitui StressTest.Body.
+	Header http.Header
+	Body   []byte
+}
+
+// Label identifica o alvo no relatório, no formato "MÉTODO URL"
+func (t Target) Label() string {
+	return t.Method + " " + t.URL
+}
+
+func (t Target) weight() int {
+	if t.Weight == 0 {
+		return 1
+	}
+	return t.Weight
+}
+
+// ParseTargets lê uma lista de alvos, um por linha, no formato "URL" ou
+// "MÉTODO URL" (como o vegeta); sem método é usado defaultMethod. Um peso
+// pode preceder a linha ("10 GET /busca"). Linhas em branco e comentários iniciados por
+// # são ignorados. URLs relativas são resolvidas contra baseURL, que pode
+// ser vazio quando todas as URLs são absolutas. Os erros indicam o número da
+// linha com problema.
+func ParseTargets(r io.Reader, defaultMethod, baseURL string) ([]Target, error) {
+	var base *url.URL
+	if baseURL != "" {
+		var err error
+		base, err = parseHTTPURL(baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("URL base inválida: %w", err)
+		}
+	}
+
+	var targets []Target
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		target, err := parseTargetLine(text, defaultMethod, base)
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: %w", line, err)
+		}
+		targets = append(targets, target)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("nenhum alvo encontrado")
+	}
+	return targets, nil
+}
+
+func parseTargetLine(text, defaultMethod string, base *url.URL) (Target, error) {
+	target := Target{Method: defaultMethod, Weight: 1}
+	fields := strings.Fields(text)
+	if len(fields) > 1 {
+		if weight, err := strconv.Atoi(fields[0]); err == nil {
+			if weight <= 0 {
+				return Target{}, fmt.Errorf("o peso deve ser maior que zero: %d", weight)
+			}
+			target.Weight = weight
+			fields = fields[1:]
+		}
+	}
+	switch len(fields) {
+	case 1:
+		target.URL = fields[0]
+	case 2:
+		target.Method = strings.ToUpper(fields[0])
+		target.URL = fields[1]
+	default:
+		return Target{}, fmt.Errorf("use o formato \"URL\" ou \"MÉTODO URL\", com um peso opcional no início: %q", text)
+	}
+	return resolveTarget(target, base)
+}
+
+// ParseTargetSpec interpreta a definição de um alvo no formato
+// "url=...,weight=N,method=..." (apenas url é obrigatório). URLs relativas
+// são resolvidas contra baseURL.
+func ParseTargetSpec(spec, defaultMethod, baseURL string) (Target, error) {
+	target := Target{Method: defaultMethod, Weight: 1}
+	var rawURL string
+	for _, field := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(field, "=")
+		switch {
+		case ok && key == "url":
+			rawURL = value
+		case ok && key == "method":
+			target.Method = strings.ToUpper(value)
+		case ok && key == "weight":
+			weight, err := strconv.Atoi(value)
+			if err != nil || weight <= 0 {
+				return Target{}, fmt.Errorf("alvo %q: o peso deve ser um inteiro maior que zero", spec)
+			}
+			target.Weight = weight
+		case rawURL != "":
+			// Vírgulas fazem parte da URL (ex.: na query string)
+			rawURL += "," + field
+		default:
+			return Target{}, fmt.Errorf("alvo %q: use o formato \"url=...,weight=N\"", spec)
+		}
+	}
+	if rawURL == "" {
+		return Target{}, fmt.Errorf("alvo %q: url não informada", spec)
+	}
+	target.URL = rawURL
+
+	var base *url.URL
+	if baseURL != "" {
+		var err error
+		base, err = parseHTTPURL(baseURL)
+		if err != nil {
+			return Target{}, fmt.Errorf("URL base inválida: %w", err)
+		}
+	}
+	target, err := resolveTarget(target, base)
+	if err != nil {
+		return Target{}, fmt.Errorf("alvo %q: %w", spec, err)
+	}
+	return target, nil
+}
+
+// templateBraces desfaz o escape dos delimitadores de template
+var templateBraces = strings.NewReplacer("%7B%7B", "{{", "%7D%7D", "}}")
+
+// resolveTarget valida o método e resolve a URL do alvo contra base
+func resolveTarget(target Target, base *url.URL) (Target, error) {
+	if !ValidMethod(target.Method) {
+		return Target{}, fmt.Errorf("método HTTP inválido: %s", target.Method)
+	}
+
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return Target{}, fmt.Errorf("URL inválida: %w", err)
+	}
+	if !u.IsAbs() {
+		if base == nil {
+			return Target{}, fmt.Errorf("a URL relativa %q requer uma URL base", target.URL)
+		}
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Target{}, fmt.Errorf("esquema não suportado %q: use http:// ou https://", u.Scheme)
+	}
+	// String escapa as chaves no caminho, mas os templates de DataSet
+	// precisam chegar intactos até serem preenchidos
+	target.URL = templateBraces.Replace(u.String())
+	return target, nil
+}
+
+// parseHTTPURL valida uma URL absoluta http:// ou https://
+func parseHTTPURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("%q não é uma URL http:// ou https:// absoluta", raw)
+	}
+	return u, nil
+}
+
+// targetSelector distribui os alvos entre as requests: em rodízio quando
+// todos têm o mesmo peso, ou por sorteio ponderado quando os pesos diferem
+type targetSelector struct {
+	targets []Target
+	counter atomic.Uint64
+
+	// cumulative guarda a soma acumulada dos pesos, usada no sorteio
+	cumulative []int
+	mu         sync.Mutex
+	rng        *rand.Rand
+}
+
+// newTargetSelector usa StressTest.Targets ou, sem alvos, URL e Method. O
+// sorteio usa seed, tornando a sequência de alvos reproduzível.
+func newTargetSelector(st *StressTest, seed uint64) *targetSelector {
+	targets := st.Targets
+	if len(targets) == 0 {
+		targets = []Target{{Method: st.Method, URL: st.URL}}
+	}
+	s := &targetSelector{targets: targets}
+	if weightedTargets(targets) {
+		total := 0
+		for _, target := range targets {
+			total += target.weight()
+			s.cumulative = append(s.cumulative, total)
+		}
+		s.rng = rand.New(rand.NewPCG(seed, seed))
+	}
+	return s
+}
+
+// weightedTargets indica se os alvos têm pesos diferentes entre si
+func weightedTargets(targets []Target) bool {
+	for _, target := range targets {
+		if target.weight() != targets[0].weight() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *targetSelector) next() Target {
+	return s.targets[s.nextIndex()]
+}
+
+// nextIndex retorna a posição do próximo alvo em targets
+func (s *targetSelector) nextIndex() int {
+	if len(s.targets) == 1 {
+		return 0
+	}
+	if s.rng != nil {
+		s.mu.Lock()
+		n := s.rng.IntN(s.cumulative[len(s.cumulative)-1])
+		s.mu.Unlock()
+		return sort.SearchInts(s.cumulative, n+1)
+	}
+	i := s.counter.Add(1) - 1
+	return int(i % uint64(len(s.targets)))
+}
+
+// newTargetRequests prepara a request de cada alvo quando algum deles define
+// Header ou Body, combinando-os aos headers e ao corpo globais. Retorna nil
+// quando todos os alvos usam apenas os globais.
+func newTargetRequests(st *StressTest, state *runState, sample map[string]string) ([]requestSpec, error) {
+	targets := state.targets.targets
+	if !slices.ContainsFunc(targets, func(t Target) bool { return t.Header != nil || t.Body != nil }) {
+		return nil, nil
+	}
+	specs := make([]requestSpec, len(targets))
+	for i, target := range targets {
+		header := st.Header
+		if target.Header != nil {
+			header = target.Header.Clone()
+			for name, values := range st.Header {
+				header[name] = values
+			}
+		}
+		body := st.Body
+		if target.Body != nil {
+			body = target.Body
+		}
+		templates, err := newRequestTemplates(sample, []string{target.URL}, header, body)
+		if err != nil {
+			return nil, fmt.Errorf("alvo %s: %w", target.Label(), err)
+		}
+		specs[i] = requestSpec{
+			target:    target,
+			label:     target.Label(),
+			header:    header,
+			body:      body,
+			multipart: state.multipart,
+			form:      state.form,
+			graphql:   state.graphql,
+			templates: templates,
+		}
+	}
+	return specs, nil
+}
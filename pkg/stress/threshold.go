@@ -0,0 +1,218 @@
+package stress
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Threshold é uma condição de falha avaliada sobre o Report final, como
+// "p95>300ms" ou "error_rate>1%": o limite é violado quando a métrica
+// satisfaz a comparação.
+//
+// Métricas de duração: min, max, avg, stddev, percentis (p50, p95, p99.9...)
+// e ttfb_avg, ttfb_p50, ttfb_p95 e ttfb_p99, com valores como "300ms".
+// Taxas: error_rate, success_rate e dropped_rate (as chegadas descartadas do
+// modelo aberto, ver StressTest.ArrivalRate), com valores como "1%" ou "0.01".
+// Vazão: rps e success_rps. Índice: apdex, entre 0 e 1 (requer
+// StressTest.ApdexT).
+type Threshold struct {
+	Metric   string
+	Operator string
+	Value    float64
+}
+
+// ThresholdResult é o resultado da avaliação de um Threshold
+type ThresholdResult struct {
+	Threshold Threshold
+	// Actual é o valor medido, na mesma unidade de Threshold.Value
+	Actual float64
+	Passed bool
+}
+
+// thresholdKind define como o valor de uma métrica é lido e exibido
+type thresholdKind int
+
+const (
+	thresholdDuration thresholdKind = iota
+	thresholdRate
+	thresholdNumber
+)
+
+// thresholdOperators são testados em ordem, para que "<=" não seja lido
+// como "<"
+var thresholdOperators = []string{"<=", ">=", "<", ">"}
+
+// ParseThreshold interpreta uma regra no formato "métrica operador valor"
+func ParseThreshold(text string) (Threshold, error) {
+	for _, op := range thresholdOperators {
+		metric, value, ok := strings.Cut(text, op)
+		if !ok {
+			continue
+		}
+		t := Threshold{Metric: strings.ToLower(strings.TrimSpace(metric)), Operator: op}
+		kind, err := metricKind(t.Metric)
+		if err != nil {
+			return Threshold{}, err
+		}
+		if t.Value, err = parseThresholdValue(kind, strings.TrimSpace(value)); err != nil {
+			return Threshold{}, fmt.Errorf("valor inválido em %q: %w", text, err)
+		}
+		return t, nil
+	}
+	return Threshold{}, fmt.Errorf("use o formato \"métrica>valor\" (operadores: <, <=, >, >=): %q", text)
+}
+
+// metricKind valida o nome da métrica e retorna seu tipo
+func metricKind(metric string) (thresholdKind, error) {
+	switch metric {
+	case "min", "max", "avg", "stddev", "ttfb_avg", "ttfb_p50", "ttfb_p95", "ttfb_p99":
+		return thresholdDuration, nil
+	case "error_rate", "success_rate", "dropped_rate":
+		return thresholdRate, nil
+	case "rps", "success_rps", "apdex":
+		return thresholdNumber, nil
+	}
+	if _, ok := percentileMetric(metric); ok {
+		return thresholdDuration, nil
+	}
+	return 0, fmt.Errorf("métrica desconhecida %q", metric)
+}
+
+// percentileMetric lê métricas como "p95" e "p99.9"
+func percentileMetric(metric string) (float64, bool) {
+	if !strings.HasPrefix(metric, "p") {
+		return 0, false
+	}
+	p, err := strconv.ParseFloat(metric[1:], 64)
+	return p, err == nil && p > 0 && p <= 100
+}
+
+func parseThresholdValue(kind thresholdKind, text string) (float64, error) {
+	switch kind {
+	case thresholdDuration:
+		d, err := time.ParseDuration(text)
+		return float64(d), err
+	case thresholdRate:
+		if percent, ok := strings.CutSuffix(text, "%"); ok {
+			v, err := strconv.ParseFloat(percent, 64)
+			return v / 100, err
+		}
+		return strconv.ParseFloat(text, 64)
+	default:
+		return strconv.ParseFloat(text, 64)
+	}
+}
+
+// String retorna a regra no formato aceito por ParseThreshold
+func (t Threshold) String() string {
+	return t.Metric + t.Operator + t.FormatValue(t.Value)
+}
+
+// FormatValue exibe v na unidade da métrica (ex.: "300ms" ou "1.5%")
+func (t Threshold) FormatValue(v float64) string {
+	kind, _ := metricKind(t.Metric)
+	switch kind {
+	case thresholdDuration:
+		return time.Duration(v).String()
+	case thresholdRate:
+		return formatRounded(v*100) + "%"
+	default:
+		return formatRounded(v)
+	}
+}
+
+// formatRounded exibe v com até duas casas decimais
+func formatRounded(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// metric lê o valor da métrica no Report
+func (t Threshold) metric(r *Report) float64 {
+	var errorRate float64
+	if r.TotalRequests > 0 {
+		errorRate = float64(r.FailedRequests) / float64(r.TotalRequests)
+	}
+	switch t.Metric {
+	case "min":
+		return float64(r.MinDuration)
+	case "max":
+		return float64(r.MaxDuration)
+	case "avg":
+		return float64(r.AvgDuration)
+	case "stddev":
+		return float64(r.StdDevDuration)
+	case "ttfb_avg":
+		return float64(r.TTFB.Avg)
+	case "ttfb_p50":
+		return float64(r.TTFB.P50)
+	case "ttfb_p95":
+		return float64(r.TTFB.P95)
+	case "ttfb_p99":
+		return float64(r.TTFB.P99)
+	case "error_rate":
+		return errorRate
+	case "success_rate":
+		if r.TotalRequests == 0 {
+			return 0
+		}
+		return 1 - errorRate
+	case "dropped_rate":
+		return r.DroppedArrivalRate()
+	case "rps":
+		return r.RequestsPerSecond
+	case "success_rps":
+		return r.SuccessfulRequestsPerSecond
+	case "apdex":
+		if r.Apdex == nil {
+			return 0
+		}
+		return r.Apdex.Score
+	}
+	p, _ := percentileMetric(t.Metric)
+	return float64(r.ValueAtQuantile(p))
+}
+
+// Evaluate compara a métrica do Report com o limite
+func (t Threshold) Evaluate(r *Report) ThresholdResult {
+	actual := t.metric(r)
+	var violated bool
+	switch t.Operator {
+	case "<":
+		violated = actual < t.Value
+	case "<=":
+		violated = actual <= t.Value
+	case ">":
+		violated = actual > t.Value
+	case ">=":
+		violated = actual >= t.Value
+	}
+	return ThresholdResult{Threshold: t, Actual: actual, Passed: !violated}
+}
+
+// thresholdsUse indica se algum limite usa a métrica
+func thresholdsUse(thresholds []Threshold, metric string) bool {
+	for _, t := range thresholds {
+		if t.Metric == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// validThresholds verifica os limites montados sem ParseThreshold
+func validThresholds(thresholds []Threshold) bool {
+	for _, t := range thresholds {
+		if _, err := metricKind(t.Metric); err != nil {
+			return false
+		}
+		switch t.Operator {
+		case "<", "<=", ">", ">=":
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package stress
+
+import "time"
+
+// TimelinePoint resume as requests concluídas em um intervalo de
+// StressTest.TimelineInterval
+type TimelinePoint struct {
+	// Start é o início do intervalo, contado a partir do início do teste
+	Start time.Duration
+	// Stage é o número, a partir de 1, da etapa de StressTest.Stages em vigor
+	// no início do intervalo (zero sem etapas), marcando os degraus da carga
+	Stage    int
+	Requests int
+	Errors   int
+	// Avg, P50, P95, P99 e Max resumem as durações das requests do
+	// intervalo que receberam resposta (zero sem respostas)
+	Avg time.Duration
+	P50 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+	Max time.Duration
+	// Resources é o consumo do próprio processo ao fim do intervalo, amostrado
+	// com StressTest.Soak (nil sem ele)
+	Resources *ResourceSample
+}
+
+const (
+	// defaultTimelineInterval é o intervalo usado com TimelineInterval zero
+	defaultTimelineInterval = time.Second
+	// minTimelineInterval é o menor StressTest.TimelineInterval aceito
+	minTimelineInterval = 10 * time.Millisecond
+)
+
+// timelineRecorder agrupa as requests pelo instante em que terminaram.
+// Apenas o intervalo corrente guarda um histograma: os resultados chegam ao
+// collector praticamente na ordem em que terminam, e os poucos que chegam
+// depois do fechamento do seu intervalo entram no corrente.
+type timelineRecorder struct {
+	start        time.Time
+	interval     time.Duration
+	histogramMax time.Duration
+	// stages é nil sem StressTest.Stages
+	stages  *stagePlan
+	points  []TimelinePoint
+	current *durationRecorder
+}
+
+func newTimelineRecorder(st *StressTest, start time.Time, stages *stagePlan) *timelineRecorder {
+	interval := st.TimelineInterval
+	if interval == 0 {
+		interval = defaultTimelineInterval
+	}
+	return &timelineRecorder{start: start, interval: interval, histogramMax: st.HistogramMax, stages: stages}
+}
+
+// add registra uma request; measured indica se a duração entra nas métricas
+func (r *timelineRecorder) add(result Result, failed, measured bool) {
+	r.advance(int(result.Timestamp.Add(result.Duration).Sub(r.start) / r.interval))
+	point := &r.points[len(r.points)-1]
+	point.Requests++
+	if failed {
+		point.Errors++
+	}
+	if measured {
+		r.current.add(result.Duration)
+	}
+}
+
+// advance fecha os intervalos anteriores a index, mantendo os intervalos
+// vazios para que a série não tenha lacunas
+func (r *timelineRecorder) advance(index int) {
+	for len(r.points) <= index {
+		r.close()
+		point := TimelinePoint{Start: time.Duration(len(r.points)) * r.interval}
+		if r.stages != nil {
+			point.Stage = r.stages.at(point.Start) + 1
+		}
+		r.points = append(r.points, point)
+		r.current = &durationRecorder{histogram: newDurationHistogram(r.histogramMax, targetHistogramSigFigs)}
+	}
+}
+
+// close completa as durações do intervalo corrente
+func (r *timelineRecorder) close() {
+	if len(r.points) == 0 {
+		return
+	}
+	stats := r.current.stats()
+	point := &r.points[len(r.points)-1]
+	point.Avg, point.P50, point.P95, point.P99, point.Max = stats.Avg, stats.P50, stats.P95, stats.P99, stats.Max
+}
+
+// finish fecha o último intervalo, estendendo a série até o fim do teste
+func (r *timelineRecorder) finish(total time.Duration) []TimelinePoint {
+	if total > 0 {
+		r.advance(int((total - 1) / r.interval))
+	}
+	r.close()
+	return r.points
+}
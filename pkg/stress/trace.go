@@ -0,0 +1,117 @@
+package stress
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Phases detalha o tempo gasto em cada fase de uma request. DNS, Connect e
+// TLS ficam zerados quando a conexão foi reaproveitada do pool (Reused).
+type Phases struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// Server vai do fim do envio da request até o primeiro byte da resposta
+	Server time.Duration
+	Reused bool
+}
+
+// requestTrace registra os instantes informados pelo httptrace. Os callbacks
+// são chamados pelas goroutines do transporte, daí o mutex.
+type requestTrace struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	gotConn      bool
+	reused       bool
+}
+
+// clientTrace monta o httptrace.ClientTrace da request. O primeiro byte e a
+// conexão obtida são sempre registrados; as demais fases apenas com phases
+// true, evitando o custo extra quando o detalhamento não foi pedido.
+func (t *requestTrace) clientTrace(phases bool) *httptrace.ClientTrace {
+	trace := &httptrace.ClientTrace{
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.gotConn = true
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+	}
+	if !phases {
+		return trace
+	}
+	trace.DNSStart = func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) }
+	trace.DNSDone = func(httptrace.DNSDoneInfo) { t.mark(&t.dnsDone) }
+	// Com vários endereços o dial pode tentar mais de uma conexão; vale a
+	// primeira tentativa e a última conclusão
+	trace.ConnectStart = func(string, string) {
+		t.mu.Lock()
+		if t.connectStart.IsZero() {
+			t.connectStart = time.Now()
+		}
+		t.mu.Unlock()
+	}
+	trace.ConnectDone = func(string, string, error) { t.mark(&t.connectDone) }
+	trace.TLSHandshakeStart = func() { t.mark(&t.tlsStart) }
+	trace.TLSHandshakeDone = func(tls.ConnectionState, error) { t.mark(&t.tlsDone) }
+	trace.WroteRequest = func(httptrace.WroteRequestInfo) { t.mark(&t.wroteRequest) }
+	return trace
+}
+
+func (t *requestTrace) mark(at *time.Time) {
+	now := time.Now()
+	t.mu.Lock()
+	*at = now
+	t.mu.Unlock()
+}
+
+// firstByteAt retorna o instante do primeiro byte, zero se não informado
+func (t *requestTrace) firstByteAt() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.firstByte
+}
+
+// connection informa se a request abriu ou reaproveitou a conexão
+func (t *requestTrace) connection() ConnectionUse {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case !t.gotConn:
+		return ConnectionUnknown
+	case t.reused:
+		return ConnectionReused
+	}
+	return ConnectionNew
+}
+
+// phases calcula a duração de cada fase a partir dos instantes registrados
+func (t *requestTrace) phases() Phases {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return Phases{
+		DNS:     between(t.dnsStart, t.dnsDone),
+		Connect: between(t.connectStart, t.connectDone),
+		TLS:     between(t.tlsStart, t.tlsDone),
+		Server:  between(t.wroteRequest, t.firstByte),
+		Reused:  t.reused,
+	}
+}
+
+// between retorna end-start, ou zero se algum dos instantes não foi registrado
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
@@ -0,0 +1,44 @@
+package stress
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math/rand/v2"
+	"net/http"
+)
+
+// TraceContext propaga o contexto de trace do W3C: cada request recebe um
+// header traceparent com um trace ID e um span ID novos, que ficam em
+// Result.TraceID e Result.SpanID para correlacionar a request com os traces
+// do servidor.
+type TraceContext struct {
+	// SampleRate é a fração das requests marcadas como amostradas (flag
+	// sampled do traceparent e Result.TraceSampled), de 0 a 1
+	SampleRate float64
+}
+
+// inject acrescenta o traceparent à request, sem substituir um definido nos
+// headers configurados
+func (t *TraceContext) inject(header http.Header, result *Result) {
+	if header.Get("traceparent") != "" {
+		return
+	}
+	var traceID [16]byte
+	var spanID [8]byte
+	// IDs zerados são inválidos no traceparent
+	for traceID == [16]byte{} {
+		binary.BigEndian.PutUint64(traceID[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(traceID[8:], rand.Uint64())
+	}
+	for spanID == [8]byte{} {
+		binary.BigEndian.PutUint64(spanID[:], rand.Uint64())
+	}
+	result.TraceID = hex.EncodeToString(traceID[:])
+	result.SpanID = hex.EncodeToString(spanID[:])
+	result.TraceSampled = t.SampleRate >= 1 || rand.Float64() < t.SampleRate
+	flags := "00"
+	if result.TraceSampled {
+		flags = "01"
+	}
+	header.Set("traceparent", "00-"+result.TraceID+"-"+result.SpanID+"-"+flags)
+}
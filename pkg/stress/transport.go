@@ -0,0 +1,250 @@
+package stress
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// TransportConfig reúne as opções usadas para montar o http.Transport do teste.
+// Em todos os timeouts, zero significa sem limite.
+type TransportConfig struct {
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	// InsecureSkipVerify desativa a verificação do certificado do servidor
+	InsecureSkipVerify bool
+	// RootCAs substitui as autoridades certificadoras do sistema
+	RootCAs *x509.CertPool
+	// Certificates são os certificados de client apresentados no mTLS
+	Certificates []tls.Certificate
+	// HTTP2 habilita explicitamente a negociação de HTTP/2 via ALPN
+	HTTP2 bool
+	// H2C usa HTTP/2 sem TLS (prior knowledge) para URLs http://
+	H2C bool
+	// HTTP1Only desativa o HTTP/2, mantendo todas as requests em HTTP/1.1
+	HTTP1Only bool
+	// Proxy, quando definido, encaminha todas as requests pelo proxy
+	// (http://, https:// ou socks5://, com credenciais opcionais na URL)
+	Proxy *url.URL
+	// NoProxyEnv ignora HTTP_PROXY/HTTPS_PROXY/NO_PROXY quando Proxy não é definido
+	NoProxyEnv bool
+	// UnixSocket, quando definido, faz todas as conexões usarem o socket
+	// Unix informado; a URL continua definindo o path e o header Host
+	UnixSocket string
+	// ConnectTo, quando definido (ip:porta), força todas as conexões TCP para
+	// esse endereço mantendo a URL, o SNI e o header Host originais
+	ConnectTo string
+	// Resolve define endereços fixos por "host:porta", como o --resolve do curl
+	Resolve map[string][]string
+	// DNSServer, quando definido (ip:porta), é usado para todas as consultas DNS
+	DNSServer string
+	// LocalPorts, quando definido, limita as portas de origem das conexões
+	// TCP ao intervalo; cada porta só sustenta uma conexão por destino
+	LocalPorts PortRange
+	// ServerName substitui o nome usado no SNI e na verificação do certificado
+	ServerName string
+	// DisableKeepAlives abre uma nova conexão para cada request
+	DisableKeepAlives bool
+	// DisableCompression impede o transporte de pedir gzip e descomprimir as
+	// respostas por conta própria, como em http.Transport
+	DisableCompression bool
+	// MaxIdleConns, MaxIdleConnsPerHost e MaxConnsPerHost controlam o pool
+	// de conexões, com a mesma semântica dos campos do http.Transport
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	// HTTP3 substitui o transporte TCP por HTTP/3 sobre QUIC
+	HTTP3 bool
+	// QUICHandshakeTimeout e QUICIdleTimeout configuram a conexão QUIC
+	// (zero usa os padrões do quic-go)
+	QUICHandshakeTimeout time.Duration
+	QUICIdleTimeout      time.Duration
+}
+
+// DefaultTransportConfig retorna a configuração equivalente ao
+// http.DefaultTransport
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		DialTimeout:         30 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		MaxIdleConns:        100,
+	}
+}
+
+// WithPoolForConcurrency ajusta o pool de conexões para a concorrência do
+// teste. O padrão do http.Transport mantém apenas 2 conexões ociosas por host,
+// o que faria a maioria das requests abrir uma nova conexão.
+func (cfg TransportConfig) WithPoolForConcurrency(concurrency int) TransportConfig {
+	cfg.MaxIdleConns = max(cfg.MaxIdleConns, concurrency)
+	cfg.MaxIdleConnsPerHost = concurrency
+	return cfg
+}
+
+// NewTransport cria um http.Transport a partir da configuração,
+// partindo dos mesmos valores do http.DefaultTransport
+func NewTransport(cfg TransportConfig) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	if cfg.DNSServer != "" {
+		dialer.Resolver = newDNSResolver(cfg.DNSServer)
+	}
+	tcpDial := dialer.DialContext
+	if !cfg.LocalPorts.IsZero() {
+		tcpDial = newPortDialer(dialer, cfg.LocalPorts).DialContext
+	}
+	dial := tcpDial
+	switch {
+	case cfg.UnixSocket != "":
+		dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", cfg.UnixSocket)
+		}
+	case cfg.ConnectTo != "":
+		dial = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return tcpDial(ctx, network, cfg.ConnectTo)
+		}
+	case len(cfg.Resolve) > 0:
+		resolver := newStaticResolver(cfg.Resolve)
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return tcpDial(ctx, network, resolver.lookup(addr))
+		}
+	}
+
+	// ForceAttemptHTTP2 mantém o HTTP/2 habilitado mesmo com um
+	// TLSClientConfig customizado
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: cfg.InsecureSkipVerify,
+			RootCAs:            cfg.RootCAs,
+			Certificates:       cfg.Certificates,
+			ServerName:         cfg.ServerName,
+		},
+		Proxy:                 proxyFunc(cfg),
+		DialContext:           dial,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		DisableKeepAlives:     cfg.DisableKeepAlives,
+		DisableCompression:    cfg.DisableCompression,
+	}
+
+	switch {
+	case cfg.HTTP1Only:
+		transport.ForceAttemptHTTP2 = false
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP1(true)
+	case cfg.H2C:
+		// Sem HTTP/1 no conjunto, URLs http:// passam a usar h2c
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP2(true)
+		transport.Protocols.SetUnencryptedHTTP2(true)
+	case cfg.HTTP2:
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP1(true)
+		transport.Protocols.SetHTTP2(true)
+	}
+	return transport
+}
+
+// RedirectPolicy retorna a função CheckRedirect do client. Com follow false a
+// primeira resposta é retornada como está; caso contrário são seguidos no
+// máximo maxRedirects redirecionamentos.
+func RedirectPolicy(follow bool, maxRedirects int) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if !follow {
+			return http.ErrUseLastResponse
+		}
+		// Com a request original em via, este é o redirecionamento len(via)
+		if len(via) > maxRedirects {
+			return fmt.Errorf("limite de %d redirecionamentos atingido", maxRedirects)
+		}
+		if count, ok := req.Context().Value(redirectCountKey{}).(*int); ok {
+			*count++
+		}
+		return nil
+	}
+}
+
+// redirectCountKey identifica no contexto da request o contador de
+// redirecionamentos seguidos, preenchido por RedirectPolicy
+type redirectCountKey struct{}
+
+// LoadCertPool carrega todos os certificados PEM do arquivo em um novo
+// x509.CertPool
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("não foi possível ler o arquivo de CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("nenhum certificado PEM válido encontrado em %s", path)
+	}
+	return pool, nil
+}
+
+// LoadClientCertificate carrega o par certificado/chave usado no mTLS. Chaves
+// PKCS#1, PKCS#8 e EC são aceitas. O handshake acontece uma vez por conexão,
+// sendo reaproveitado pelas requests seguintes via keep-alive.
+func LoadClientCertificate(certFile, keyFile string) (tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("não foi possível carregar o certificado de client (verifique se a chave corresponde ao certificado): %w", err)
+	}
+	return cert, nil
+}
+
+// proxyFunc escolhe a função de proxy do transporte: o proxy explícito, as
+// variáveis de ambiente ou nenhum proxy
+func proxyFunc(cfg TransportConfig) func(*http.Request) (*url.URL, error) {
+	switch {
+	case cfg.Proxy != nil:
+		return http.ProxyURL(cfg.Proxy)
+	case cfg.NoProxyEnv:
+		return nil
+	default:
+		return http.ProxyFromEnvironment
+	}
+}
+
+// ParseProxyURL valida a URL informada em -proxy
+func ParseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("URL de proxy inválida: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("esquema de proxy não suportado %q: use http://, https:// ou socks5://", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("URL de proxy inválida: host não informado")
+	}
+	return u, nil
+}
+
+// CheckUnixSocket verifica se path existe e é um socket Unix
+func CheckUnixSocket(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("socket Unix inacessível: %w", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s não é um socket Unix", path)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package stress
+
+import (
+	"context"
+	"crypto/x509"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// redirectServer redireciona /r/N para /r/N-1 até /r/0, que responde 200
+func redirectServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/r/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if n > 0 {
+			http.Redirect(w, r, "/r/"+strconv.Itoa(n-1), http.StatusFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRedirectPolicy(t *testing.T) {
+	server := redirectServer(t)
+	tests := []struct {
+		name         string
+		follow       bool
+		maxRedirects int
+		status       int
+		redirected   int
+		failed       int
+	}{
+		{name: "segue", follow: true, maxRedirects: 10, status: http.StatusOK, redirected: 4},
+		{name: "segue até o limite", follow: true, maxRedirects: 3, status: http.StatusOK, redirected: 4},
+		{name: "não segue", follow: false, status: http.StatusFound},
+		{name: "limite atingido", follow: true, maxRedirects: 2, failed: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := NewStressTest(server.URL+"/r/3", 4, 2)
+			st.Client.CheckRedirect = RedirectPolicy(tt.follow, tt.maxRedirects)
+			report := runTest(t, st)
+			if report.RedirectedRequests != tt.redirected {
+				t.Errorf("RedirectedRequests = %d, esperava %d", report.RedirectedRequests, tt.redirected)
+			}
+			if report.FailedRequests != tt.failed {
+				t.Errorf("FailedRequests = %d, esperava %d", report.FailedRequests, tt.failed)
+			}
+			if tt.status != 0 && report.StatusCodes[tt.status] != 4 {
+				t.Errorf("StatusCodes = %v, esperava 4 respostas %d", report.StatusCodes, tt.status)
+			}
+		})
+	}
+}
+
+func TestRedirectPolicyLimitError(t *testing.T) {
+	server := redirectServer(t)
+	client := &http.Client{CheckRedirect: RedirectPolicy(true, 2)}
+	resp, err := client.Get(server.URL + "/r/3")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("esperava o erro do limite de redirecionamentos")
+	}
+	if !strings.Contains(err.Error(), "limite de 2 redirecionamentos atingido") {
+		t.Errorf("erro = %v, esperava o limite de 2 redirecionamentos", err)
+	}
+}
+
+func TestTransportInsecureSkipVerify(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	// Os handshakes recusados pelo client não poluem a saída do teste
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	tests := []struct {
+		name     string
+		insecure bool
+		rootCAs  *x509.CertPool
+		ok       bool
+	}{
+		{name: "verificado"},
+		{name: "insecure", insecure: true, ok: true},
+		{name: "RootCAs", rootCAs: pool, ok: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultTransportConfig()
+			cfg.InsecureSkipVerify = tt.insecure
+			cfg.RootCAs = tt.rootCAs
+			st := NewStressTest(server.URL, 2, 1)
+			st.Client.Transport = NewTransport(cfg)
+			report := runTest(t, st)
+			if tt.ok {
+				if report.SuccessfulRequests != 2 {
+					t.Errorf("SuccessfulRequests = %d, esperava 2 (erros: %v)", report.SuccessfulRequests, report.ErrorCategories)
+				}
+				return
+			}
+			if report.ErrorCategories[ErrorTLS] != 2 {
+				t.Errorf("ErrorCategories = %v, esperava 2 erros %q", report.ErrorCategories, ErrorTLS)
+			}
+		})
+	}
+}
+
+func TestWithPoolForConcurrency(t *testing.T) {
+	cfg := DefaultTransportConfig().WithPoolForConcurrency(500)
+	if cfg.MaxIdleConns != 500 || cfg.MaxIdleConnsPerHost != 500 {
+		t.Errorf("MaxIdleConns = %d e MaxIdleConnsPerHost = %d, esperava 500", cfg.MaxIdleConns, cfg.MaxIdleConnsPerHost)
+	}
+	if cfg := DefaultTransportConfig().WithPoolForConcurrency(4); cfg.MaxIdleConns != 100 {
+		t.Errorf("MaxIdleConns = %d, esperava manter 100", cfg.MaxIdleConns)
+	}
+}
+
+// reusedConns envia duas rodadas de n requests simultâneas e retorna quantas
+// requests da segunda rodada reaproveitaram uma conexão, segundo o
+// httptrace. O servidor só responde quando as n requests da rodada chegam,
+// então cada rodada ocupa n conexões.
+func reusedConns(t *testing.T, cfg TransportConfig, n int) int {
+	t.Helper()
+	var mu sync.Mutex
+	var barrier *sync.WaitGroup
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		mu.Lock()
+		round := barrier
+		mu.Unlock()
+		round.Done()
+		round.Wait()
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(cfg)}
+	var reused atomic.Int32
+	for round := range 2 {
+		mu.Lock()
+		barrier = &sync.WaitGroup{}
+		barrier.Add(n)
+		mu.Unlock()
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				trace := &httptrace.ClientTrace{GotConn: func(info httptrace.GotConnInfo) {
+					if round == 1 && info.Reused {
+						reused.Add(1)
+					}
+				}}
+				req, _ := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), http.MethodGet, server.URL, nil)
+				resp, err := client.Do(req)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}()
+		}
+		wg.Wait()
+	}
+	return int(reused.Load())
+}
+
+func TestWithPoolForConcurrencyReusesConnections(t *testing.T) {
+	const concurrency = 8
+	if got := reusedConns(t, DefaultTransportConfig().WithPoolForConcurrency(concurrency), concurrency); got != concurrency {
+		t.Errorf("com o pool ajustado, %d de %d requests reaproveitaram a conexão", got, concurrency)
+	}
+	// Sem o ajuste, o http.Transport mantém apenas 2 conexões ociosas por host
+	if got := reusedConns(t, DefaultTransportConfig(), concurrency); got > http.DefaultMaxIdleConnsPerHost {
+		t.Errorf("sem o ajuste, %d requests reaproveitaram a conexão, esperava no máximo %d", got, http.DefaultMaxIdleConnsPerHost)
+	}
+}
+
+func TestTransportUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("socket Unix indisponível: %v", err)
+	}
+	var mu sync.Mutex
+	var hosts, paths []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hosts = append(hosts, r.Host)
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+	}))
+	server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	if err := CheckUnixSocket(path); err != nil {
+		t.Fatalf("CheckUnixSocket: %v", err)
+	}
+	cfg := DefaultTransportConfig()
+	cfg.UnixSocket = path
+	// O host da URL não é resolvido: vale apenas para o header Host
+	st := NewStressTest("http://api.local/v1/status", 3, 1)
+	st.Client.Transport = NewTransport(cfg)
+	report := runTest(t, st)
+	if report.SuccessfulRequests != 3 {
+		t.Fatalf("SuccessfulRequests = %d, esperava 3 (erros: %v)", report.SuccessfulRequests, report.ErrorCategories)
+	}
+	for i := range hosts {
+		if hosts[i] != "api.local" || paths[i] != "/v1/status" {
+			t.Errorf("request %d com Host %q e path %q, esperava api.local e /v1/status", i, hosts[i], paths[i])
+		}
+	}
+}
+
+func TestCheckUnixSocket(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "arquivo")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{file, filepath.Join(dir, "inexistente")} {
+		if err := CheckUnixSocket(path); err == nil {
+			t.Errorf("CheckUnixSocket(%q) sem erro, esperava que não fosse um socket", path)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package stress
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ParseUserAgents lê uma lista de User-Agents, um por linha. Linhas em branco
+// e comentários iniciados por # são ignorados.
+func ParseUserAgents(r io.Reader) ([]string, error) {
+	var agents []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		agents = append(agents, text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(agents) == 0 {
+		return nil, errors.New("nenhum User-Agent encontrado")
+	}
+	return agents, nil
+}
+
+// validUserAgents rejeita valores vazios ou com quebras de linha, que não
+// podem ser enviados em um header
+func validUserAgents(agents []string) bool {
+	for _, agent := range agents {
+		if agent == "" || strings.ContainsAny(agent, "\r\n") {
+			return false
+		}
+	}
+	return true
+}
+
+// userAgentSelector escolhe o User-Agent de cada request em rodízio ou,
+// com StressTest.RandomUserAgent, por sorteio
+type userAgentSelector struct {
+	agents  []string
+	counter atomic.Uint64
+	mu      sync.Mutex
+	rng     *rand.Rand
+}
+
+// newUserAgentSelector retorna nil quando não há User-Agents configurados
+func newUserAgentSelector(st *StressTest, seed uint64) *userAgentSelector {
+	if len(st.UserAgents) == 0 {
+		return nil
+	}
+	s := &userAgentSelector{agents: st.UserAgents}
+	if st.RandomUserAgent && len(st.UserAgents) > 1 {
+		// A semente é deslocada para não repetir as sequências dos alvos e da
+		// query string
+		s.rng = rand.New(rand.NewPCG(seed, seed+2))
+	}
+	return s
+}
+
+// random indica se o User-Agent é sorteado
+func (s *userAgentSelector) random() bool {
+	return s != nil && s.rng != nil
+}
+
+// next retorna o User-Agent da próxima request (vazio sem User-Agents)
+func (s *userAgentSelector) next() string {
+	switch {
+	case s == nil:
+		return ""
+	case len(s.agents) == 1:
+		return s.agents[0]
+	case s.rng != nil:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.agents[s.rng.IntN(len(s.agents))]
+	}
+	i := s.counter.Add(1) - 1
+	return s.agents[i%uint64(len(s.agents))]
+}
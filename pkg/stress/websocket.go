@@ -0,0 +1,332 @@
+package stress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket ativa o modo WebSocket: em vez de requests HTTP, cada worker
+// mantém uma conexão com StressTest.URL (ws:// ou wss://), aberta com o
+// transporte de Client (proxy, TLS e opções de conexão) e com os mesmos
+// headers das requests. Com Body, cada iteração envia o corpo como mensagem
+// e aguarda a próxima mensagem recebida, registrando o round-trip em
+// Duration, com ThinkTime como intervalo entre as mensagens e Client.Timeout
+// como prazo da resposta; Requests passa a contar mensagens. Sem Body, as
+// conexões apenas ficam abertas recebendo mensagens até o fim de Duration.
+// Conexões encerradas pelo servidor são reabertas na iteração seguinte, e
+// todas são fechadas com o handshake de encerramento ao fim do teste. As
+// métricas das conexões ficam em Report.WebSocket.
+type WebSocket struct {
+	// MaxMessageSize limita o tamanho das mensagens recebidas (0 = 1 MiB);
+	// uma mensagem maior encerra a conexão
+	MaxMessageSize int64
+	// Binary envia as mensagens como binárias em vez de texto
+	Binary bool
+}
+
+// defaultMaxMessageSize é o limite usado quando WebSocket.MaxMessageSize
+// não é informado
+const defaultMaxMessageSize = 1 << 20
+
+// webSocketPoll é quanto cada iteração aguarda mensagens quando não há
+// Body, para que o fim de Duration seja percebido logo
+const webSocketPoll = 250 * time.Millisecond
+
+// webSocketCloseTimeout limita a espera pela confirmação do servidor no
+// encerramento da conexão
+const webSocketCloseTimeout = time.Second
+
+// Categorias dos erros específicos das conexões WebSocket
+const (
+	// ErrorWebSocketHandshake indica que o servidor respondeu ao handshake
+	// sem aceitar o upgrade
+	ErrorWebSocketHandshake = "websocket_handshake"
+	// ErrorWebSocketClosed indica que o servidor encerrou a conexão
+	ErrorWebSocketClosed = "websocket_closed"
+	// ErrorWebSocketMessageTooBig indica uma mensagem maior que
+	// WebSocket.MaxMessageSize
+	ErrorWebSocketMessageTooBig = "websocket_message_too_big"
+)
+
+// webSocketErrorCategory classifica os erros das conexões WebSocket,
+// recorrendo a classifyError para os de transporte
+func webSocketErrorCategory(err error) string {
+	var closeErr *websocket.CloseError
+	switch {
+	case errors.Is(err, websocket.ErrBadHandshake):
+		return ErrorWebSocketHandshake
+	case errors.Is(err, websocket.ErrReadLimit):
+		return ErrorWebSocketMessageTooBig
+	case errors.As(err, &closeErr), errors.Is(err, websocket.ErrCloseSent):
+		return ErrorWebSocketClosed
+	}
+	return classifyError(err)
+}
+
+// validWebSocketURL verifica se a URL usa ws:// ou wss://
+func validWebSocketURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "ws" || u.Scheme == "wss") && u.Host != ""
+}
+
+// webSocketSession é a conexão de um worker. Uma goroutine lê as mensagens
+// continuamente, respondendo aos pings e percebendo o encerramento mesmo
+// enquanto o worker aguarda o ThinkTime.
+type webSocketSession struct {
+	conn *websocket.Conn
+	// messages recebe o tamanho de cada mensagem lida
+	messages chan int
+	// done é fechado quando a leitura termina, com o motivo em err
+	done chan struct{}
+	err  error
+	stop chan struct{}
+}
+
+func newWebSocketSession(conn *websocket.Conn, maxMessageSize int64) *webSocketSession {
+	if maxMessageSize == 0 {
+		maxMessageSize = defaultMaxMessageSize
+	}
+	conn.SetReadLimit(maxMessageSize)
+	s := &webSocketSession{
+		conn:     conn,
+		messages: make(chan int, 64),
+		done:     make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+	go s.read()
+	return s
+}
+
+func (s *webSocketSession) read() {
+	defer close(s.done)
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			s.err = err
+			return
+		}
+		select {
+		case s.messages <- len(data):
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// drain descarta as mensagens já recebidas, retornando quantas eram
+func (s *webSocketSession) drain() int {
+	for n := 0; ; n++ {
+		select {
+		case <-s.messages:
+		default:
+			return n
+		}
+	}
+}
+
+// close encerra a conexão; com graceful, envia o close frame e aguarda a
+// confirmação do servidor por até webSocketCloseTimeout
+func (s *webSocketSession) close(graceful bool) {
+	if graceful {
+		deadline := time.Now().Add(webSocketCloseTimeout)
+		message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+		if s.conn.WriteControl(websocket.CloseMessage, message, deadline) == nil {
+			timer := time.NewTimer(webSocketCloseTimeout)
+		wait:
+			for {
+				select {
+				case <-s.messages:
+				case <-s.done:
+					break wait
+				case <-timer.C:
+					break wait
+				}
+			}
+			timer.Stop()
+		}
+	}
+	close(s.stop)
+	s.conn.Close()
+	<-s.done
+}
+
+// webSocketDialer cria o dialer a partir do transporte do client, para que
+// o handshake use o mesmo proxy, TLS e conexão das requests HTTP
+func webSocketDialer(client *http.Client) *websocket.Dialer {
+	dialer := &websocket.Dialer{Jar: client.Jar, HandshakeTimeout: client.Timeout}
+	transport, ok := client.Transport.(*http.Transport)
+	if client.Transport == nil {
+		transport, ok = http.DefaultTransport.(*http.Transport)
+	}
+	if ok {
+		dialer.Proxy = transport.Proxy
+		dialer.NetDialContext = transport.DialContext
+		if transport.TLSClientConfig != nil {
+			// O upgrade só existe no HTTP/1.1, então o h2 não é oferecido
+			dialer.TLSClientConfig = transport.TLSClientConfig.Clone()
+			dialer.TLSClientConfig.NextProtos = nil
+		}
+	}
+	return dialer
+}
+
+// runWebSocket executa uma iteração do modo WebSocket, abrindo a conexão do
+// worker quando ainda não há uma
+func (st *StressTest) runWebSocket(ctx context.Context, workerID int, client *http.Client, state *runState, row map[string]string, emit func(Result)) {
+	session := state.sockets[workerID]
+	if session == nil {
+		var result Result
+		session, result = st.dialWebSocket(ctx, workerID, client, state, row)
+		emit(result)
+		if session == nil {
+			return
+		}
+		state.sockets[workerID] = session
+	}
+	var result Result
+	if st.Body == nil {
+		result = st.awaitWebSocket(ctx, workerID, session)
+	} else {
+		result = st.sendWebSocket(ctx, workerID, client, state, session, row)
+	}
+	if result.WebSocket.Disconnected {
+		session.close(false)
+		state.sockets[workerID] = nil
+	}
+	// Sem Body, iterações sem mensagens nem desconexão não geram resultado
+	if result.WebSocket.Sent || result.WebSocket.Received > 0 || result.WebSocket.Disconnected {
+		emit(result)
+	}
+}
+
+// dialWebSocket faz o handshake, retornando a conexão quando ele é aceito
+func (st *StressTest) dialWebSocket(ctx context.Context, workerID int, client *http.Client, state *runState, row map[string]string) (*webSocketSession, Result) {
+	result := Result{WorkerID: workerID, Timestamp: time.Now(), Target: st.URL, WebSocket: &WebSocketResult{Handshake: true}}
+	// A request só é usada para montar a URL e os headers do handshake
+	spec := requestSpec{target: Target{Method: http.MethodGet, URL: st.URL}, header: st.Header, templates: state.templates}
+	req, err := st.newRequest(ctx, state, spec, row)
+	if err != nil {
+		result.Error = err
+		return nil, result
+	}
+	if st.ClientIDHeader != "" {
+		req.Header.Set(st.ClientIDHeader, WorkerName(workerID))
+	}
+	if req.Host != "" {
+		req.Header.Set("Host", req.Host)
+	}
+
+	start := time.Now()
+	conn, resp, err := webSocketDialer(client).DialContext(ctx, req.URL.String(), req.Header)
+	result.Duration = time.Since(start)
+	result.TTFB = result.Duration
+	if resp != nil {
+		result.StatusCode = resp.StatusCode
+		result.ProtoMajor = resp.ProtoMajor
+		result.ProtoMinor = resp.ProtoMinor
+	}
+	if err != nil {
+		result.Error = err
+		result.ErrorCategory = webSocketErrorCategory(err)
+		result.Canceled = ctx.Err() != nil
+		return nil, result
+	}
+	return newWebSocketSession(conn, st.WebSocket.MaxMessageSize), result
+}
+
+// sendWebSocket envia uma mensagem e mede o tempo até a próxima mensagem
+// recebida
+func (st *StressTest) sendWebSocket(ctx context.Context, workerID int, client *http.Client, state *runState, session *webSocketSession, row map[string]string) Result {
+	result := Result{WorkerID: workerID, Timestamp: time.Now(), Target: st.URL, WebSocket: &WebSocketResult{Sent: true}}
+	// Mensagens que o servidor enviou sem pedido desde a última resposta
+	result.WebSocket.Received = session.drain()
+	message, err := st.renderBody(state, row)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	kind := websocket.TextMessage
+	if st.WebSocket.Binary {
+		kind = websocket.BinaryMessage
+	}
+
+	var timeout <-chan time.Time
+	var writeDeadline time.Time
+	if client.Timeout > 0 {
+		timer := time.NewTimer(client.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+		writeDeadline = time.Now().Add(client.Timeout)
+	}
+	result.BytesSent = int64(len(message))
+	start := time.Now()
+	session.conn.SetWriteDeadline(writeDeadline)
+	if err := session.conn.WriteMessage(kind, message); err != nil {
+		result.Duration = time.Since(start)
+		result.Error = err
+		result.ErrorCategory = webSocketErrorCategory(err)
+		result.WebSocket.Disconnected = true
+		return result
+	}
+	select {
+	case size := <-session.messages:
+		result.Duration = time.Since(start)
+		result.BytesRead = int64(size)
+		result.WebSocket.Received++
+	case <-session.done:
+		result.Duration = time.Since(start)
+		result.Error = session.err
+		result.ErrorCategory = webSocketErrorCategory(session.err)
+		result.WebSocket.Disconnected = true
+	case <-timeout:
+		result.Duration = time.Since(start)
+		result.Error = fmt.Errorf("nenhuma mensagem recebida em %v", client.Timeout)
+		result.ErrorCategory = ErrorTimeout
+	case <-ctx.Done():
+		result.Duration = time.Since(start)
+		result.Error = ctx.Err()
+		result.Canceled = true
+	}
+	result.TTFB = result.Duration
+	return result
+}
+
+// awaitWebSocket recebe mensagens por até webSocketPoll, sem enviar nada
+func (st *StressTest) awaitWebSocket(ctx context.Context, workerID int, session *webSocketSession) Result {
+	result := Result{WorkerID: workerID, Timestamp: time.Now(), Target: st.URL, WebSocket: &WebSocketResult{}}
+	timer := time.NewTimer(webSocketPoll)
+	defer timer.Stop()
+	for {
+		select {
+		case size := <-session.messages:
+			result.BytesRead += int64(size)
+			result.WebSocket.Received++
+		case <-session.done:
+			result.Error = session.err
+			result.ErrorCategory = webSocketErrorCategory(session.err)
+			result.WebSocket.Disconnected = true
+			return result
+		case <-timer.C:
+			return result
+		case <-ctx.Done():
+			return result
+		}
+	}
+}
+
+// closeWebSocket encerra a conexão do worker ao fim do teste
+func (st *StressTest) closeWebSocket(state *runState, workerID int) {
+	if st.WebSocket == nil {
+		return
+	}
+	if session := state.sockets[workerID]; session != nil {
+		state.sockets[workerID] = nil
+		session.close(true)
+	}
+}
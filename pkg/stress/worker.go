@@ -0,0 +1,52 @@
+package stress
+
+import "strconv"
+
+// WorkerName retorna a identidade estável de um worker (ex.: "worker-17"),
+// enviada em StressTest.ClientIDHeader
+func WorkerName(workerID int) string {
+	return "worker-" + strconv.Itoa(workerID)
+}
+
+// WorkerReport contém as métricas de um worker, preenchidas quando cada
+// worker tem o próprio client (StressTest.WorkerTransport) ou identidade
+// (StressTest.ClientIDHeader)
+type WorkerReport struct {
+	Requests           int
+	SuccessfulRequests int
+	FailedRequests     int
+	// TransportErrors conta as falhas sem resposta, como conexões recusadas
+	// ou encerradas, agrupadas por categoria em ErrorCategories
+	TransportErrors int
+	ErrorCategories map[string]int
+}
+
+// newWorkerReports prepara as métricas por worker, indexadas pelo id
+func (st *StressTest) newWorkerReports() []WorkerReport {
+	if st.WorkerTransport == nil && st.ClientIDHeader == "" {
+		return nil
+	}
+	workers := make([]WorkerReport, st.workers())
+	for i := range workers {
+		workers[i].ErrorCategories = make(map[string]int)
+	}
+	return workers
+}
+
+// addWorker incorpora um resultado às métricas do worker que o produziu
+func (c *collector) addWorker(result Result, failed bool) {
+	if result.WorkerID < 0 || result.WorkerID >= len(c.report.Workers) {
+		return
+	}
+	worker := &c.report.Workers[result.WorkerID]
+	worker.Requests++
+	if !failed {
+		worker.SuccessfulRequests++
+		return
+	}
+	worker.FailedRequests++
+	if result.Error != nil && result.StatusCode == 0 && result.GRPCCode == "" {
+		worker.TransportErrors++
+		worker.ErrorCategories[result.ErrorCategory]++
+	}
+}
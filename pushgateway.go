@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+// pushTimeout limita o envio do relatório ao Pushgateway
+const pushTimeout = 10 * time.Second
+
+// metricLabel é um par nome=valor de -label, que compõe a chave de
+// agrupamento do Pushgateway
+type metricLabel struct {
+	name  string
+	value string
+}
+
+// labelNamePattern segue a sintaxe de nomes de labels do Prometheus
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// parseMetricLabel interpreta um -label no formato "nome=valor"
+func parseMetricLabel(raw string) (metricLabel, error) {
+	name, value, ok := strings.Cut(raw, "=")
+	if !ok || !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+		return metricLabel{}, fmt.Errorf("label inválido %q: use o formato \"nome=valor\", com um nome de label do Prometheus", raw)
+	}
+	if name == "job" {
+		return metricLabel{}, fmt.Errorf("label inválido %q: o job é definido por --push-job", raw)
+	}
+	return metricLabel{name: name, value: value}, nil
+}
+
+// pushGroupingURL monta a URL da chave de agrupamento: o job e os labels de
+// -label, na ordem em que foram informados. Valores vazios ou com "/" usam a
+// codificação base64 do Pushgateway.
+func pushGroupingURL(base, job string, labels []metricLabel) string {
+	var path strings.Builder
+	path.WriteString(strings.TrimSuffix(base, "/"))
+	path.WriteString("/metrics")
+	for _, label := range append([]metricLabel{{name: "job", value: job}}, labels...) {
+		switch {
+		case label.value == "":
+			fmt.Fprintf(&path, "/%s@base64/=", label.name)
+		case strings.Contains(label.value, "/"):
+			fmt.Fprintf(&path, "/%s@base64/%s", label.name, base64.RawURLEncoding.EncodeToString([]byte(label.value)))
+		default:
+			fmt.Fprintf(&path, "/%s/%s", label.name, url.PathEscape(label.value))
+		}
+	}
+	return path.String()
+}
+
+// writePushMetrics converte o relatório em gauges no formato de texto do
+// Prometheus. As métricas por alvo levam os labels method e url; as do
+// teste inteiro, apenas os labels da chave de agrupamento, adicionados pelo
+// Pushgateway.
+func writePushMetrics(w io.Writer, report *stress.Report, completed time.Time) {
+	labels := slices.Sorted(maps.Keys(report.Targets))
+	gauge := func(name, help string, value func(target *stress.TargetReport) float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, label := range labels {
+			method, target := targetLabels(report, label)
+			fmt.Fprintf(w, "%s{method=\"%s\",url=\"%s\"} %s\n", name, promLabel(method), promLabel(target), promValue(value(report.Targets[label])))
+		}
+	}
+	gauge("stress_run_requests", "Requests concluídas no teste.", func(t *stress.TargetReport) float64 { return float64(t.Requests) })
+	gauge("stress_run_successful_requests", "Requests com sucesso no teste.", func(t *stress.TargetReport) float64 { return float64(t.SuccessfulRequests) })
+	gauge("stress_run_failed_requests", "Requests com falha no teste.", func(t *stress.TargetReport) float64 { return float64(t.FailedRequests) })
+
+	fmt.Fprintln(w, "# HELP stress_run_duration_seconds Percentis da duração das requests que receberam resposta.")
+	fmt.Fprintln(w, "# TYPE stress_run_duration_seconds gauge")
+	for _, label := range labels {
+		method, target := targetLabels(report, label)
+		durations := report.Targets[label].Durations
+		for _, q := range []struct {
+			quantile string
+			value    time.Duration
+		}{{"0.5", durations.P50}, {"0.95", durations.P95}, {"0.99", durations.P99}} {
+			fmt.Fprintf(w, "stress_run_duration_seconds{method=\"%s\",url=\"%s\",quantile=\"%s\"} %s\n",
+				promLabel(method), promLabel(target), q.quantile, promValue(q.value.Seconds()))
+		}
+	}
+
+	single := func(name, help string, value float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", name, help, name, name, promValue(value))
+	}
+	single("stress_run_requests_per_second", "Vazão do teste inteiro.", report.RequestsPerSecond)
+	single("stress_run_total_time_seconds", "Tempo total do teste.", report.TotalTime.Seconds())
+	if len(report.Thresholds) > 0 {
+		passed := 0.0
+		if report.ThresholdsPassed() {
+			passed = 1
+		}
+		single("stress_run_thresholds_passed", "1 quando todos os limites de --fail-if foram respeitados.", passed)
+	}
+	single("stress_run_last_completion_timestamp_seconds", "Horário Unix do fim do teste.", float64(completed.UnixNano())/1e9)
+}
+
+// targetLabels separa o rótulo de um alvo como splitTargetLabel; alvos sem
+// método (ex.: gRPC) usam o método do relatório
+func targetLabels(report *stress.Report, label string) (method, target string) {
+	if method, target = splitTargetLabel(label); method == "" {
+		method = report.Method
+	}
+	return method, target
+}
+
+// splitTargetLabel separa o rótulo "MÉTODO URL" de um alvo (ver
+// stress.Target.Label); rótulos sem método retornam o método vazio
+func splitTargetLabel(label string) (method, target string) {
+	if method, target, ok := strings.Cut(label, " "); ok {
+		return method, target
+	}
+	return "", label
+}
+
+// resultTarget retorna o método e a URL de um resultado: os da request
+// executada, com StressTest.RecordRequests, ou os do rótulo do alvo
+func resultTarget(result stress.Result) (method, target string) {
+	if result.URL != "" {
+		return result.Method, result.URL
+	}
+	return splitTargetLabel(result.Target)
+}
+
+// pushReport envia o relatório ao Pushgateway com PUT, substituindo as
+// métricas anteriores da mesma chave de agrupamento
+func pushReport(ctx context.Context, client *http.Client, groupingURL string, report *stress.Report) error {
+	var body bytes.Buffer
+	writePushMetrics(&body, report, time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, pushTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, groupingURL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// promLabelEscaper escapa os valores de labels como o formato de texto do
+// Prometheus exige, sem os escapes \u que %q produziria
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func promLabel(value string) string {
+	return promLabelEscaper.Replace(value)
+}
+
+// promValue formata um valor de amostra
+func promValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+func TestPushGroupingURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   string
+		job    string
+		labels []metricLabel
+		want   string
+	}{
+		{name: "apenas o job", base: "http://pg:9091", job: "stress", want: "http://pg:9091/metrics/job/stress"},
+		{name: "barra no fim da base", base: "http://pg:9091/", job: "stress", want: "http://pg:9091/metrics/job/stress"},
+		{
+			name: "labels na ordem informada", base: "http://pg:9091", job: "stress",
+			labels: []metricLabel{{"env", "prod"}, {"app", "api"}},
+			want:   "http://pg:9091/metrics/job/stress/env/prod/app/api",
+		},
+		{name: "espaço escapado", base: "http://pg:9091", job: "carga noturna", want: "http://pg:9091/metrics/job/carga%20noturna"},
+		{
+			name: "valor vazio em base64", base: "http://pg:9091", job: "stress",
+			labels: []metricLabel{{"env", ""}},
+			want:   "http://pg:9091/metrics/job/stress/env@base64/=",
+		},
+		{
+			name: "valor com barra em base64", base: "http://pg:9091", job: "stress",
+			labels: []metricLabel{{"path", "/api/v1"}},
+			want:   "http://pg:9091/metrics/job/stress/path@base64/L2FwaS92MQ",
+		},
+		{name: "job com barra em base64", base: "http://pg:9091", job: "a/b", want: "http://pg:9091/metrics/job@base64/YS9i"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pushGroupingURL(tt.base, tt.job, tt.labels); got != tt.want {
+				t.Errorf("pushGroupingURL = %q, esperava %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMetricLabel(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want metricLabel
+		err  bool
+	}{
+		{raw: "env=prod", want: metricLabel{"env", "prod"}},
+		{raw: "env=", want: metricLabel{"env", ""}},
+		{raw: "url=http://x/?a=b", want: metricLabel{"url", "http://x/?a=b"}},
+		{raw: "env", err: true},
+		{raw: "1env=prod", err: true},
+		{raw: "__name__=x", err: true},
+		{raw: "job=outro", err: true},
+	}
+	for _, tt := range tests {
+		got, err := parseMetricLabel(tt.raw)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("parseMetricLabel(%q) = %+v, %v; esperava %+v, erro: %v", tt.raw, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+// pushSamplePattern casa uma amostra do formato de texto do Prometheus
+var pushSamplePattern = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\]|\\.)*"(?:,[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\]|\\.)*")*\})? (\S+)$`)
+
+func TestPushReport(t *testing.T) {
+	var method, path, contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, path, contentType, body = r.Method, r.URL.EscapedPath(), r.Header.Get("Content-Type"), string(data)
+	}))
+	defer server.Close()
+
+	report := &stress.Report{
+		Method:            "GET",
+		RequestsPerSecond: 250,
+		TotalTime:         4 * time.Second,
+		Targets: map[string]*stress.TargetReport{
+			`GET http://api/"x"`: {
+				Requests: 1000, SuccessfulRequests: 990, FailedRequests: 10,
+				Durations: stress.DurationStats{P50: 20 * time.Millisecond, P95: 80 * time.Millisecond, P99: 150 * time.Millisecond},
+			},
+		},
+		Thresholds: []stress.ThresholdResult{{Passed: true}},
+	}
+	labels := []metricLabel{{"env", "prod"}, {"branch", "feature/x"}}
+	if err := pushReport(context.Background(), server.Client(), pushGroupingURL(server.URL, "stress", labels), report); err != nil {
+		t.Fatalf("pushReport: %v", err)
+	}
+	if method != http.MethodPut {
+		t.Errorf("método %s, esperava PUT", method)
+	}
+	if want := "/metrics/job/stress/env/prod/branch@base64/ZmVhdHVyZS94"; path != want {
+		t.Errorf("path %q, esperava %q", path, want)
+	}
+	if !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type %q, esperava o formato de texto do Prometheus", contentType)
+	}
+
+	// Cada amostra vem depois do HELP e do TYPE da métrica
+	declared := make(map[string]bool)
+	samples := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "# TYPE "); ok {
+			name, kind, _ := strings.Cut(name, " ")
+			if kind != "gauge" {
+				t.Errorf("métrica %s do tipo %q, esperava gauge", name, kind)
+			}
+			declared[name] = true
+			continue
+		}
+		if strings.HasPrefix(line, "# HELP ") {
+			continue
+		}
+		m := pushSamplePattern.FindStringSubmatch(line)
+		if m == nil {
+			t.Errorf("linha fora do formato de texto do Prometheus: %q", line)
+			continue
+		}
+		if !declared[m[1]] {
+			t.Errorf("amostra de %s antes do # TYPE", m[1])
+		}
+		samples[m[1]+m[2]] = m[3]
+	}
+	want := map[string]string{
+		`stress_run_requests{method="GET",url="http://api/\"x\""}`:                         "1000",
+		`stress_run_failed_requests{method="GET",url="http://api/\"x\""}`:                  "10",
+		`stress_run_duration_seconds{method="GET",url="http://api/\"x\"",quantile="0.95"}`: "0.08",
+		`stress_run_requests_per_second`:                                                   "250",
+		`stress_run_total_time_seconds`:                                                    "4",
+		`stress_run_thresholds_passed`:                                                     "1",
+	}
+	for sample, value := range want {
+		if samples[sample] != value {
+			t.Errorf("%s = %q, esperava %q", sample, samples[sample], value)
+		}
+	}
+	if _, ok := samples["stress_run_last_completion_timestamp_seconds"]; !ok {
+		t.Error("stress_run_last_completion_timestamp_seconds ausente")
+	}
+}
+
+func TestPushReportError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "formato inválido", http.StatusBadRequest)
+	}))
+	defer server.Close()
+	err := pushReport(context.Background(), server.Client(), pushGroupingURL(server.URL, "stress", nil), &stress.Report{})
+	if err == nil || !strings.Contains(err.Error(), "status 400: formato inválido") {
+		t.Errorf("pushReport: %v, esperava o status 400 com a mensagem do Pushgateway", err)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+// resultsQueueSize é a quantidade de resultados aguardando gravação em
+// --results-jsonl. Com a fila cheia, add bloqueia o coletor até que o disco
+// alcance o teste, em vez de descartar resultados.
+const resultsQueueSize = 4096
+
+// jsonlResult é uma linha de --results-jsonl; os campos que não se aplicam à
+// request (status de erros de transporte, erro de respostas recebidas) são
+// omitidos
+type jsonlResult struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Worker        int       `json:"worker"`
+	Method        string    `json:"method,omitempty"`
+	URL           string    `json:"url"`
+	Status        int       `json:"status,omitempty"`
+	GRPCCode      string    `json:"grpc_code,omitempty"`
+	DurationMs    float64   `json:"duration_ms"`
+	Bytes         int64     `json:"bytes"`
+	Error         string    `json:"error,omitempty"`
+	ErrorCategory string    `json:"error_category,omitempty"`
+	Canceled      bool      `json:"canceled,omitempty"`
+}
+
+// resultsWriter grava uma linha JSON por request concluída. add é chamado a
+// partir de OnResult e apenas coloca o resultado na fila; uma goroutine
+// própria serializa e grava as linhas, trocando de arquivo a cada rotateBytes
+// quando definido.
+type resultsWriter struct {
+	path        string
+	rotateBytes int64
+
+	file    *os.File
+	out     *bufio.Writer
+	written int64
+	// index é o número do arquivo corrente: 0 para path e n para o n-ésimo
+	// arquivo rotacionado
+	index int
+	files int
+	err   error
+
+	queue chan stress.Result
+	done  chan struct{}
+}
+
+// newResultsWriter cria o primeiro arquivo antes do teste, para que um
+// caminho inválido seja informado logo
+func newResultsWriter(path string, rotateBytes int64) (*resultsWriter, error) {
+	w := &resultsWriter{path: path, rotateBytes: rotateBytes, queue: make(chan stress.Result, resultsQueueSize), done: make(chan struct{})}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// add enfileira o resultado, aguardando quando a fila está cheia
+func (w *resultsWriter) add(result stress.Result) {
+	w.queue <- result
+}
+
+func (w *resultsWriter) run() {
+	defer close(w.done)
+	for result := range w.queue {
+		// Após um erro de gravação, a fila continua sendo consumida para
+		// não travar o teste
+		if w.err == nil {
+			w.err = w.write(result)
+		}
+	}
+	if w.err == nil {
+		w.err = w.out.Flush()
+	}
+	if err := w.file.Close(); w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *resultsWriter) write(result stress.Result) error {
+	method, target := resultTarget(result)
+	line := jsonlResult{
+		Timestamp:     result.Timestamp,
+		Worker:        result.WorkerID,
+		Method:        method,
+		URL:           target,
+		Status:        result.StatusCode,
+		GRPCCode:      result.GRPCCode,
+		DurationMs:    float64(result.Duration) / float64(time.Millisecond),
+		Bytes:         result.BytesRead,
+		ErrorCategory: result.ErrorCategory,
+		Canceled:      result.Canceled,
+	}
+	if result.Error != nil {
+		line.Error = result.Error.Error()
+	}
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	if w.rotateBytes > 0 && w.written > 0 && w.written+int64(len(data))+1 > w.rotateBytes {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := w.out.Write(append(data, '\n'))
+	w.written += int64(n)
+	return err
+}
+
+// rotate fecha o arquivo corrente e abre o próximo, numerado antes da
+// extensão (results.jsonl, results.1.jsonl, results.2.jsonl...)
+func (w *resultsWriter) rotate() error {
+	if err := w.out.Flush(); err != nil {
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.index++
+	return w.open()
+}
+
+func (w *resultsWriter) open() error {
+	file, err := os.Create(w.fileName())
+	if err != nil {
+		return err
+	}
+	w.file, w.out, w.written = file, bufio.NewWriter(file), 0
+	w.files++
+	return nil
+}
+
+func (w *resultsWriter) fileName() string {
+	if w.index == 0 {
+		return w.path
+	}
+	ext := filepath.Ext(w.path)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(w.path, ext), w.index, ext)
+}
+
+// Close grava os resultados pendentes e fecha o arquivo corrente
+func (w *resultsWriter) Close() error {
+	close(w.queue)
+	<-w.done
+	return w.err
+}
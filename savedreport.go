@@ -0,0 +1,533 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+// savedReportVersion é a versão do formato de --save-report. Deve ser
+// incrementada quando um campo mudar de significado; campos novos não exigem
+// uma versão nova.
+const savedReportVersion = 1
+
+// savedReport é o conteúdo de --save-report: o relatório JSON completo, com a
+// versão do formato, o horário em que foi gravado e os histogramas de
+// durações, necessários para combinar os percentis no merge
+type savedReport struct {
+	Version     int       `json:"version"`
+	SavedAt     time.Time `json:"saved_at"`
+	ToolVersion string    `json:"tool_version"`
+	jsonReport
+	Histogram        *jsonHistogram            `json:"histogram,omitempty"`
+	TargetHistograms map[string]*jsonHistogram `json:"target_histograms,omitempty"`
+	StageHistograms  []*jsonHistogram          `json:"stage_histograms,omitempty"`
+	// ResponseTimeHistogram é o histograma de stress.ResponseTimeStats
+	ResponseTimeHistogram *jsonHistogram `json:"response_time_histogram,omitempty"`
+	// RetryHistogram é o histograma dos tempos totais de stress.RetryStats
+	RetryHistogram *jsonHistogram `json:"retry_histogram,omitempty"`
+}
+
+// jsonHistogram é um stress.Histogram de durações, com cada posição com
+// amostras gravada como [nanossegundos, contagem]
+type jsonHistogram struct {
+	Highest jsonDuration `json:"highest"`
+	SigFigs int          `json:"sig_figs"`
+	Buckets [][2]int64   `json:"buckets"`
+}
+
+func newJSONHistogram(h *stress.Histogram) *jsonHistogram {
+	if h == nil {
+		return nil
+	}
+	histogram := &jsonHistogram{Highest: newJSONDuration(h.Highest()), SigFigs: h.SigFigs(), Buckets: [][2]int64{}}
+	for _, bucket := range h.Buckets() {
+		histogram.Buckets = append(histogram.Buckets, [2]int64{int64(bucket.Value), bucket.Count})
+	}
+	return histogram
+}
+
+func (j *jsonHistogram) histogram() *stress.Histogram {
+	if j == nil {
+		return nil
+	}
+	h := stress.NewHistogram(int64(time.Microsecond), j.Highest.Nanoseconds, j.SigFigs)
+	for _, bucket := range j.Buckets {
+		h.RecordN(time.Duration(bucket[0]), bucket[1])
+	}
+	return h
+}
+
+// writeSavedReport grava o relatório em path para uso posterior com
+// --baseline ou com o subcomando merge
+func writeSavedReport(path string, report *stress.Report) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	saved := savedReport{
+		Version:     savedReportVersion,
+		SavedAt:     time.Now(),
+		ToolVersion: stress.Version,
+		jsonReport:  newJSONReport(report),
+		Histogram:   newJSONHistogram(report.Histogram()),
+	}
+	for label, target := range report.Targets {
+		if histogram := newJSONHistogram(target.Histogram()); histogram != nil {
+			if saved.TargetHistograms == nil {
+				saved.TargetHistograms = make(map[string]*jsonHistogram)
+			}
+			saved.TargetHistograms[label] = histogram
+		}
+	}
+	for _, stage := range report.Stages {
+		saved.StageHistograms = append(saved.StageHistograms, newJSONHistogram(stage.Histogram()))
+	}
+	if report.ResponseTime != nil {
+		saved.ResponseTimeHistogram = newJSONHistogram(report.ResponseTime.Histogram())
+	}
+	if report.Retries != nil {
+		saved.RetryHistogram = newJSONHistogram(report.Retries.Histogram())
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(saved); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// readSavedReport lê um relatório de --save-report. Arquivos sem version,
+// como os de --output=json, têm os mesmos campos e são aceitos, sem os
+// histogramas; versões mais novas que a suportada são rejeitadas.
+func readSavedReport(path string) (*savedReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var saved savedReport
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("%s não é um relatório JSON válido: %w", path, err)
+	}
+	if saved.Version > savedReportVersion {
+		return nil, fmt.Errorf("%s usa a versão %d do formato, mais nova que a suportada (%d): atualize o stress-test ou gere o relatório novamente",
+			path, saved.Version, savedReportVersion)
+	}
+	if saved.Version < 0 {
+		return nil, fmt.Errorf("%s tem uma versão de formato inválida: %d", path, saved.Version)
+	}
+	if saved.TotalRequests == 0 {
+		return nil, fmt.Errorf("%s não contém requests", path)
+	}
+	return &saved, nil
+}
+
+// report reconstrói o stress.Report do arquivo
+func (s *savedReport) report() (*stress.Report, error) {
+	j := &s.jsonReport
+	expect, err := stress.ParseStatusRanges(j.ExpectStatus)
+	if j.ExpectStatus != "" && err != nil {
+		return nil, fmt.Errorf("expect_status: %w", err)
+	}
+	report := &stress.Report{
+		Method:                      j.Method,
+		TotalRequests:               j.TotalRequests,
+		SuccessfulRequests:          j.SuccessfulRequests,
+		FailedRequests:              j.FailedRequests,
+		TransportErrors:             j.TransportErrors,
+		UnexpectedStatus:            j.UnexpectedStatus,
+		ApplicationErrors:           j.ApplicationErrors,
+		ExpectStatus:                expect,
+		ErrorCategories:             j.ErrorCategories,
+		Apdex:                       j.Apdex.score(),
+		AssertionFailures:           j.AssertionFailures,
+		GraphQLErrors:               j.GraphQLErrors,
+		RedirectedRequests:          j.RedirectedRequests,
+		CanceledRequests:            j.CanceledRequests,
+		WarmupRequests:              j.WarmupRequests,
+		DataExhausted:               j.DataExhausted,
+		Aborted:                     j.Aborted,
+		AbortReason:                 j.AbortReason,
+		Interrupted:                 j.Interrupted,
+		TotalTime:                   j.TotalTime.duration(),
+		TargetRPS:                   j.TargetRPS,
+		ArrivalRate:                 j.ArrivalRate,
+		MaxInFlight:                 j.MaxInFlight,
+		ArrivalDistribution:         stress.ArrivalDistribution(j.ArrivalDistribution),
+		Arrivals:                    j.Arrivals,
+		DroppedArrivals:             j.DroppedArrivals,
+		AchievedArrivalRate:         j.AchievedArrivalRate,
+		PeakInFlight:                j.PeakInFlight,
+		RequestsPerSecond:           j.RequestsPerSecond,
+		SuccessfulRequestsPerSecond: j.SuccessfulRequestsPerSecond,
+		PlannedDuration:             j.PlannedDuration.duration(),
+		BytesSent:                   j.BytesSent,
+		BytesReceived:               j.BytesReceived,
+		MinResponseSize:             j.MinResponseSize,
+		MaxResponseSize:             j.MaxResponseSize,
+		AvgResponseSize:             j.AvgResponseSize,
+		TruncatedResponses:          j.TruncatedResponses,
+		BodyLimitedResponses:        j.BodyLimitedResponses,
+		BodySkipped:                 j.BodySkipped,
+		NewConnections:              j.NewConnections,
+		ReusedConnections:           j.ReusedConnections,
+		Compression:                 j.Compression.stats(),
+		WebSocket:                   j.WebSocket.stats(),
+		SSE:                         j.SSE.stats(),
+		RampUp:                      j.RampUp.duration(),
+		FullConcurrencyAt:           j.FullConcurrencyAt.duration(),
+		ThinkTime:                   j.ThinkTime.duration(),
+		ThinkTimeJitter:             j.ThinkTimeJitter.duration(),
+		Settings:                    j.Settings,
+		Targets:                     make(map[string]*stress.TargetReport, len(j.Targets)),
+		Scenario:                    j.Scenario.stats(),
+		Seed:                        j.Seed,
+		StatusCodes:                 j.StatusCodes,
+		GRPCMethod:                  j.GRPCMethod,
+		GRPCCodes:                   j.GRPCCodes,
+		Protocols:                   j.Protocols,
+		ExpectedProtocol:            j.ExpectedProtocol,
+		MinDuration:                 j.MinDuration.duration(),
+		MaxDuration:                 j.MaxDuration.duration(),
+		AvgDuration:                 j.AvgDuration.duration(),
+		StdDevDuration:              j.StdDevDuration.duration(),
+		CoefficientOfVariation:      j.CoefficientOfVariation,
+		TTFB:                        j.TTFB.stats(),
+		ResponseTime:                j.ResponseTime.stats(),
+		Retries:                     j.Retries.stats(),
+		RetryAfter:                  j.RetryAfter.stats(),
+		Slowest:                     j.Slowest.stats(),
+		Phases:                      j.Phases.stats(),
+		P50:                         j.P50.duration(),
+		P90:                         j.P90.duration(),
+		P95:                         j.P95.duration(),
+		P99:                         j.P99.duration(),
+		Timeline:                    timelinePoints(j.Timeline),
+		TimelineInterval:            j.TimelineInterval.duration(),
+		Spike:                       j.Spike.stats(),
+		Trend:                       j.Trend.stats(),
+		FindMax:                     j.FindMax.stats(),
+		ClampedDurations:            j.ClampedDurations,
+		Merged:                      j.Merged,
+		MergeConflicts:              j.MergeConflicts,
+	}
+	if j.Interrupted {
+		report.InterruptCause = errors.New(j.InterruptCause)
+	}
+	for _, result := range j.Thresholds {
+		threshold, err := stress.ParseThreshold(result.Rule)
+		if err != nil {
+			return nil, fmt.Errorf("thresholds: %w", err)
+		}
+		report.Thresholds = append(report.Thresholds, stress.ThresholdResult{Threshold: threshold, Passed: result.Passed})
+	}
+	for label, target := range j.Targets {
+		restored := &stress.TargetReport{
+			Requests:           target.Requests,
+			SuccessfulRequests: target.SuccessfulRequests,
+			FailedRequests:     target.FailedRequests,
+			Weight:             target.Weight,
+			StatusCodes:        target.StatusCodes,
+			Durations:          target.Durations.stats(),
+		}
+		restored.SetHistogram(s.TargetHistograms[label].histogram())
+		report.Targets[label] = restored
+	}
+	for i, stage := range j.Stages {
+		restored := &stress.StageReport{
+			Stage:              stress.Stage{Duration: stage.Duration.duration(), Rate: stage.Rate, Concurrency: stage.Concurrency},
+			Start:              stage.Start.duration(),
+			Requests:           stage.Requests,
+			SuccessfulRequests: stage.SuccessfulRequests,
+			FailedRequests:     stage.FailedRequests,
+			Arrivals:           stage.Arrivals,
+			DroppedArrivals:    stage.DroppedArrivals,
+			RequestsPerSecond:  stage.RequestsPerSecond,
+			Durations:          stage.Durations.stats(),
+		}
+		if i < len(s.StageHistograms) {
+			restored.SetHistogram(s.StageHistograms[i].histogram())
+		}
+		report.Stages = append(report.Stages, restored)
+	}
+	for _, worker := range j.Workers {
+		report.Workers = append(report.Workers, stress.WorkerReport{
+			Requests:           worker.Requests,
+			SuccessfulRequests: worker.SuccessfulRequests,
+			FailedRequests:     worker.FailedRequests,
+			TransportErrors:    worker.TransportErrors,
+			ErrorCategories:    worker.ErrorCategories,
+		})
+	}
+	if h := s.Histogram.histogram(); h != nil {
+		report.SetHistogram(h)
+		report.HistogramMax = h.Highest()
+	}
+	if report.ResponseTime != nil {
+		report.ResponseTime.SetHistogram(s.ResponseTimeHistogram.histogram())
+	}
+	if report.Retries != nil {
+		report.Retries.SetHistogram(s.RetryHistogram.histogram())
+	}
+	// Os valores medidos dos limites vêm do próprio relatório
+	for i, result := range report.Thresholds {
+		report.Thresholds[i].Actual = result.Threshold.Evaluate(report).Actual
+	}
+	return report, nil
+}
+
+func (d jsonDuration) duration() time.Duration {
+	return time.Duration(d.Nanoseconds)
+}
+
+func (j jsonDurationStats) stats() stress.DurationStats {
+	return stress.DurationStats{
+		Min: j.Min.duration(),
+		Max: j.Max.duration(),
+		Avg: j.Avg.duration(),
+		P50: j.P50.duration(),
+		P95: j.P95.duration(),
+		P99: j.P99.duration(),
+	}
+}
+
+func (j *jsonApdexScore) score() *stress.ApdexScore {
+	if j == nil {
+		return nil
+	}
+	return &stress.ApdexScore{T: j.T.duration(), Satisfied: j.Satisfied, Tolerating: j.Tolerating, Frustrated: j.Frustrated, Score: j.Score}
+}
+
+func (j *jsonCompressionStats) stats() *stress.CompressionStats {
+	if j == nil {
+		return nil
+	}
+	return &stress.CompressionStats{
+		CompressedResponses: j.CompressedResponses,
+		WireBytes:           j.WireBytes,
+		DecodedBytes:        j.DecodedBytes,
+		Ratio:               j.Ratio,
+		Decompression:       j.Decompression.stats(),
+	}
+}
+
+func (j *jsonWebSocketStats) stats() *stress.WebSocketStats {
+	if j == nil {
+		return nil
+	}
+	return &stress.WebSocketStats{
+		ConnectionsEstablished: j.ConnectionsEstablished,
+		ConnectionFailures:     j.ConnectionFailures,
+		Disconnects:            j.Disconnects,
+		DisconnectReasons:      j.DisconnectReasons,
+		MessagesSent:           j.MessagesSent,
+		MessagesReceived:       j.MessagesReceived,
+		Handshake:              j.Handshake.stats(),
+		RTT:                    j.RTT.stats(),
+	}
+}
+
+func (j *jsonSSEStats) stats() *stress.SSEStats {
+	if j == nil {
+		return nil
+	}
+	return &stress.SSEStats{
+		Connections:            j.Connections,
+		ConnectionFailures:     j.ConnectionFailures,
+		Disconnects:            j.Disconnects,
+		DisconnectReasons:      j.DisconnectReasons,
+		Events:                 j.Events,
+		EventsPerSecond:        j.EventsPerSecond,
+		MalformedLines:         j.MalformedLines,
+		MinEventsPerConnection: j.MinEventsPerConnection,
+		MaxEventsPerConnection: j.MaxEventsPerConnection,
+		AvgEventsPerConnection: j.AvgEventsPerConnection,
+		FirstEvent:             j.FirstEvent.stats(),
+		InterEvent:             j.InterEvent.stats(),
+	}
+}
+
+func (j *jsonScenarioStats) stats() *stress.ScenarioStats {
+	if j == nil {
+		return nil
+	}
+	return &stress.ScenarioStats{
+		Iterations:          j.Iterations,
+		CompletedIterations: j.CompletedIterations,
+		AbortedIterations:   j.AbortedIterations,
+		AbortedBySteps:      j.AbortedBySteps,
+		Durations:           j.Durations.stats(),
+	}
+}
+
+func (j *jsonSpikeReport) stats() *stress.SpikeReport {
+	if j == nil {
+		return nil
+	}
+	return &stress.SpikeReport{
+		BaselineErrorRate: j.BaselineErrorRate,
+		BaselineP99:       j.BaselineP99.duration(),
+		PeakErrorRate:     j.PeakErrorRate,
+		PeakP99:           j.PeakP99.duration(),
+		AfterErrorRate:    j.AfterErrorRate,
+		AfterP99:          j.AfterP99.duration(),
+		RecoveryTime:      j.RecoveryTime.duration(),
+		Recovered:         j.Recovered,
+	}
+}
+
+func (j *jsonTrendReport) stats() *stress.TrendReport {
+	if j == nil {
+		return nil
+	}
+	return &stress.TrendReport{
+		Window:          j.Window.duration(),
+		FirstRequests:   j.FirstRequests,
+		LastRequests:    j.LastRequests,
+		FirstP95:        j.FirstP95.duration(),
+		LastP95:         j.LastP95.duration(),
+		FirstErrorRate:  j.FirstErrorRate,
+		LastErrorRate:   j.LastErrorRate,
+		FirstRSS:        j.FirstRSS,
+		LastRSS:         j.LastRSS,
+		FirstGoroutines: j.FirstGoroutines,
+		LastGoroutines:  j.LastGoroutines,
+	}
+}
+
+func (j *jsonResponseTimeStats) stats() *stress.ResponseTimeStats {
+	if j == nil {
+		return nil
+	}
+	return &stress.ResponseTimeStats{
+		Durations:          j.Durations.stats(),
+		P90:                j.P90.duration(),
+		Requests:           j.Requests,
+		SynthesizedSamples: j.SynthesizedSamples,
+		ExpectedInterval:   j.ExpectedInterval.duration(),
+		LateRequests:       j.LateRequests,
+		AvgDelay:           j.AvgDelay.duration(),
+		MaxDelay:           j.MaxDelay.duration(),
+	}
+}
+
+func (j *jsonRetryStats) stats() *stress.RetryStats {
+	if j == nil {
+		return nil
+	}
+	// Os status vieram de StatusRanges.String; vazio resulta em nil
+	status, _ := stress.ParseStatusRanges(j.Status)
+	return &stress.RetryStats{
+		Max:         j.Max,
+		BackoffBase: j.BackoffBase.duration(),
+		BackoffMax:  j.BackoffMax.duration(),
+		Status:      status,
+		Requests:    j.Requests,
+		Attempts:    j.Attempts,
+		Retried:     j.Retried,
+		Recovered:   j.Recovered,
+		Exhausted:   j.Exhausted,
+		Backoff:     j.Backoff.duration(),
+		Durations:   j.Durations.stats(),
+		P90:         j.P90.duration(),
+	}
+}
+
+func (j *jsonRetryAfterStats) stats() *stress.RetryAfterStats {
+	if j == nil {
+		return nil
+	}
+	return &stress.RetryAfterStats{Max: j.Max.duration(), Pauses: j.Pauses, Wait: j.Wait.duration()}
+}
+
+func (j *jsonSlowestStats) stats() *stress.SlowestStats {
+	if j == nil {
+		return nil
+	}
+	slowest := &stress.SlowestStats{Max: j.Max, Requests: make([]stress.SlowRequest, len(j.Requests))}
+	for i, request := range j.Requests {
+		slowest.Requests[i] = stress.SlowRequest{
+			Timestamp:     request.Timestamp,
+			Duration:      request.Duration.duration(),
+			WorkerID:      request.WorkerID,
+			Target:        request.Target,
+			StatusCode:    request.StatusCode,
+			ErrorCategory: request.ErrorCategory,
+			TraceID:       request.TraceID,
+			TraceSampled:  request.TraceSampled,
+		}
+	}
+	return slowest
+}
+
+func (j *jsonFindMaxReport) stats() *stress.FindMaxReport {
+	if j == nil {
+		return nil
+	}
+	search := &stress.FindMaxReport{Rate: j.Target == "rate", Level: j.Level, Found: j.Found, Limited: j.Limited}
+	for _, probe := range j.Probes {
+		search.Probes = append(search.Probes, stress.FindMaxProbe{
+			Level:             probe.Level,
+			Requests:          probe.Requests,
+			RequestsPerSecond: probe.RequestsPerSecond,
+			ErrorRate:         probe.ErrorRate,
+			P95:               probe.P95.duration(),
+			P99:               probe.P99.duration(),
+			Passed:            probe.Passed,
+			Violated:          probe.Violated,
+		})
+	}
+	return search
+}
+
+func (j *jsonResourceSample) stats() *stress.ResourceSample {
+	if j == nil {
+		return nil
+	}
+	return &stress.ResourceSample{
+		RSS:        j.RSS,
+		HeapAlloc:  j.HeapAlloc,
+		Goroutines: j.Goroutines,
+		GCCycles:   j.GCCycles,
+		GCPause:    j.GCPause.duration(),
+	}
+}
+
+func (j *jsonPhaseStats) stats() *stress.PhaseStats {
+	if j == nil {
+		return nil
+	}
+	return &stress.PhaseStats{
+		NewConnections:    j.NewConnections,
+		ReusedConnections: j.ReusedConnections,
+		DNS:               j.DNS.stats(),
+		Connect:           j.Connect.stats(),
+		TLS:               j.TLS.stats(),
+		Server:            j.Server.stats(),
+	}
+}
+
+func timelinePoints(timeline []jsonTimelinePoint) []stress.TimelinePoint {
+	points := make([]stress.TimelinePoint, len(timeline))
+	for i, point := range timeline {
+		points[i] = stress.TimelinePoint{
+			Start:     point.Start.duration(),
+			Stage:     point.Stage,
+			Requests:  point.Requests,
+			Errors:    point.Errors,
+			Avg:       point.Avg.duration(),
+			P50:       point.P50.duration(),
+			P95:       point.P95.duration(),
+			P99:       point.P99.duration(),
+			Max:       point.Max.duration(),
+			Resources: point.Resources.stats(),
+		}
+	}
+	return points
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+// secretMinLength é o tamanho mínimo dos valores substituídos que são
+// ocultados nas saídas; valores menores, como uma porta, apareceriam em
+// trechos comuns do texto
+const secretMinLength = 6
+
+// secretPattern casa ${VARIAVEL}, ${file:/caminho} e a forma escapada
+// $${...}, que produz o texto literal
+var secretPattern = regexp.MustCompile(`\$?\$\{([^}]*)\}`)
+
+// secrets substitui as referências a variáveis de ambiente e arquivos nos
+// valores da configuração e oculta os valores substituídos nas saídas do
+// teste, trocando-os pela referência que os gerou
+type secrets struct {
+	// values mapeia cada valor substituído para a sua referência
+	values map[string]string
+	// failures lista, na ordem em que aparecem, as referências que não
+	// puderam ser resolvidas
+	failures []string
+	replacer *strings.Replacer
+}
+
+// expand substitui as referências de text. As falhas são acumuladas e
+// informadas juntas por check, e a referência é mantida no texto.
+func (s *secrets) expand(text string) string {
+	return secretPattern.ReplaceAllStringFunc(text, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		name := ref[2 : len(ref)-1]
+		var value string
+		if path, ok := strings.CutPrefix(name, "file:"); ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				s.fail(fmt.Sprintf("%s (%v)", ref, err))
+				return ref
+			}
+			// Arquivos de secrets costumam terminar com uma quebra de linha
+			value = strings.TrimRight(string(data), "\r\n")
+		} else {
+			var ok bool
+			if value, ok = os.LookupEnv(name); !ok {
+				s.fail(ref + " (variável não definida)")
+				return ref
+			}
+		}
+		if len(value) >= secretMinLength {
+			if s.values == nil {
+				s.values = make(map[string]string)
+			}
+			s.values[value] = ref
+		}
+		return value
+	})
+}
+
+func (s *secrets) fail(failure string) {
+	if !slices.Contains(s.failures, failure) {
+		s.failures = append(s.failures, failure)
+	}
+}
+
+// check retorna todas as falhas de substituição em um único erro e prepara
+// a ocultação dos valores substituídos
+func (s *secrets) check() error {
+	if len(s.failures) > 0 {
+		return fmt.Errorf("não foi possível substituir %s", strings.Join(s.failures, ", "))
+	}
+	if len(s.values) == 0 {
+		return nil
+	}
+	// Os valores mais longos vêm primeiro, para que um valor contido em
+	// outro não oculte apenas parte dele
+	values := slices.Collect(maps.Keys(s.values))
+	slices.SortFunc(values, func(a, b string) int {
+		return cmp.Or(len(b)-len(a), strings.Compare(a, b))
+	})
+	pairs := make([]string, 0, 2*len(values))
+	for _, value := range values {
+		pairs = append(pairs, value, s.values[value])
+	}
+	s.replacer = strings.NewReplacer(pairs...)
+	return nil
+}
+
+// active indica se há valores a ocultar
+func (s *secrets) active() bool {
+	return s.replacer != nil
+}
+
+func (s *secrets) redact(text string) string {
+	if s.replacer == nil {
+		return text
+	}
+	return s.replacer.Replace(text)
+}
+
+func (s *secrets) redactHeader(header http.Header) http.Header {
+	if header == nil {
+		return nil
+	}
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		for _, value := range values {
+			redacted[name] = append(redacted[name], s.redact(value))
+		}
+	}
+	return redacted
+}
+
+// redactedError troca a mensagem do erro, mantendo a cadeia original para
+// errors.Is e errors.As
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e redactedError) Error() string { return e.message }
+func (e redactedError) Unwrap() error { return e.err }
+
+// redactResult oculta os valores substituídos nos campos do resultado que
+// chegam aos logs e exportadores: o alvo, a URL, os headers, o erro e a
+// falha capturada
+func (s *secrets) redactResult(result stress.Result) stress.Result {
+	result.Target = s.redact(result.Target)
+	result.URL = s.redact(result.URL)
+	result.RequestHeader = s.redactHeader(result.RequestHeader)
+	result.ResponseHeader = s.redactHeader(result.ResponseHeader)
+	if result.Error != nil {
+		result.Error = redactedError{message: s.redact(result.Error.Error()), err: result.Error}
+	}
+	if result.Capture != nil {
+		capture := *result.Capture
+		capture.URL = s.redact(capture.URL)
+		capture.RequestHeader = s.redactHeader(capture.RequestHeader)
+		capture.RequestBody = []byte(s.redact(string(capture.RequestBody)))
+		capture.ResponseHeader = s.redactHeader(capture.ResponseHeader)
+		result.Capture = &capture
+	}
+	if result.Iteration != nil {
+		iteration := *result.Iteration
+		iteration.FailedStep = s.redact(iteration.FailedStep)
+		result.Iteration = &iteration
+	}
+	return result
+}
+
+// redactReport oculta os valores substituídos nos textos do relatório: as
+// configurações registradas, os rótulos dos alvos e passos, os alvos das
+// requests mais lentas e o motivo da interrupção
+func (s *secrets) redactReport(report *stress.Report) {
+	if !s.active() {
+		return
+	}
+	for key, value := range report.Settings {
+		report.Settings[key] = s.redact(value)
+	}
+	if report.Targets != nil {
+		targets := make(map[string]*stress.TargetReport, len(report.Targets))
+		for label, target := range report.Targets {
+			targets[s.redact(label)] = target
+		}
+		report.Targets = targets
+	}
+	if report.Scenario != nil && report.Scenario.AbortedBySteps != nil {
+		steps := make(map[string]int, len(report.Scenario.AbortedBySteps))
+		for label, n := range report.Scenario.AbortedBySteps {
+			steps[s.redact(label)] += n
+		}
+		report.Scenario.AbortedBySteps = steps
+	}
+	if report.Slowest != nil {
+		for i := range report.Slowest.Requests {
+			report.Slowest.Requests[i].Target = s.redact(report.Slowest.Requests[i].Target)
+		}
+	}
+	report.AbortReason = s.redact(report.AbortReason)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+func TestSecretsExpand(t *testing.T) {
+	t.Setenv("STRESS_TOKEN", "token-secreto")
+	file := filepath.Join(t.TempDir(), "senha")
+	if err := os.WriteFile(file, []byte("senha-secreta\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "Bearer ${STRESS_TOKEN}", want: "Bearer token-secreto"},
+		{text: "${file:" + file + "}", want: "senha-secreta"},
+		{text: "$${STRESS_TOKEN}", want: "${STRESS_TOKEN}"},
+		{text: "sem referências", want: "sem referências"},
+	}
+	var s secrets
+	for _, tt := range tests {
+		if got := s.expand(tt.text); got != tt.want {
+			t.Errorf("expand(%q) = %q, esperava %q", tt.text, got, tt.want)
+		}
+	}
+	if err := s.check(); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if got, want := s.redact("token-secreto e senha-secreta"), "${STRESS_TOKEN} e ${file:"+file+"}"; got != want {
+		t.Errorf("redact = %q, esperava %q", got, want)
+	}
+}
+
+func TestSecretsCheck(t *testing.T) {
+	var s secrets
+	s.expand("${STRESS_INEXISTENTE} ${file:/inexistente/arquivo}")
+	err := s.check()
+	if err == nil || !strings.Contains(err.Error(), "${STRESS_INEXISTENTE} (variável não definida)") || !strings.Contains(err.Error(), "${file:/inexistente/arquivo}") {
+		t.Fatalf("check = %v, esperava as duas referências", err)
+	}
+}
+
+func TestRedactReport(t *testing.T) {
+	t.Setenv("STRESS_KEY", "chave-secreta")
+	var s secrets
+	target := s.expand("GET https://api.exemplo.com/?key=${STRESS_KEY}")
+	if err := s.check(); err != nil {
+		t.Fatal(err)
+	}
+	const redacted = "GET https://api.exemplo.com/?key=${STRESS_KEY}"
+	report := &stress.Report{
+		Settings:    map[string]string{"url": target},
+		Targets:     map[string]*stress.TargetReport{target: {}},
+		AbortReason: "falhas em " + target,
+		Slowest: &stress.SlowestStats{Max: 2, Requests: []stress.SlowRequest{
+			{Target: target},
+			{Target: "GET https://api.exemplo.com/saude"},
+		}},
+	}
+	s.redactReport(report)
+	if got := report.Settings["url"]; got != redacted {
+		t.Errorf("Settings[url] = %q, esperava %q", got, redacted)
+	}
+	if _, ok := report.Targets[redacted]; !ok || len(report.Targets) != 1 {
+		t.Errorf("Targets = %v, esperava apenas %q", report.Targets, redacted)
+	}
+	if got := report.AbortReason; got != "falhas em "+redacted {
+		t.Errorf("AbortReason = %q", got)
+	}
+	if got := report.Slowest.Requests[0].Target; got != redacted {
+		t.Errorf("Slowest.Requests[0].Target = %q, esperava %q", got, redacted)
+	}
+	if got := report.Slowest.Requests[1].Target; got != "GET https://api.exemplo.com/saude" {
+		t.Errorf("Slowest.Requests[1].Target = %q, esperava o alvo sem alterações", got)
+	}
+}
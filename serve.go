@@ -0,0 +1,474 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serveHistory é a quantidade de testes encerrados mantidos pela API; os
+// mais antigos são descartados
+const serveHistory = 100
+
+// serveInterval é o intervalo em que as estatísticas parciais dos testes
+// são atualizadas
+const serveInterval = time.Second
+
+// serveMaxConfig é o tamanho máximo da configuração enviada a POST /tests
+const serveMaxConfig = 10 << 20
+
+// Estados de um teste da API
+const (
+	serveRunning  = "running"
+	serveFinished = "finished"
+	serveCanceled = "canceled"
+	serveFailed   = "failed"
+)
+
+// serveConfigFlags são as chaves aceitas na configuração de POST /tests além
+// das de workerFlags: as que mudam apenas o relatório. Como nos workers, os
+// flags que leem ou gravam arquivos ou abrem portas na máquina do serviço
+// ficam de fora; scenario é aceito apenas com os passos na própria
+// configuração.
+var serveConfigFlags = map[string]bool{
+	"output": true, "no-color": true, "no-histogram": true, "histogram-buckets": true, "label": true,
+	"quiet": true, "no-progress": true, "user-agent-mode": true, "scenario": true,
+}
+
+// checkServeConfig recusa as chaves fora de workerFlags e serveConfigFlags e
+// os valores que leriam arquivos da máquina do serviço: as referências
+// ${file:/caminho} e o @arquivo das queries GraphQL, como em jobArgs. As
+// referências ${VAR} também são recusadas, pois exporiam as variáveis de
+// ambiente do serviço a quem envia a configuração.
+func checkServeConfig(object map[string]json.RawMessage) error {
+	for _, name := range slices.Sorted(maps.Keys(object)) {
+		if !workerFlags[name] && !serveConfigFlags[name] {
+			return fmt.Errorf("a chave %q não é aceita pela API, que não lê nem grava arquivos nem abre portas", name)
+		}
+		var value any
+		if err := json.Unmarshal(object[name], &value); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if _, inline := value.(map[string]any); name == "scenario" && !inline {
+			return errors.New("scenario: envie os passos do cenário na configuração, e não um caminho")
+		}
+		if text, ok := value.(string); ok && strings.HasPrefix(text, "@") && (name == "graphql-query" || name == "graphql-variables") {
+			return fmt.Errorf("%s: a API não lê arquivos, envie o conteúdo em vez de @arquivo", name)
+		}
+		if containsReference(value) {
+			return fmt.Errorf("%s: as referências ${VAR} e ${file:/caminho} não são aceitas pela API", name)
+		}
+	}
+	return nil
+}
+
+// containsReference indica se algum texto de um valor JSON traz uma
+// referência ${...}
+func containsReference(value any) bool {
+	switch value := value.(type) {
+	case string:
+		return strings.Contains(value, "${")
+	case []any:
+		return slices.ContainsFunc(value, containsReference)
+	case map[string]any:
+		for _, item := range value {
+			if containsReference(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// serveStats são as estatísticas parciais de um teste em execução, do
+// último resumo periódico, lidas de um subconjunto de jsonIntervalStats.
+// RPS, P95 e ErrorRate se referem ao último intervalo; Requests e Errors,
+// ao teste inteiro.
+type serveStats struct {
+	Elapsed           jsonDuration `json:"elapsed"`
+	Requests          int          `json:"requests"`
+	Errors            int          `json:"errors"`
+	RequestsPerSecond float64      `json:"rps"`
+	P95               jsonDuration `json:"p95"`
+	ErrorRate         float64      `json:"error_rate"`
+}
+
+// serveTest é um teste iniciado por POST /tests. Os campos são protegidos
+// pelo mutex de serveServer.
+type serveTest struct {
+	id       string
+	status   string
+	started  time.Time
+	finished time.Time
+	stats    *serveStats
+	warnings []string
+	exitCode int
+	err      string
+	report   []byte
+	// process é o teste em execução, nil antes do início; canceled indica
+	// um DELETE, atendido assim que o processo inicia
+	process  *os.Process
+	canceled bool
+}
+
+// serveStatus é a representação de um serveTest em GET /tests/{id}
+type serveStatus struct {
+	ID         string      `json:"id"`
+	Status     string      `json:"status"`
+	StartedAt  time.Time   `json:"started_at"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+	Stats      *serveStats `json:"stats,omitempty"`
+	Warnings   []string    `json:"warnings,omitempty"`
+	// ExitCode é o código de saída da CLI no teste encerrado (ex.: 2 com
+	// algum limite de --fail-if violado)
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (t *serveTest) statusJSON() serveStatus {
+	status := serveStatus{
+		ID:        t.id,
+		Status:    t.status,
+		StartedAt: t.started,
+		Stats:     t.stats,
+		Warnings:  t.warnings,
+		Error:     t.err,
+	}
+	if t.status != serveRunning {
+		finished, code := t.finished, t.exitCode
+		status.FinishedAt, status.ExitCode = &finished, &code
+	}
+	return status
+}
+
+// serveServer é a API de --serve. Cada teste é executado em um processo
+// próprio da CLI (ver runCLI) a partir de uma configuração no formato de
+// --config.
+type serveServer struct {
+	token      string
+	maxRunning int
+
+	mu      sync.Mutex
+	tests   map[string]*serveTest
+	order   []string
+	running int
+}
+
+// runServe atende a API de --serve em addr até o processo ser encerrado.
+// Com token, as requests devem enviar "Authorization: Bearer <token>", que
+// é obrigatório fora da própria máquina.
+func runServe(addr, token string, maxRunning int) int {
+	if token == "" && !loopbackAddr(addr) {
+		fmt.Printf("Erro: --serve=%s aceita conexões de outras máquinas e requer --serve-token\n", addr)
+		return exitUsage
+	}
+	s := &serveServer{token: token, maxRunning: maxRunning, tests: make(map[string]*serveTest)}
+	fmt.Fprintf(os.Stderr, "API de testes em %s\n", addr)
+	if err := http.ListenAndServe(addr, s.handler()); err != nil {
+		fmt.Printf("Erro: %v\n", err)
+		return exitUsage
+	}
+	return exitOK
+}
+
+// handler retorna as rotas da API
+func (s *serveServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /tests", sameOrigin(s.auth(s.create)))
+	mux.HandleFunc("GET /tests/{id}", sameOrigin(s.auth(s.status)))
+	mux.HandleFunc("GET /tests/{id}/report", sameOrigin(s.auth(s.report)))
+	mux.HandleFunc("DELETE /tests/{id}", sameOrigin(s.auth(s.cancel)))
+	return mux
+}
+
+func (s *serveServer) auth(next http.HandlerFunc) http.HandlerFunc {
+	if s.token == "" {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !validBearer(r, s.token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			serveError(w, http.StatusUnauthorized, "token ausente ou inválido")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// sameOrigin recusa as requests enviadas por páginas de outra origem: sem
+// --serve-token, qualquer página aberta no navegador da máquina poderia
+// iniciar testes com um POST para 127.0.0.1
+func sameOrigin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+				serveError(w, http.StatusForbidden, "requests de outra origem não são aceitas")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+func (s *serveServer) create(w http.ResponseWriter, r *http.Request) {
+	// Um formulário HTML não envia application/json, que exige a
+	// autorização prévia (preflight) do navegador em outra origem
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		serveError(w, http.StatusUnsupportedMediaType, "envie a configuração com Content-Type: application/json")
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, serveMaxConfig+1))
+	if err != nil {
+		serveError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(data) > serveMaxConfig {
+		serveError(w, http.StatusRequestEntityTooLarge, "configuração muito grande")
+		return
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		serveError(w, http.StatusBadRequest, "a configuração deve ser um objeto JSON com os nomes dos flags como chaves")
+		return
+	}
+	if err := checkServeConfig(object); err != nil {
+		serveError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "stress-serve-*")
+	if err != nil {
+		serveError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	config := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(config, data, 0o600); err != nil {
+		os.RemoveAll(dir)
+		serveError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// A configuração é verificada antes de ocupar uma vaga, para que os
+	// erros voltem na própria resposta
+	failure, err := runCLI([]string{"validate", "--config=" + config}, nil, func(*os.Process) {}, func(string) {})
+	if err != nil {
+		os.RemoveAll(dir)
+		if failure == "" {
+			failure = err.Error()
+		}
+		serveError(w, http.StatusBadRequest, strings.TrimPrefix(failure, "--config "+config+": "))
+		return
+	}
+
+	s.mu.Lock()
+	if s.running >= s.maxRunning {
+		s.mu.Unlock()
+		os.RemoveAll(dir)
+		serveError(w, http.StatusTooManyRequests, fmt.Sprintf("limite de %d teste(s) em execução atingido", s.maxRunning))
+		return
+	}
+	test := &serveTest{id: newTestID(), status: serveRunning, started: time.Now()}
+	s.tests[test.id] = test
+	s.order = append(s.order, test.id)
+	s.running++
+	status := test.statusJSON()
+	s.mu.Unlock()
+
+	go s.execute(test, dir, config)
+	w.Header().Set("Location", "/tests/"+test.id)
+	serveJSON(w, http.StatusCreated, status)
+}
+
+// execute executa o teste e registra o resultado. Os flags da linha de
+// comando prevalecem sobre a configuração, então as saídas usadas pela API
+// são sempre as mesmas. As estatísticas parciais chegam pelo descritor 3,
+// com os resumos de --interval-jsonl.
+func (s *serveServer) execute(test *serveTest, dir, config string) {
+	defer os.RemoveAll(dir)
+	report := filepath.Join(dir, "report.json")
+	args := []string{"--config=" + config, "--save-report=" + report, "--no-progress", "--quiet=false", "--report-interval=" + serveInterval.String()}
+	var extra []*os.File
+	decoded := make(chan struct{})
+	if intervals, writer, err := os.Pipe(); err != nil {
+		close(decoded)
+		s.mu.Lock()
+		test.warnings = append(test.warnings, fmt.Sprintf("estatísticas parciais indisponíveis: %v", err))
+		s.mu.Unlock()
+	} else {
+		args = append(args, "--interval-jsonl=/dev/fd/3")
+		extra = []*os.File{writer}
+		go func() {
+			defer close(decoded)
+			defer intervals.Close()
+			s.decodeStats(test, intervals)
+		}()
+	}
+	failure, err := runCLI(args, extra, func(process *os.Process) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		test.process = process
+		if test.canceled {
+			process.Signal(os.Interrupt)
+		}
+	}, func(line string) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if warning, ok := strings.CutPrefix(line, "AVISO: "); ok {
+			test.warnings = append(test.warnings, warning)
+		}
+	})
+	<-decoded
+	data, _ := os.ReadFile(report)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	test.finished = time.Now()
+	test.process = nil
+	test.report = data
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		test.exitCode = exit.ExitCode()
+	}
+	switch {
+	case len(data) == 0:
+		test.status = serveFailed
+		if test.err = failure; failure == "" && err != nil {
+			test.err = err.Error()
+			test.exitCode = exitUsage
+		}
+	case test.canceled:
+		test.status = serveCanceled
+	default:
+		test.status = serveFinished
+	}
+	s.running--
+	s.prune()
+}
+
+// decodeStats atualiza as estatísticas parciais do teste com cada resumo de
+// --interval-jsonl lido de r, até o fim do processo
+func (s *serveServer) decodeStats(test *serveTest, r io.Reader) {
+	decoder := json.NewDecoder(r)
+	for {
+		var stats serveStats
+		if err := decoder.Decode(&stats); err != nil {
+			if err != io.EOF {
+				s.mu.Lock()
+				test.warnings = append(test.warnings, fmt.Sprintf("estatísticas parciais inválidas: %v", err))
+				s.mu.Unlock()
+			}
+			// O restante é descartado para que o processo não fique
+			// bloqueado na escrita
+			io.Copy(io.Discard, r)
+			return
+		}
+		s.mu.Lock()
+		test.stats = &stats
+		s.mu.Unlock()
+	}
+}
+
+// prune descarta os testes encerrados mais antigos além de serveHistory
+func (s *serveServer) prune() {
+	finished := len(s.order) - s.running
+	for i := 0; i < len(s.order) && finished > serveHistory; {
+		if s.tests[s.order[i]].status == serveRunning {
+			i++
+			continue
+		}
+		delete(s.tests, s.order[i])
+		s.order = append(s.order[:i], s.order[i+1:]...)
+		finished--
+	}
+}
+
+// lookup retorna o teste de {id}, respondendo 404 quando ele não existe.
+// Com sucesso, o mutex fica travado.
+func (s *serveServer) lookup(w http.ResponseWriter, r *http.Request) (*serveTest, bool) {
+	s.mu.Lock()
+	test, ok := s.tests[r.PathValue("id")]
+	if !ok {
+		s.mu.Unlock()
+		serveError(w, http.StatusNotFound, "teste não encontrado")
+	}
+	return test, ok
+}
+
+func (s *serveServer) status(w http.ResponseWriter, r *http.Request) {
+	test, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	status := test.statusJSON()
+	s.mu.Unlock()
+	serveJSON(w, http.StatusOK, status)
+}
+
+func (s *serveServer) report(w http.ResponseWriter, r *http.Request) {
+	test, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	status, report := test.status, test.report
+	s.mu.Unlock()
+	switch {
+	case status == serveRunning:
+		serveError(w, http.StatusConflict, "o teste ainda está em execução")
+	case len(report) == 0:
+		serveError(w, http.StatusNotFound, "o teste falhou sem gerar um relatório")
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(report)
+	}
+}
+
+// cancel interrompe o teste como o Ctrl+C; o relatório parcial fica
+// disponível em GET /tests/{id}/report
+func (s *serveServer) cancel(w http.ResponseWriter, r *http.Request) {
+	test, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	if test.status != serveRunning {
+		s.mu.Unlock()
+		serveError(w, http.StatusConflict, "o teste já foi encerrado")
+		return
+	}
+	test.canceled = true
+	if test.process != nil {
+		test.process.Signal(os.Interrupt)
+	}
+	status := test.statusJSON()
+	s.mu.Unlock()
+	serveJSON(w, http.StatusAccepted, status)
+}
+
+// newTestID gera o identificador de um teste da API
+func newTestID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+func serveJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func serveError(w http.ResponseWriter, code int, message string) {
+	serveJSON(w, code, map[string]string{"error": message})
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+func TestCheckServeConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{name: "válida", config: `{"url": "http://localhost:8080/", "requests": 10, "concurrency": 2, "header": {"Accept": "application/json"}}`},
+		{name: "cenário inline", config: `{"scenario": {"steps": [{"url": "http://localhost:8080/"}]}}`},
+		{name: "graphql inline", config: `{"graphql-query": "{ produtos { id } }", "graphql-variables": "{\"id\": 1}"}`},
+		{name: "chave desconhecida", config: `{"url-file": "/etc/hosts"}`, err: `a chave "url-file" não é aceita pela API`},
+		{name: "body-file", config: `{"body-file": "/etc/shadow"}`, err: `a chave "body-file" não é aceita pela API`},
+		{name: "cenário em arquivo", config: `{"scenario": "/tmp/cenario.json"}`, err: "scenario: envie os passos do cenário na configuração"},
+		{name: "graphql-query de arquivo", config: `{"graphql-query": "@/etc/shadow"}`, err: "graphql-query: a API não lê arquivos"},
+		{name: "graphql-variables de arquivo", config: `{"graphql-variables": "@/etc/shadow"}`, err: "graphql-variables: a API não lê arquivos"},
+		{name: "referência a arquivo", config: `{"header": ["Authorization: ${file:/etc/shadow}"]}`, err: "header: as referências"},
+		{name: "variável de ambiente", config: `{"url": "http://localhost/?k=${AWS_SECRET_ACCESS_KEY}"}`, err: "url: as referências"},
+		{name: "variável na lista", config: `{"fail-if": ["p95>${LIMITE}"]}`, err: "fail-if: as referências"},
+		{name: "referência a arquivo no cenário", config: `{"scenario": {"steps": [{"url": "http://localhost/", "body": "${file:/etc/shadow}"}]}}`, err: "scenario: as referências"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var object map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(tt.config), &object); err != nil {
+				t.Fatal(err)
+			}
+			err := checkServeConfig(object)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("checkServeConfig: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Fatalf("checkServeConfig = %v, esperava %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestServeRequestChecks(t *testing.T) {
+	s := &serveServer{maxRunning: 1, tests: make(map[string]*serveTest)}
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		origin      string
+		status      int
+	}{
+		{name: "text/plain", method: http.MethodPost, path: "/tests", contentType: "text/plain", status: http.StatusUnsupportedMediaType},
+		{name: "formulário", method: http.MethodPost, path: "/tests", contentType: "application/x-www-form-urlencoded", status: http.StatusUnsupportedMediaType},
+		{name: "sem Content-Type", method: http.MethodPost, path: "/tests", status: http.StatusUnsupportedMediaType},
+		{name: "outra origem", method: http.MethodPost, path: "/tests", contentType: "application/json", origin: "http://exemplo.com", status: http.StatusForbidden},
+		{name: "origem nula", method: http.MethodPost, path: "/tests", contentType: "application/json", origin: "null", status: http.StatusForbidden},
+		{name: "outra origem no DELETE", method: http.MethodDelete, path: "/tests/abc", origin: "http://exemplo.com", status: http.StatusForbidden},
+		// Passam pelas verificações e param na configuração ou no teste
+		{name: "JSON", method: http.MethodPost, path: "/tests", contentType: "application/json; charset=utf-8", status: http.StatusBadRequest},
+		{name: "mesma origem", method: http.MethodPost, path: "/tests", contentType: "application/json", origin: "http://127.0.0.1:8080", status: http.StatusBadRequest},
+		{name: "GET sem origem", method: http.MethodGet, path: "/tests/abc", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://127.0.0.1:8080"+tt.path, strings.NewReader("[]"))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			s.handler().ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, esperava %d (%s)", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestServeDecodeStats(t *testing.T) {
+	var buf bytes.Buffer
+	write := intervalWriter(&buf)
+	write(stress.IntervalStats{Elapsed: time.Second, Requests: 100, Errors: 1, IntervalRequests: 100, IntervalErrors: 1, RPS: 100, P95: 20 * time.Millisecond})
+	write(stress.IntervalStats{Elapsed: 2 * time.Second, Requests: 250, Errors: 4, IntervalRequests: 150, IntervalErrors: 3, RPS: 150, P95: 30 * time.Millisecond})
+
+	s := &serveServer{tests: make(map[string]*serveTest)}
+	test := &serveTest{}
+	s.decodeStats(test, &buf)
+	want := serveStats{
+		Elapsed:           newJSONDuration(2 * time.Second),
+		Requests:          250,
+		Errors:            4,
+		RequestsPerSecond: 150,
+		P95:               newJSONDuration(30 * time.Millisecond),
+		ErrorRate:         0.02,
+	}
+	if test.stats == nil || *test.stats != want {
+		t.Fatalf("stats = %+v, esperava %+v", test.stats, want)
+	}
+	if len(test.warnings) > 0 {
+		t.Errorf("avisos inesperados: %q", test.warnings)
+	}
+
+	s.decodeStats(test, strings.NewReader("[10s] Requests: 1\n"))
+	if len(test.warnings) != 1 || !strings.HasPrefix(test.warnings[0], "estatísticas parciais inválidas") {
+		t.Errorf("avisos = %q, esperava o erro de leitura das estatísticas", test.warnings)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+const (
+	// statsdPacketSize limita cada datagrama UDP, abaixo do MTU comum em
+	// redes com encapsulamento
+	statsdPacketSize = 1432
+	// statsdQueueSize é a quantidade de métricas aguardando envio; com a
+	// fila cheia, as novas são descartadas em vez de atrasar o teste
+	statsdQueueSize = 8192
+	// statsdFlushInterval é o intervalo máximo entre os envios de um pacote
+	// incompleto
+	statsdFlushInterval = 100 * time.Millisecond
+)
+
+// statsdEmitter envia uma métrica por request concluída a um servidor
+// StatsD ou DogStatsD. add é chamado a partir de OnResult e nunca bloqueia:
+// as linhas vão para uma fila consumida por uma goroutine que as agrupa em
+// pacotes UDP, e as que não cabem na fila são descartadas e contadas.
+type statsdEmitter struct {
+	conn       net.Conn
+	prefix     string
+	dogstatsd  bool
+	sampleRate float64
+	queue      chan string
+	dropped    atomic.Int64
+	done       chan struct{}
+}
+
+// newStatsdEmitter abre o socket UDP; como o UDP não tem conexão, um
+// servidor ausente não é detectado aqui e as métricas são perdidas
+// silenciosamente
+func newStatsdEmitter(addr, prefix, format string, sampleRate float64) (*statsdEmitter, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	e := &statsdEmitter{
+		conn:       conn,
+		prefix:     prefix,
+		dogstatsd:  format == "dogstatsd",
+		sampleRate: sampleRate,
+		queue:      make(chan string, statsdQueueSize),
+		done:       make(chan struct{}),
+	}
+	go e.send()
+	return e, nil
+}
+
+// add emite a duração da request, amostrada conforme sampleRate, e um
+// contador para cada erro, sempre enviado
+func (e *statsdEmitter) add(result stress.Result) {
+	if result.Canceled {
+		return
+	}
+	method, target := splitTargetLabel(result.Target)
+	class := statusClass(result)
+	if e.sampleRate >= 1 || rand.Float64() < e.sampleRate {
+		duration := strconv.FormatFloat(float64(result.Duration)/float64(time.Millisecond), 'f', 3, 64)
+		rate := ""
+		if e.sampleRate < 1 {
+			rate = "|@" + strconv.FormatFloat(e.sampleRate, 'g', -1, 64)
+		}
+		if e.dogstatsd {
+			e.enqueue(fmt.Sprintf("%srequest.duration:%s|ms%s|#status_class:%s,method:%s,target:%s",
+				e.prefix, duration, rate, class, statsdTag(method), statsdTag(target)))
+		} else {
+			e.enqueue(fmt.Sprintf("%srequest.duration.%s:%s|ms%s", e.prefix, class, duration, rate))
+		}
+	}
+	if result.Error != nil {
+		if e.dogstatsd {
+			e.enqueue(fmt.Sprintf("%serrors:1|c|#category:%s,method:%s,target:%s",
+				e.prefix, statsdTag(result.ErrorCategory), statsdTag(method), statsdTag(target)))
+		} else {
+			e.enqueue(fmt.Sprintf("%serrors.%s:1|c", e.prefix, statsdName(result.ErrorCategory)))
+		}
+	}
+}
+
+func (e *statsdEmitter) enqueue(line string) {
+	select {
+	case e.queue <- line:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+// send agrupa as linhas da fila em pacotes de até statsdPacketSize bytes,
+// enviados quando enchem ou a cada statsdFlushInterval
+func (e *statsdEmitter) send() {
+	defer close(e.done)
+	ticker := time.NewTicker(statsdFlushInterval)
+	defer ticker.Stop()
+
+	packet := make([]byte, 0, statsdPacketSize)
+	flush := func() {
+		if len(packet) == 0 {
+			return
+		}
+		// Com o servidor fora do ar, a escrita falha com ECONNREFUSED; o
+		// envio é best-effort e o pacote é descartado
+		if _, err := e.conn.Write(packet); err != nil {
+			e.dropped.Add(int64(countLines(packet)))
+		}
+		packet = packet[:0]
+	}
+	for {
+		select {
+		case line, ok := <-e.queue:
+			if !ok {
+				flush()
+				return
+			}
+			if len(packet) > 0 && len(packet)+1+len(line) > statsdPacketSize {
+				flush()
+			}
+			if len(packet) > 0 {
+				packet = append(packet, '\n')
+			}
+			packet = append(packet, line...)
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// Close envia as métricas pendentes, incluindo a quantidade descartada, e
+// fecha o socket. Retorna quantas métricas foram descartadas.
+func (e *statsdEmitter) Close() int64 {
+	if dropped := e.dropped.Load(); dropped > 0 {
+		e.enqueue(fmt.Sprintf("%sstatsd.dropped:%d|c", e.prefix, dropped))
+	}
+	close(e.queue)
+	<-e.done
+	e.conn.Close()
+	return e.dropped.Load()
+}
+
+// countLines conta as métricas de um pacote
+func countLines(packet []byte) int {
+	return strings.Count(string(packet), "\n") + 1
+}
+
+// statsdTagReplacer remove dos valores de tags os separadores do DogStatsD
+var statsdTagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+
+func statsdTag(value string) string {
+	return statsdTagReplacer.Replace(value)
+}
+
+// statsdName converte um valor em um segmento de nome de métrica, trocando
+// por "_" os caracteres fora de [a-zA-Z0-9_-]
+func statsdName(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, value)
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+)
+
+// Sequências ANSI usadas no relatório colorido
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+)
+
+// reportStyle define como o relatório em texto é impresso. No terminal, os
+// campos de cada seção ficam alinhados e, sem NO_COLOR ou --no-color,
+// coloridos; redirecionado para um arquivo ou pipe, o relatório sai como
+// texto simples. Com markdown ou html, as mesmas seções viram tabelas do
+// GitHub ou tabelas HTML.
+type reportStyle struct {
+	aligned  bool
+	color    bool
+	markdown bool
+	html     bool
+}
+
+// markdownStyle imprime o relatório para --output=markdown
+var markdownStyle = reportStyle{markdown: true}
+
+// htmlStyle imprime as seções do relatório de --output-html
+var htmlStyle = reportStyle{html: true}
+
+// document indica os estilos que formam tabelas, em que as durações saem
+// com precisão fixa (ver fixedDuration)
+func (s reportStyle) document() bool {
+	return s.markdown || s.html
+}
+
+func newReportStyle(out *os.File, noColor bool) reportStyle {
+	if !isTerminal(out) {
+		return reportStyle{}
+	}
+	return reportStyle{aligned: true, color: !noColor && os.Getenv("NO_COLOR") == ""}
+}
+
+// isTerminal indica se o arquivo é um terminal, e não um arquivo ou pipe
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// paint aplica a cor ao texto quando as cores estão ativas
+func (s reportStyle) paint(color, text string) string {
+	if !s.color || color == "" || text == "" {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+// reportRow é uma linha pendente de uma seção do relatório alinhado
+type reportRow struct {
+	kind  rowKind
+	color string
+	label string
+	text  string
+}
+
+type rowKind int
+
+const (
+	// rowField é um par "rótulo: valor", com o valor alinhado à esquerda
+	rowField rowKind = iota
+	// rowColumn é um par com o valor alinhado à direita, como nas tabelas
+	// de percentis
+	rowColumn
+	// rowDetail complementa o campo anterior, na coluna dos valores
+	rowDetail
+	// rowLine ocupa a linha inteira, sem alinhamento
+	rowLine
+)
+
+// reportPrinter imprime o relatório em out. Em texto simples cada linha
+// é escrita na hora; no modo alinhado e no Markdown as linhas de uma seção
+// são guardadas até a próxima seção, para que os rótulos tenham a mesma
+// largura ou formem uma tabela.
+type reportPrinter struct {
+	out   io.Writer
+	style reportStyle
+	rows  []reportRow
+}
+
+// title imprime o título do relatório; no HTML o título fica no cabeçalho
+// da página, e os campos iniciais formam a seção de resumo
+func (p *reportPrinter) title(text string) {
+	if p.style.html {
+		fmt.Fprintln(p.out, "<h2>Resumo</h2>")
+		return
+	}
+	if p.style.markdown {
+		fmt.Fprintf(p.out, "# %s\n\n", text)
+		return
+	}
+	p.heading("=== %s ===", text)
+}
+
+// heading inicia uma seção com o título informado, precedido de uma linha
+// em branco
+func (p *reportPrinter) heading(format string, args ...any) {
+	p.flush()
+	heading := p.sprintf(format, args...)
+	if p.style.html {
+		fmt.Fprintf(p.out, "<h2>%s</h2>\n", html.EscapeString(strings.TrimSuffix(heading, ":")))
+		return
+	}
+	if p.style.markdown {
+		fmt.Fprintf(p.out, "## %s\n\n", strings.TrimSuffix(heading, ":"))
+		return
+	}
+	fmt.Fprintf(p.out, "\n%s\n", p.style.paint(ansiBold, heading))
+}
+
+// section inicia uma seção no formato "Título:"
+func (p *reportPrinter) section(title string) {
+	p.heading("%s:", title)
+}
+
+// field imprime "rótulo: valor"
+func (p *reportPrinter) field(color, label, format string, args ...any) {
+	p.add(reportRow{kind: rowField, color: color, label: label, text: p.sprintf(format, args...)})
+}
+
+// column imprime "rótulo: valor", com o valor alinhado à direita no modo
+// alinhado e no Markdown
+func (p *reportPrinter) column(color, label string, value any) {
+	p.add(reportRow{kind: rowColumn, color: color, label: label, text: p.sprintf("%v", value)})
+}
+
+// detail imprime um complemento do campo anterior, recuado no texto simples
+func (p *reportPrinter) detail(format string, args ...any) {
+	p.add(reportRow{kind: rowDetail, text: p.sprintf(format, args...)})
+}
+
+// line imprime uma linha sem rótulo
+func (p *reportPrinter) line(color, format string, args ...any) {
+	p.add(reportRow{kind: rowLine, color: color, text: p.sprintf(format, args...)})
+}
+
+// table imprime uma tabela com cabeçalho: colunas separadas por espaços no
+// texto e uma tabela do GitHub no Markdown
+func (p *reportPrinter) table(header []string, rows [][]string) {
+	p.flush()
+	if p.style.html {
+		fmt.Fprintln(p.out, "<table>")
+		fmt.Fprintln(p.out, htmlRow("th", "", header))
+		for _, row := range rows {
+			fmt.Fprintln(p.out, htmlRow("td", "", row))
+		}
+		fmt.Fprintln(p.out, "</table>")
+		return
+	}
+	if p.style.markdown {
+		fmt.Fprintln(p.out, markdownRow(header))
+		separator := make([]string, len(header))
+		for i := range separator {
+			separator[i] = "---"
+		}
+		fmt.Fprintln(p.out, markdownRow(separator))
+		for _, row := range rows {
+			fmt.Fprintln(p.out, markdownRow(row))
+		}
+		fmt.Fprintln(p.out)
+		return
+	}
+	w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+// sprintf formata os valores; no Markdown e no HTML as durações saem com
+// precisão fixa (ver fixedDuration)
+func (p *reportPrinter) sprintf(format string, args ...any) string {
+	if p.style.document() {
+		for i, arg := range args {
+			if d, ok := arg.(time.Duration); ok {
+				args[i] = fixedDuration(d)
+			}
+		}
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+func (p *reportPrinter) add(row reportRow) {
+	if p.style.aligned || p.style.document() {
+		p.rows = append(p.rows, row)
+		return
+	}
+	fmt.Fprintln(p.out, p.style.paint(row.color, plainRow(row)))
+}
+
+// plainRow formata a linha como no texto simples
+func plainRow(row reportRow) string {
+	switch row.kind {
+	case rowField, rowColumn:
+		return row.label + ": " + row.text
+	case rowDetail:
+		return "  " + row.text
+	}
+	return row.text
+}
+
+// flush imprime as linhas pendentes da seção, alinhando os rótulos e os
+// valores das colunas
+func (p *reportPrinter) flush() {
+	switch {
+	case p.style.markdown:
+		p.flushMarkdown()
+		return
+	case p.style.html:
+		p.flushHTML()
+		return
+	}
+	labelWidth, valueWidth := 0, 0
+	for _, row := range p.rows {
+		switch row.kind {
+		case rowField:
+			labelWidth = max(labelWidth, utf8.RuneCountInString(row.label)+1)
+		case rowColumn:
+			labelWidth = max(labelWidth, utf8.RuneCountInString(row.label)+1)
+			valueWidth = max(valueWidth, utf8.RuneCountInString(row.text))
+		}
+	}
+	for _, row := range p.rows {
+		var text string
+		switch row.kind {
+		case rowField:
+			text = pad(row.label+":", labelWidth) + "  " + row.text
+		case rowColumn:
+			text = pad(row.label+":", labelWidth) + "  " + strings.Repeat(" ", valueWidth-utf8.RuneCountInString(row.text)) + row.text
+		case rowDetail:
+			text = strings.Repeat(" ", labelWidth+2) + row.text
+		default:
+			text = row.text
+		}
+		fmt.Fprintln(p.out, p.style.paint(row.color, text))
+	}
+	p.rows = p.rows[:0]
+}
+
+// pad completa o texto com espaços até a largura, contada em caracteres
+func pad(text string, width int) string {
+	return text + strings.Repeat(" ", max(width-utf8.RuneCountInString(text), 0))
+}
+
+// flushMarkdown imprime as linhas pendentes como tabelas de duas colunas,
+// com as linhas sem rótulo como parágrafos entre elas
+func (p *reportPrinter) flushMarkdown() {
+	for start := 0; start < len(p.rows); {
+		if p.rows[start].kind == rowLine {
+			fmt.Fprintf(p.out, "%s\n\n", p.rows[start].text)
+			start++
+			continue
+		}
+		end, align := start, "---"
+		for ; end < len(p.rows) && p.rows[end].kind != rowLine; end++ {
+			if p.rows[end].kind == rowColumn {
+				align = "---:"
+			}
+		}
+		fmt.Fprintln(p.out, markdownRow([]string{"Métrica", "Valor"}))
+		fmt.Fprintln(p.out, markdownRow([]string{"---", align}))
+		for _, row := range p.rows[start:end] {
+			fmt.Fprintln(p.out, markdownRow([]string{row.label, row.text}))
+		}
+		fmt.Fprintln(p.out)
+		start = end
+	}
+	p.rows = p.rows[:0]
+}
+
+// flushHTML imprime as linhas pendentes como tabelas de duas colunas, com
+// as cores do terminal convertidas em classes CSS
+func (p *reportPrinter) flushHTML() {
+	open := false
+	for _, row := range p.rows {
+		class := htmlClasses[row.color]
+		if row.kind == rowLine {
+			if open {
+				fmt.Fprintln(p.out, "</table>")
+				open = false
+			}
+			fmt.Fprintf(p.out, "<p%s>%s</p>\n", classAttr(class), html.EscapeString(row.text))
+			continue
+		}
+		if !open {
+			fmt.Fprintln(p.out, "<table>")
+			open = true
+		}
+		if row.kind == rowColumn {
+			class += " num"
+		}
+		fmt.Fprintln(p.out, htmlRow("td", strings.TrimSpace(class), []string{row.label, row.text}))
+	}
+	if open {
+		fmt.Fprintln(p.out, "</table>")
+	}
+	p.rows = p.rows[:0]
+}
+
+// htmlClasses traduz as cores do terminal nas classes do relatório HTML
+var htmlClasses = map[string]string{
+	ansiGreen:  "good",
+	ansiRed:    "bad",
+	ansiYellow: "warn",
+}
+
+// htmlRow formata uma linha de tabela HTML com as células escapadas
+func htmlRow(cell, class string, cells []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<tr%s>", classAttr(class))
+	for _, text := range cells {
+		fmt.Fprintf(&b, "<%s>%s</%s>", cell, html.EscapeString(text), cell)
+	}
+	b.WriteString("</tr>")
+	return b.String()
+}
+
+// classAttr retorna o atributo class, vazio sem classes
+func classAttr(class string) string {
+	if class == "" {
+		return ""
+	}
+	return fmt.Sprintf(" class=%q", class)
+}
+
+// markdownRow formata uma linha de tabela, escapando as barras verticais
+// das células
+func markdownRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = strings.ReplaceAll(cell, "|", "\\|")
+	}
+	return "| " + strings.Join(escaped, " | ") + " |"
+}
+
+// fixedDuration formata a duração com duas casas decimais na maior unidade
+// em que ela não fica abaixo de 1 (ex.: 231.46ms, 11.90s)
+func fixedDuration(d time.Duration) string {
+	switch abs := max(d, -d); {
+	case abs >= time.Second:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	case abs >= time.Millisecond:
+		return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+	case abs >= time.Microsecond:
+		return fmt.Sprintf("%.2fµs", float64(d)/float64(time.Microsecond))
+	}
+	return fmt.Sprintf("%dns", d)
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// setKeyInput não é suportado fora do Linux: as teclas só chegam após o
+// Enter
+func setKeyInput(f *os.File) (restore func(), err error) {
+	return nil, errors.New("modo de teclas não suportado neste sistema")
+}
+
+// terminalSize não é suportado fora do Linux; o painel usa o tamanho padrão
+func terminalSize(f *os.File) (width, height int, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+// dashboardInterval é o intervalo de atualização do painel de --tui
+const dashboardInterval = 500 * time.Millisecond
+
+// dashboardBuckets é a quantidade de faixas do histograma do painel
+const dashboardBuckets = 8
+
+// errDashboardQuit é a causa registrada no relatório quando o teste é
+// encerrado pela tecla q do painel
+var errDashboardQuit = errors.New("teste encerrado pelo painel (q)")
+
+// Sequências ANSI usadas pelo painel
+const (
+	ansiAltScreen  = "\x1b[?1049h"
+	ansiMainScreen = "\x1b[?1049l"
+	ansiHideCursor = "\x1b[?25l"
+	ansiShowCursor = "\x1b[?25h"
+	ansiHome       = "\x1b[H"
+	ansiClearLine  = "\x1b[K"
+	ansiClearBelow = "\x1b[J"
+)
+
+// sparkBlocks são os níveis dos gráficos de linha do painel
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// dashboard desenha o painel de --tui em tela cheia a partir dos resumos de
+// OnInterval, os mesmos de --report-interval. Os resumos chegam pela
+// goroutine do collector e são desenhados por uma goroutine própria, para
+// que uma escrita lenta no terminal não atrase a agregação.
+type dashboard struct {
+	out   *os.File
+	style reportStyle
+	title string
+	// requests e duration são os limites do teste, usados no progresso
+	requests int
+	duration time.Duration
+	quit     func()
+
+	updates chan stress.IntervalStats
+	done    chan struct{}
+	restore func()
+	once    sync.Once
+
+	last stress.IntervalStats
+	rps  []float64
+	p95  []float64
+}
+
+// newDashboard cria o painel; quit é chamado quando a tecla q é pressionada
+func newDashboard(out *os.File, noColor bool, title string, requests int, duration time.Duration, quit func()) *dashboard {
+	return &dashboard{
+		out:      out,
+		style:    newReportStyle(out, noColor),
+		title:    title,
+		requests: requests,
+		duration: duration,
+		quit:     quit,
+		updates:  make(chan stress.IntervalStats, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// update recebe um resumo de OnInterval sem bloquear; um resumo ainda não
+// desenhado é substituído pelo mais recente
+func (d *dashboard) update(stats stress.IntervalStats) {
+	for {
+		select {
+		case d.updates <- stats:
+			return
+		default:
+			select {
+			case <-d.updates:
+			default:
+			}
+		}
+	}
+}
+
+// start troca para a tela alternativa do terminal e começa a desenhar o
+// painel e a ler as teclas
+func (d *dashboard) start() {
+	fmt.Fprint(d.out, ansiAltScreen+ansiHideCursor)
+	if isTerminal(os.Stdin) {
+		if restore, err := setKeyInput(os.Stdin); err == nil {
+			d.restore = restore
+		}
+		go d.readKeys()
+	}
+	go func() {
+		defer close(d.done)
+		d.render()
+		for stats := range d.updates {
+			d.last = stats
+			d.rps = append(d.rps, stats.RPS)
+			d.p95 = append(d.p95, float64(stats.P95))
+			d.render()
+		}
+	}()
+}
+
+// stop encerra o painel depois do teste, quando não há mais resumos, e
+// restaura o terminal para o relatório final
+func (d *dashboard) stop() {
+	close(d.updates)
+	<-d.done
+	d.restoreTerminal()
+}
+
+// restoreTerminal volta à tela normal e ao modo anterior do terminal. É
+// chamado também antes de o segundo Ctrl+C encerrar o processo.
+func (d *dashboard) restoreTerminal() {
+	d.once.Do(func() {
+		if d.restore != nil {
+			d.restore()
+		}
+		fmt.Fprint(d.out, ansiShowCursor+ansiMainScreen)
+	})
+}
+
+// readKeys encerra o teste com q. Sem o modo de teclas (fora do Linux), a
+// tecla só chega após o Enter.
+func (d *dashboard) readKeys() {
+	buf := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		if strings.ContainsAny(string(buf[:n]), "qQ") {
+			d.quit()
+			return
+		}
+	}
+}
+
+func (d *dashboard) render() {
+	width, height, ok := terminalSize(d.out)
+	if !ok {
+		width, height = 80, 24
+	}
+	stats := d.last
+	var lines []string
+	add := func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	paint := d.style.paint
+	add("%s  %s", paint(ansiBold, "stress-test"), d.title)
+	add("%s", paint(ansiYellow, "q ou Ctrl+C encerram o teste e exibem o relatório"))
+	add("")
+	progress := d.progress(stats)
+	gauge := max(width-30, 10)
+	filled := int(progress * float64(gauge))
+	add("Progresso  [%s%s] %5.1f%%  %s", strings.Repeat("#", filled), strings.Repeat("-", gauge-filled), progress*100, compactDuration(stats.Elapsed.Round(time.Second)))
+	add("")
+
+	errorRate := 0.0
+	if stats.Requests > 0 {
+		errorRate = float64(stats.Errors) / float64(stats.Requests)
+	}
+	errorColor := ansiGreen
+	if stats.Errors > 0 {
+		errorColor = ansiRed
+	}
+	add("Requests: %d   Erros: %s   RPS: %.1f   P95: %s   Erros no intervalo: %.2f%%",
+		stats.Requests, paint(errorColor, fmt.Sprintf("%d (%.2f%%)", stats.Errors, errorRate*100)), stats.RPS, compactDuration(stats.P95), stats.ErrorRate()*100)
+	add("")
+	spark := max(width-24, 10)
+	add("RPS  %s  máx %.1f", sparkline(d.rps, spark), slices.Max(append([]float64{0}, d.rps...)))
+	add("P95  %s  máx %s", sparkline(d.p95, spark), compactDuration(time.Duration(slices.Max(append([]float64{0}, d.p95...)))))
+	add("")
+
+	add("%s (desde o início)", paint(ansiBold, "Latências"))
+	var biggest int64
+	labels := make([]string, len(stats.Latencies))
+	labelWidth := 0
+	for i, bucket := range stats.Latencies {
+		biggest = max(biggest, bucket.Count)
+		labels[i] = compactDuration(bucket.From) + " – " + compactDuration(bucket.To)
+		labelWidth = max(labelWidth, utf8.RuneCountInString(labels[i]))
+	}
+	bar := max(width-labelWidth-14, 10)
+	for i, bucket := range stats.Latencies {
+		n := 0
+		if biggest > 0 {
+			n = int(bucket.Count * int64(bar) / biggest)
+		}
+		padding := strings.Repeat(" ", labelWidth-utf8.RuneCountInString(labels[i]))
+		add("%s%s  %s%s %d", labels[i], padding, strings.Repeat("█", n), strings.Repeat(" ", bar-n), bucket.Count)
+	}
+	if len(stats.Latencies) == 0 {
+		add("aguardando respostas...")
+	}
+	add("")
+
+	add("%s", paint(ansiBold, "Status"))
+	var codes []string
+	for _, code := range slices.Sorted(maps.Keys(stats.StatusCodes)) {
+		color := ansiGreen
+		if code >= 400 {
+			color = ansiRed
+		}
+		codes = append(codes, fmt.Sprintf("%s: %d", paint(color, fmt.Sprint(code)), stats.StatusCodes[code]))
+	}
+	add("%s", strings.Join(codes, "   "))
+
+	var frame strings.Builder
+	frame.WriteString(ansiHome)
+	for i, line := range lines {
+		if i >= height {
+			break
+		}
+		if i > 0 {
+			frame.WriteString("\r\n")
+		}
+		frame.WriteString(clipLine(line, width))
+		frame.WriteString(ansiClearLine)
+	}
+	frame.WriteString(ansiClearBelow)
+	fmt.Fprint(d.out, frame.String())
+}
+
+// progress estima a fração concluída do teste pelo limite de requests ou
+// de duração, o que estiver mais adiantado
+func (d *dashboard) progress(stats stress.IntervalStats) float64 {
+	var done float64
+	if d.requests > 0 {
+		done = float64(stats.Requests) / float64(d.requests)
+	}
+	if d.duration > 0 {
+		done = max(done, float64(stats.Elapsed)/float64(d.duration))
+	}
+	return min(done, 1)
+}
+
+// sparkline desenha os últimos width valores em uma linha, na escala do
+// maior deles
+func sparkline(values []float64, width int) string {
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	highest := slices.Max(append([]float64{0}, values...))
+	var line strings.Builder
+	for _, v := range values {
+		level := 0
+		if highest > 0 {
+			level = int(v / highest * float64(len(sparkBlocks)-1))
+		}
+		line.WriteRune(sparkBlocks[level])
+	}
+	return line.String()
+}
+
+// clipLine corta a linha em width colunas, sem contar as sequências ANSI
+func clipLine(line string, width int) string {
+	var clipped strings.Builder
+	columns := 0
+	for i := 0; i < len(line); {
+		if line[i] == '\x1b' {
+			end := strings.IndexByte(line[i:], 'm')
+			if end < 0 {
+				break
+			}
+			clipped.WriteString(line[i : i+end+1])
+			i += end + 1
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if columns < width {
+			clipped.WriteRune(r)
+			columns++
+		}
+		i += size
+	}
+	return clipped.String()
+}
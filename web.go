@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Playerleleo/Stress-Test/pkg/stress"
+)
+
+// webPage é a página de --web, sem nenhum arquivo externo
+//
+//go:embed web/index.html
+var webPage []byte
+
+// webInterval é o intervalo dos resumos enviados à página quando
+// --report-interval e --tui não definem outro
+const webInterval = time.Second
+
+// webHistory limita os resumos guardados para as páginas abertas durante o
+// teste; ao atingir o limite, metade dos pontos é descartada, alternadamente,
+// e a série continua cobrindo o teste inteiro com menos resolução
+const webHistory = 3600
+
+// webSnapshot é um resumo de OnInterval enviado à página. As durações vão em
+// milissegundos, prontas para os gráficos.
+type webSnapshot struct {
+	ElapsedSeconds float64     `json:"elapsed_s"`
+	Requests       int         `json:"requests"`
+	Errors         int         `json:"errors"`
+	RPS            float64     `json:"rps"`
+	P50            float64     `json:"p50_ms"`
+	P95            float64     `json:"p95_ms"`
+	P99            float64     `json:"p99_ms"`
+	ErrorRate      float64     `json:"error_rate"`
+	StatusCodes    map[int]int `json:"status_codes"`
+}
+
+// webSummary é o resultado do teste exibido na página ao fim
+type webSummary struct {
+	DurationSeconds    float64 `json:"duration_s"`
+	Requests           int     `json:"requests"`
+	SuccessfulRequests int     `json:"successful_requests"`
+	FailedRequests     int     `json:"failed_requests"`
+	RPS                float64 `json:"rps"`
+	P50                float64 `json:"p50_ms"`
+	P95                float64 `json:"p95_ms"`
+	P99                float64 `json:"p99_ms"`
+	// Thresholds é "none", "pass" ou "fail", como em printSummaryLine
+	Thresholds  string `json:"thresholds"`
+	Interrupted string `json:"interrupted,omitempty"`
+}
+
+// webState é o primeiro evento de cada página: o histórico do teste até o
+// momento e, se ele já terminou, o resultado
+type webState struct {
+	Title     string        `json:"title"`
+	Snapshots []webSnapshot `json:"snapshots"`
+	Summary   *webSummary   `json:"summary"`
+}
+
+// webServer serve a página de --web e o stream SSE dos resumos do teste
+type webServer struct {
+	listener net.Listener
+	server   *http.Server
+
+	mu      sync.Mutex
+	state   webState
+	report  []byte
+	clients map[chan []byte]struct{}
+}
+
+// startWebServer começa a servir a página em addr
+func startWebServer(addr, title string) (*webServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &webServer{listener: listener, state: webState{Title: title, Snapshots: []webSnapshot{}}, clients: make(map[chan []byte]struct{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(webPage)
+	})
+	mux.HandleFunc("GET /events", s.events)
+	mux.HandleFunc("GET /report.json", s.reportJSON)
+	s.server = &http.Server{Handler: mux}
+	go s.server.Serve(listener)
+	return s, nil
+}
+
+// URL retorna o endereço da página
+func (s *webServer) URL() string {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	host := addr.IP.String()
+	if addr.IP.IsUnspecified() {
+		host = "localhost"
+	}
+	return fmt.Sprintf("http://%s/", net.JoinHostPort(host, fmt.Sprint(addr.Port)))
+}
+
+// add registra um resumo de OnInterval e o envia às páginas abertas. É
+// chamado pela goroutine do collector e não bloqueia: uma página que não
+// acompanha o stream perde os resumos, que voltam ao recarregá-la.
+func (s *webServer) add(stats stress.IntervalStats) {
+	snapshot := webSnapshot{
+		ElapsedSeconds: stats.Elapsed.Seconds(),
+		Requests:       stats.Requests,
+		Errors:         stats.Errors,
+		RPS:            stats.RPS,
+		P50:            milliseconds(stats.P50),
+		P95:            milliseconds(stats.P95),
+		P99:            milliseconds(stats.P99),
+		ErrorRate:      stats.ErrorRate(),
+		StatusCodes:    stats.StatusCodes,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.state.Snapshots) >= webHistory {
+		kept := s.state.Snapshots[:0]
+		for i := 1; i < len(s.state.Snapshots); i += 2 {
+			kept = append(kept, s.state.Snapshots[i])
+		}
+		s.state.Snapshots = kept
+	}
+	s.state.Snapshots = append(s.state.Snapshots, snapshot)
+	s.broadcast("snapshot", snapshot)
+}
+
+// finish registra o relatório final, servido em /report.json no formato de
+// --output=json, e envia o resultado às páginas abertas
+func (s *webServer) finish(report *stress.Report) error {
+	var buf bytes.Buffer
+	if err := printJSONReport(&buf, report); err != nil {
+		return err
+	}
+	thresholds := "none"
+	if len(report.Thresholds) > 0 {
+		thresholds = "pass"
+		if !report.ThresholdsPassed() {
+			thresholds = "fail"
+		}
+	}
+	summary := &webSummary{
+		DurationSeconds:    report.TotalTime.Seconds(),
+		Requests:           report.TotalRequests,
+		SuccessfulRequests: report.SuccessfulRequests,
+		FailedRequests:     report.FailedRequests,
+		RPS:                report.RequestsPerSecond,
+		P50:                milliseconds(report.P50),
+		P95:                milliseconds(report.P95),
+		P99:                milliseconds(report.P99),
+		Thresholds:         thresholds,
+	}
+	switch {
+	case report.Aborted:
+		summary.Interrupted = report.AbortReason
+	case report.Interrupted:
+		summary.Interrupted = interruptReason(report.InterruptCause)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.report = buf.Bytes()
+	s.state.Summary = summary
+	s.broadcast("done", summary)
+	return nil
+}
+
+// Close encerra o servidor e as conexões das páginas
+func (s *webServer) Close() error {
+	return s.server.Close()
+}
+
+// broadcast envia um evento às páginas; o mutex deve estar travado
+func (s *webServer) broadcast(event string, data any) {
+	message := sseMessage(event, data)
+	for client := range s.clients {
+		select {
+		case client <- message:
+		default:
+		}
+	}
+}
+
+func (s *webServer) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming não suportado", http.StatusInternalServerError)
+		return
+	}
+	client := make(chan []byte, 64)
+	s.mu.Lock()
+	state := sseMessage("state", s.state)
+	s.clients[client] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, client)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(state)
+	flusher.Flush()
+	for {
+		select {
+		case message := <-client:
+			if _, err := w.Write(message); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *webServer) reportJSON(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	report := s.report
+	s.mu.Unlock()
+	if report == nil {
+		http.Error(w, "o teste ainda está em execução", http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="stress-report.json"`)
+	w.Write(report)
+}
+
+// sseMessage formata um evento Server-Sent Events com data em JSON
+func sseMessage(event string, data any) []byte {
+	encoded, _ := json.Marshal(data)
+	return fmt.Appendf(nil, "event: %s\ndata: %s\n\n", event, encoded)
+}
+
+// milliseconds converte a duração em milissegundos fracionários
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultWorkerListen é o endereço padrão do worker, acessível apenas na
+// própria máquina
+const defaultWorkerListen = "127.0.0.1:7070"
+
+// workerJob é o teste enviado pelo coordenador a POST /run: os valores de
+// cada flag da execução local (ver workerFlags) e a espera antes de começar,
+// para que todos os workers iniciem juntos. A espera é relativa ao
+// recebimento, então a diferença entre os relógios das máquinas não
+// interfere.
+type workerJob struct {
+	Flags   map[string][]string `json:"flags"`
+	DelayMs int64               `json:"delay_ms"`
+}
+
+// workerFlags são os flags aceitos em um workerJob: os que definem a carga,
+// as requests e as verificações das respostas. Ficam de fora os subcomandos
+// e os flags que leem ou gravam arquivos, abrem portas ou enviam dados a
+// outros destinos, para que quem alcança o worker não tenha acesso à
+// máquina.
+var workerFlags = map[string]bool{
+	// Carga
+	"requests": true, "duration": true, "concurrency": true, "rps": true, "burst": true, "arrival-rate": true,
+	"arrival-distribution": true, "max-in-flight": true, "stages": true, "stage-target": true, "spike": true,
+	"soak": true, "warmup": true, "ramp-up": true, "exclude-ramp-up": true, "think-time": true,
+	"think-time-jitter": true, "grace-period": true, "report-interval": true, "seed": true,
+	// Requests
+	"url": true, "method": true, "header": true, "body": true, "content-type": true, "host": true,
+	"user-agent": true, "query": true, "target": true, "base-url": true, "cache-bust": true,
+	"cache-bust-param": true, "cookie": true, "cookies": true, "form": true, "graphql-query": true,
+	"graphql-operation": true, "graphql-variables": true, "user": true, "bearer-token": true,
+	"client-id-header": true, "per-worker-client": true, "otel": true, "otel-sample-rate": true, "slowest": true,
+	"retries": true, "retry-backoff": true, "retry-status": true, "respect-retry-after": true, "retry-after-max": true,
+	// Verificações e métricas
+	"expect-status": true, "assert-body-contains": true, "assert-body-not-contains": true,
+	"assert-body-regex": true, "assert-body-not-regex": true, "assert-json": true, "assert-max-body": true,
+	"abort-on-error-rate": true, "abort-window": true, "abort-on-consecutive-errors": true, "apdex-t": true,
+	"fail-if": true, "histogram-max": true, "histogram-sigfigs": true, "timeline-interval": true, "trace": true,
+	// Transporte
+	"timeout": true, "dial-timeout": true, "tls-timeout": true, "response-header-timeout": true,
+	"quic-handshake-timeout": true, "quic-idle-timeout": true, "disable-keepalive": true,
+	"max-conns-per-host": true, "max-idle-conns": true, "max-idle-conns-per-host": true, "http1": true,
+	"http2": true, "h2c": true, "http3": true, "insecure": true, "compression": true, "follow-redirects": true,
+	"max-redirects": true, "max-body-bytes": true, "no-body-read": true, "skip-body": true, "discard-body": true,
+	"resolve": true, "connect-to": true, "dns-server": true, "proxy": true, "no-proxy-env": true, "source-ports": true,
+	// Modos
+	"ws": true, "ws-binary": true, "ws-interval": true, "ws-max-message-size": true, "sse": true,
+	"sse-max-line-size": true, "grpc": true, "grpc-method": true, "grpc-plaintext": true, "grpc-timeout": true,
+	"grpc-connections": true,
+}
+
+// jobArgs monta os argumentos da CLI de um workerJob, recusando os flags
+// fora de workerFlags e os valores que leriam arquivos (o @arquivo das
+// queries GraphQL). As referências ${VAR} e ${file:/caminho} de --url e
+// --header são escapadas, pois os valores já chegam substituídos pelo
+// coordenador.
+func jobArgs(flags map[string][]string) ([]string, error) {
+	var args []string
+	for _, name := range slices.Sorted(maps.Keys(flags)) {
+		if !workerFlags[name] {
+			return nil, fmt.Errorf("flag não aceito pelo worker: --%s", name)
+		}
+		for _, value := range flags[name] {
+			switch name {
+			case "url", "header":
+				value = strings.ReplaceAll(value, "${", "$${")
+			case "graphql-query", "graphql-variables":
+				if strings.HasPrefix(value, "@") {
+					return nil, fmt.Errorf("--%s: o worker não lê arquivos, envie o conteúdo", name)
+				}
+			}
+			args = append(args, "--"+name+"="+value)
+		}
+	}
+	return args, nil
+}
+
+// loopbackAddr indica se addr (host:porta) só aceita conexões da própria
+// máquina; um host vazio (ex.: ":7070") escuta em todas as interfaces
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// validBearer indica se a request envia "Authorization: Bearer <token>",
+// comparado em tempo constante
+func validBearer(r *http.Request, token string) bool {
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// workerEvent é uma linha do stream JSON devolvido por POST /run: as linhas
+// de progresso da execução e, ao fim, o relatório de --save-report ou o erro
+type workerEvent struct {
+	Type   string          `json:"type"`
+	Line   string          `json:"line,omitempty"`
+	Report json.RawMessage `json:"report,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Tipos de workerEvent
+const (
+	workerEventProgress = "progress"
+	workerEventReport   = "report"
+	workerEventError    = "error"
+)
+
+// workerServer executa um teste por vez, cada um em um processo próprio da
+// CLI (ver runCLI)
+type workerServer struct {
+	// token é exigido em "Authorization: Bearer <token>" quando definido
+	token string
+
+	mu   sync.Mutex
+	busy bool
+	// process é o teste em execução, nil antes do início
+	process *os.Process
+}
+
+// runWorker executa o subcomando worker, que aguarda os testes de um
+// coordenador iniciado com --workers. Fora da própria máquina, o worker
+// exige um token.
+func runWorker(args []string) int {
+	flags := flag.NewFlagSet("worker", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: ./stress-test worker [--listen="+defaultWorkerListen+"] [--token=<token>]")
+		flags.PrintDefaults()
+	}
+	listen := flags.String("listen", defaultWorkerListen, "Endereço em que o worker aguarda os testes do coordenador; fora da própria máquina, requer --token")
+	tokenFlag := flags.String("token", "", "Token exigido do coordenador no header \"Authorization: Bearer <token>\" (ver --worker-token); aceita ${VAR} e ${file:/caminho}")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Printf("Erro: argumento inesperado: %s\n", flags.Arg(0))
+		return exitUsage
+	}
+	secrets := &secrets{}
+	token := secrets.expand(*tokenFlag)
+	if err := secrets.check(); err != nil {
+		fmt.Printf("Erro: %v\n", err)
+		return exitUsage
+	}
+	if token == "" && !loopbackAddr(*listen) {
+		fmt.Printf("Erro: --listen=%s aceita conexões de outras máquinas e requer --token\n", *listen)
+		return exitUsage
+	}
+
+	s := &workerServer{token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /run", s.auth(s.run))
+	mux.HandleFunc("POST /stop", s.auth(s.stop))
+	fmt.Fprintf(os.Stderr, "Worker aguardando testes em %s\n", *listen)
+	if err := http.ListenAndServe(*listen, mux); err != nil {
+		fmt.Printf("Erro: %v\n", err)
+		return exitUsage
+	}
+	return exitOK
+}
+
+func (s *workerServer) auth(next http.HandlerFunc) http.HandlerFunc {
+	if s.token == "" {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !validBearer(r, s.token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "token ausente ou inválido", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *workerServer) run(w http.ResponseWriter, r *http.Request) {
+	var job workerJob
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		http.Error(w, fmt.Sprintf("teste inválido: %v", err), http.StatusBadRequest)
+		return
+	}
+	args, err := jobArgs(job.Flags)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("teste inválido: %v", err), http.StatusBadRequest)
+		return
+	}
+	report, err := os.CreateTemp("", "stress-worker-*.json")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	report.Close()
+	defer os.Remove(report.Name())
+
+	s.mu.Lock()
+	if s.busy {
+		s.mu.Unlock()
+		http.Error(w, "o worker já está executando um teste", http.StatusConflict)
+		return
+	}
+	s.busy = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.busy, s.process = false, nil
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	send := func(event workerEvent) {
+		encoder.Encode(event)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	// A resposta começa antes da espera, confirmando ao coordenador que o
+	// teste foi aceito
+	w.WriteHeader(http.StatusOK)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	select {
+	case <-time.After(time.Duration(job.DelayMs) * time.Millisecond):
+	case <-r.Context().Done():
+		return
+	}
+	stopped := make(chan struct{})
+	defer close(stopped)
+	args = append(args, "--save-report="+report.Name(), "--no-progress")
+	failure, err := runCLI(args, nil, func(process *os.Process) {
+		s.mu.Lock()
+		s.process = process
+		s.mu.Unlock()
+		// Sem o coordenador, o teste é interrompido como no Ctrl+C
+		go func() {
+			select {
+			case <-r.Context().Done():
+				process.Signal(os.Interrupt)
+			case <-stopped:
+			}
+		}()
+	}, func(line string) {
+		send(workerEvent{Type: workerEventProgress, Line: line})
+	})
+	data, _ := os.ReadFile(report.Name())
+	if len(data) == 0 {
+		if failure == "" && err != nil {
+			failure = err.Error()
+		}
+		send(workerEvent{Type: workerEventError, Error: failure})
+		return
+	}
+	send(workerEvent{Type: workerEventReport, Report: data})
+}
+
+// stop interrompe o teste em andamento, que ainda entrega o relatório
+// parcial pelo stream de POST /run
+func (s *workerServer) stop(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.process != nil {
+		s.process.Signal(os.Interrupt)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// runCLI executa a CLI com args em um processo próprio, para que o estado
+// global dos flags não passe de um teste ao seguinte e uma falha do teste não
+// derrube o servidor que o iniciou. started recebe o processo assim que ele
+// inicia, e line, cada linha não vazia de stderr (os resumos periódicos e os
+// avisos). O relatório de stdout é descartado, e a última mensagem de erro
+// impressa pela CLI é retornada em failure. Os arquivos de extra chegam ao
+// processo como os descritores 3 em diante e são fechados no retorno.
+func runCLI(args []string, extra []*os.File, started func(*os.Process), line func(string)) (failure string, err error) {
+	defer func() {
+		for _, file := range extra {
+			file.Close()
+		}
+	}()
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command(executable, args...)
+	cmd.ExtraFiles = extra
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+	started(cmd.Process)
+
+	scanned := make(chan struct{})
+	go func() {
+		defer close(scanned)
+		lines := newLineScanner(stdout)
+		for lines.Scan() {
+			if message, ok := strings.CutPrefix(lines.Text(), "Erro: "); ok {
+				failure = message
+			}
+		}
+		// Uma linha longa demais interrompe a leitura; o restante é
+		// descartado para que o processo não fique bloqueado na escrita
+		io.Copy(io.Discard, stdout)
+	}()
+	lines := newLineScanner(stderr)
+	for lines.Scan() {
+		if text := lines.Text(); strings.TrimSpace(text) != "" {
+			line(text)
+		}
+	}
+	io.Copy(io.Discard, stderr)
+	<-scanned
+	return failure, cmd.Wait()
+}
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	return scanner
+}